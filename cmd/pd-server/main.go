@@ -31,6 +31,7 @@ import (
 	// Register schedulers.
 	_ "github.com/pingcap/pd/server/schedulers"
 	// Register namespace classifiers.
+	_ "github.com/pingcap/pd/keyrange"
 	_ "github.com/pingcap/pd/table"
 )
 
@@ -0,0 +1,425 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keyrange
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/namespace"
+	log "github.com/sirupsen/logrus"
+)
+
+func init() {
+	namespace.RegisterClassifier("keyrange", NewKeyRangeNamespaceClassifier)
+}
+
+// KeyRange is a hex encoded key range [StartKey, EndKey), an empty EndKey
+// means the end of the key space.
+type KeyRange struct {
+	StartKey string `json:"start_key"`
+	EndKey   string `json:"end_key"`
+}
+
+func (r KeyRange) decode() ([]byte, []byte, error) {
+	startKey, err := hex.DecodeString(r.StartKey)
+	if err != nil {
+		return nil, nil, errors.Errorf("invalid start key %s", r.StartKey)
+	}
+	endKey, err := hex.DecodeString(r.EndKey)
+	if err != nil {
+		return nil, nil, errors.Errorf("invalid end key %s", r.EndKey)
+	}
+	if len(endKey) > 0 && bytes.Compare(startKey, endKey) >= 0 {
+		return nil, nil, errors.Errorf("start key %s is not less than end key %s", r.StartKey, r.EndKey)
+	}
+	return startKey, endKey, nil
+}
+
+// Namespace binds several key ranges and several stores with a name.
+type Namespace struct {
+	ID       uint64          `json:"ID"`
+	Name     string          `json:"Name"`
+	Ranges   []KeyRange      `json:"ranges,omitempty"`
+	StoreIDs map[uint64]bool `json:"store_ids,omitempty"`
+}
+
+// NewNamespace creates a new namespace.
+func NewNamespace(id uint64, name string) *Namespace {
+	return &Namespace{
+		ID:       id,
+		Name:     name,
+		StoreIDs: make(map[uint64]bool),
+	}
+}
+
+// AddStoreID adds a storeID to this namespace.
+func (ns *Namespace) AddStoreID(storeID uint64) {
+	if ns.StoreIDs == nil {
+		ns.StoreIDs = make(map[uint64]bool)
+	}
+	ns.StoreIDs[storeID] = true
+}
+
+// rangeItem is a decoded key range of a namespace in the index.
+type rangeItem struct {
+	startKey []byte
+	endKey   []byte
+	name     string
+}
+
+// rangeIndex is the sorted key ranges of all namespaces, which do not
+// overlap each other.
+type rangeIndex struct {
+	items []rangeItem
+	// boundaries are the sorted start and end keys of the ranges, the keys
+	// between two adjacent boundaries are always in the same namespace.
+	boundaries [][]byte
+}
+
+func newRangeIndex(namespaces map[string]*Namespace) (*rangeIndex, error) {
+	index := &rangeIndex{}
+	for name, ns := range namespaces {
+		for _, r := range ns.Ranges {
+			startKey, endKey, err := r.decode()
+			if err != nil {
+				return nil, errors.Errorf("%v of namespace %s", err, name)
+			}
+			index.items = append(index.items, rangeItem{startKey: startKey, endKey: endKey, name: name})
+		}
+	}
+	sort.Slice(index.items, func(i, j int) bool {
+		return bytes.Compare(index.items[i].startKey, index.items[j].startKey) < 0
+	})
+	for i, item := range index.items {
+		if i > 0 {
+			prev := index.items[i-1]
+			if len(prev.endKey) == 0 || bytes.Compare(prev.endKey, item.startKey) > 0 {
+				return nil, errors.Errorf("key range of namespace %s overlaps with namespace %s", item.name, prev.name)
+			}
+		}
+		index.boundaries = append(index.boundaries, item.startKey)
+		if len(item.endKey) > 0 {
+			index.boundaries = append(index.boundaries, item.endKey)
+		}
+	}
+	return index, nil
+}
+
+// getNamespace returns the namespace of the range containing the key, or the
+// default namespace if there is no such range.
+func (index *rangeIndex) getNamespace(key []byte) string {
+	i := sort.Search(len(index.items), func(i int) bool {
+		return bytes.Compare(index.items[i].startKey, key) > 0
+	})
+	if i == 0 {
+		return namespace.DefaultNamespace
+	}
+	item := index.items[i-1]
+	if len(item.endKey) > 0 && bytes.Compare(key, item.endKey) >= 0 {
+		return namespace.DefaultNamespace
+	}
+	return item.name
+}
+
+// getSegment returns the number of the boundaries not greater than the key.
+func (index *rangeIndex) getSegment(key []byte) int {
+	return sort.Search(len(index.boundaries), func(i int) bool {
+		return bytes.Compare(index.boundaries[i], key) > 0
+	})
+}
+
+// getBoundaries returns the distinct boundaries strictly inside the key range
+// [startKey, endKey).
+func (index *rangeIndex) getBoundaries(startKey, endKey []byte) [][]byte {
+	var keys [][]byte
+	for _, b := range index.boundaries[index.getSegment(startKey):] {
+		if len(endKey) > 0 && bytes.Compare(b, endKey) >= 0 {
+			break
+		}
+		if len(keys) > 0 && bytes.Equal(keys[len(keys)-1], b) {
+			continue
+		}
+		keys = append(keys, b)
+	}
+	return keys
+}
+
+// keyRangeNamespaceClassifier implements Classifier interface.
+type keyRangeNamespaceClassifier struct {
+	sync.RWMutex
+	namespaces map[string]*Namespace
+	index      *rangeIndex
+	kv         *core.KV
+	idAlloc    core.IDAllocator
+	http.Handler
+}
+
+const kvRangeLimit = 1000
+
+// NewKeyRangeNamespaceClassifier creates a new namespace classifier that
+// classifies stores and regions by key range.
+func NewKeyRangeNamespaceClassifier(kv *core.KV, idAlloc core.IDAllocator) (namespace.Classifier, error) {
+	c := &keyRangeNamespaceClassifier{
+		namespaces: make(map[string]*Namespace),
+		index:      &rangeIndex{},
+		kv:         kv,
+		idAlloc:    idAlloc,
+	}
+	if err := c.loadNamespaces(kvRangeLimit); err != nil {
+		return nil, errors.Trace(err)
+	}
+	index, err := newRangeIndex(c.namespaces)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	c.index = index
+	c.Handler = newKeyRangeClassifierHandler(c)
+	return c, nil
+}
+
+func (c *keyRangeNamespaceClassifier) GetAllNamespaces() []string {
+	c.RLock()
+	defer c.RUnlock()
+
+	nsList := make([]string, 0, len(c.namespaces)+1)
+	for name := range c.namespaces {
+		nsList = append(nsList, name)
+	}
+	nsList = append(nsList, namespace.DefaultNamespace)
+	return nsList
+}
+
+func (c *keyRangeNamespaceClassifier) GetStoreNamespace(storeInfo *core.StoreInfo) string {
+	c.RLock()
+	defer c.RUnlock()
+
+	for name, ns := range c.namespaces {
+		if ns.StoreIDs[storeInfo.GetId()] {
+			return name
+		}
+	}
+	return namespace.DefaultNamespace
+}
+
+func (c *keyRangeNamespaceClassifier) GetRegionNamespace(regionInfo *core.RegionInfo) string {
+	c.RLock()
+	defer c.RUnlock()
+	return c.index.getNamespace(regionInfo.GetStartKey())
+}
+
+// AllowMerge returns false if there is a boundary of the key ranges between
+// the start keys of the regions.
+func (c *keyRangeNamespaceClassifier) AllowMerge(one *core.RegionInfo, other *core.RegionInfo) bool {
+	c.RLock()
+	defer c.RUnlock()
+	return c.index.getSegment(one.GetStartKey()) == c.index.getSegment(other.GetStartKey())
+}
+
+// GetBoundaries returns the boundaries of the key ranges inside the region,
+// the region straddles several namespaces if there is any.
+func (c *keyRangeNamespaceClassifier) GetBoundaries(regionInfo *core.RegionInfo) [][]byte {
+	c.RLock()
+	defer c.RUnlock()
+	return c.index.getBoundaries(regionInfo.GetStartKey(), regionInfo.GetEndKey())
+}
+
+func (c *keyRangeNamespaceClassifier) IsNamespaceExist(name string) bool {
+	c.RLock()
+	defer c.RUnlock()
+	_, ok := c.namespaces[name]
+	return ok
+}
+
+// GetNamespaces returns all namespace details.
+func (c *keyRangeNamespaceClassifier) GetNamespaces() []*Namespace {
+	c.RLock()
+	defer c.RUnlock()
+
+	nsList := make([]*Namespace, 0, len(c.namespaces))
+	for _, ns := range c.namespaces {
+		nsList = append(nsList, ns)
+	}
+	sort.Slice(nsList, func(i, j int) bool { return nsList[i].ID < nsList[j].ID })
+	return nsList
+}
+
+// CreateNamespace creates a new Namespace.
+func (c *keyRangeNamespaceClassifier) CreateNamespace(name string) error {
+	c.Lock()
+	defer c.Unlock()
+
+	if !regexp.MustCompile(`^\w+$`).MatchString(name) {
+		return errors.New("Name should be 0-9, a-z or A-Z")
+	}
+	if name == namespace.DefaultNamespace {
+		return errors.Errorf("%s is reserved as default namespace", name)
+	}
+	if _, ok := c.namespaces[name]; ok {
+		return errors.New("Duplicate namespace Name")
+	}
+
+	id, err := c.idAlloc.Alloc()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return c.putNamespaceLocked(NewNamespace(id, name))
+}
+
+// AddNamespaceRange adds a key range to namespace, the range should not
+// overlap the ranges of any namespace.
+func (c *keyRangeNamespaceClassifier) AddNamespaceRange(name string, r KeyRange) error {
+	c.Lock()
+	defer c.Unlock()
+
+	n, ok := c.namespaces[name]
+	if !ok {
+		return errors.Errorf("invalid namespace Name %s, not found", name)
+	}
+	if _, _, err := r.decode(); err != nil {
+		return errors.Trace(err)
+	}
+
+	ns := *n
+	ns.Ranges = append(append([]KeyRange(nil), n.Ranges...), r)
+	return c.putNamespaceLocked(&ns)
+}
+
+// RemoveNamespaceRange removes a key range from namespace.
+func (c *keyRangeNamespaceClassifier) RemoveNamespaceRange(name string, r KeyRange) error {
+	c.Lock()
+	defer c.Unlock()
+
+	n, ok := c.namespaces[name]
+	if !ok {
+		return errors.Errorf("invalid namespace Name %s, not found", name)
+	}
+
+	ns := *n
+	ns.Ranges = nil
+	for _, old := range n.Ranges {
+		if old != r {
+			ns.Ranges = append(ns.Ranges, old)
+		}
+	}
+	if len(ns.Ranges) == len(n.Ranges) {
+		return errors.Errorf("key range [%s, %s) is not belong to %s", r.StartKey, r.EndKey, name)
+	}
+	return c.putNamespaceLocked(&ns)
+}
+
+// AddNamespaceStoreID adds store ID to namespace.
+func (c *keyRangeNamespaceClassifier) AddNamespaceStoreID(name string, storeID uint64) error {
+	c.Lock()
+	defer c.Unlock()
+
+	n, ok := c.namespaces[name]
+	if !ok {
+		return errors.Errorf("invalid namespace Name %s, not found", name)
+	}
+	for _, ns := range c.namespaces {
+		if ns.StoreIDs[storeID] {
+			return errors.New("Store ID already exists in this namespace")
+		}
+	}
+
+	n.AddStoreID(storeID)
+	return c.putNamespaceLocked(n)
+}
+
+// RemoveNamespaceStoreID removes store ID from namespace.
+func (c *keyRangeNamespaceClassifier) RemoveNamespaceStoreID(name string, storeID uint64) error {
+	c.Lock()
+	defer c.Unlock()
+
+	n, ok := c.namespaces[name]
+	if !ok {
+		return errors.Errorf("invalid namespace Name %s, not found", name)
+	}
+	if !n.StoreIDs[storeID] {
+		return errors.Errorf("Store ID %d is not belong to %s", storeID, name)
+	}
+
+	delete(n.StoreIDs, storeID)
+	return c.putNamespaceLocked(n)
+}
+
+// putNamespaceLocked rebuilds the range index with the namespace and saves
+// the namespace if the index is valid.
+func (c *keyRangeNamespaceClassifier) putNamespaceLocked(ns *Namespace) error {
+	namespaces := make(map[string]*Namespace, len(c.namespaces)+1)
+	for name, n := range c.namespaces {
+		namespaces[name] = n
+	}
+	namespaces[ns.Name] = ns
+	index, err := newRangeIndex(namespaces)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	if c.kv != nil {
+		value, err := json.Marshal(ns)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err := c.kv.Save(namespacePath(ns.ID), string(value)); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	c.namespaces, c.index = namespaces, index
+	return nil
+}
+
+func namespacePath(nsID uint64) string {
+	return path.Join("keyrange_namespace", fmt.Sprintf("%20d", nsID))
+}
+
+func (c *keyRangeNamespaceClassifier) loadNamespaces(rangeLimit int) error {
+	start := time.Now()
+
+	nextID := uint64(0)
+	endKey := namespacePath(math.MaxUint64)
+
+	for {
+		key := namespacePath(nextID)
+		res, err := c.kv.LoadRange(key, endKey, rangeLimit)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		for _, s := range res {
+			ns := &Namespace{}
+			if err := json.Unmarshal([]byte(s), ns); err != nil {
+				return errors.Trace(err)
+			}
+			nextID = ns.ID + 1
+			c.namespaces[ns.Name] = ns
+		}
+
+		if len(res) < rangeLimit {
+			log.Infof("load %v key range namespaces cost %v", len(c.namespaces), time.Since(start))
+			return nil
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keyrange
+
+import (
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/namespace"
+)
+
+func TestKeyRange(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testKeyRangeNamespaceSuite{})
+
+type testKeyRangeNamespaceSuite struct{}
+
+func newRegion(startKey, endKey string) *core.RegionInfo {
+	return core.NewRegionInfo(&metapb.Region{StartKey: []byte(startKey), EndKey: []byte(endKey)}, nil)
+}
+
+func (s *testKeyRangeNamespaceSuite) TestNamespaceOperation(c *C) {
+	kv := core.NewKV(core.NewMemoryKV())
+	classifier, err := NewKeyRangeNamespaceClassifier(kv, core.NewMockIDAllocator())
+	c.Assert(err, IsNil)
+	rc := classifier.(*keyRangeNamespaceClassifier)
+
+	c.Assert(rc.CreateNamespace("(invalid_name"), NotNil)
+	c.Assert(rc.CreateNamespace(namespace.DefaultNamespace), NotNil)
+	c.Assert(rc.CreateNamespace("ns1"), IsNil)
+	c.Assert(rc.CreateNamespace("ns1"), NotNil)
+	c.Assert(rc.CreateNamespace("ns2"), IsNil)
+	c.Assert(rc.GetNamespaces(), HasLen, 2)
+	c.Assert(rc.IsNamespaceExist("ns1"), IsTrue)
+	c.Assert(rc.GetAllNamespaces(), HasLen, 3)
+
+	// ns1: ["a", "c"), ns2: ["e", "g") and ["x", +inf)
+	c.Assert(rc.AddNamespaceRange("ns1", KeyRange{StartKey: "61", EndKey: "63"}), IsNil)
+	c.Assert(rc.AddNamespaceRange("ns2", KeyRange{StartKey: "65", EndKey: "67"}), IsNil)
+	c.Assert(rc.AddNamespaceRange("ns2", KeyRange{StartKey: "78"}), IsNil)
+
+	// Invalid or overlapped ranges are rejected.
+	c.Assert(rc.AddNamespaceRange("ns1", KeyRange{StartKey: "zz"}), NotNil)
+	c.Assert(rc.AddNamespaceRange("ns1", KeyRange{StartKey: "63", EndKey: "61"}), NotNil)
+	c.Assert(rc.AddNamespaceRange("ns1", KeyRange{StartKey: "66", EndKey: "68"}), NotNil)
+	c.Assert(rc.AddNamespaceRange("ns1", KeyRange{StartKey: "79", EndKey: "7a"}), NotNil)
+	c.Assert(rc.AddNamespaceRange("ns3", KeyRange{StartKey: "30", EndKey: "31"}), NotNil)
+	c.Assert(rc.RemoveNamespaceRange("ns1", KeyRange{StartKey: "65", EndKey: "67"}), NotNil)
+
+	c.Assert(rc.GetRegionNamespace(newRegion("", "a")), Equals, namespace.DefaultNamespace)
+	c.Assert(rc.GetRegionNamespace(newRegion("a", "b")), Equals, "ns1")
+	c.Assert(rc.GetRegionNamespace(newRegion("b", "c")), Equals, "ns1")
+	c.Assert(rc.GetRegionNamespace(newRegion("c", "e")), Equals, namespace.DefaultNamespace)
+	c.Assert(rc.GetRegionNamespace(newRegion("f", "g")), Equals, "ns2")
+	c.Assert(rc.GetRegionNamespace(newRegion("y", "")), Equals, "ns2")
+
+	// Regions can't be merged across the boundaries.
+	c.Assert(rc.AllowMerge(newRegion("a", "b"), newRegion("b", "c")), IsTrue)
+	c.Assert(rc.AllowMerge(newRegion("b", "c"), newRegion("c", "d")), IsFalse)
+	c.Assert(rc.AllowMerge(newRegion("c", "d"), newRegion("d", "e")), IsTrue)
+	c.Assert(rc.AllowMerge(newRegion("", "a"), newRegion("c", "d")), IsFalse)
+	c.Assert(rc.AllowMerge(newRegion("x", "y"), newRegion("y", "")), IsTrue)
+
+	// Regions straddling the boundaries.
+	c.Assert(rc.GetBoundaries(newRegion("a", "c")), HasLen, 0)
+	c.Assert(rc.GetBoundaries(newRegion("b", "f")), DeepEquals, [][]byte{[]byte("c"), []byte("e")})
+	c.Assert(rc.GetBoundaries(newRegion("", "")), DeepEquals, [][]byte{[]byte("a"), []byte("c"), []byte("e"), []byte("g"), []byte("x")})
+
+	// Stores.
+	c.Assert(rc.AddNamespaceStoreID("ns1", 1), IsNil)
+	c.Assert(rc.AddNamespaceStoreID("ns2", 1), NotNil)
+	c.Assert(rc.AddNamespaceStoreID("ns3", 2), NotNil)
+	c.Assert(rc.GetStoreNamespace(core.NewStoreInfo(&metapb.Store{Id: 1})), Equals, "ns1")
+	c.Assert(rc.GetStoreNamespace(core.NewStoreInfo(&metapb.Store{Id: 2})), Equals, namespace.DefaultNamespace)
+	c.Assert(rc.RemoveNamespaceStoreID("ns1", 2), NotNil)
+	c.Assert(rc.RemoveNamespaceStoreID("ns1", 1), IsNil)
+	c.Assert(rc.GetStoreNamespace(core.NewStoreInfo(&metapb.Store{Id: 1})), Equals, namespace.DefaultNamespace)
+	c.Assert(rc.AddNamespaceStoreID("ns2", 1), IsNil)
+
+	// The namespaces are reloaded from the kv.
+	c.Assert(rc.RemoveNamespaceRange("ns1", KeyRange{StartKey: "61", EndKey: "63"}), IsNil)
+	classifier, err = NewKeyRangeNamespaceClassifier(kv, core.NewMockIDAllocator())
+	c.Assert(err, IsNil)
+	c.Assert(classifier.GetRegionNamespace(newRegion("a", "b")), Equals, namespace.DefaultNamespace)
+	c.Assert(classifier.GetRegionNamespace(newRegion("e", "f")), Equals, "ns2")
+	c.Assert(classifier.GetStoreNamespace(core.NewStoreInfo(&metapb.Store{Id: 1})), Equals, "ns2")
+	c.Assert(classifier.AllowMerge(newRegion("a", "b"), newRegion("c", "d")), IsTrue)
+}
@@ -0,0 +1,158 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keyrange
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/juju/errors"
+	"github.com/pingcap/pd/pkg/apiutil"
+	"github.com/unrolled/render"
+)
+
+func newKeyRangeClassifierHandler(classifier *keyRangeNamespaceClassifier) http.Handler {
+	h := &keyRangeNamespaceHandler{
+		classifier: classifier,
+		rd:         render.New(render.Options{IndentJSON: true}),
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/keyrange/namespaces", h.Get).Methods("GET")
+	router.HandleFunc("/keyrange/namespaces", h.Post).Methods("POST")
+	router.HandleFunc("/keyrange/namespaces/range", h.Update).Methods("POST")
+	router.HandleFunc("/keyrange/store_ns/{id}", h.SetNamespace).Methods("POST")
+	return router
+}
+
+type keyRangeNamespaceHandler struct {
+	classifier *keyRangeNamespaceClassifier
+	rd         *render.Render
+}
+
+func (h *keyRangeNamespaceHandler) Get(w http.ResponseWriter, r *http.Request) {
+	type namespacesInfo struct {
+		Count      int          `json:"count"`
+		Namespaces []*Namespace `json:"namespaces"`
+	}
+
+	namespaces := h.classifier.GetNamespaces()
+	nsInfo := &namespacesInfo{
+		Count:      len(namespaces),
+		Namespaces: namespaces,
+	}
+	h.rd.JSON(w, http.StatusOK, nsInfo)
+}
+
+func readJSONRespondError(r *render.Render, w http.ResponseWriter, body io.ReadCloser, data interface{}) (err error) {
+	err = apiutil.ReadJSON(body, &data)
+	if err == nil {
+		return nil
+	}
+	status := http.StatusInternalServerError
+	if _, ok := errors.Cause(err).(apiutil.JSONError); ok {
+		status = http.StatusBadRequest
+	}
+	r.JSON(w, status, err.Error())
+	return err
+}
+
+// Post creates a namespace.
+func (h *keyRangeNamespaceHandler) Post(w http.ResponseWriter, r *http.Request) {
+	var input map[string]string
+	if err := readJSONRespondError(h.rd, w, r.Body, &input); err != nil {
+		return
+	}
+	ns := input["namespace"]
+
+	if err := h.classifier.CreateNamespace(ns); err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.rd.JSON(w, http.StatusOK, nil)
+}
+
+// Update adds a key range to or removes a key range from a namespace.
+func (h *keyRangeNamespaceHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var input map[string]string
+	if err := readJSONRespondError(h.rd, w, r.Body, &input); err != nil {
+		return
+	}
+	ns := input["namespace"]
+	keyRange := KeyRange{StartKey: input["start_key"], EndKey: input["end_key"]}
+	action, ok := input["action"]
+	if !ok {
+		h.rd.JSON(w, http.StatusBadRequest, errors.New("missing parameters"))
+		return
+	}
+
+	switch action {
+	case "add":
+		if err := h.classifier.AddNamespaceRange(ns, keyRange); err != nil {
+			h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	case "remove":
+		if err := h.classifier.RemoveNamespaceRange(ns, keyRange); err != nil {
+			h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	default:
+		h.rd.JSON(w, http.StatusBadRequest, errors.New("unknown action"))
+		return
+	}
+
+	h.rd.JSON(w, http.StatusOK, nil)
+}
+
+func (h *keyRangeNamespaceHandler) SetNamespace(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	storeIDStr := vars["id"]
+	storeID, err := strconv.ParseUint(storeIDStr, 10, 64)
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var input map[string]string
+	if err := readJSONRespondError(h.rd, w, r.Body, &input); err != nil {
+		return
+	}
+	ns := input["namespace"]
+	action, ok := input["action"]
+	if !ok {
+		h.rd.JSON(w, http.StatusBadRequest, errors.New("missing parameters"))
+		return
+	}
+
+	switch action {
+	case "add":
+		if err := h.classifier.AddNamespaceStoreID(ns, storeID); err != nil {
+			h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	case "remove":
+		if err := h.classifier.RemoveNamespaceStoreID(ns, storeID); err != nil {
+			h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	default:
+		h.rd.JSON(w, http.StatusBadRequest, errors.New("unknown action"))
+		return
+	}
+
+	h.rd.JSON(w, http.StatusOK, nil)
+}
@@ -17,6 +17,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"path"
 	"strconv"
@@ -25,12 +26,16 @@ import (
 )
 
 var (
-	configPrefix         = "pd/api/v1/config"
-	schedulePrefix       = "pd/api/v1/config/schedule"
-	replicationPrefix    = "pd/api/v1/config/replicate"
-	namespacePrefix      = "pd/api/v1/config/namespace"
-	labelPropertyPrefix  = "pd/api/v1/config/label-property"
-	clusterVersionPrefix = "pd/api/v1/config/cluster-version"
+	configPrefix           = "pd/api/v1/config"
+	schedulePrefix         = "pd/api/v1/config/schedule"
+	replicationPrefix      = "pd/api/v1/config/replicate"
+	namespacePrefix        = "pd/api/v1/config/namespace"
+	labelPropertyPrefix    = "pd/api/v1/config/label-property"
+	clusterVersionPrefix   = "pd/api/v1/config/cluster-version"
+	rulesPrefix            = "pd/api/v1/config/rules"
+	rulePrefix             = "pd/api/v1/config/rule"
+	rangeConstraintsPrefix = "pd/api/v1/config/range-constraints"
+	rangeConstraintPrefix  = "pd/api/v1/config/range-constraint"
 )
 
 // NewConfigCommand return a config subcommand of rootCmd
@@ -42,13 +47,15 @@ func NewConfigCommand() *cobra.Command {
 	conf.AddCommand(NewShowConfigCommand())
 	conf.AddCommand(NewSetConfigCommand())
 	conf.AddCommand(NewDeleteConfigCommand())
+	conf.AddCommand(NewPlacementRulesCommand())
+	conf.AddCommand(NewRangeConstraintsCommand())
 	return conf
 }
 
 // NewShowConfigCommand return a show subcommand of configCmd
 func NewShowConfigCommand() *cobra.Command {
 	sc := &cobra.Command{
-		Use:   "show [namespace|replication|label-property|all]",
+		Use:   "show [namespace|replication|label-property|effective|all]",
 		Short: "show schedule config of PD",
 		Run:   showConfigCommandFunc,
 	}
@@ -57,6 +64,7 @@ func NewShowConfigCommand() *cobra.Command {
 	sc.AddCommand(NewShowReplicationConfigCommand())
 	sc.AddCommand(NewShowLabelPropertyCommand())
 	sc.AddCommand(NewShowClusterVersionCommand())
+	sc.AddCommand(NewShowEffectiveConfigCommand())
 	return sc
 }
 
@@ -110,6 +118,16 @@ func NewShowClusterVersionCommand() *cobra.Command {
 	return sc
 }
 
+// NewShowEffectiveConfigCommand returns an effective subcommand of show subcommand.
+func NewShowEffectiveConfigCommand() *cobra.Command {
+	sc := &cobra.Command{
+		Use:   "effective",
+		Short: "show schedule config overridden by the active time window",
+		Run:   showEffectiveConfigCommandFunc,
+	}
+	return sc
+}
+
 // NewSetConfigCommand return a set subcommand of configCmd
 func NewSetConfigCommand() *cobra.Command {
 	sc := &cobra.Command{
@@ -127,7 +145,7 @@ func NewSetConfigCommand() *cobra.Command {
 func NewSetNamespaceConfigCommand() *cobra.Command {
 	sc := &cobra.Command{
 		Use:   "namespace <name> <option> <value>",
-		Short: "set the namespace config's option with value",
+		Short: "set the namespace config's option with value, any schedule or replication option which is not global-only can be set",
 		Run:   setNamespaceConfigCommandFunc,
 	}
 	return sc
@@ -167,7 +185,7 @@ func NewDeleteConfigCommand() *cobra.Command {
 // NewDeleteNamespaceConfigCommand a set subcommand of delete subcommand
 func NewDeleteNamespaceConfigCommand() *cobra.Command {
 	sc := &cobra.Command{
-		Use:   "namespace <name>",
+		Use:   "namespace <name> [<option>]",
 		Short: "delete the namespace config's all options or given option",
 		Run:   deleteNamespaceConfigCommandFunc,
 	}
@@ -243,6 +261,15 @@ func showClusterVersionCommandFunc(cmd *cobra.Command, args []string) {
 	fmt.Println(r)
 }
 
+func showEffectiveConfigCommandFunc(cmd *cobra.Command, args []string) {
+	r, err := doRequest(cmd, path.Join(schedulePrefix, "effective"), http.MethodGet)
+	if err != nil {
+		fmt.Printf("Failed to get config: %s\n", err)
+		return
+	}
+	fmt.Println(r)
+}
+
 func postConfigDataWithPath(cmd *cobra.Command, key, value, path string) error {
 	var val interface{}
 	data := make(map[string]interface{})
@@ -251,6 +278,10 @@ func postConfigDataWithPath(cmd *cobra.Command, key, value, path string) error {
 		val = value
 	}
 	data[key] = val
+	return postConfigMapWithPath(cmd, data, path)
+}
+
+func postConfigMapWithPath(cmd *cobra.Command, data map[string]interface{}, path string) error {
 	reqData, err := json.Marshal(data)
 	if err != nil {
 		return err
@@ -300,13 +331,15 @@ func deleteNamespaceConfigCommandFunc(cmd *cobra.Command, args []string) {
 		fmt.Println(cmd.UsageString())
 		return
 	}
-	name, opt := args[0], args[1]
+	name, opt := args[0], ""
 	prefix := path.Join(namespacePrefix, name)
 
 	var err error
 	if len(args) == 2 {
-		// delete namespace config's option by setting the option with zero value
-		err = postConfigDataWithPath(cmd, opt, "0", prefix)
+		// delete namespace config's option by setting the option with null,
+		// then the global value takes effect
+		opt = args[1]
+		err = postConfigMapWithPath(cmd, map[string]interface{}{opt: nil}, prefix)
 	} else {
 		_, err = doRequest(cmd, prefix, http.MethodDelete)
 	}
@@ -351,3 +384,155 @@ func setClusterVersionCommandFunc(cmd *cobra.Command, args []string) {
 	}
 	postJSON(cmd, clusterVersionPrefix, input)
 }
+
+// NewPlacementRulesCommand returns a placement rules subcommand of configCmd.
+func NewPlacementRulesCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "placement-rules <subcommand>",
+		Short: "show or update the placement rules",
+	}
+	c.AddCommand(&cobra.Command{
+		Use:   "show [<rule_id>]",
+		Short: "show all placement rules or the specified one",
+		Run:   showPlacementRulesCommandFunc,
+	})
+	c.AddCommand(&cobra.Command{
+		Use:   "set <rule_file>",
+		Short: "add or replace a placement rule with the JSON file",
+		Run:   setPlacementRuleCommandFunc,
+	})
+	c.AddCommand(&cobra.Command{
+		Use:   "delete <rule_id>",
+		Short: "delete a placement rule",
+		Run:   deletePlacementRuleCommandFunc,
+	})
+	return c
+}
+
+func showPlacementRulesCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) > 1 {
+		fmt.Println(cmd.UsageString())
+		return
+	}
+	prefix := rulesPrefix
+	if len(args) == 1 {
+		prefix = path.Join(rulePrefix, args[0])
+	}
+	r, err := doRequest(cmd, prefix, http.MethodGet)
+	if err != nil {
+		fmt.Printf("Failed to get placement rules: %s\n", err)
+		return
+	}
+	fmt.Println(r)
+}
+
+func setPlacementRuleCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		fmt.Println(cmd.UsageString())
+		return
+	}
+	data, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		fmt.Printf("Failed to read the rule file: %s\n", err)
+		return
+	}
+	req, err := getRequest(cmd, rulePrefix, http.MethodPost, "application/json", bytes.NewBuffer(data))
+	if err != nil {
+		fmt.Printf("Failed to set placement rule: %s\n", err)
+		return
+	}
+	if _, err = dail(req); err != nil {
+		fmt.Printf("Failed to set placement rule: %s\n", err)
+		return
+	}
+	fmt.Println("Success!")
+}
+
+func deletePlacementRuleCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		fmt.Println(cmd.UsageString())
+		return
+	}
+	_, err := doRequest(cmd, path.Join(rulePrefix, args[0]), http.MethodDelete)
+	if err != nil {
+		fmt.Printf("Failed to delete placement rule %s: %s\n", args[0], err)
+		return
+	}
+	fmt.Println("Success!")
+}
+
+// NewRangeConstraintsCommand returns a range constraints subcommand of configCmd.
+func NewRangeConstraintsCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "range-constraints <subcommand>",
+		Short: "show or update the key range label constraints",
+	}
+	c.AddCommand(&cobra.Command{
+		Use:   "show [<constraint_id>]",
+		Short: "show all range constraints or the specified one",
+		Run:   showRangeConstraintsCommandFunc,
+	})
+	c.AddCommand(&cobra.Command{
+		Use:   "set <constraint_file>",
+		Short: "add or replace a range constraint with the JSON file",
+		Run:   setRangeConstraintCommandFunc,
+	})
+	c.AddCommand(&cobra.Command{
+		Use:   "delete <constraint_id>",
+		Short: "delete a range constraint",
+		Run:   deleteRangeConstraintCommandFunc,
+	})
+	return c
+}
+
+func showRangeConstraintsCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) > 1 {
+		fmt.Println(cmd.UsageString())
+		return
+	}
+	prefix := rangeConstraintsPrefix
+	if len(args) == 1 {
+		prefix = path.Join(rangeConstraintPrefix, args[0])
+	}
+	r, err := doRequest(cmd, prefix, http.MethodGet)
+	if err != nil {
+		fmt.Printf("Failed to get range constraints: %s\n", err)
+		return
+	}
+	fmt.Println(r)
+}
+
+func setRangeConstraintCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		fmt.Println(cmd.UsageString())
+		return
+	}
+	data, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		fmt.Printf("Failed to read the constraint file: %s\n", err)
+		return
+	}
+	req, err := getRequest(cmd, rangeConstraintPrefix, http.MethodPost, "application/json", bytes.NewBuffer(data))
+	if err != nil {
+		fmt.Printf("Failed to set range constraint: %s\n", err)
+		return
+	}
+	if _, err = dail(req); err != nil {
+		fmt.Printf("Failed to set range constraint: %s\n", err)
+		return
+	}
+	fmt.Println("Success!")
+}
+
+func deleteRangeConstraintCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		fmt.Println(cmd.UsageString())
+		return
+	}
+	_, err := doRequest(cmd, path.Join(rangeConstraintPrefix, args[0]), http.MethodDelete)
+	if err != nil {
+		fmt.Printf("Failed to delete range constraint %s: %s\n", args[0], err)
+		return
+	}
+	fmt.Println("Success!")
+}
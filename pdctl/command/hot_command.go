@@ -16,6 +16,9 @@ package command
 import (
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -24,6 +27,7 @@ const (
 	hotReadRegionsPrefix  = "pd/api/v1/hotspot/regions/read"
 	hotWriteRegionsPrefix = "pd/api/v1/hotspot/regions/write"
 	hotStoresPrefix       = "pd/api/v1/hotspot/stores"
+	hotHistoryPrefix      = "pd/api/v1/hotspot/regions/history"
 )
 
 // NewHotSpotCommand return a hot subcommand of rootCmd
@@ -35,6 +39,7 @@ func NewHotSpotCommand() *cobra.Command {
 	cmd.AddCommand(NewHotWriteRegionCommand())
 	cmd.AddCommand(NewHotReadRegionCommand())
 	cmd.AddCommand(NewHotStoreCommand())
+	cmd.AddCommand(NewHotHistoryCommand())
 	return cmd
 }
 
@@ -94,3 +99,62 @@ func showHotStoresCommandFunc(cmd *cobra.Command, args []string) {
 	}
 	fmt.Println(r)
 }
+
+// NewHotHistoryCommand return a hot regions history subcommand of hotSpotCmd
+func NewHotHistoryCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history --start-time=<unix_time> [--end-time=<unix_time>] [--type=<read|write>] [--store=<store_id>] [--table=<table_id>] [--limit=<limit>] [--next=<cursor>]",
+		Short: "show the history of the hot regions",
+		Run:   showHotHistoryCommandFunc,
+	}
+	cmd.Flags().Int64("start-time", 0, "the unix time in seconds to start with")
+	cmd.Flags().Int64("end-time", 0, "the unix time in seconds to end before, now by default")
+	cmd.Flags().String("type", "", "the hot type, read or write")
+	cmd.Flags().Uint64("store", 0, "the store of the hot peers")
+	cmd.Flags().Int64("table", 0, "the table overlapping with the hot regions")
+	cmd.Flags().Int("limit", 0, "the max number of the records in a page")
+	cmd.Flags().String("next", "", "the cursor of the next page")
+	return cmd
+}
+
+func showHotHistoryCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		fmt.Println(cmd.UsageString())
+		return
+	}
+
+	query := make(url.Values)
+	startTime, _ := cmd.Flags().GetInt64("start-time")
+	if startTime == 0 {
+		fmt.Println(cmd.UsageString())
+		return
+	}
+	query.Set("start_time", strconv.FormatInt(startTime, 10))
+	endTime, _ := cmd.Flags().GetInt64("end-time")
+	if endTime == 0 {
+		endTime = time.Now().Unix()
+	}
+	query.Set("end_time", strconv.FormatInt(endTime, 10))
+	if typ, _ := cmd.Flags().GetString("type"); typ != "" {
+		query.Set("hot_type", typ)
+	}
+	if storeID, _ := cmd.Flags().GetUint64("store"); storeID != 0 {
+		query.Set("store_id", strconv.FormatUint(storeID, 10))
+	}
+	if tableID, _ := cmd.Flags().GetInt64("table"); tableID != 0 {
+		query.Set("table_id", strconv.FormatInt(tableID, 10))
+	}
+	if limit, _ := cmd.Flags().GetInt("limit"); limit != 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	if next, _ := cmd.Flags().GetString("next"); next != "" {
+		query.Set("next", next)
+	}
+	path := hotHistoryPrefix + "?" + query.Encode()
+	r, err := doRequest(cmd, path, http.MethodGet)
+	if err != nil {
+		fmt.Printf("Failed to get hotspot history: %s\n", err)
+		return
+	}
+	fmt.Println(r)
+}
@@ -0,0 +1,138 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	keyRangeNamespacesPrefix     = "pd/api/v1/classifier/keyrange/namespaces"
+	keyRangeNamespaceRangePrefix = "pd/api/v1/classifier/keyrange/namespaces/range"
+	keyRangeStoreNsPrefix        = "pd/api/v1/classifier/keyrange/store_ns/%s"
+)
+
+// NewKeyRangeNamespaceCommand return a key range namespace sub-command of rootCmd
+func NewKeyRangeNamespaceCommand() *cobra.Command {
+	s := &cobra.Command{
+		Use:   "keyrange_ns [create|add|remove|set_store|rm_store]",
+		Short: "show the key range namespace information",
+		Run:   showKeyRangeNamespaceCommandFunc,
+	}
+	s.AddCommand(&cobra.Command{
+		Use:   "create <namespace>",
+		Short: "create namespace",
+		Run:   createKeyRangeNamespaceCommandFunc,
+	})
+	s.AddCommand(&cobra.Command{
+		Use:   "add <name> <start_key> [end_key]",
+		Short: "add hex encoded key range to namespace, the key range is unbounded if end_key is omitted",
+		Run:   addKeyRangeCommandFunc,
+	})
+	s.AddCommand(&cobra.Command{
+		Use:   "remove <name> <start_key> [end_key]",
+		Short: "remove hex encoded key range from namespace",
+		Run:   removeKeyRangeCommandFunc,
+	})
+	s.AddCommand(&cobra.Command{
+		Use:   "set_store <store_id> <namespace>",
+		Short: "set namespace to store",
+		Run:   setKeyRangeNamespaceStoreCommandFunc,
+	})
+	s.AddCommand(&cobra.Command{
+		Use:   "rm_store <store_id> <namespace>",
+		Short: "remove namespace from store",
+		Run:   removeKeyRangeNamespaceStoreCommandFunc,
+	})
+	return s
+}
+
+func showKeyRangeNamespaceCommandFunc(cmd *cobra.Command, args []string) {
+	r, err := doRequest(cmd, keyRangeNamespacesPrefix, http.MethodGet)
+	if err != nil {
+		fmt.Printf("Failed to get the namespace information: %s\n", err)
+		return
+	}
+	fmt.Println(r)
+}
+
+func createKeyRangeNamespaceCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		fmt.Println("Usage: keyrange_ns create <name>")
+		return
+	}
+	postJSON(cmd, keyRangeNamespacesPrefix, map[string]interface{}{
+		"namespace": args[0],
+	})
+}
+
+func addKeyRangeCommandFunc(cmd *cobra.Command, args []string) {
+	updateKeyRange(cmd, args, "add")
+}
+
+func removeKeyRangeCommandFunc(cmd *cobra.Command, args []string) {
+	updateKeyRange(cmd, args, "remove")
+}
+
+func updateKeyRange(cmd *cobra.Command, args []string, action string) {
+	if len(args) != 2 && len(args) != 3 {
+		fmt.Printf("Usage: keyrange_ns %s <name> <start_key> [end_key]\n", action)
+		return
+	}
+	input := map[string]interface{}{
+		"namespace": args[0],
+		"start_key": args[1],
+		"end_key":   "",
+		"action":    action,
+	}
+	if len(args) == 3 {
+		input["end_key"] = args[2]
+	}
+	for _, key := range []string{"start_key", "end_key"} {
+		if _, err := hex.DecodeString(input[key].(string)); err != nil {
+			fmt.Printf("%s should be hex encoded\n", key)
+			return
+		}
+	}
+	postJSON(cmd, keyRangeNamespaceRangePrefix, input)
+}
+
+func setKeyRangeNamespaceStoreCommandFunc(cmd *cobra.Command, args []string) {
+	updateKeyRangeNamespaceStore(cmd, args, "set_store", "add")
+}
+
+func removeKeyRangeNamespaceStoreCommandFunc(cmd *cobra.Command, args []string) {
+	updateKeyRangeNamespaceStore(cmd, args, "rm_store", "remove")
+}
+
+func updateKeyRangeNamespaceStore(cmd *cobra.Command, args []string, name, action string) {
+	if len(args) != 2 {
+		fmt.Printf("Usage: keyrange_ns %s <store_id> <namespace>\n", name)
+		return
+	}
+	if _, err := strconv.Atoi(args[0]); err != nil {
+		fmt.Println("store_id should be a number")
+		return
+	}
+	prefix := fmt.Sprintf(keyRangeStoreNsPrefix, args[0])
+	postJSON(cmd, prefix, map[string]interface{}{
+		"namespace": args[1],
+		"action":    action,
+	})
+}
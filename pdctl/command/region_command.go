@@ -18,8 +18,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os/exec"
 	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -30,6 +32,7 @@ var (
 	regionsWriteflowPrefix = "pd/api/v1/regions/writeflow"
 	regionsReadflowPrefix  = "pd/api/v1/regions/readflow"
 	regionsSiblingPrefix   = "pd/api/v1/regions/sibling"
+	regionsScatterPrefix   = "pd/api/v1/regions/scatter"
 	regionIDPrefix         = "pd/api/v1/region/id"
 	regionKeyPrefix        = "pd/api/v1/region/key"
 )
@@ -44,6 +47,7 @@ func NewRegionCommand() *cobra.Command {
 	r.AddCommand(NewRegionWithKeyCommand())
 	r.AddCommand(NewRegionWithCheckCommand())
 	r.AddCommand(NewRegionWithSiblingCommand())
+	r.AddCommand(NewRegionScatterCommand())
 
 	topRead := &cobra.Command{
 		Use:   "topread <limit>",
@@ -191,7 +195,7 @@ func decodeProtobufText(text string) (string, error) {
 // NewRegionWithCheckCommand return a region with check subcommand of regionCmd
 func NewRegionWithCheckCommand() *cobra.Command {
 	r := &cobra.Command{
-		Use:   "check [miss-peer|extra-peer|down-peer|pending-peer|incorrect-ns]",
+		Use:   "check [miss-peer|extra-peer|miss-learner|extra-learner|down-peer|pending-peer|incorrect-ns|constraint-violated|under-isolated]",
 		Short: "show the region with check specific status",
 		Run:   showRegionWithCheckCommandFunc,
 	}
@@ -238,6 +242,61 @@ func showRegionWithSiblingCommandFunc(cmd *cobra.Command, args []string) {
 	fmt.Println(r)
 }
 
+// NewRegionScatterCommand returns a scatter subcommand of regionCmd
+func NewRegionScatterCommand() *cobra.Command {
+	r := &cobra.Command{
+		Use:   "scatter [<start_key> <end_key>] [--regions=<id>,<id>] [--group=<name>]",
+		Short: "scatter the regions in the key range or of the ids within a group",
+		Run:   scatterRegionsCommandFunc,
+	}
+	r.Flags().String("regions", "", "the ids of the regions to scatter, separated by comma")
+	r.Flags().String("group", "", "the group of the regions to be evenly scattered")
+
+	progress := &cobra.Command{
+		Use:   "progress [--group=<name>]",
+		Short: "show the scatter progress of a group",
+		Run:   showScatterProgressCommandFunc,
+	}
+	progress.Flags().String("group", "", "the group of the regions")
+	r.AddCommand(progress)
+	return r
+}
+
+func scatterRegionsCommandFunc(cmd *cobra.Command, args []string) {
+	regions, _ := cmd.Flags().GetString("regions")
+	if (len(args) != 0 && len(args) != 2) || (len(args) == 0 && regions == "") {
+		fmt.Println(cmd.UsageString())
+		return
+	}
+
+	input := make(map[string]interface{})
+	if len(args) == 2 {
+		input["start_key"] = url.QueryEscape(args[0])
+		input["end_key"] = url.QueryEscape(args[1])
+	}
+	if regions != "" {
+		ids, err := parseUint64s(strings.Split(regions, ","))
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		input["region_ids"] = ids
+	}
+	input["group"], _ = cmd.Flags().GetString("group")
+	postJSON(cmd, regionsScatterPrefix, input)
+}
+
+func showScatterProgressCommandFunc(cmd *cobra.Command, args []string) {
+	group, _ := cmd.Flags().GetString("group")
+	prefix := regionsScatterPrefix + "?group=" + url.QueryEscape(group)
+	r, err := doRequest(cmd, prefix, http.MethodGet)
+	if err != nil {
+		fmt.Printf("Failed to get scatter progress: %s\n", err)
+		return
+	}
+	fmt.Println(r)
+}
+
 func printWithJQFilter(data, filter string) {
 	cmd := exec.Command("jq", "-c", filter)
 	stdin, err := cmd.StdinPipe()
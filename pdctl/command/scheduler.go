@@ -14,16 +14,20 @@
 package command
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
 var (
-	schedulersPrefix = "pd/api/v1/schedulers"
+	schedulersPrefix    = "pd/api/v1/schedulers"
+	filterRecordsPrefix = "pd/api/v1/filter-records"
 )
 
 // NewSchedulerCommand returns a scheduler command.
@@ -35,6 +39,11 @@ func NewSchedulerCommand() *cobra.Command {
 	c.AddCommand(NewShowSchedulerCommand())
 	c.AddCommand(NewAddSchedulerCommand())
 	c.AddCommand(NewRemoveSchedulerCommand())
+	c.AddCommand(NewPauseSchedulerCommand())
+	c.AddCommand(NewResumeSchedulerCommand())
+	c.AddCommand(NewSchedulerConfigCommand())
+	c.AddCommand(NewExplainSchedulerCommand())
+	c.AddCommand(NewFilterRecordsCommand())
 	return c
 }
 
@@ -42,9 +51,10 @@ func NewSchedulerCommand() *cobra.Command {
 func NewShowSchedulerCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "show",
-		Short: "show schedulers",
+		Short: "show the names of schedulers, or their pause status with --status",
 		Run:   showSchedulerCommandFunc,
 	}
+	c.Flags().String("status", "", "show the status of schedulers including the remaining pause time, can be all or paused")
 	return c
 }
 
@@ -54,7 +64,11 @@ func showSchedulerCommandFunc(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	r, err := doRequest(cmd, schedulersPrefix, http.MethodGet)
+	path := schedulersPrefix
+	if status := cmd.Flags().Lookup("status").Value.String(); status != "" {
+		path += "?status=" + url.QueryEscape(status)
+	}
+	r, err := doRequest(cmd, path, http.MethodGet)
 	if err != nil {
 		fmt.Println(err)
 		return
@@ -70,9 +84,11 @@ func NewAddSchedulerCommand() *cobra.Command {
 	}
 	c.AddCommand(NewGrantLeaderSchedulerCommand())
 	c.AddCommand(NewEvictLeaderSchedulerCommand())
+	c.AddCommand(NewEvictSlowStoreSchedulerCommand())
 	c.AddCommand(NewShuffleLeaderSchedulerCommand())
 	c.AddCommand(NewShuffleRegionSchedulerCommand())
 	c.AddCommand(NewScatterRangeSchedulerCommand())
+	c.AddCommand(NewPreferredLeaderSchedulerCommand())
 	return c
 }
 
@@ -90,7 +106,7 @@ func NewGrantLeaderSchedulerCommand() *cobra.Command {
 func NewEvictLeaderSchedulerCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "evict-leader-scheduler <store_id>",
-		Short: "add a scheduler to evict leader from a store",
+		Short: "add a store to the scheduler evicting leaders, remove it by `remove evict-leader-scheduler-<store_id>`",
 		Run:   addSchedulerForStoreCommandFunc,
 	}
 	return c
@@ -114,6 +130,16 @@ func addSchedulerForStoreCommandFunc(cmd *cobra.Command, args []string) {
 	postJSON(cmd, schedulersPrefix, input)
 }
 
+// NewEvictSlowStoreSchedulerCommand returns a command to add a evict-slow-store-scheduler.
+func NewEvictSlowStoreSchedulerCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "evict-slow-store-scheduler",
+		Short: "add a scheduler to evict leaders from the busy or slow stores temporarily",
+		Run:   addSchedulerCommandFunc,
+	}
+	return c
+}
+
 // NewShuffleLeaderSchedulerCommand returns a command to add a shuffle-leader-scheduler.
 func NewShuffleLeaderSchedulerCommand() *cobra.Command {
 	c := &cobra.Command{
@@ -169,6 +195,37 @@ func addSchedulerForScatterRangeCommandFunc(cmd *cobra.Command, args []string) {
 	postJSON(cmd, schedulersPrefix, input)
 }
 
+// NewPreferredLeaderSchedulerCommand returns a command to add a preferred-leader-scheduler.
+func NewPreferredLeaderSchedulerCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "preferred-leader-scheduler <key=value> [<key=value>...]",
+		Short: "add a scheduler to transfer leaders to the stores with the labels in the order of preference",
+		Run:   addSchedulerForPreferredLeaderCommandFunc,
+	}
+	return c
+}
+
+func addSchedulerForPreferredLeaderCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		fmt.Println(cmd.UsageString())
+		return
+	}
+
+	preferences := make([]map[string]string, 0, len(args))
+	for _, arg := range args {
+		kv := strings.SplitN(arg, "=", 2)
+		if len(kv) != 2 {
+			fmt.Println(cmd.UsageString())
+			return
+		}
+		preferences = append(preferences, map[string]string{"key": kv[0], "value": kv[1]})
+	}
+	input := make(map[string]interface{})
+	input["name"] = cmd.Name()
+	input["preferences"] = preferences
+	postJSON(cmd, schedulersPrefix, input)
+}
+
 // NewRemoveSchedulerCommand returns a command to remove scheduler.
 func NewRemoveSchedulerCommand() *cobra.Command {
 	c := &cobra.Command{
@@ -192,3 +249,187 @@ func removeSchedulerCommandFunc(cmd *cobra.Command, args []string) {
 		return
 	}
 }
+
+// NewPauseSchedulerCommand returns a command to pause a scheduler.
+func NewPauseSchedulerCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "pause <scheduler> <delay>",
+		Short: "pause a scheduler for a duration, such as 30m, the paused schedulers are shown by show --status paused",
+		Run:   pauseSchedulerCommandFunc,
+	}
+	return c
+}
+
+func pauseSchedulerCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		fmt.Println(cmd.UsageString())
+		return
+	}
+
+	delay, err := time.ParseDuration(args[1])
+	if err != nil {
+		// Treat a plain number as seconds.
+		seconds, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			fmt.Println(cmd.UsageString())
+			return
+		}
+		delay = time.Duration(seconds) * time.Second
+	}
+	if delay < time.Second {
+		fmt.Println("the delay should be at least 1s")
+		return
+	}
+
+	input := make(map[string]interface{})
+	input["delay"] = int64(delay / time.Second)
+	postJSON(cmd, schedulersPrefix+"/"+args[0], input)
+}
+
+// NewResumeSchedulerCommand returns a command to resume a paused scheduler.
+func NewResumeSchedulerCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "resume <scheduler>",
+		Short: "resume a paused scheduler",
+		Run:   resumeSchedulerCommandFunc,
+	}
+	return c
+}
+
+func resumeSchedulerCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		fmt.Println(cmd.UsageString())
+		return
+	}
+
+	input := make(map[string]interface{})
+	input["delay"] = 0
+	postJSON(cmd, schedulersPrefix+"/"+args[0], input)
+}
+
+// NewSchedulerConfigCommand returns commands to show and set the config of a scheduler.
+func NewSchedulerConfigCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "config",
+		Short: "show or set the config of a scheduler",
+	}
+	c.AddCommand(&cobra.Command{
+		Use:   "show <scheduler>",
+		Short: "show the config of a scheduler",
+		Run:   showSchedulerConfigCommandFunc,
+	})
+	c.AddCommand(&cobra.Command{
+		Use:   "set <scheduler> <key> <value>",
+		Short: "set a config item of a scheduler, the value is parsed as JSON if possible, e.g. `set evict-leader-scheduler store-id-list [1,2]`",
+		Run:   setSchedulerConfigCommandFunc,
+	})
+	return c
+}
+
+func showSchedulerConfigCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		fmt.Println(cmd.UsageString())
+		return
+	}
+
+	r, err := doRequest(cmd, schedulersPrefix+"/"+args[0]+"/config", http.MethodGet)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(r)
+}
+
+func setSchedulerConfigCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 3 {
+		fmt.Println(cmd.UsageString())
+		return
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(args[2]), &value); err != nil {
+		value = args[2]
+	}
+	input := map[string]interface{}{args[1]: value}
+	postJSON(cmd, schedulersPrefix+"/"+args[0]+"/config", input)
+}
+
+// NewExplainSchedulerCommand returns a command to explain how a scheduler
+// evaluates a region or a store.
+func NewExplainSchedulerCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "explain <scheduler> [--region=<region_id>] [--store=<store_id>]",
+		Short: "explain how a scheduler or the replica-checker evaluates a region or a store",
+		Run:   explainSchedulerCommandFunc,
+	}
+	c.Flags().Uint64("region", 0, "the region to explain")
+	c.Flags().Uint64("store", 0, "the store to explain")
+	return c
+}
+
+func explainSchedulerCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		fmt.Println(cmd.UsageString())
+		return
+	}
+
+	query := make(url.Values)
+	if regionID, _ := cmd.Flags().GetUint64("region"); regionID != 0 {
+		query.Set("region_id", strconv.FormatUint(regionID, 10))
+	}
+	if storeID, _ := cmd.Flags().GetUint64("store"); storeID != 0 {
+		query.Set("store_id", strconv.FormatUint(storeID, 10))
+	}
+	path := schedulersPrefix + "/" + args[0] + "/explain"
+	if len(query) != 0 {
+		path += "?" + query.Encode()
+	}
+	r, err := doRequest(cmd, path, http.MethodGet)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(r)
+}
+
+// NewFilterRecordsCommand returns a command to show the sampled records of
+// the stores recently rejected by filters.
+func NewFilterRecordsCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "filter-records [--scope=<scope>] [--type=<filter_type>] [--store=<store_id>]",
+		Short: "show the stores recently rejected by the filters of schedulers and checkers",
+		Run:   showFilterRecordsCommandFunc,
+	}
+	c.Flags().String("scope", "", "the scheduler or checker which uses the filter")
+	c.Flags().String("type", "", "the filter type, such as health-filter")
+	c.Flags().Uint64("store", 0, "the rejected store")
+	return c
+}
+
+func showFilterRecordsCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		fmt.Println(cmd.UsageString())
+		return
+	}
+
+	query := make(url.Values)
+	if scope, _ := cmd.Flags().GetString("scope"); scope != "" {
+		query.Set("scope", scope)
+	}
+	if typ, _ := cmd.Flags().GetString("type"); typ != "" {
+		query.Set("type", typ)
+	}
+	if storeID, _ := cmd.Flags().GetUint64("store"); storeID != 0 {
+		query.Set("store_id", strconv.FormatUint(storeID, 10))
+	}
+	path := filterRecordsPrefix
+	if len(query) != 0 {
+		path += "?" + query.Encode()
+	}
+	r, err := doRequest(cmd, path, http.MethodGet)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(r)
+}
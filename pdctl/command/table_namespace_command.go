@@ -17,31 +17,44 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
 
 const (
-	namespacesPrefix     = "pd/api/v1/classifier/table/namespaces"
-	namespaceTablePrefix = "pd/api/v1/classifier/table/namespaces/table"
-	namespaceMetaPrefix  = "pd/api/v1/classifier/table/namespaces/meta"
-	storeNsPrefix        = "pd/api/v1/classifier/table/store_ns/%s"
+	namespacesPrefix          = "pd/api/v1/classifier/table/namespaces"
+	namespaceTablePrefix      = "pd/api/v1/classifier/table/namespaces/table"
+	namespaceMetaPrefix       = "pd/api/v1/classifier/table/namespaces/meta"
+	namespaceLabelPrefix      = "pd/api/v1/classifier/table/namespaces/store_labels"
+	namespaceRenamePrefix     = "pd/api/v1/classifier/table/namespaces/rename"
+	namespaceMigratePrefix    = "pd/api/v1/classifier/table/namespaces/migrate"
+	namespaceMigrationsPrefix = "pd/api/v1/classifier/table/namespaces/migrations"
+	namespaceStatsPrefix      = "pd/api/v1/namespaces/%s/stats"
+	storeNsPrefix             = "pd/api/v1/classifier/table/store_ns/%s"
 )
 
 // NewTableNamespaceCommand return a table namespace sub-command of rootCmd
 func NewTableNamespaceCommand() *cobra.Command {
 	s := &cobra.Command{
-		Use:   "table_ns [create|add|remove|set_store|rm_store|set_meta|rm_meta]",
+		Use:   "table_ns [create|delete|rename|add|remove|migrate|migration|stats|set_store|rm_store|set_meta|rm_meta|set_store_labels|rm_store_labels]",
 		Short: "show the table namespace information",
 		Run:   showNamespaceCommandFunc,
 	}
 	s.AddCommand(NewCreateNamespaceCommand())
+	s.AddCommand(newDeleteNamespaceCommand())
+	s.AddCommand(newRenameNamespaceCommand())
 	s.AddCommand(NewAddTableIDCommand())
 	s.AddCommand(NewRemoveTableIDCommand())
+	s.AddCommand(newMigrateTableCommand())
+	s.AddCommand(newMigrationCommand())
+	s.AddCommand(newNamespaceStatsCommand())
 	s.AddCommand(NewSetNamespaceStoreCommand())
 	s.AddCommand(NewRemoveNamespaceStoreCommand())
 	s.AddCommand(newSetMetaNamespaceCommand())
 	s.AddCommand(newRemoveMetaNamespaceCommand())
+	s.AddCommand(newSetStoreLabelsNamespaceCommand())
+	s.AddCommand(newRemoveStoreLabelsNamespaceCommand())
 	return s
 }
 
@@ -230,3 +243,175 @@ func removeMetaNamespaceCommandFunc(cmd *cobra.Command, args []string) {
 	}
 	postJSON(cmd, namespaceMetaPrefix, input)
 }
+
+func newSetStoreLabelsNamespaceCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set_store_labels <namespace> <key>=<value>...",
+		Short: "set the label selector of the stores to namespace",
+		Run:   setStoreLabelsNamespaceCommandFunc,
+	}
+}
+
+func newRemoveStoreLabelsNamespaceCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rm_store_labels <namespace>",
+		Short: "remove the label selector of the stores from namespace",
+		Run:   removeStoreLabelsNamespaceCommandFunc,
+	}
+}
+
+func setStoreLabelsNamespaceCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) < 2 {
+		fmt.Println("Usage: set_store_labels <namespace> <key>=<value>...")
+		return
+	}
+	for _, label := range args[1:] {
+		if kv := strings.SplitN(label, "=", 2); len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			fmt.Printf("invalid store label %s, should be key=value\n", label)
+			return
+		}
+	}
+	input := map[string]interface{}{
+		"namespace":    args[0],
+		"store_labels": strings.Join(args[1:], ","),
+	}
+	postJSON(cmd, namespaceLabelPrefix, input)
+}
+
+func removeStoreLabelsNamespaceCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		fmt.Println("Usage: rm_store_labels <namespace>")
+		return
+	}
+	input := map[string]interface{}{
+		"namespace":    args[0],
+		"store_labels": "",
+	}
+	postJSON(cmd, namespaceLabelPrefix, input)
+}
+
+func newDeleteNamespaceCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete <namespace>",
+		Short: "delete an empty namespace",
+		Run:   deleteNamespaceCommandFunc,
+	}
+}
+
+func newRenameNamespaceCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rename <namespace> <new_name>",
+		Short: "rename namespace",
+		Run:   renameNamespaceCommandFunc,
+	}
+}
+
+func newMigrateTableCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "migrate <table_id> <namespace>",
+		Short: "migrate table to namespace",
+		Run:   migrateTableCommandFunc,
+	}
+}
+
+func newMigrationCommand() *cobra.Command {
+	m := &cobra.Command{
+		Use:   "migration [delete <table_id>]",
+		Short: "show the progress of the table migrations",
+		Run:   showMigrationCommandFunc,
+	}
+	m.AddCommand(&cobra.Command{
+		Use:   "delete <table_id>",
+		Short: "delete the progress of a table migration",
+		Run:   deleteMigrationCommandFunc,
+	})
+	return m
+}
+
+func deleteNamespaceCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		fmt.Println("Usage: delete <namespace>")
+		return
+	}
+	_, err := doRequest(cmd, namespacesPrefix+"/"+args[0], http.MethodDelete)
+	if err != nil {
+		fmt.Printf("Failed to delete namespace: %s\n", err)
+		return
+	}
+	fmt.Println("Success!")
+}
+
+func renameNamespaceCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		fmt.Println("Usage: rename <namespace> <new_name>")
+		return
+	}
+	input := map[string]interface{}{
+		"namespace": args[0],
+		"new_name":  args[1],
+	}
+	postJSON(cmd, namespaceRenamePrefix, input)
+}
+
+func migrateTableCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		fmt.Println("Usage: migrate <table_id> <namespace>")
+		return
+	}
+	if _, err := strconv.Atoi(args[0]); err != nil {
+		fmt.Println("table_id shoud be a number")
+		return
+	}
+	input := map[string]interface{}{
+		"table_id":  args[0],
+		"namespace": args[1],
+	}
+	postJSON(cmd, namespaceMigratePrefix, input)
+}
+
+func showMigrationCommandFunc(cmd *cobra.Command, args []string) {
+	r, err := doRequest(cmd, namespaceMigrationsPrefix, http.MethodGet)
+	if err != nil {
+		fmt.Printf("Failed to get the migrations: %s\n", err)
+		return
+	}
+	fmt.Println(r)
+}
+
+func deleteMigrationCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		fmt.Println("Usage: migration delete <table_id>")
+		return
+	}
+	if _, err := strconv.Atoi(args[0]); err != nil {
+		fmt.Println("table_id shoud be a number")
+		return
+	}
+	_, err := doRequest(cmd, namespaceMigrationsPrefix+"/"+args[0], http.MethodDelete)
+	if err != nil {
+		fmt.Printf("Failed to delete the migration: %s\n", err)
+		return
+	}
+	fmt.Println("Success!")
+}
+
+func newNamespaceStatsCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "stats <namespace>",
+		Short: "show the statistics of the stores and regions of namespace",
+		Run:   showNamespaceStatsCommandFunc,
+	}
+}
+
+func showNamespaceStatsCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		fmt.Println("Usage: stats <namespace>")
+		return
+	}
+	r, err := doRequest(cmd, fmt.Sprintf(namespaceStatsPrefix, args[0]), http.MethodGet)
+	if err != nil {
+		fmt.Printf("Failed to get the namespace statistics: %s\n", err)
+		return
+	}
+	fmt.Println(r)
+}
@@ -60,6 +60,7 @@ func Start(args []string) {
 		command.NewHotSpotCommand(),
 		command.NewClusterCommand(),
 		command.NewTableNamespaceCommand(),
+		command.NewKeyRangeNamespaceCommand(),
 		command.NewHealthCommand(),
 		command.NewLogCommand(),
 	)
@@ -28,6 +28,16 @@ func CheckAddPeer(c *check.C, op *schedule.Operator, kind schedule.OperatorKind,
 	c.Assert(op.Kind()&kind, check.Equals, kind)
 }
 
+// CheckAddLearner checks if the operator is to add a learner which is never
+// promoted on specified store.
+func CheckAddLearner(c *check.C, op *schedule.Operator, kind schedule.OperatorKind, storeID uint64) {
+	c.Assert(op, check.NotNil)
+	c.Assert(op.Len(), check.Equals, 1)
+	c.Assert(op.Step(0).(schedule.AddLearner).ToStore, check.Equals, storeID)
+	kind |= schedule.OpRegion
+	c.Assert(op.Kind()&kind, check.Equals, kind)
+}
+
 // CheckRemovePeer checks if the operator is to remove peer on specified store.
 func CheckRemovePeer(c *check.C, op *schedule.Operator, storeID uint64) {
 	if op.Len() == 1 {
@@ -98,3 +108,14 @@ func CheckTransferPeerWithLeaderTransferFrom(c *check.C, op *schedule.Operator,
 	kind |= (schedule.OpRegion | schedule.OpLeader)
 	c.Assert(op.Kind()&kind, check.Equals, kind)
 }
+
+// CheckTransferLearner checks if the operator is to transfer a learner
+// between the specified source and target stores without promoting it.
+func CheckTransferLearner(c *check.C, op *schedule.Operator, kind schedule.OperatorKind, sourceID, targetID uint64) {
+	c.Assert(op, check.NotNil)
+	c.Assert(op.Len(), check.Equals, 2)
+	c.Assert(op.Step(0).(schedule.AddLearner).ToStore, check.Equals, targetID)
+	c.Assert(op.Step(1).(schedule.RemovePeer).FromStore, check.Equals, sourceID)
+	kind |= schedule.OpRegion
+	c.Assert(op.Kind()&kind, check.Equals, kind)
+}
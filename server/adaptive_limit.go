@@ -0,0 +1,152 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	adaptiveLimitInterval = 30 * time.Second
+	// The cluster is under pressure if any of the signals reaches the high
+	// ratio, and it is idle if all the signals are below the low ratio.
+	pressureHighRatio = 0.8
+	pressureLowRatio  = 0.3
+	// operatorTimeoutHighRate is the rate of the timeout operators which
+	// means the cluster is under pressure.
+	operatorTimeoutHighRate = 0.1
+	// adaptiveLimitStep is the step to increase the adaptive factor when the
+	// cluster is idle, the factor is halved when it is under pressure.
+	adaptiveLimitStep = 0.25
+)
+
+// limitPressure is the signals of the cluster pressure which the adaptive
+// schedule limits are adjusted by.
+type limitPressure struct {
+	// PendingPeerRatio is the max ratio of the pending peers of a store to
+	// the max pending peer count.
+	PendingPeerRatio float64
+	// SnapshotRatio is the max ratio of the sending, receiving or applying
+	// snapshots of a store to the max snapshot count.
+	SnapshotRatio float64
+	// OperatorTimeoutRate is the rate of the timeout operators among the
+	// operators ended since the last adjustment.
+	OperatorTimeoutRate float64
+	// BusyStoreRatio is the ratio of the busy stores to the up stores.
+	BusyStoreRatio float64
+}
+
+func (p limitPressure) isHigh() bool {
+	return p.PendingPeerRatio >= pressureHighRatio || p.SnapshotRatio >= pressureHighRatio ||
+		p.OperatorTimeoutRate >= operatorTimeoutHighRate || p.BusyStoreRatio > 0
+}
+
+func (p limitPressure) isLow() bool {
+	return p.PendingPeerRatio < pressureLowRatio && p.SnapshotRatio < pressureLowRatio &&
+		p.OperatorTimeoutRate == 0 && p.BusyStoreRatio == 0
+}
+
+// adaptiveLimitController adjusts the adaptive factor of the schedule limits
+// by the pressure of the cluster. It halves the factor when the cluster is
+// under pressure, and increases it step by step when the cluster is idle.
+type adaptiveLimitController struct {
+	sync.Mutex
+	cluster *clusterInfo
+	// finished and timeout are the numbers of the operators ended since the
+	// last adjustment.
+	finished int
+	timeout  int
+}
+
+func newAdaptiveLimitController(cluster *clusterInfo) *adaptiveLimitController {
+	return &adaptiveLimitController{cluster: cluster}
+}
+
+// recordOperator records an operator which is finished or timeout.
+func (a *adaptiveLimitController) recordOperator(timeout bool) {
+	a.Lock()
+	defer a.Unlock()
+	if timeout {
+		a.timeout++
+	} else {
+		a.finished++
+	}
+}
+
+func (a *adaptiveLimitController) collectPressure() limitPressure {
+	var p limitPressure
+	maxPending := float64(a.cluster.GetMaxPendingPeerCount())
+	maxSnapshot := float64(a.cluster.GetMaxSnapshotCount())
+	var upStores, busyStores int
+	for _, store := range a.cluster.GetStores() {
+		if !store.IsUp() || store.IsDisconnected() {
+			continue
+		}
+		upStores++
+		if store.Stats.GetIsBusy() {
+			busyStores++
+		}
+		if maxPending > 0 {
+			p.PendingPeerRatio = math.Max(p.PendingPeerRatio, float64(store.PendingPeerCount)/maxPending)
+		}
+		if maxSnapshot > 0 {
+			snapshots := math.Max(float64(store.Stats.GetSendingSnapCount()), float64(store.Stats.GetReceivingSnapCount()))
+			snapshots = math.Max(snapshots, float64(store.Stats.GetApplyingSnapCount()))
+			p.SnapshotRatio = math.Max(p.SnapshotRatio, snapshots/maxSnapshot)
+		}
+	}
+	if upStores > 0 {
+		p.BusyStoreRatio = float64(busyStores) / float64(upStores)
+	}
+
+	a.Lock()
+	defer a.Unlock()
+	if total := a.finished + a.timeout; total > 0 {
+		p.OperatorTimeoutRate = float64(a.timeout) / float64(total)
+	}
+	a.finished, a.timeout = 0, 0
+	return p
+}
+
+// adjust updates the adaptive factor by the current pressure of the cluster.
+func (a *adaptiveLimitController) adjust() {
+	opt := a.cluster.opt
+	cfg := opt.load()
+	p := a.collectPressure()
+	old := opt.adaptiveFactor.Load().(float64)
+	factor := float64(1)
+	if cfg.EnableAdaptiveScheduleLimit {
+		factor = old
+		if p.isHigh() {
+			factor /= 2
+		} else if p.isLow() {
+			factor += adaptiveLimitStep
+		}
+		factor = math.Max(cfg.AdaptiveLimitMinRatio, math.Min(cfg.AdaptiveLimitMaxRatio, factor))
+	}
+
+	adaptiveLimitGauge.WithLabelValues("pending_peer_ratio").Set(p.PendingPeerRatio)
+	adaptiveLimitGauge.WithLabelValues("snapshot_ratio").Set(p.SnapshotRatio)
+	adaptiveLimitGauge.WithLabelValues("operator_timeout_rate").Set(p.OperatorTimeoutRate)
+	adaptiveLimitGauge.WithLabelValues("busy_store_ratio").Set(p.BusyStoreRatio)
+	adaptiveLimitGauge.WithLabelValues("factor").Set(factor)
+	if factor != old {
+		log.Infof("adaptive schedule limit factor is changed from %.2f to %.2f, pressure: %+v", old, factor, p)
+		opt.setAdaptiveLimitFactor(factor)
+	}
+}
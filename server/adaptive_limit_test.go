@@ -0,0 +1,92 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	. "github.com/pingcap/check"
+)
+
+var _ = Suite(&testAdaptiveLimitSuite{})
+
+type testAdaptiveLimitSuite struct{}
+
+func (s *testAdaptiveLimitSuite) TestAdaptiveLimit(c *C) {
+	cfg, opt := newTestScheduleConfig()
+	tc := newTestClusterInfo(opt)
+	tc.addRegionStore(1, 10)
+	tc.addRegionStore(2, 10)
+	a := newAdaptiveLimitController(tc.clusterInfo)
+
+	// The limits are not scaled if it is disabled.
+	a.adjust()
+	c.Assert(opt.GetAdaptiveLimitFactor(), Equals, float64(1))
+	c.Assert(opt.GetRegionScheduleLimit(""), Equals, cfg.RegionScheduleLimit)
+
+	// The factor increases step by step when the cluster is idle.
+	cfg.EnableAdaptiveScheduleLimit = true
+	a.adjust()
+	c.Assert(opt.GetAdaptiveLimitFactor(), Equals, 1+adaptiveLimitStep)
+	for i := 0; i < 10; i++ {
+		a.adjust()
+	}
+	c.Assert(opt.GetAdaptiveLimitFactor(), Equals, cfg.AdaptiveLimitMaxRatio)
+	c.Assert(opt.GetRegionScheduleLimit(""), Equals, uint64(float64(cfg.RegionScheduleLimit)*cfg.AdaptiveLimitMaxRatio))
+	c.Assert(opt.GetEffectiveScheduleConfig().AdaptiveLimitFactor, Equals, cfg.AdaptiveLimitMaxRatio)
+
+	// The factor is halved when the snapshots are piled up.
+	store := tc.GetStore(1)
+	store.Stats.SendingSnapCount = uint32(cfg.MaxSnapshotCount)
+	tc.putStore(store)
+	a.adjust()
+	c.Assert(opt.GetAdaptiveLimitFactor(), Equals, cfg.AdaptiveLimitMaxRatio/2)
+	store.Stats.SendingSnapCount = 0
+	tc.putStore(store)
+
+	// The factor is unchanged when the pressure is moderate.
+	store = tc.GetStore(2)
+	store.PendingPeerCount = int(cfg.MaxPendingPeerCount / 2)
+	tc.putStore(store)
+	a.adjust()
+	c.Assert(opt.GetAdaptiveLimitFactor(), Equals, cfg.AdaptiveLimitMaxRatio/2)
+	store.PendingPeerCount = 0
+	tc.putStore(store)
+
+	// The factor is halved when the operators time out, and bounded by the
+	// min ratio.
+	for i := 0; i < 5; i++ {
+		a.recordOperator(false)
+		a.recordOperator(true)
+		a.adjust()
+	}
+	c.Assert(opt.GetAdaptiveLimitFactor(), Equals, cfg.AdaptiveLimitMinRatio)
+	c.Assert(opt.GetRegionScheduleLimit(""), Equals, uint64(1))
+	c.Assert(opt.GetMergeScheduleLimit(""), Equals, uint64(2))
+
+	// The timeout operators are counted since the last adjustment.
+	a.adjust()
+	c.Assert(opt.GetAdaptiveLimitFactor(), Equals, cfg.AdaptiveLimitMinRatio+adaptiveLimitStep)
+
+	// The factor is halved when any store is busy.
+	store = tc.GetStore(2)
+	store.Stats.IsBusy = true
+	tc.putStore(store)
+	a.adjust()
+	c.Assert(opt.GetAdaptiveLimitFactor(), Equals, cfg.AdaptiveLimitMinRatio)
+
+	// The limits are restored once it is disabled.
+	cfg.EnableAdaptiveScheduleLimit = false
+	c.Assert(opt.GetRegionScheduleLimit(""), Equals, cfg.RegionScheduleLimit)
+	a.adjust()
+	c.Assert(opt.adaptiveFactor.Load(), Equals, float64(1))
+}
@@ -54,6 +54,10 @@ func (h *confHandler) Post(w http.ResponseWriter, r *http.Request) {
 		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	if err := resetLearnerLabels(data, &config.Replication); err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
 	if err := json.Unmarshal(data, &config.Replication); err != nil {
 		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
 		return
@@ -73,6 +77,10 @@ func (h *confHandler) GetSchedule(w http.ResponseWriter, r *http.Request) {
 	h.rd.JSON(w, http.StatusOK, h.svr.GetScheduleConfig())
 }
 
+func (h *confHandler) GetEffectiveSchedule(w http.ResponseWriter, r *http.Request) {
+	h.rd.JSON(w, http.StatusOK, h.svr.GetEffectiveScheduleConfig())
+}
+
 func (h *confHandler) SetSchedule(w http.ResponseWriter, r *http.Request) {
 	config := h.svr.GetScheduleConfig()
 	if err := readJSONRespondError(h.rd, w, r.Body, &config); err != nil {
@@ -92,14 +100,42 @@ func (h *confHandler) GetReplication(w http.ResponseWriter, r *http.Request) {
 
 func (h *confHandler) SetReplication(w http.ResponseWriter, r *http.Request) {
 	config := h.svr.GetReplicationConfig()
-	if err := readJSONRespondError(h.rd, w, r.Body, &config); err != nil {
+	data, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err = resetLearnerLabels(data, config); err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err = json.Unmarshal(data, config); err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	h.svr.SetReplicationConfig(*config)
+	if err = h.svr.SetReplicationConfig(*config); err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
 	h.rd.JSON(w, http.StatusOK, nil)
 }
 
+// resetLearnerLabels clears the learner labels of the config if the request
+// sets them, so the labels are replaced rather than merged into the current
+// ones and can be removed.
+func resetLearnerLabels(data []byte, config *server.ReplicationConfig) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return errors.Trace(err)
+	}
+	if _, ok := fields["learner-labels"]; ok {
+		config.LearnerLabels = nil
+	}
+	return nil
+}
+
 func (h *confHandler) GetNamespace(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	name := vars["name"]
@@ -109,9 +145,7 @@ func (h *confHandler) GetNamespace(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// adjust field that is zero value to global value
-	cfg := h.svr.GetNamespaceConfigWithAdjust(name)
-	h.rd.JSON(w, http.StatusOK, cfg)
+	h.rd.JSON(w, http.StatusOK, h.svr.GetNamespaceConfigDetail(name))
 }
 
 func (h *confHandler) SetNamespace(w http.ResponseWriter, r *http.Request) {
@@ -128,7 +162,10 @@ func (h *confHandler) SetNamespace(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.svr.SetNamespaceConfig(name, *config)
+	if err := h.svr.SetNamespaceConfig(name, *config); err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
 	h.rd.JSON(w, http.StatusOK, nil)
 }
 
@@ -93,6 +93,54 @@ func (s *testConfigSuite) TestConfigSchedule(c *C) {
 	c.Assert(*sc, DeepEquals, *sc1)
 }
 
+func (s *testConfigSuite) TestConfigEffectiveSchedule(c *C) {
+	addr := s.cfgs[rand.Intn(len(s.cfgs))].ClientUrls + apiPrefix + "/api/v1/config/schedule"
+	now := time.Now()
+	windows := map[string]interface{}{
+		"time-windows": []map[string]interface{}{{
+			"name":                  "busy",
+			"start":                 now.Add(-time.Hour).Format("15:04"),
+			"end":                   now.Add(time.Hour).Format("15:04"),
+			"region-schedule-limit": 1,
+			"disabled-schedulers":   []string{"balance-region-scheduler"},
+		}},
+	}
+	postData, err := json.Marshal(windows)
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(addr, postData), IsNil)
+
+	sc := &server.EffectiveScheduleConfig{}
+	c.Assert(readJSONWithURL(addr+"/effective", sc), IsNil)
+	c.Assert(sc.ActiveTimeWindow, Equals, "busy")
+	c.Assert(sc.RegionScheduleLimit, Equals, uint64(1))
+	c.Assert(sc.DisabledSchedulers, DeepEquals, []string{"balance-region-scheduler"})
+
+	// The time window is validated.
+	windows["time-windows"] = []map[string]interface{}{{"name": "busy", "start": "8:00pm", "end": "06:00"}}
+	postData, err = json.Marshal(windows)
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(addr, postData), NotNil)
+
+	// The rejected window doesn't change the active one.
+	cfg := &server.ScheduleConfig{}
+	c.Assert(readJSONWithURL(addr, cfg), IsNil)
+	c.Assert(cfg.TimeWindows, HasLen, 1)
+	c.Assert(cfg.TimeWindows[0].Start, Equals, now.Add(-time.Hour).Format("15:04"))
+	c.Assert(*cfg.TimeWindows[0].RegionScheduleLimit, Equals, uint64(1))
+	sc = &server.EffectiveScheduleConfig{}
+	c.Assert(readJSONWithURL(addr+"/effective", sc), IsNil)
+	c.Assert(sc.ActiveTimeWindow, Equals, "busy")
+
+	windows["time-windows"] = []map[string]interface{}{}
+	postData, err = json.Marshal(windows)
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(addr, postData), IsNil)
+	sc = &server.EffectiveScheduleConfig{}
+	c.Assert(readJSONWithURL(addr+"/effective", sc), IsNil)
+	c.Assert(sc.ActiveTimeWindow, Equals, "")
+	c.Assert(sc.RegionScheduleLimit, Not(Equals), uint64(1))
+}
+
 func (s *testConfigSuite) TestConfigReplication(c *C) {
 	addr := s.cfgs[rand.Intn(len(s.cfgs))].ClientUrls + apiPrefix + "/api/v1/config/replicate"
 	resp, err := doGet(addr)
@@ -128,6 +176,36 @@ func (s *testConfigSuite) TestConfigReplication(c *C) {
 	c.Assert(*rc, DeepEquals, *rc3)
 }
 
+func (s *testConfigSuite) TestConfigLearnerLabels(c *C) {
+	addr := s.cfgs[rand.Intn(len(s.cfgs))].ClientUrls + apiPrefix + "/api/v1/config/replicate"
+	postLearner := func(input map[string]interface{}) error {
+		postData, err := json.Marshal(input)
+		c.Assert(err, IsNil)
+		return postJSON(addr, postData)
+	}
+	getLearnerLabels := func() map[string]string {
+		rc := &server.ReplicationConfig{}
+		c.Assert(readJSONWithURL(addr, rc), IsNil)
+		return rc.LearnerLabels
+	}
+
+	labels := map[string]string{"engine": "tiflash", "zone": "z1"}
+	c.Assert(postLearner(map[string]interface{}{"learner-replicas": 1, "learner-labels": labels}), IsNil)
+	c.Assert(getLearnerLabels(), DeepEquals, labels)
+
+	// The labels are replaced rather than merged.
+	labels = map[string]string{"engine": "tiflash"}
+	c.Assert(postLearner(map[string]interface{}{"learner-labels": labels}), IsNil)
+	c.Assert(getLearnerLabels(), DeepEquals, labels)
+
+	// The invalid update leaves the config unchanged.
+	c.Assert(postLearner(map[string]interface{}{"learner-labels": map[string]string{}}), NotNil)
+	c.Assert(getLearnerLabels(), DeepEquals, labels)
+
+	c.Assert(postLearner(map[string]interface{}{"learner-replicas": 0, "learner-labels": map[string]string{}}), IsNil)
+	c.Assert(getLearnerLabels(), HasLen, 0)
+}
+
 func (s *testConfigSuite) TestConfigLabelProperty(c *C) {
 	addr := s.servers[0].GetAddr() + apiPrefix + "/api/v1/config/label-property"
 
@@ -144,9 +222,9 @@ func (s *testConfigSuite) TestConfigLabelProperty(c *C) {
 	c.Assert(cfg, HasLen, 0)
 
 	cmds := []string{
-		`{"type": "foo", "action": "set", "label-key": "zone", "label-value": "cn1"}`,
-		`{"type": "foo", "action": "set", "label-key": "zone", "label-value": "cn2"}`,
-		`{"type": "bar", "action": "set", "label-key": "host", "label-value": "h1"}`,
+		`{"type": "reject-leader", "action": "set", "label-key": "zone", "label-value": "cn1"}`,
+		`{"type": "reject-leader", "action": "set", "label-key": "zone", "label-value": "cn2"}`,
+		`{"type": "no-balance", "action": "set", "label-key": "host", "label-value": "h1"}`,
 	}
 	for _, cmd := range cmds {
 		err := postJSON(addr, []byte(cmd))
@@ -154,15 +232,25 @@ func (s *testConfigSuite) TestConfigLabelProperty(c *C) {
 	}
 	cfg = loadProperties()
 	c.Assert(cfg, HasLen, 2)
-	c.Assert(cfg["foo"], DeepEquals, []server.StoreLabel{
+	c.Assert(cfg["reject-leader"], DeepEquals, []server.StoreLabel{
 		{Key: "zone", Value: "cn1"},
 		{Key: "zone", Value: "cn2"},
 	})
-	c.Assert(cfg["bar"], DeepEquals, []server.StoreLabel{{Key: "host", Value: "h1"}})
+	c.Assert(cfg["no-balance"], DeepEquals, []server.StoreLabel{{Key: "host", Value: "h1"}})
+
+	// The unknown types and the empty labels are rejected.
+	cmds = []string{
+		`{"type": "foo", "action": "set", "label-key": "zone", "label-value": "cn1"}`,
+		`{"type": "read-only", "action": "set", "label-key": "zone", "label-value": ""}`,
+	}
+	for _, cmd := range cmds {
+		err := postJSON(addr, []byte(cmd))
+		c.Assert(err, NotNil)
+	}
 
 	cmds = []string{
-		`{"type": "foo", "action": "delete", "label-key": "zone", "label-value": "cn1"}`,
-		`{"type": "bar", "action": "delete", "label-key": "host", "label-value": "h1"}`,
+		`{"type": "reject-leader", "action": "delete", "label-key": "zone", "label-value": "cn1"}`,
+		`{"type": "no-balance", "action": "delete", "label-key": "host", "label-value": "h1"}`,
 	}
 	for _, cmd := range cmds {
 		err := postJSON(addr, []byte(cmd))
@@ -170,5 +258,5 @@ func (s *testConfigSuite) TestConfigLabelProperty(c *C) {
 	}
 	cfg = loadProperties()
 	c.Assert(cfg, HasLen, 1)
-	c.Assert(cfg["foo"], DeepEquals, []server.StoreLabel{{Key: "zone", Value: "cn2"}})
+	c.Assert(cfg["reject-leader"], DeepEquals, []server.StoreLabel{{Key: "zone", Value: "cn2"}})
 }
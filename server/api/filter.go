@@ -0,0 +1,60 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/pingcap/pd/server/schedule"
+	"github.com/unrolled/render"
+)
+
+type filterHandler struct {
+	rd *render.Render
+}
+
+func newFilterHandler(rd *render.Render) *filterHandler {
+	return &filterHandler{
+		rd: rd,
+	}
+}
+
+// GetRecords lists the sampled records of the recent rejections by filters,
+// from the newest to the oldest. They can be narrowed by the scope, type and
+// store_id query parameters.
+func (h *filterHandler) GetRecords(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	scope, typ := query.Get("scope"), query.Get("type")
+	var storeID uint64
+	if str := query.Get("store_id"); str != "" {
+		id, err := strconv.ParseUint(str, 10, 64)
+		if err != nil {
+			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		storeID = id
+	}
+
+	records := make([]schedule.FilterRecord, 0)
+	for _, record := range schedule.GetFilterRecords() {
+		if (scope != "" && record.Scope != scope) ||
+			(typ != "" && record.Type != typ) ||
+			(storeID != 0 && record.StoreID != storeID) {
+			continue
+		}
+		records = append(records, record)
+	}
+	h.rd.JSON(w, http.StatusOK, records)
+}
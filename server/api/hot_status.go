@@ -14,9 +14,18 @@
 package api
 
 import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/juju/errors"
 	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/table"
 	"github.com/unrolled/render"
 )
 
@@ -47,11 +56,144 @@ func (h *hotStatusHandler) GetHotReadRegions(w http.ResponseWriter, r *http.Requ
 	h.rd.JSON(w, http.StatusOK, h.Handler.GetHotReadRegions())
 }
 
+const (
+	defaultHistoryHotRegionsLimit = 1000
+	maxHistoryHotRegionsLimit     = 10000
+)
+
+// historyHotRegions is a page of the hot regions history.
+type historyHotRegions struct {
+	Records []*core.HistoryHotRegion `json:"records"`
+	// Next is the cursor to get the next page, it is empty if there are no
+	// more records.
+	Next string `json:"next,omitempty"`
+}
+
+// GetHistoryHotRegions lists a page of the hot regions sampled in the time
+// range, they can be narrowed by the hot_type, store_id and table_id query
+// parameters.
+func (h *hotStatusHandler) GetHistoryHotRegions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("start_time") == "" || query.Get("end_time") == "" {
+		h.rd.JSON(w, http.StatusBadRequest, "missing start_time or end_time")
+		return
+	}
+	startTime, err := strconv.ParseInt(query.Get("start_time"), 10, 64)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	endTime, err := strconv.ParseInt(query.Get("end_time"), 10, 64)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit := defaultHistoryHotRegionsLimit
+	if str := query.Get("limit"); str != "" {
+		limit, err = strconv.Atoi(str)
+		if err != nil {
+			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if limit <= 0 || limit > maxHistoryHotRegionsLimit {
+			h.rd.JSON(w, http.StatusBadRequest, fmt.Sprintf("limit should be in [1, %d]", maxHistoryHotRegionsLimit))
+			return
+		}
+	}
+	var after *core.HistoryHotRegion
+	if str := query.Get("next"); str != "" {
+		if after, err = parseHistoryHotRegionsCursor(str); err != nil {
+			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	var storeID uint64
+	if str := query.Get("store_id"); str != "" {
+		id, err := strconv.ParseUint(str, 10, 64)
+		if err != nil {
+			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		storeID = id
+	}
+	var tableID int64
+	if str := query.Get("table_id"); str != "" {
+		id, err := strconv.ParseInt(str, 10, 64)
+		if err != nil {
+			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		tableID = id
+	}
+	hotType := query.Get("hot_type")
+	if hotType != "" && hotType != core.HotTypeRead && hotType != core.HotTypeWrite {
+		h.rd.JSON(w, http.StatusBadRequest, "unknown hot type "+hotType)
+		return
+	}
+
+	// The records are filtered while being scanned, so that a page is only
+	// short at the end of the records.
+	filter := func(record *core.HistoryHotRegion) bool {
+		return (storeID == 0 || record.StoreID == storeID) &&
+			(tableID == 0 || overlapTable(record, tableID))
+	}
+	records, err := h.Handler.GetHistoryHotRegions(hotType, time.Unix(startTime, 0), time.Unix(endTime, 0), after, limit, filter)
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	res := &historyHotRegions{Records: records}
+	if res.Records == nil {
+		res.Records = []*core.HistoryHotRegion{}
+	}
+	// The page is full, there may be more records.
+	if len(records) == limit {
+		last := records[len(records)-1]
+		res.Next = fmt.Sprintf("%s/%d/%d/%d", last.HotType, last.UpdateTime, last.RegionID, last.StoreID)
+	}
+	h.rd.JSON(w, http.StatusOK, res)
+}
+
+// parseHistoryHotRegionsCursor parses the cursor of the next page in the
+// format of "hot_type/update_time/region_id/store_id".
+func parseHistoryHotRegionsCursor(s string) (*core.HistoryHotRegion, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 4 || (parts[0] != core.HotTypeRead && parts[0] != core.HotTypeWrite) {
+		return nil, errors.Errorf("invalid cursor %s", s)
+	}
+	record := &core.HistoryHotRegion{HotType: parts[0]}
+	var err error
+	if record.UpdateTime, err = strconv.ParseInt(parts[1], 10, 64); err != nil {
+		return nil, errors.Errorf("invalid cursor %s", s)
+	}
+	if record.RegionID, err = strconv.ParseUint(parts[2], 10, 64); err != nil {
+		return nil, errors.Errorf("invalid cursor %s", s)
+	}
+	if record.StoreID, err = strconv.ParseUint(parts[3], 10, 64); err != nil {
+		return nil, errors.Errorf("invalid cursor %s", s)
+	}
+	return record, nil
+}
+
+// overlapTable checks if the key range of the record overlaps with the table.
+func overlapTable(record *core.HistoryHotRegion, tableID int64) bool {
+	startKey, err := hex.DecodeString(record.StartKey)
+	if err != nil {
+		return false
+	}
+	endKey, err := hex.DecodeString(record.EndKey)
+	if err != nil {
+		return false
+	}
+	tableStart, tableEnd := table.EncodeRange(tableID)
+	return bytes.Compare(startKey, tableEnd) < 0 && (len(endKey) == 0 || bytes.Compare(endKey, tableStart) > 0)
+}
+
 func (h *hotStatusHandler) GetHotStores(w http.ResponseWriter, r *http.Request) {
 	bytesWriteStats := h.GetHotBytesWriteStores()
 	bytesReadStats := h.GetHotBytesReadStores()
 	keysWriteStats := h.GetHotKeysWriteStores()
-	keysReadStats := h.GetHotKeysWriteStores()
+	keysReadStats := h.GetHotKeysReadStores()
 
 	stats := hotStoreStats{
 		BytesWriteStats: bytesWriteStats,
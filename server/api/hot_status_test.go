@@ -14,12 +14,15 @@
 package api
 
 import (
+	"encoding/hex"
 	"fmt"
 	"net/http"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/core"
 	_ "github.com/pingcap/pd/server/schedulers"
+	"github.com/pingcap/pd/table"
 )
 
 var _ = Suite(&testHotStatusSuite{})
@@ -51,3 +54,40 @@ func (s testHotStatusSuite) TestGetHotStore(c *C) {
 	err = readJSON(resp.Body, &stat)
 	c.Assert(err, IsNil)
 }
+
+func (s testHotStatusSuite) TestGetHistoryHotRegions(c *C) {
+	var res historyHotRegions
+	err := readJSONWithURL(s.urlPrefix+"/regions/history?start_time=0&end_time=1&hot_type=write&store_id=1&table_id=1", &res)
+	c.Assert(err, IsNil)
+	c.Assert(res.Records, HasLen, 0)
+	c.Assert(res.Next, Equals, "")
+
+	for _, query := range []string{"end_time=1", "start_time=0", "start_time=a&end_time=1", "start_time=0&end_time=1&hot_type=unknown",
+		"start_time=0&end_time=1&store_id=a", "start_time=0&end_time=1&table_id=a", "start_time=0&end_time=1&limit=0",
+		"start_time=0&end_time=1&limit=10001", "start_time=0&end_time=1&next=write/a/1/1"} {
+		resp, err := http.Get(s.urlPrefix + "/regions/history?" + query)
+		c.Assert(err, IsNil)
+		c.Assert(resp.StatusCode, Equals, http.StatusBadRequest)
+		resp.Body.Close()
+	}
+}
+
+func (s testHotStatusSuite) TestParseHistoryHotRegionsCursor(c *C) {
+	record, err := parseHistoryHotRegionsCursor("read/10/2/3")
+	c.Assert(err, IsNil)
+	c.Assert(record, DeepEquals, &core.HistoryHotRegion{HotType: core.HotTypeRead, UpdateTime: 10, RegionID: 2, StoreID: 3})
+	for _, s := range []string{"", "read/10/2", "unknown/10/2/3", "read/10/a/3"} {
+		_, err = parseHistoryHotRegionsCursor(s)
+		c.Assert(err, NotNil)
+	}
+}
+
+func (s testHotStatusSuite) TestOverlapTable(c *C) {
+	start, end := table.EncodeRange(2)
+	record := &core.HistoryHotRegion{StartKey: hex.EncodeToString(start), EndKey: hex.EncodeToString(end)}
+	c.Assert(overlapTable(record, 1), IsFalse)
+	c.Assert(overlapTable(record, 2), IsTrue)
+	c.Assert(overlapTable(record, 3), IsFalse)
+	record.EndKey = ""
+	c.Assert(overlapTable(record, 3), IsTrue)
+}
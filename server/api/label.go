@@ -85,7 +85,8 @@ func (h *labelsHandler) GetStores(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		storeInfo := newStoreInfo(h.svr.GetScheduleConfig(), store)
+		storeInfo := newStoreInfo(h.svr.GetStoreScheduleConfig(store), store)
+		storeInfo.LabelProperties = h.svr.GetStoreLabelProperties(store)
 		storesInfo.Stores = append(storesInfo.Stores, storeInfo)
 	}
 	storesInfo.Count = len(storesInfo.Stores)
@@ -170,3 +170,21 @@ func (s *testLabelsStoreSuite) TestStoresLabelFilter(c *C) {
 	_, err := newStoresLabelFilter("test", ".[test")
 	c.Assert(err, NotNil)
 }
+
+func (s *testLabelsStoreSuite) TestStoresLabelProperties(c *C) {
+	c.Assert(s.svr.SetLabelProperty("no-balance", "disk", "hdd"), IsNil)
+	defer s.svr.DeleteLabelProperty("no-balance", "disk", "hdd")
+
+	url := fmt.Sprintf("%s/labels/stores?name=disk", s.urlPrefix)
+	info := new(StoresInfo)
+	err := readJSONWithURL(url, info)
+	c.Assert(err, IsNil)
+	c.Assert(info.Stores, HasLen, len(s.stores))
+	for _, store := range info.Stores {
+		if store.Store.GetId() == 4 {
+			c.Assert(store.LabelProperties, DeepEquals, []string{"no-balance"})
+		} else {
+			c.Assert(store.LabelProperties, HasLen, 0)
+		}
+	}
+}
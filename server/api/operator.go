@@ -206,6 +206,12 @@ func (h *operatorHandler) Post(w http.ResponseWriter, r *http.Request) {
 			h.r.JSON(w, http.StatusBadRequest, "missing region id")
 			return
 		}
+		// TiKV decides the split key itself as pdpb.SplitRegion can't carry
+		// the keys yet, so reject them instead of splitting elsewhere.
+		if _, ok := input["keys"]; ok {
+			h.r.JSON(w, http.StatusBadRequest, "splitting at explicit keys is not supported")
+			return
+		}
 		policy, ok := input["policy"].(string)
 		if !ok {
 			h.r.JSON(w, http.StatusBadRequest, "missing split policy")
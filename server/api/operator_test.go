@@ -77,6 +77,18 @@ func (s *testOperatorSuite) TestAddRemovePeer(c *C) {
 	operator = mustReadURL(c, regionURL)
 	c.Log(operator)
 	c.Assert(strings.Contains(operator, "remove peer on store 2"), IsTrue)
+
+	err = doDelete(regionURL)
+	c.Assert(err, IsNil)
+
+	// Splitting at explicit keys is rejected until TiKV supports it.
+	err = postJSON(fmt.Sprintf("%s/operators", s.urlPrefix), []byte(`{"name":"split-region", "region_id": 1, "policy": "scan", "keys": ["a"]}`))
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "not supported"), IsTrue)
+	err = postJSON(fmt.Sprintf("%s/operators", s.urlPrefix), []byte(`{"name":"split-region", "region_id": 1, "policy": "scan"}`))
+	c.Assert(err, IsNil)
+	operator = mustReadURL(c, regionURL)
+	c.Assert(strings.Contains(operator, "split region with policy SCAN"), IsTrue)
 }
 
 func mustPutStore(c *C, svr *server.Server, id uint64, state metapb.StoreState, labels []*metapb.StoreLabel) {
@@ -0,0 +1,95 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/schedule/placement"
+	"github.com/unrolled/render"
+)
+
+type rangeConstraintHandler struct {
+	*server.Handler
+	rd *render.Render
+}
+
+func newRangeConstraintHandler(handler *server.Handler, rd *render.Render) *rangeConstraintHandler {
+	return &rangeConstraintHandler{
+		Handler: handler,
+		rd:      rd,
+	}
+}
+
+func (h *rangeConstraintHandler) List(w http.ResponseWriter, r *http.Request) {
+	manager, err := h.GetRangeConstraintManager()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, manager.GetAllConstraints())
+}
+
+func (h *rangeConstraintHandler) Get(w http.ResponseWriter, r *http.Request) {
+	manager, err := h.GetRangeConstraintManager()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	id := mux.Vars(r)["id"]
+	constraint := manager.GetConstraint(id)
+	if constraint == nil {
+		h.rd.JSON(w, http.StatusNotFound, fmt.Sprintf("range constraint %s not found", id))
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, constraint)
+}
+
+func (h *rangeConstraintHandler) Set(w http.ResponseWriter, r *http.Request) {
+	manager, err := h.GetRangeConstraintManager()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var constraint placement.RangeConstraint
+	if err := readJSONRespondError(h.rd, w, r.Body, &constraint); err != nil {
+		return
+	}
+	if err := manager.SetConstraint(&constraint); err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, nil)
+}
+
+func (h *rangeConstraintHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	manager, err := h.GetRangeConstraintManager()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	id := mux.Vars(r)["id"]
+	if manager.GetConstraint(id) == nil {
+		h.rd.JSON(w, http.StatusNotFound, fmt.Sprintf("range constraint %s not found", id))
+		return
+	}
+	if err := manager.DeleteConstraint(id); err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, nil)
+}
@@ -0,0 +1,98 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/schedule/placement"
+	"github.com/pingcap/pd/table"
+)
+
+var _ = Suite(&testRangeConstraintSuite{})
+
+type testRangeConstraintSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testRangeConstraintSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+	mustPutStore(c, s.svr, 1, metapb.StoreState_Up, []*metapb.StoreLabel{{Key: "disk", Value: "ssd"}})
+	mustPutStore(c, s.svr, 2, metapb.StoreState_Up, []*metapb.StoreLabel{{Key: "disk", Value: "hdd"}})
+}
+
+func (s *testRangeConstraintSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testRangeConstraintSuite) TestAPI(c *C) {
+	startKey, endKey := table.EncodeRange(45)
+	mustRegionHeartbeat(c, s.svr, newTestRegionInfo(2, 1, startKey, endKey))
+	mustRegionHeartbeat(c, s.svr, newTestRegionInfo(3, 2, endKey, []byte("")))
+
+	constraint := &placement.RangeConstraint{
+		ID:               "t45",
+		TableID:          45,
+		LabelConstraints: []placement.LabelConstraint{{Key: "disk", Op: placement.In}},
+	}
+	data, err := json.Marshal(constraint)
+	c.Assert(err, IsNil)
+	// The label constraint has no value.
+	c.Assert(postJSON(s.urlPrefix+"/config/range-constraint", data), NotNil)
+
+	constraint.LabelConstraints[0].Values = []string{"ssd"}
+	data, err = json.Marshal(constraint)
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(s.urlPrefix+"/config/range-constraint", data), IsNil)
+
+	var got placement.RangeConstraint
+	c.Assert(readJSONWithURL(s.urlPrefix+"/config/range-constraint/t45", &got), IsNil)
+	c.Assert(got.TableID, Equals, int64(45))
+	var constraints []*placement.RangeConstraint
+	c.Assert(readJSONWithURL(s.urlPrefix+"/config/range-constraints", &constraints), IsNil)
+	c.Assert(constraints, HasLen, 1)
+
+	var regions []*core.RegionInfo
+	c.Assert(readJSONWithURL(s.urlPrefix+"/regions/check/constraint-violated", &regions), IsNil)
+	c.Assert(regions, HasLen, 0)
+
+	// Region 3 is on the hdd store.
+	constraint.ID = "t46"
+	constraint.TableID = 46
+	data, err = json.Marshal(constraint)
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(s.urlPrefix+"/config/range-constraint", data), IsNil)
+	c.Assert(readJSONWithURL(s.urlPrefix+"/regions/check/constraint-violated", &regions), IsNil)
+	c.Assert(regions, HasLen, 1)
+	c.Assert(regions[0].GetId(), Equals, uint64(3))
+
+	c.Assert(doDelete(s.urlPrefix+"/config/range-constraint/t46"), IsNil)
+	_, err = doGet(s.urlPrefix + "/config/range-constraint/t46")
+	c.Assert(err, NotNil)
+	c.Assert(readJSONWithURL(s.urlPrefix+"/regions/check/constraint-violated", &regions), IsNil)
+	c.Assert(regions, HasLen, 0)
+}
@@ -34,12 +34,15 @@ type regionInfo struct {
 	EndKey      string              `json:"end_key"`
 	RegionEpoch *metapb.RegionEpoch `json:"epoch,omitempty"`
 	Peers       []*metapb.Peer      `json:"peers,omitempty"`
+	Learners    []*metapb.Peer      `json:"learners,omitempty"`
 
 	Leader          *metapb.Peer      `json:"leader,omitempty"`
 	DownPeers       []*pdpb.PeerStats `json:"down_peers,omitempty"`
 	PendingPeers    []*metapb.Peer    `json:"pending_peers,omitempty"`
 	WrittenBytes    uint64            `json:"written_bytes,omitempty"`
+	WrittenKeys     uint64            `json:"written_keys,omitempty"`
 	ReadBytes       uint64            `json:"read_bytes,omitempty"`
+	ReadKeys        uint64            `json:"read_keys,omitempty"`
 	ApproximateSize int64             `json:"approximate_size,omitempty"`
 	ApproximateKeys int64             `json:"approximate_keys,omitempty"`
 }
@@ -54,11 +57,14 @@ func newRegionInfo(r *core.RegionInfo) *regionInfo {
 		EndKey:          strings.Trim(fmt.Sprintf("%q", r.EndKey), "\""),
 		RegionEpoch:     r.RegionEpoch,
 		Peers:           r.Peers,
+		Learners:        r.Learners,
 		Leader:          r.Leader,
 		DownPeers:       r.DownPeers,
 		PendingPeers:    r.PendingPeers,
 		WrittenBytes:    r.WrittenBytes,
+		WrittenKeys:     r.WrittenKeys,
 		ReadBytes:       r.ReadBytes,
+		ReadKeys:        r.ReadKeys,
 		ApproximateSize: r.ApproximateSize,
 		ApproximateKeys: r.ApproximateKeys,
 	}
@@ -163,6 +169,26 @@ func (h *regionsHandler) GetExtraPeerRegions(w http.ResponseWriter, r *http.Requ
 	h.rd.JSON(w, http.StatusOK, res)
 }
 
+func (h *regionsHandler) GetMissLearnerRegions(w http.ResponseWriter, r *http.Request) {
+	handler := h.svr.GetHandler()
+	res, err := handler.GetMissLearnerRegions()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, res)
+}
+
+func (h *regionsHandler) GetExtraLearnerRegions(w http.ResponseWriter, r *http.Request) {
+	handler := h.svr.GetHandler()
+	res, err := handler.GetExtraLearnerRegions()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, res)
+}
+
 func (h *regionsHandler) GetPendingPeerRegions(w http.ResponseWriter, r *http.Request) {
 	handler := h.svr.GetHandler()
 	res, err := handler.GetPendingPeerRegions()
@@ -193,6 +219,26 @@ func (h *regionsHandler) GetIncorrectNamespaceRegions(w http.ResponseWriter, r *
 	h.rd.JSON(w, http.StatusOK, res)
 }
 
+func (h *regionsHandler) GetConstraintViolatedRegions(w http.ResponseWriter, r *http.Request) {
+	handler := h.svr.GetHandler()
+	res, err := handler.GetConstraintViolatedRegions()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, res)
+}
+
+func (h *regionsHandler) GetUnderIsolatedRegions(w http.ResponseWriter, r *http.Request) {
+	handler := h.svr.GetHandler()
+	res, err := handler.GetUnderIsolatedRegions()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, res)
+}
+
 func (h *regionsHandler) GetRegionSiblings(w http.ResponseWriter, r *http.Request) {
 	cluster := h.svr.GetRaftCluster()
 	if cluster == nil {
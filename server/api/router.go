@@ -41,7 +41,14 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	schedulerHandler := newSchedulerHandler(handler, rd)
 	router.HandleFunc("/api/v1/schedulers", schedulerHandler.List).Methods("GET")
 	router.HandleFunc("/api/v1/schedulers", schedulerHandler.Post).Methods("POST")
+	router.HandleFunc("/api/v1/schedulers/{name}", schedulerHandler.PauseOrResume).Methods("POST")
 	router.HandleFunc("/api/v1/schedulers/{name}", schedulerHandler.Delete).Methods("DELETE")
+	router.HandleFunc("/api/v1/schedulers/{name}/config", schedulerHandler.GetConfig).Methods("GET")
+	router.HandleFunc("/api/v1/schedulers/{name}/config", schedulerHandler.SetConfig).Methods("POST")
+	router.HandleFunc("/api/v1/schedulers/{name}/explain", schedulerHandler.Explain).Methods("GET")
+
+	filterHandler := newFilterHandler(rd)
+	router.HandleFunc("/api/v1/filter-records", filterHandler.GetRecords).Methods("GET")
 
 	router.Handle("/api/v1/cluster", newClusterHandler(svr, rd)).Methods("GET")
 	router.HandleFunc("/api/v1/cluster/status", newClusterHandler(svr, rd).GetClusterStatus).Methods("GET")
@@ -51,6 +58,7 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	router.HandleFunc("/api/v1/config", confHandler.Post).Methods("POST")
 	router.HandleFunc("/api/v1/config/schedule", confHandler.SetSchedule).Methods("POST")
 	router.HandleFunc("/api/v1/config/schedule", confHandler.GetSchedule).Methods("GET")
+	router.HandleFunc("/api/v1/config/schedule/effective", confHandler.GetEffectiveSchedule).Methods("GET")
 	router.HandleFunc("/api/v1/config/replicate", confHandler.SetReplication).Methods("POST")
 	router.HandleFunc("/api/v1/config/replicate", confHandler.GetReplication).Methods("GET")
 	router.HandleFunc("/api/v1/config/namespace/{name}", confHandler.GetNamespace).Methods("GET")
@@ -61,6 +69,18 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	router.HandleFunc("/api/v1/config/cluster-version", confHandler.GetClusterVersion).Methods("GET")
 	router.HandleFunc("/api/v1/config/cluster-version", confHandler.SetClusterVersion).Methods("POST")
 
+	ruleHandler := newRuleHandler(handler, rd)
+	router.HandleFunc("/api/v1/config/rules", ruleHandler.List).Methods("GET")
+	router.HandleFunc("/api/v1/config/rule", ruleHandler.Set).Methods("POST")
+	router.HandleFunc("/api/v1/config/rule/{id}", ruleHandler.Get).Methods("GET")
+	router.HandleFunc("/api/v1/config/rule/{id}", ruleHandler.Delete).Methods("DELETE")
+
+	rangeConstraintHandler := newRangeConstraintHandler(handler, rd)
+	router.HandleFunc("/api/v1/config/range-constraints", rangeConstraintHandler.List).Methods("GET")
+	router.HandleFunc("/api/v1/config/range-constraint", rangeConstraintHandler.Set).Methods("POST")
+	router.HandleFunc("/api/v1/config/range-constraint/{id}", rangeConstraintHandler.Get).Methods("GET")
+	router.HandleFunc("/api/v1/config/range-constraint/{id}", rangeConstraintHandler.Delete).Methods("DELETE")
+
 	storeHandler := newStoreHandler(svr, rd)
 	router.HandleFunc("/api/v1/store/{id}", storeHandler.Get).Methods("GET")
 	router.HandleFunc("/api/v1/store/{id}", storeHandler.Delete).Methods("DELETE")
@@ -76,6 +96,7 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	hotStatusHandler := newHotStatusHandler(handler, rd)
 	router.HandleFunc("/api/v1/hotspot/regions/write", hotStatusHandler.GetHotWriteRegions).Methods("GET")
 	router.HandleFunc("/api/v1/hotspot/regions/read", hotStatusHandler.GetHotReadRegions).Methods("GET")
+	router.HandleFunc("/api/v1/hotspot/regions/history", hotStatusHandler.GetHistoryHotRegions).Methods("GET")
 	router.HandleFunc("/api/v1/hotspot/stores", hotStatusHandler.GetHotStores).Methods("GET")
 
 	regionHandler := newRegionHandler(svr, rd)
@@ -88,10 +109,18 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	router.HandleFunc("/api/v1/regions/readflow", regionsHandler.GetTopReadFlow).Methods("GET")
 	router.HandleFunc("/api/v1/regions/check/miss-peer", regionsHandler.GetMissPeerRegions).Methods("GET")
 	router.HandleFunc("/api/v1/regions/check/extra-peer", regionsHandler.GetExtraPeerRegions).Methods("GET")
+	router.HandleFunc("/api/v1/regions/check/miss-learner", regionsHandler.GetMissLearnerRegions).Methods("GET")
+	router.HandleFunc("/api/v1/regions/check/extra-learner", regionsHandler.GetExtraLearnerRegions).Methods("GET")
 	router.HandleFunc("/api/v1/regions/check/pending-peer", regionsHandler.GetPendingPeerRegions).Methods("GET")
 	router.HandleFunc("/api/v1/regions/check/down-peer", regionsHandler.GetDownPeerRegions).Methods("GET")
 	router.HandleFunc("/api/v1/regions/sibling/{id}", regionsHandler.GetRegionSiblings).Methods("GET")
 	router.HandleFunc("/api/v1/regions/check/incorrect-ns", regionsHandler.GetIncorrectNamespaceRegions).Methods("GET")
+	router.HandleFunc("/api/v1/regions/check/constraint-violated", regionsHandler.GetConstraintViolatedRegions).Methods("GET")
+	router.HandleFunc("/api/v1/regions/check/under-isolated", regionsHandler.GetUnderIsolatedRegions).Methods("GET")
+
+	scatterHandler := newScatterHandler(handler, rd)
+	router.HandleFunc("/api/v1/regions/scatter", scatterHandler.Get).Methods("GET")
+	router.HandleFunc("/api/v1/regions/scatter", scatterHandler.Post).Methods("POST")
 
 	router.Handle("/api/v1/version", newVersionHandler(rd)).Methods("GET")
 	router.Handle("/api/v1/status", newStatusHandler(rd)).Methods("GET")
@@ -113,6 +142,7 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 
 	statsHandler := newStatsHandler(svr, rd)
 	router.HandleFunc("/api/v1/stats/region", statsHandler.Region).Methods("GET")
+	router.HandleFunc("/api/v1/namespaces/{name}/stats", statsHandler.Namespace).Methods("GET")
 
 	trendHandler := newTrendHandler(svr, rd)
 	router.HandleFunc("/api/v1/trend", trendHandler.Handle).Methods("GET")
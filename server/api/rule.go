@@ -0,0 +1,95 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/schedule/placement"
+	"github.com/unrolled/render"
+)
+
+type ruleHandler struct {
+	*server.Handler
+	rd *render.Render
+}
+
+func newRuleHandler(handler *server.Handler, rd *render.Render) *ruleHandler {
+	return &ruleHandler{
+		Handler: handler,
+		rd:      rd,
+	}
+}
+
+func (h *ruleHandler) List(w http.ResponseWriter, r *http.Request) {
+	manager, err := h.GetRuleManager()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, manager.GetAllRules())
+}
+
+func (h *ruleHandler) Get(w http.ResponseWriter, r *http.Request) {
+	manager, err := h.GetRuleManager()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	id := mux.Vars(r)["id"]
+	rule := manager.GetRule(id)
+	if rule == nil {
+		h.rd.JSON(w, http.StatusNotFound, fmt.Sprintf("placement rule %s not found", id))
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, rule)
+}
+
+func (h *ruleHandler) Set(w http.ResponseWriter, r *http.Request) {
+	manager, err := h.GetRuleManager()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var rule placement.Rule
+	if err := readJSONRespondError(h.rd, w, r.Body, &rule); err != nil {
+		return
+	}
+	if err := manager.SetRule(&rule); err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, nil)
+}
+
+func (h *ruleHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	manager, err := h.GetRuleManager()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	id := mux.Vars(r)["id"]
+	if manager.GetRule(id) == nil {
+		h.rd.JSON(w, http.StatusNotFound, fmt.Sprintf("placement rule %s not found", id))
+		return
+	}
+	if err := manager.DeleteRule(id); err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, nil)
+}
@@ -0,0 +1,94 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/schedule/placement"
+)
+
+var _ = Suite(&testRuleSuite{})
+
+type testRuleSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testRuleSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1/config", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+}
+
+func (s *testRuleSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testRuleSuite) TestAPI(c *C) {
+	var rules []*placement.Rule
+	c.Assert(readJSONWithURL(s.urlPrefix+"/rules", &rules), IsNil)
+	c.Assert(rules, HasLen, 1)
+	c.Assert(rules[0].ID, Equals, placement.DefaultRuleID)
+	c.Assert(rules[0].Count, Equals, int(s.svr.GetReplicationConfig().MaxReplicas))
+
+	rule := &placement.Rule{
+		ID:               "ssd",
+		Index:            1,
+		StartKeyHex:      "7480000000000000ff",
+		EndKeyHex:        "7480000000000000ff1",
+		Role:             placement.Voter,
+		Count:            3,
+		LabelConstraints: []placement.LabelConstraint{{Key: "disk", Op: placement.In, Values: []string{"ssd"}}},
+	}
+	data, err := json.Marshal(rule)
+	c.Assert(err, IsNil)
+	// The end key is not a valid hex string.
+	c.Assert(postJSON(s.urlPrefix+"/rule", data), NotNil)
+
+	rule.EndKeyHex = "7480000000000000ff10"
+	data, err = json.Marshal(rule)
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(s.urlPrefix+"/rule", data), IsNil)
+
+	var got placement.Rule
+	c.Assert(readJSONWithURL(s.urlPrefix+"/rule/ssd", &got), IsNil)
+	c.Assert(got.StartKeyHex, Equals, rule.StartKeyHex)
+	c.Assert(got.EndKeyHex, Equals, rule.EndKeyHex)
+	c.Assert(got.LabelConstraints, DeepEquals, rule.LabelConstraints)
+	c.Assert(readJSONWithURL(s.urlPrefix+"/rules", &rules), IsNil)
+	c.Assert(rules, HasLen, 2)
+	c.Assert(rules[1].ID, Equals, "ssd")
+
+	c.Assert(doDelete(s.urlPrefix+"/rule/ssd"), IsNil)
+	_, err = doGet(s.urlPrefix + "/rule/ssd")
+	c.Assert(err, NotNil)
+	c.Assert(readJSONWithURL(s.urlPrefix+"/rules", &rules), IsNil)
+	c.Assert(rules, HasLen, 1)
+
+	// The default rule follows the replication config.
+	c.Assert(postJSON(s.urlPrefix+"/replicate", []byte(`{"max-replicas": 5, "location-labels": "zone"}`)), IsNil)
+	c.Assert(readJSONWithURL(s.urlPrefix+"/rule/"+placement.DefaultRuleID, &got), IsNil)
+	c.Assert(got.Count, Equals, 5)
+	c.Assert(got.LocationLabels, DeepEquals, []string{"zone"})
+	c.Assert(postJSON(s.urlPrefix+"/replicate", []byte(`{"max-replicas": 3, "location-labels": ""}`)), IsNil)
+}
@@ -0,0 +1,86 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/pd/server"
+	"github.com/unrolled/render"
+)
+
+type scatterHandler struct {
+	*server.Handler
+	r *render.Render
+}
+
+func newScatterHandler(handler *server.Handler, r *render.Render) *scatterHandler {
+	return &scatterHandler{
+		Handler: handler,
+		r:       r,
+	}
+}
+
+// scatterInput is the request to scatter regions, the keys are escaped in the
+// same way as the scatter-range-scheduler.
+type scatterInput struct {
+	StartKey  string   `json:"start_key"`
+	EndKey    string   `json:"end_key"`
+	RegionIDs []uint64 `json:"region_ids"`
+	Group     string   `json:"group"`
+}
+
+func (h *scatterHandler) Post(w http.ResponseWriter, r *http.Request) {
+	var input scatterInput
+	if err := readJSONRespondError(h.r, w, r.Body, &input); err != nil {
+		return
+	}
+	if input.StartKey == "" && input.EndKey == "" && len(input.RegionIDs) == 0 {
+		h.r.JSON(w, http.StatusBadRequest, "missing key range or region ids")
+		return
+	}
+
+	startKey, err := url.QueryUnescape(input.StartKey)
+	if err != nil {
+		h.r.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	endKey, err := url.QueryUnescape(input.EndKey)
+	if err != nil {
+		h.r.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	progress, err := h.ScatterRegions([]byte(startKey), []byte(endKey), input.RegionIDs, input.Group)
+	if errors.IsNotValid(err) {
+		h.r.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		h.r.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.r.JSON(w, http.StatusOK, progress)
+}
+
+func (h *scatterHandler) Get(w http.ResponseWriter, r *http.Request) {
+	progress, err := h.GetScatterProgress(r.URL.Query().Get("group"))
+	if err != nil {
+		h.r.JSON(w, http.StatusNotFound, err.Error())
+		return
+	}
+	h.r.JSON(w, http.StatusOK, progress)
+}
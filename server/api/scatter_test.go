@@ -0,0 +1,105 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/core"
+)
+
+var _ = Suite(&testScatterSuite{})
+
+type testScatterSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testScatterSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1/regions/scatter", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+}
+
+func (s *testScatterSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testScatterSuite) TestScatterRegions(c *C) {
+	for i := uint64(1); i <= 6; i++ {
+		mustPutStore(c, s.svr, i, metapb.StoreState_Up, nil)
+	}
+	keys := []string{"a", "b", "c", "d"}
+	for i := uint64(0); i < 3; i++ {
+		peers := []*metapb.Peer{
+			{Id: 10*i + 11, StoreId: 1},
+			{Id: 10*i + 12, StoreId: 2},
+			{Id: 10*i + 13, StoreId: 3},
+		}
+		region := &metapb.Region{
+			Id:          10*i + 10,
+			StartKey:    []byte(keys[i]),
+			EndKey:      []byte(keys[i+1]),
+			Peers:       peers,
+			RegionEpoch: &metapb.RegionEpoch{ConfVer: 1, Version: 1},
+		}
+		mustRegionHeartbeat(c, s.svr, core.NewRegionInfo(region, peers[0]))
+	}
+
+	// The key range or the region ids are required.
+	err := postJSON(s.urlPrefix, []byte(`{"group":"t1"}`))
+	c.Assert(err, NotNil)
+	// The group has never been scattered.
+	var progress server.ScatterProgress
+	err = readJSONWithURL(s.urlPrefix+"?group=t1", &progress)
+	c.Assert(err, NotNil)
+
+	err = postJSON(s.urlPrefix, []byte(`{"start_key":"a", "end_key":"c", "group":"t1"}`))
+	c.Assert(err, IsNil)
+	err = postJSON(s.urlPrefix, []byte(`{"region_ids":[30], "group":"t1"}`))
+	c.Assert(err, IsNil)
+	err = readJSONWithURL(s.urlPrefix+"?group=t1", &progress)
+	c.Assert(err, IsNil)
+	c.Assert(progress.Group, Equals, "t1")
+	c.Assert(progress.Total, Equals, 3)
+	c.Assert(progress.Scattered+len(progress.Skipped), Equals, 3)
+	c.Assert(progress.Running+progress.Finished+progress.Failed, Equals, progress.Scattered)
+
+	// The region does not exist.
+	err = postJSON(s.urlPrefix, []byte(`{"region_ids":[100], "group":"t1"}`))
+	c.Assert(err, NotNil)
+
+	// Too many regions are requested at once.
+	ids := make([]uint64, 200)
+	for i := range ids {
+		ids[i] = uint64(i + 1)
+	}
+	data, err := json.Marshal(map[string]interface{}{"region_ids": ids, "group": "t1"})
+	c.Assert(err, IsNil)
+	resp, err := server.DialClient.Post(s.urlPrefix, "application/json", bytes.NewBuffer(data))
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusBadRequest)
+}
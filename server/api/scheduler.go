@@ -14,10 +14,16 @@
 package api
 
 import (
+	"encoding/json"
+	"io/ioutil"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
+	"github.com/juju/errors"
+	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/schedule"
 	"github.com/unrolled/render"
 )
 
@@ -34,12 +40,36 @@ func newSchedulerHandler(handler *server.Handler, r *render.Render) *schedulerHa
 }
 
 func (h *schedulerHandler) List(w http.ResponseWriter, r *http.Request) {
-	schedulers, err := h.GetSchedulers()
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		schedulers, err := h.GetSchedulers()
+		if err != nil {
+			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		h.r.JSON(w, http.StatusOK, schedulers)
+		return
+	}
+
+	schedulers, err := h.GetSchedulersStatus()
 	if err != nil {
 		h.r.JSON(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	h.r.JSON(w, http.StatusOK, schedulers)
+	switch status {
+	case "all":
+		h.r.JSON(w, http.StatusOK, schedulers)
+	case "paused":
+		paused := schedulers[:0]
+		for _, s := range schedulers {
+			if s.PausedUntil > 0 {
+				paused = append(paused, s)
+			}
+		}
+		h.r.JSON(w, http.StatusOK, paused)
+	default:
+		h.r.JSON(w, http.StatusBadRequest, "unknown status, should be all or paused")
+	}
 }
 
 func (h *schedulerHandler) Post(w http.ResponseWriter, r *http.Request) {
@@ -130,6 +160,36 @@ func (h *schedulerHandler) Post(w http.ResponseWriter, r *http.Request) {
 			h.r.JSON(w, http.StatusInternalServerError, err.Error())
 			return
 		}
+	case "preferred-leader-scheduler":
+		preferences, ok := input["preferences"].([]interface{})
+		if !ok {
+			h.r.JSON(w, http.StatusBadRequest, "missing preferences")
+			return
+		}
+		var preference schedule.LeaderPreference
+		for _, p := range preferences {
+			label, ok := p.(map[string]interface{})
+			if !ok {
+				h.r.JSON(w, http.StatusBadRequest, "invalid preferences")
+				return
+			}
+			key, _ := label["key"].(string)
+			value, _ := label["value"].(string)
+			preference = append(preference, &metapb.StoreLabel{Key: key, Value: value})
+		}
+		if err := preference.Validate(); err != nil {
+			h.r.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := h.AddPreferredLeaderScheduler(preference); err != nil {
+			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	case "evict-slow-store-scheduler":
+		if err := h.AddEvictSlowStoreScheduler(); err != nil {
+			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			return
+		}
 	case "shuffle-leader-scheduler":
 		if err := h.AddShuffleLeaderScheduler(); err != nil {
 			h.r.JSON(w, http.StatusInternalServerError, err.Error())
@@ -153,6 +213,91 @@ func (h *schedulerHandler) Post(w http.ResponseWriter, r *http.Request) {
 	h.r.JSON(w, http.StatusOK, nil)
 }
 
+// PauseOrResume pauses the scheduler for `delay` seconds, a zero delay
+// resumes the scheduler.
+func (h *schedulerHandler) PauseOrResume(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	var input map[string]interface{}
+	if err := readJSONRespondError(h.r, w, r.Body, &input); err != nil {
+		return
+	}
+	delay, ok := input["delay"].(float64)
+	if !ok || delay < 0 {
+		h.r.JSON(w, http.StatusBadRequest, "missing or invalid delay")
+		return
+	}
+
+	if err := h.PauseOrResumeScheduler(name, int64(delay)); err != nil {
+		h.r.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.r.JSON(w, http.StatusOK, nil)
+}
+
+func (h *schedulerHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	cfg, err := h.GetSchedulerConfig(name)
+	if err != nil {
+		h.r.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.r.JSON(w, http.StatusOK, cfg)
+}
+
+func (h *schedulerHandler) SetConfig(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	data, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		h.r.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var input map[string]interface{}
+	if err = json.Unmarshal(data, &input); err != nil {
+		h.r.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err = h.SetSchedulerConfig(name, data); err != nil {
+		if errors.IsNotValid(err) {
+			h.r.JSON(w, http.StatusBadRequest, err.Error())
+		} else {
+			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	h.r.JSON(w, http.StatusOK, nil)
+}
+
+func (h *schedulerHandler) Explain(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	var ids [2]uint64
+	for i, key := range []string{"region_id", "store_id"} {
+		str := r.URL.Query().Get(key)
+		if str == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(str, 10, 64)
+		if err != nil {
+			h.r.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		ids[i] = id
+	}
+
+	explanation, err := h.Handler.Explain(name, ids[0], ids[1])
+	if err != nil {
+		h.r.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.r.JSON(w, http.StatusOK, explanation)
+}
+
 func (h *schedulerHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	name := mux.Vars(r)["name"]
 
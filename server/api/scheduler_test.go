@@ -16,10 +16,14 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"strings"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/schedule"
 	_ "github.com/pingcap/pd/server/schedulers"
 )
 
@@ -60,6 +64,7 @@ func (s *testScheduleSuite) TestAPI(c *C) {
 		{name: "balance-hot-region-scheduler"},
 		{name: "balance-region-scheduler"},
 		{name: "shuffle-leader-scheduler"},
+		{name: "evict-slow-store-scheduler"},
 		{name: "shuffle-region-scheduler"},
 		{
 			name:        "grant-leader-scheduler",
@@ -67,9 +72,12 @@ func (s *testScheduleSuite) TestAPI(c *C) {
 			args:        []arg{{"store_id", 1}},
 		},
 		{
-			name:        "evict-leader-scheduler",
-			createdName: "evict-leader-scheduler-1",
-			args:        []arg{{"store_id", 1}},
+			name: "evict-leader-scheduler",
+			args: []arg{{"store_id", 1}},
+		},
+		{
+			name: "preferred-leader-scheduler",
+			args: []arg{{"preferences", []map[string]string{{"key": "zone", "value": "z1"}}}},
 		},
 	}
 	for _, ca := range cases {
@@ -85,6 +93,74 @@ func (s *testScheduleSuite) TestAPI(c *C) {
 
 }
 
+func (s *testScheduleSuite) TestConfig(c *C) {
+	err := postJSON(s.urlPrefix, []byte(`{"name":"evict-leader-scheduler","store_id":1}`))
+	c.Assert(err, IsNil)
+	defer doDelete(fmt.Sprintf("%s/%s", s.urlPrefix, "evict-leader-scheduler"))
+
+	configURL := fmt.Sprintf("%s/%s/config", s.urlPrefix, "evict-leader-scheduler")
+	var cfg map[string]interface{}
+	c.Assert(readJSONWithURL(configURL, &cfg), IsNil)
+	c.Assert(cfg["store-id-list"], DeepEquals, []interface{}{float64(1)})
+	for _, data := range []string{`{"store-id-list":[2]}`, `{"store-id-list"`} {
+		resp, err := server.DialClient.Post(configURL, "application/json", strings.NewReader(data))
+		c.Assert(err, IsNil)
+		resp.Body.Close()
+		c.Assert(resp.StatusCode, Equals, http.StatusBadRequest)
+	}
+	c.Assert(readJSONWithURL(configURL, &cfg), IsNil)
+	c.Assert(cfg["store-id-list"], DeepEquals, []interface{}{float64(1)})
+}
+
+func (s *testScheduleSuite) TestExplain(c *C) {
+	err := postJSON(s.urlPrefix, []byte(`{"name":"balance-leader-scheduler"}`))
+	c.Assert(err, IsNil)
+	defer doDelete(fmt.Sprintf("%s/%s", s.urlPrefix, "balance-leader-scheduler"))
+
+	peer := &metapb.Peer{Id: 100, StoreId: 1}
+	region := &metapb.Region{Id: 100, Peers: []*metapb.Peer{peer}}
+	mustRegionHeartbeat(c, s.svr, core.NewRegionInfo(region, peer))
+
+	var e schedule.Explanation
+	explainURL := fmt.Sprintf("%s/%s/explain", s.urlPrefix, "balance-leader-scheduler")
+	c.Assert(readJSONWithURL(explainURL+"?region_id=100", &e), IsNil)
+	c.Assert(e.Scheduler, Equals, "balance-leader-scheduler")
+	c.Assert(e.SourceStoreID, Equals, uint64(1))
+	c.Assert(e.Result, Equals, "no follower store of region 100 can be selected as target")
+	c.Assert(readJSONWithURL(explainURL+"?store_id=1", &e), IsNil)
+	c.Assert(e.Sources, HasLen, 1)
+	c.Assert(readJSONWithURL(explainURL+"?region_id=101", &e), NotNil)
+	c.Assert(readJSONWithURL(explainURL+"?region_id=abc", &e), NotNil)
+
+	checkerURL := fmt.Sprintf("%s/%s/explain", s.urlPrefix, "replica-checker")
+	c.Assert(readJSONWithURL(checkerURL, &e), NotNil)
+	c.Assert(readJSONWithURL(checkerURL+"?region_id=100", &e), IsNil)
+	c.Assert(e.Scheduler, Equals, "replica-checker")
+	c.Assert(e.Targets, HasLen, 1)
+	c.Assert(e.Targets[0].Filter, Not(Equals), "")
+}
+
+func (s *testScheduleSuite) TestFilterRecords(c *C) {
+	tc := schedule.NewMockCluster(schedule.NewMockSchedulerOptions())
+	tc.AddRegionStore(1, 1)
+	tc.AddRegionStore(2, 1)
+	filters := []schedule.Filter{schedule.NewExcludedFilter("api-test", map[uint64]struct{}{1: {}}, map[uint64]struct{}{2: {}})}
+	c.Assert(schedule.FilterSource(tc, tc.GetStore(1), filters), IsTrue)
+	c.Assert(schedule.FilterTarget(tc, tc.GetStore(2), filters), IsTrue)
+
+	var records []schedule.FilterRecord
+	recordsURL := fmt.Sprintf("%s/filter-records", strings.TrimSuffix(s.urlPrefix, "/schedulers"))
+	c.Assert(readJSONWithURL(recordsURL+"?scope=api-test", &records), IsNil)
+	c.Assert(records, HasLen, 2)
+	c.Assert(records[0].Type, Equals, "exclude-filter")
+	c.Assert(readJSONWithURL(recordsURL+"?scope=api-test&store_id=1", &records), IsNil)
+	c.Assert(records, HasLen, 1)
+	c.Assert(records[0].StoreID, Equals, uint64(1))
+	c.Assert(readJSONWithURL(recordsURL+"?scope=api-test&type=health-filter", &records), IsNil)
+	c.Assert(records, HasLen, 0)
+	c.Assert(readJSONWithURL(recordsURL+"?store_id=abc", &records), NotNil)
+}
+
 func (s *testScheduleSuite) testAddAndRemoveScheduler(name, createdName string, body []byte, c *C) {
 	if createdName == "" {
 		createdName = name
@@ -96,7 +172,67 @@ func (s *testScheduleSuite) testAddAndRemoveScheduler(name, createdName string,
 	c.Assert(err, IsNil)
 	c.Assert(sches[0], Equals, createdName)
 
+	pauseURL := fmt.Sprintf("%s/%s", s.urlPrefix, createdName)
+	err = postJSON(pauseURL, []byte(`{"delay":60}`))
+	c.Assert(err, IsNil)
+	var status []server.SchedulerStatus
+	err = readJSONWithURL(s.urlPrefix+"?status=paused", &status)
+	c.Assert(err, IsNil)
+	c.Assert(status, HasLen, 1)
+	c.Assert(status[0].Name, Equals, createdName)
+	c.Assert(status[0].RemainingPauseTime, Greater, int64(0))
+	err = postJSON(pauseURL, []byte(`{"delay":0}`))
+	c.Assert(err, IsNil)
+	err = readJSONWithURL(s.urlPrefix+"?status=paused", &status)
+	c.Assert(err, IsNil)
+	c.Assert(status, HasLen, 0)
+
 	deleteURL := fmt.Sprintf("%s/%s", s.urlPrefix, createdName)
 	err = doDelete(deleteURL)
 	c.Assert(err, IsNil)
 }
+
+var _ = Suite(&testEvictLeaderSuite{})
+
+type testEvictLeaderSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testEvictLeaderSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1/schedulers", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+	mustPutStore(c, s.svr, 1, metapb.StoreState_Up, nil)
+	mustPutStore(c, s.svr, 2, metapb.StoreState_Up, nil)
+}
+
+func (s *testEvictLeaderSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testEvictLeaderSuite) TestAddAndRemoveStores(c *C) {
+	c.Assert(postJSON(s.urlPrefix, []byte(`{"name":"evict-leader-scheduler","store_id":1}`)), IsNil)
+	configURL := fmt.Sprintf("%s/%s/config", s.urlPrefix, "evict-leader-scheduler")
+	var cfg map[string]interface{}
+
+	// Evicting leaders from another store updates the same scheduler, and the
+	// stores are removed one by one by the names with store ids.
+	c.Assert(postJSON(s.urlPrefix, []byte(`{"name":"evict-leader-scheduler","store_id":2}`)), IsNil)
+	c.Assert(readJSONWithURL(configURL, &cfg), IsNil)
+	c.Assert(cfg["store-id-list"], DeepEquals, []interface{}{float64(1), float64(2)})
+	handler := s.svr.GetHandler()
+	c.Assert(handler.RemoveScheduler("evict-leader-scheduler-1"), IsNil)
+	c.Assert(handler.RemoveScheduler("evict-leader-scheduler-1"), NotNil)
+	c.Assert(readJSONWithURL(configURL, &cfg), IsNil)
+	c.Assert(cfg["store-id-list"], DeepEquals, []interface{}{float64(2)})
+	c.Assert(handler.RemoveScheduler("evict-leader-scheduler-2"), IsNil)
+	sches, err := handler.GetSchedulers()
+	c.Assert(err, IsNil)
+	c.Assert(sches, HasLen, 0)
+}
@@ -14,9 +14,12 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
 
+	"github.com/gorilla/mux"
 	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/namespace"
 	"github.com/unrolled/render"
 )
 
@@ -42,3 +45,17 @@ func (h *statsHandler) Region(w http.ResponseWriter, r *http.Request) {
 	stats := cluster.GetRegionStats([]byte(startKey), []byte(endKey))
 	h.rd.JSON(w, http.StatusOK, stats)
 }
+
+func (h *statsHandler) Namespace(w http.ResponseWriter, r *http.Request) {
+	cluster := h.svr.GetRaftCluster()
+	if cluster == nil {
+		h.rd.JSON(w, http.StatusInternalServerError, server.ErrNotBootstrapped.Error())
+		return
+	}
+	name := mux.Vars(r)["name"]
+	if name != namespace.DefaultNamespace && !h.svr.IsNamespaceExist(name) {
+		h.rd.JSON(w, http.StatusNotFound, fmt.Sprintf("invalid namespace Name %s, not found", name))
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, cluster.GetNamespaceStats(name))
+}
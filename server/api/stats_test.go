@@ -160,3 +160,19 @@ func (s *testStatsSuite) TestRegionStats(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(stats, DeepEquals, stats23)
 }
+
+func (s *testStatsSuite) TestNamespaceStats(c *C) {
+	res, err := http.Get(s.urlPrefix + "/namespaces/global/stats")
+	c.Assert(err, IsNil)
+	c.Assert(res.StatusCode, Equals, http.StatusOK)
+	stats := &server.NamespaceStats{}
+	err = apiutil.ReadJSON(res.Body, stats)
+	c.Assert(err, IsNil)
+	c.Assert(stats.Name, Equals, "global")
+	c.Assert(stats.Regions, NotNil)
+
+	res, err = http.Get(s.urlPrefix + "/namespaces/ns_not_exist/stats")
+	c.Assert(err, IsNil)
+	c.Assert(res.StatusCode, Equals, http.StatusNotFound)
+	res.Body.Close()
+}
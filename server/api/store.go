@@ -48,6 +48,7 @@ type StoreStatus struct {
 	RegionWeight       float64            `json:"region_weight,omitempty"`
 	RegionScore        float64            `json:"region_score,omitempty"`
 	RegionSize         int64              `json:"region_size,omitempty"`
+	LearnerCount       int                `json:"learner_count,omitempty"`
 	SendingSnapCount   uint32             `json:"sending_snap_count,omitempty"`
 	ReceivingSnapCount uint32             `json:"receiving_snap_count,omitempty"`
 	ApplyingSnapCount  uint32             `json:"applying_snap_count,omitempty"`
@@ -61,6 +62,9 @@ type StoreStatus struct {
 type StoreInfo struct {
 	Store  *MetaStore   `json:"store"`
 	Status *StoreStatus `json:"status"`
+	// LabelProperties are the label property types of the store, it is only
+	// set when listing the stores by labels.
+	LabelProperties []string `json:"label_properties,omitempty"`
 }
 
 const (
@@ -79,12 +83,13 @@ func newStoreInfo(opt *server.ScheduleConfig, store *core.StoreInfo) *StoreInfo
 			Available:          typeutil.ByteSize(store.Stats.GetAvailable()),
 			LeaderCount:        store.LeaderCount,
 			LeaderWeight:       store.LeaderWeight,
-			LeaderScore:        store.LeaderScore(0),
+			LeaderScore:        store.LeaderScore(opt.LeaderScoreStrategy, 0),
 			LeaderSize:         store.LeaderSize,
 			RegionCount:        store.RegionCount,
 			RegionWeight:       store.RegionWeight,
-			RegionScore:        store.RegionScore(opt.HighSpaceRatio, opt.LowSpaceRatio, 0),
+			RegionScore:        store.RegionScore(opt.RegionScoreStrategy, opt.HighSpaceRatio, opt.LowSpaceRatio, 0),
 			RegionSize:         store.RegionSize,
+			LearnerCount:       store.LearnerCount,
 			SendingSnapCount:   store.Stats.GetSendingSnapCount(),
 			ReceivingSnapCount: store.Stats.GetReceivingSnapCount(),
 			ApplyingSnapCount:  store.Stats.GetApplyingSnapCount(),
@@ -152,7 +157,7 @@ func (h *storeHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	storeInfo := newStoreInfo(h.svr.GetScheduleConfig(), store)
+	storeInfo := newStoreInfo(h.svr.GetStoreScheduleConfig(store), store)
 	h.rd.JSON(w, http.StatusOK, storeInfo)
 }
 
@@ -342,7 +347,7 @@ func (h *storesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		storeInfo := newStoreInfo(h.svr.GetScheduleConfig(), store)
+		storeInfo := newStoreInfo(h.svr.GetStoreScheduleConfig(store), store)
 		StoresInfo.Stores = append(StoresInfo.Stores, storeInfo)
 	}
 	StoresInfo.Count = len(StoresInfo.Stores)
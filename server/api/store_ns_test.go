@@ -17,6 +17,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
@@ -93,3 +94,53 @@ func (s *testStoreNsSuite) TestCreateNamespace(c *C) {
 	err = postJSON(fmt.Sprintf("%s/classifier/table/namespaces", s.urlPrefix), b)
 	c.Assert(err, IsNil)
 }
+
+func (s *testStoreNsSuite) TestNamespaceConfig(c *C) {
+	b, err := json.Marshal(map[string]string{"namespace": "cfg"})
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(fmt.Sprintf("%s/classifier/table/namespaces", s.urlPrefix), b), IsNil)
+	url := fmt.Sprintf("%s/config/namespace/cfg", s.urlPrefix)
+	global := s.svr.GetScheduleConfig()
+
+	b, err = json.Marshal(map[string]interface{}{
+		"leader-schedule-limit": 8,
+		"tolerant-size-ratio":   10,
+		"location-labels":       "zone,host",
+	})
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(url, b), IsNil)
+	detail := &server.NamespaceConfigDetail{}
+	c.Assert(readJSONWithURL(url, detail), IsNil)
+	c.Assert(detail.Config.LeaderScheduleLimit, Equals, uint64(8))
+	c.Assert(detail.Config.RegionScheduleLimit, Equals, uint64(0))
+	c.Assert(detail.Config.Overrides, DeepEquals, map[string]interface{}{
+		"tolerant-size-ratio": float64(10),
+		"location-labels":     "zone,host",
+	})
+	c.Assert(detail.Schedule.LeaderScheduleLimit, Equals, uint64(8))
+	c.Assert(detail.Schedule.RegionScheduleLimit, Equals, global.RegionScheduleLimit)
+	c.Assert(detail.Schedule.TolerantSizeRatio, Equals, float64(10))
+	c.Assert([]string(detail.Replication.LocationLabels), DeepEquals, []string{"zone", "host"})
+
+	// The option is removed by null.
+	c.Assert(postJSON(url, []byte(`{"tolerant-size-ratio": null}`)), IsNil)
+	detail = &server.NamespaceConfigDetail{}
+	c.Assert(readJSONWithURL(url, detail), IsNil)
+	c.Assert(detail.Config.Overrides, HasLen, 1)
+	c.Assert(detail.Config.LeaderScheduleLimit, Equals, uint64(8))
+	c.Assert(detail.Schedule.TolerantSizeRatio, Equals, global.TolerantSizeRatio)
+
+	// The global-only and invalid options are rejected.
+	c.Assert(postJSON(url, []byte(`{"patrol-region-interval": "1s"}`)), NotNil)
+	c.Assert(postJSON(url, []byte(`{"low-space-ratio": 2}`)), NotNil)
+
+	// The config is moved and deleted together with the namespace.
+	b, err = json.Marshal(map[string]string{"namespace": "cfg", "new_name": "cfg2"})
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(fmt.Sprintf("%s/classifier/table/namespaces/rename", s.urlPrefix), b), IsNil)
+	c.Assert(s.svr.GetNamespaceConfig("cfg").LeaderScheduleLimit, Equals, uint64(0))
+	c.Assert(s.svr.GetNamespaceConfig("cfg2").LeaderScheduleLimit, Equals, uint64(8))
+	status, _ := requestStatusBody(c, newHTTPClient(), http.MethodDelete, fmt.Sprintf("%s/classifier/table/namespaces/cfg2", s.urlPrefix))
+	c.Assert(status, Equals, http.StatusOK)
+	c.Assert(s.svr.GetNamespaceConfig("cfg2").LeaderScheduleLimit, Equals, uint64(0))
+}
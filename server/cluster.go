@@ -106,7 +106,7 @@ func (c *RaftCluster) start() error {
 	}
 	c.cachedCluster = cluster
 	c.coordinator = newCoordinator(c.cachedCluster, c.s.hbStreams, c.s.classifier)
-	c.cachedCluster.regionStats = newRegionStatistics(c.s.scheduleOpt, c.s.classifier)
+	c.cachedCluster.regionStats = newRegionStatistics(c.s.scheduleOpt, c.s.classifier, c.cachedCluster.GetRangeConstraintManager())
 	c.quit = make(chan struct{})
 
 	c.wg.Add(2)
@@ -251,6 +251,12 @@ func (c *RaftCluster) GetRegionStats(startKey, endKey []byte) *core.RegionStats
 	return c.cachedCluster.getRegionStats(startKey, endKey)
 }
 
+// GetNamespaceStats returns the statistics of the stores and regions of the
+// namespace.
+func (c *RaftCluster) GetNamespaceStats(name string) *NamespaceStats {
+	return c.cachedCluster.getNamespaceStats(c.s.classifier, name)
+}
+
 // DropCacheRegion removes a region from the cache.
 func (c *RaftCluster) DropCacheRegion(id uint64) {
 	c.cachedCluster.dropRegion(id)
@@ -537,6 +543,7 @@ func (c *RaftCluster) runBackgroundJobs(interval time.Duration) {
 			c.checkStores()
 			c.collectMetrics()
 			c.coordinator.pruneHistory()
+			c.coordinator.pruneScatterGroups()
 		}
 	}
 }
@@ -14,6 +14,8 @@
 package server
 
 import (
+	"encoding/hex"
+	"sort"
 	"sync"
 	"time"
 
@@ -25,6 +27,7 @@ import (
 	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/server/namespace"
 	"github.com/pingcap/pd/server/schedule"
+	"github.com/pingcap/pd/server/schedule/placement"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -32,22 +35,26 @@ type clusterInfo struct {
 	sync.RWMutex
 	core *schedule.BasicCluster
 
-	id              core.IDAllocator
-	kv              *core.KV
-	meta            *metapb.Cluster
-	activeRegions   int
-	opt             *scheduleOption
-	regionStats     *regionStatistics
-	labelLevelStats *labelLevelStatistics
+	id               core.IDAllocator
+	kv               *core.KV
+	meta             *metapb.Cluster
+	activeRegions    int
+	opt              *scheduleOption
+	regionStats      *regionStatistics
+	labelLevelStats  *labelLevelStatistics
+	ruleManager      *placement.RuleManager
+	rangeConstraints *placement.RangeConstraintManager
 }
 
 func newClusterInfo(id core.IDAllocator, opt *scheduleOption, kv *core.KV) *clusterInfo {
 	return &clusterInfo{
-		core:            schedule.NewBasicCluster(),
-		id:              id,
-		opt:             opt,
-		kv:              kv,
-		labelLevelStats: newLabelLevelStatistics(),
+		core:             schedule.NewBasicCluster(),
+		id:               id,
+		opt:              opt,
+		kv:               kv,
+		labelLevelStats:  newLabelLevelStatistics(),
+		ruleManager:      placement.NewRuleManager(kv),
+		rangeConstraints: placement.NewRangeConstraintManager(kv),
 	}
 }
 
@@ -76,6 +83,13 @@ func loadClusterInfo(id core.IDAllocator, kv *core.KV, opt *scheduleOption) (*cl
 	}
 	log.Infof("load %v regions cost %v", c.core.Regions.GetRegionCount(), time.Since(start))
 
+	if err := c.ruleManager.Initialize(opt.rep.GetMaxReplicas(), opt.rep.GetLocationLabels()); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := c.rangeConstraints.Initialize(); err != nil {
+		return nil, errors.Trace(err)
+	}
+
 	return c, nil
 }
 
@@ -197,6 +211,34 @@ func (c *clusterInfo) UnblockStore(storeID uint64) {
 	c.core.UnblockStore(storeID)
 }
 
+// BlockStoreLeader stops balancer from transferring leaders to the store.
+func (c *clusterInfo) BlockStoreLeader(storeID uint64) error {
+	c.Lock()
+	defer c.Unlock()
+	return c.core.BlockStoreLeader(storeID)
+}
+
+// UnblockStoreLeader allows balancer to transfer leaders to the store.
+func (c *clusterInfo) UnblockStoreLeader(storeID uint64) {
+	c.Lock()
+	defer c.Unlock()
+	c.core.UnblockStoreLeader(storeID)
+}
+
+// SetLeaderPreference sets the preference of the stores to place leaders.
+func (c *clusterInfo) SetLeaderPreference(preference schedule.LeaderPreference) {
+	c.Lock()
+	defer c.Unlock()
+	c.core.SetLeaderPreference(preference)
+}
+
+// GetLeaderPreference returns the preference of the stores to place leaders.
+func (c *clusterInfo) GetLeaderPreference() schedule.LeaderPreference {
+	c.RLock()
+	defer c.RUnlock()
+	return c.core.GetLeaderPreference()
+}
+
 // GetStores returns all stores in the cluster.
 func (c *clusterInfo) GetStores() []*core.StoreInfo {
 	c.RLock()
@@ -268,6 +310,13 @@ func (c *clusterInfo) IsRegionHot(id uint64) bool {
 	return c.core.IsRegionHot(id, c.GetHotRegionLowThreshold())
 }
 
+// GetHotRegionStat returns the flow statistics of the region in the hot cache.
+func (c *clusterInfo) GetHotRegionStat(id uint64, kind schedule.FlowKind) *core.RegionStat {
+	c.RLock()
+	defer c.RUnlock()
+	return c.core.GetHotRegionStat(id, kind)
+}
+
 // RandHotRegionFromStore randomly picks a hot region in specified store.
 func (c *clusterInfo) RandHotRegionFromStore(store uint64, kind schedule.FlowKind) *core.RegionInfo {
 	c.RLock()
@@ -358,6 +407,13 @@ func (c *clusterInfo) RandFollowerRegion(storeID uint64, opts ...core.RegionOpti
 	return c.core.RandFollowerRegion(storeID, opts...)
 }
 
+// RandLearnerRegion returns a random region that has a learner on the store.
+func (c *clusterInfo) RandLearnerRegion(storeID uint64, opts ...core.RegionOption) *core.RegionInfo {
+	c.RLock()
+	defer c.RUnlock()
+	return c.core.RandLearnerRegion(storeID, opts...)
+}
+
 // GetAverageRegionSize returns the average region approximate size.
 func (c *clusterInfo) GetAverageRegionSize() int64 {
 	c.RLock()
@@ -439,6 +495,7 @@ func (c *clusterInfo) handleStoreHeartbeat(stats *pdpb.StoreStats) error {
 func (c *clusterInfo) updateStoreStatusLocked(id uint64) {
 	c.core.Stores.SetLeaderCount(id, c.core.Regions.GetStoreLeaderCount(id))
 	c.core.Stores.SetRegionCount(id, c.core.Regions.GetStoreRegionCount(id))
+	c.core.Stores.SetLearnerCount(id, c.core.Regions.GetStoreLearnerCount(id))
 	c.core.Stores.SetPendingPeerCount(id, c.core.Regions.GetStorePendingPeerCount(id))
 	c.core.Stores.SetLeaderSize(id, c.core.Regions.GetStoreLeaderRegionSize(id))
 	c.core.Stores.SetRegionSize(id, c.core.Regions.GetStoreRegionSize(id))
@@ -449,8 +506,8 @@ func (c *clusterInfo) handleRegionHeartbeat(region *core.RegionInfo) error {
 	region = region.Clone()
 	c.RLock()
 	origin := c.core.Regions.GetRegion(region.GetId())
-	isWriteUpdate, writeItem := c.core.CheckWriteStatus(region)
-	isReadUpdate, readItem := c.core.CheckReadStatus(region)
+	writeItems := c.core.CheckWriteStatus(region, c.GetHotRegionWindowSize())
+	readItems := c.core.CheckReadStatus(region, c.GetHotRegionWindowSize())
 	c.RUnlock()
 
 	// Save to KV if meta is updated.
@@ -507,7 +564,7 @@ func (c *clusterInfo) handleRegionHeartbeat(region *core.RegionInfo) error {
 			log.Errorf("[region %d] fail to save region %v: %v", region.GetId(), region, err)
 		}
 	}
-	if !isWriteUpdate && !isReadUpdate && !saveCache && !isNew {
+	if len(writeItems) == 0 && len(readItems) == 0 && !saveCache && !isNew {
 		return nil
 	}
 
@@ -531,6 +588,7 @@ func (c *clusterInfo) handleRegionHeartbeat(region *core.RegionInfo) error {
 				c.regionStats.clearDefunctRegion(item.GetId())
 			}
 			c.labelLevelStats.clearDefunctRegion(item.GetId())
+			c.core.HotCache.RemoveRegion(item.GetId())
 		}
 
 		// Update related stores.
@@ -548,13 +606,8 @@ func (c *clusterInfo) handleRegionHeartbeat(region *core.RegionInfo) error {
 		c.regionStats.Observe(region, c.takeRegionStoresLocked(region))
 	}
 
-	key := region.GetId()
-	if isWriteUpdate {
-		c.core.HotCache.Update(key, writeItem, schedule.WriteFlow)
-	}
-	if isReadUpdate {
-		c.core.HotCache.Update(key, readItem, schedule.ReadFlow)
-	}
+	c.core.HotCache.Update(writeItems, schedule.WriteFlow)
+	c.core.HotCache.Update(readItems, schedule.ReadFlow)
 	return nil
 }
 
@@ -619,6 +672,36 @@ func (c *clusterInfo) GetHighSpaceRatio() float64 {
 	return c.opt.GetHighSpaceRatio()
 }
 
+func (c *clusterInfo) GetLeaderScoreStrategy() string {
+	return c.opt.GetLeaderScoreStrategy(namespace.DefaultNamespace)
+}
+
+func (c *clusterInfo) GetRegionScoreStrategy() string {
+	return c.opt.GetRegionScoreStrategy(namespace.DefaultNamespace)
+}
+
+// getLeaderScoreStrategy returns the leader score strategy of the namespace.
+func (c *clusterInfo) getLeaderScoreStrategy(name string) string {
+	return c.opt.GetLeaderScoreStrategy(name)
+}
+
+// getRegionScoreStrategy returns the region score strategy of the namespace.
+func (c *clusterInfo) getRegionScoreStrategy(name string) string {
+	return c.opt.GetRegionScoreStrategy(name)
+}
+
+// getNamespaceConfigs returns the schedule and replication configs of the
+// namespace.
+func (c *clusterInfo) getNamespaceConfigs(name string) (*ScheduleConfig, *ReplicationConfig) {
+	return c.opt.getNamespaceConfigs(name)
+}
+
+// isSchedulerDisabledByNamespace returns true if the scheduler should not
+// schedule the namespace.
+func (c *clusterInfo) isSchedulerDisabledByNamespace(name, scheduler string) bool {
+	return c.opt.IsSchedulerDisabledByNamespace(name, scheduler)
+}
+
 func (c *clusterInfo) GetMaxSnapshotCount() uint64 {
 	return c.opt.GetMaxSnapshotCount()
 }
@@ -639,6 +722,38 @@ func (c *clusterInfo) GetSplitMergeInterval() time.Duration {
 	return c.opt.GetSplitMergeInterval()
 }
 
+func (c *clusterInfo) GetSplitScheduleLimit() uint64 {
+	return c.opt.GetSplitScheduleLimit()
+}
+
+func (c *clusterInfo) GetIsolationScheduleLimit() uint64 {
+	return c.opt.GetIsolationScheduleLimit()
+}
+
+func (c *clusterInfo) GetLoadSplitHotDegree() int {
+	return c.opt.GetLoadSplitHotDegree()
+}
+
+func (c *clusterInfo) GetLoadSplitMinFlowBytes() uint64 {
+	return c.opt.GetLoadSplitMinFlowBytes()
+}
+
+func (c *clusterInfo) GetLoadSplitCooldown() time.Duration {
+	return c.opt.GetLoadSplitCooldown()
+}
+
+func (c *clusterInfo) GetHotRegionWindowSize() int {
+	return c.opt.GetHotRegionWindowSize()
+}
+
+func (c *clusterInfo) GetHotRegionsWriteInterval() time.Duration {
+	return c.opt.GetHotRegionsWriteInterval()
+}
+
+func (c *clusterInfo) GetHotRegionsRetention() time.Duration {
+	return c.opt.GetHotRegionsRetention()
+}
+
 func (c *clusterInfo) GetPatrolRegionInterval() time.Duration {
 	return c.opt.GetPatrolRegionInterval()
 }
@@ -651,6 +766,14 @@ func (c *clusterInfo) GetMaxReplicas() int {
 	return c.opt.GetMaxReplicas(namespace.DefaultNamespace)
 }
 
+func (c *clusterInfo) GetLearnerReplicas() int {
+	return c.opt.GetLearnerReplicas(namespace.DefaultNamespace)
+}
+
+func (c *clusterInfo) GetLearnerLabels() map[string]string {
+	return c.opt.GetLearnerLabels()
+}
+
 func (c *clusterInfo) GetLocationLabels() []string {
 	return c.opt.GetLocationLabels()
 }
@@ -686,6 +809,39 @@ func (c *clusterInfo) IsLocationReplacementEnabled() bool {
 	return c.opt.IsLocationReplacementEnabled()
 }
 
+func (c *clusterInfo) IsPlacementRulesEnabled() bool {
+	return c.opt.IsPlacementRulesEnabled()
+}
+
+func (c *clusterInfo) GetRuleManager() *placement.RuleManager {
+	return c.ruleManager
+}
+
+func (c *clusterInfo) GetRangeConstraintManager() *placement.RangeConstraintManager {
+	return c.rangeConstraints
+}
+
+// getConstraintViolatedRegions returns the regions with voters on the stores
+// which do not match their range constraints. The learners are skipped like
+// the checkers do, as the read-only learners are not restricted by the range
+// constraints.
+func (c *clusterInfo) getConstraintViolatedRegions() []*core.RegionInfo {
+	var res []*core.RegionInfo
+	for _, region := range c.getRegions() {
+		constraints := c.rangeConstraints.GetLabelConstraintsForRegion(region)
+		if len(constraints) == 0 {
+			continue
+		}
+		for _, peer := range region.GetVoters() {
+			if store := c.GetStore(peer.GetStoreId()); store != nil && !placement.MatchLabelConstraints(store, constraints) {
+				res = append(res, region)
+				break
+			}
+		}
+	}
+	return res
+}
+
 func (c *clusterInfo) CheckLabelProperty(typ string, labels []*metapb.StoreLabel) bool {
 	return c.opt.CheckLabelProperty(typ, labels)
 }
@@ -701,3 +857,72 @@ func (c *clusterInfo) RegionWriteStats() []*core.RegionStat {
 	// RegionStats is a thread-safe method
 	return c.core.HotCache.RegionStats(schedule.WriteFlow)
 }
+
+// getHotWriteRegions returns the write flow of the hot peers on each store.
+func (c *clusterInfo) getHotWriteRegions() *core.StoreHotRegionInfos {
+	threshold := c.GetHotRegionLowThreshold()
+	return &core.StoreHotRegionInfos{
+		AsPeer:   c.core.HotCache.StoreStats(schedule.WriteFlow, true, threshold),
+		AsLeader: c.core.HotCache.StoreStats(schedule.WriteFlow, false, threshold),
+	}
+}
+
+// maxHistoryHotRegionsPerSample is the max number of the hot peers of each
+// hot type saved in a sample, the hottest ones are kept.
+const maxHistoryHotRegionsPerSample = 1000
+
+// saveHistoryHotRegions samples the hot peers and saves them as history, the
+// history older than the retention is deleted.
+func (c *clusterInfo) saveHistoryHotRegions(now time.Time) error {
+	if c.kv == nil {
+		return nil
+	}
+	samples := map[string]core.StoreHotRegionsStat{
+		core.HotTypeWrite: c.getHotWriteRegions().AsPeer,
+		core.HotTypeRead:  c.getHotReadRegions().AsLeader,
+	}
+	for hotType, stats := range samples {
+		var records []*core.HistoryHotRegion
+		for storeID, stat := range stats {
+			for _, r := range stat.RegionsStat {
+				region := c.GetRegion(r.RegionID)
+				if region == nil {
+					continue
+				}
+				record := &core.HistoryHotRegion{
+					UpdateTime: now.Unix(),
+					RegionID:   r.RegionID,
+					StoreID:    storeID,
+					PeerID:     region.GetStorePeer(storeID).GetId(),
+					IsLeader:   region.Leader.GetStoreId() == storeID,
+					HotType:    hotType,
+					HotDegree:  r.HotDegree,
+					FlowBytes:  r.FlowBytes,
+					FlowKeys:   r.FlowKeys,
+					StartKey:   hex.EncodeToString(region.StartKey),
+					EndKey:     hex.EncodeToString(region.EndKey),
+				}
+				records = append(records, record)
+			}
+		}
+		if len(records) > maxHistoryHotRegionsPerSample {
+			sort.Slice(records, func(i, j int) bool { return records[i].FlowBytes > records[j].FlowBytes })
+			records = records[:maxHistoryHotRegionsPerSample]
+		}
+		if err := c.kv.SaveHistoryHotRegions(records); err != nil {
+			return errors.Trace(err)
+		}
+		if err := c.kv.DeleteHistoryHotRegions(hotType, now.Add(-c.GetHotRegionsRetention()).Unix()); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// getHotReadRegions returns the read flow of the hot leaders on each store.
+func (c *clusterInfo) getHotReadRegions() *core.StoreHotRegionInfos {
+	threshold := c.GetHotRegionLowThreshold()
+	return &core.StoreHotRegionInfos{
+		AsLeader: c.core.HotCache.StoreStats(schedule.ReadFlow, false, threshold),
+	}
+}
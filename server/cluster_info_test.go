@@ -14,13 +14,17 @@
 package server
 
 import (
+	"encoding/hex"
 	"math/rand"
+	"time"
 
 	"github.com/juju/errors"
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/schedule"
+	"github.com/pingcap/pd/server/schedule/placement"
 )
 
 var _ = Suite(&testStoresInfoSuite{})
@@ -651,6 +655,74 @@ func checkPendingPeerCount(expect []int, cluster *clusterInfo, c *C) {
 	}
 }
 
+func (s *testClusterInfoSuite) TestSaveHistoryHotRegions(c *C) {
+	_, opt := newTestScheduleConfig()
+	tc := newTestClusterInfo(opt)
+	for _, s := range newTestStores(3) {
+		tc.putStore(s)
+	}
+	tc.addLeaderRegion(1, 1, 2, 3)
+	hot := func(storeID uint64, isLeader bool) *core.RegionStat {
+		return &core.RegionStat{RegionID: 1, StoreID: storeID, IsLeader: isLeader, FlowBytes: 1024, HotDegree: 3, LastUpdateTime: time.Now()}
+	}
+	tc.core.HotCache.Update([]*core.RegionStat{hot(1, true), hot(2, false), hot(3, false)}, schedule.WriteFlow)
+	tc.core.HotCache.Update([]*core.RegionStat{hot(1, true)}, schedule.ReadFlow)
+
+	now := time.Now()
+	c.Assert(tc.saveHistoryHotRegions(now), IsNil)
+	records, err := tc.kv.LoadHistoryHotRegions(core.HotTypeWrite, 0, now.Unix()+1, nil, 10, nil)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 3)
+	region := tc.GetRegion(1)
+	for _, record := range records {
+		c.Assert(record.UpdateTime, Equals, now.Unix())
+		c.Assert(record.PeerID, Equals, region.GetStorePeer(record.StoreID).GetId())
+		c.Assert(record.IsLeader, Equals, record.StoreID == 1)
+		c.Assert(record.FlowBytes, Equals, uint64(1024))
+		c.Assert(record.StartKey, Equals, hex.EncodeToString(region.StartKey))
+	}
+	records, err = tc.kv.LoadHistoryHotRegions(core.HotTypeRead, 0, now.Unix()+1, nil, 10, nil)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 1)
+
+	// The history older than the retention is deleted.
+	c.Assert(tc.saveHistoryHotRegions(now.Add(opt.GetHotRegionsRetention()+time.Second)), IsNil)
+	records, err = tc.kv.LoadHistoryHotRegions(core.HotTypeWrite, 0, now.Unix()+1, nil, 10, nil)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 0)
+}
+
+func (s *testClusterInfoSuite) TestConstraintViolatedRegions(c *C) {
+	_, opt := newTestScheduleConfig()
+	tc := newTestClusterInfo(opt)
+	for _, s := range newTestStores(3) {
+		disk := "ssd"
+		if s.GetId() == 3 {
+			disk = "hdd"
+		}
+		s.Labels = []*metapb.StoreLabel{{Key: "disk", Value: disk}}
+		tc.putStore(s)
+	}
+	c.Assert(tc.rangeConstraints.SetConstraint(&placement.RangeConstraint{
+		ID:               "ssd",
+		LabelConstraints: []placement.LabelConstraint{{Key: "disk", Op: placement.In, Values: []string{"ssd"}}},
+	}), IsNil)
+
+	// The learner on the hdd store is not counted.
+	tc.addLeaderRegion(1, 1, 2)
+	region := tc.GetRegion(1).Clone()
+	learner, _ := tc.AllocPeer(3)
+	learner.IsLearner = true
+	region.AddPeer(learner)
+	tc.putRegion(region)
+	c.Assert(tc.getConstraintViolatedRegions(), HasLen, 0)
+
+	tc.addLeaderRegion(2, 1, 3)
+	regions := tc.getConstraintViolatedRegions()
+	c.Assert(regions, HasLen, 1)
+	c.Assert(regions[0].GetId(), Equals, uint64(2))
+}
+
 var _ = Suite(&testClusterUtilSuite{})
 
 type testClusterUtilSuite struct{}
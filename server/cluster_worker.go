@@ -66,8 +66,9 @@ func (c *RaftCluster) handleAskSplit(request *pdpb.AskSplitRequest) (*pdpb.AskSp
 	}
 
 	// Disable merge for the 2 regions in a period of time.
-	c.coordinator.mergeChecker.RecordRegionSplit(reqRegion.GetId())
-	c.coordinator.mergeChecker.RecordRegionSplit(newRegionID)
+	mergeChecker := c.coordinator.mergeChecker.WithCluster(c.coordinator.regionCluster(core.NewRegionInfo(reqRegion, nil)))
+	mergeChecker.RecordRegionSplit(reqRegion.GetId())
+	mergeChecker.RecordRegionSplit(newRegionID)
 
 	split := &pdpb.AskSplitResponse{
 		NewRegionId: newRegionID,
@@ -20,6 +20,8 @@ import (
 	"fmt"
 	"net/url"
 	"path/filepath"
+	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
@@ -31,7 +33,7 @@ import (
 	"github.com/pingcap/pd/pkg/logutil"
 	"github.com/pingcap/pd/pkg/metricutil"
 	"github.com/pingcap/pd/pkg/typeutil"
-	"github.com/pingcap/pd/server/namespace"
+	"github.com/pingcap/pd/server/core"
 )
 
 // Config is the pd server configuration.
@@ -399,6 +401,35 @@ type ScheduleConfig struct {
 	ReplicaScheduleLimit uint64 `toml:"replica-schedule-limit,omitempty" json:"replica-schedule-limit"`
 	// MergeScheduleLimit is the max coexist merge schedules.
 	MergeScheduleLimit uint64 `toml:"merge-schedule-limit,omitempty" json:"merge-schedule-limit"`
+	// SplitScheduleLimit is the max coexist load-based split schedules.
+	SplitScheduleLimit uint64 `toml:"split-schedule-limit,omitempty" json:"split-schedule-limit"`
+	// IsolationScheduleLimit is the max coexist schedules which improve the
+	// isolation level of the regions.
+	IsolationScheduleLimit uint64 `toml:"isolation-schedule-limit,omitempty" json:"isolation-schedule-limit"`
+	// EnableAdaptiveScheduleLimit is the option to scale the leader, region,
+	// replica and merge schedule limits by the pressure of the cluster.
+	EnableAdaptiveScheduleLimit bool `toml:"enable-adaptive-schedule-limit" json:"enable-adaptive-schedule-limit,string"`
+	// AdaptiveLimitMinRatio and AdaptiveLimitMaxRatio bound the ratio of the
+	// adaptive schedule limits to the configured ones.
+	AdaptiveLimitMinRatio float64 `toml:"adaptive-limit-min-ratio,omitempty" json:"adaptive-limit-min-ratio"`
+	AdaptiveLimitMaxRatio float64 `toml:"adaptive-limit-max-ratio,omitempty" json:"adaptive-limit-max-ratio"`
+	// LoadSplitHotDegree is the number of the consecutive report intervals a
+	// region should stay hot before it is split by load, 0 disables it.
+	LoadSplitHotDegree uint64 `toml:"load-split-hot-degree" json:"load-split-hot-degree"`
+	// LoadSplitMinFlowBytes is the minimum read or write flow in bytes per
+	// second of a region to split it by load.
+	LoadSplitMinFlowBytes uint64 `toml:"load-split-min-flow-bytes,omitempty" json:"load-split-min-flow-bytes"`
+	// LoadSplitCooldown is the minimum interval to split a region by load
+	// again.
+	LoadSplitCooldown typeutil.Duration `toml:"load-split-cooldown,omitempty" json:"load-split-cooldown"`
+	// HotRegionWindowSize is the number of the recent reports which the flow
+	// of a hot peer is averaged over.
+	HotRegionWindowSize uint64 `toml:"hot-region-window-size,omitempty" json:"hot-region-window-size"`
+	// HotRegionsWriteInterval is the interval to sample the hot regions and
+	// save them as history.
+	HotRegionsWriteInterval typeutil.Duration `toml:"hot-regions-write-interval,omitempty" json:"hot-regions-write-interval"`
+	// HotRegionsRetention is how long the history of the hot regions is kept.
+	HotRegionsRetention typeutil.Duration `toml:"hot-regions-retention,omitempty" json:"hot-regions-retention"`
 	// TolerantSizeRatio is the ratio of buffer size for balance scheduler.
 	TolerantSizeRatio float64 `toml:"tolerant-size-ratio,omitempty" json:"tolerant-size-ratio"`
 	//
@@ -413,6 +444,13 @@ type ScheduleConfig struct {
 	// HighSpaceRatio is the highest usage ratio of store which regraded as high space.
 	// High space means there is a lot of spare capacity, and store region score varies directly with used size.
 	HighSpaceRatio float64 `toml:"high-space-ratio,omitempty" json:"high-space-ratio"`
+	// LeaderScoreStrategy is how to score the stores to balance leaders, it is
+	// either size or count.
+	LeaderScoreStrategy string `toml:"leader-score-strategy,omitempty" json:"leader-score-strategy"`
+	// RegionScoreStrategy is how to score the stores to balance regions, it
+	// is size, count or space. The size strategy takes the low space stage
+	// into account, and the space strategy balances the available space only.
+	RegionScoreStrategy string `toml:"region-score-strategy,omitempty" json:"region-score-strategy"`
 	// DisableLearner is the option to disable using AddLearnerNode instead of AddNode
 	DisableLearner bool `toml:"disable-raft-learner" json:"disable-raft-learner,string"`
 
@@ -434,11 +472,20 @@ type ScheduleConfig struct {
 
 	// Schedulers support for loding customized schedulers
 	Schedulers SchedulerConfigs `toml:"schedulers,omitempty" json:"schedulers-v2"` // json v2 is for the sake of compatible upgrade
+
+	// TimeWindows override some of the schedule options in the periods of
+	// the day, the first window containing the current time takes effect.
+	// The limits of the window also override the ones of the namespaces.
+	TimeWindows []TimeWindow `toml:"time-windows,omitempty" json:"time-windows"`
 }
 
 func (c *ScheduleConfig) clone() *ScheduleConfig {
 	schedulers := make(SchedulerConfigs, len(c.Schedulers))
 	copy(schedulers, c.Schedulers)
+	timeWindows := make([]TimeWindow, 0, len(c.TimeWindows))
+	for _, w := range c.TimeWindows {
+		timeWindows = append(timeWindows, *w.clone())
+	}
 	return &ScheduleConfig{
 		MaxSnapshotCount:             c.MaxSnapshotCount,
 		MaxPendingPeerCount:          c.MaxPendingPeerCount,
@@ -451,7 +498,20 @@ func (c *ScheduleConfig) clone() *ScheduleConfig {
 		RegionScheduleLimit:          c.RegionScheduleLimit,
 		ReplicaScheduleLimit:         c.ReplicaScheduleLimit,
 		MergeScheduleLimit:           c.MergeScheduleLimit,
+		SplitScheduleLimit:           c.SplitScheduleLimit,
+		IsolationScheduleLimit:       c.IsolationScheduleLimit,
+		EnableAdaptiveScheduleLimit:  c.EnableAdaptiveScheduleLimit,
+		AdaptiveLimitMinRatio:        c.AdaptiveLimitMinRatio,
+		AdaptiveLimitMaxRatio:        c.AdaptiveLimitMaxRatio,
+		LoadSplitHotDegree:           c.LoadSplitHotDegree,
+		LoadSplitMinFlowBytes:        c.LoadSplitMinFlowBytes,
+		LoadSplitCooldown:            c.LoadSplitCooldown,
+		HotRegionWindowSize:          c.HotRegionWindowSize,
+		HotRegionsWriteInterval:      c.HotRegionsWriteInterval,
+		HotRegionsRetention:          c.HotRegionsRetention,
 		TolerantSizeRatio:            c.TolerantSizeRatio,
+		LeaderScoreStrategy:          c.LeaderScoreStrategy,
+		RegionScoreStrategy:          c.RegionScoreStrategy,
 		LowSpaceRatio:                c.LowSpaceRatio,
 		HighSpaceRatio:               c.HighSpaceRatio,
 		DisableLearner:               c.DisableLearner,
@@ -461,25 +521,35 @@ func (c *ScheduleConfig) clone() *ScheduleConfig {
 		DisableRemoveExtraReplica:    c.DisableRemoveExtraReplica,
 		DisableLocationReplacement:   c.DisableLocationReplacement,
 		Schedulers:                   schedulers,
+		TimeWindows:                  timeWindows,
 	}
 }
 
 const (
-	defaultMaxReplicas          = 3
-	defaultMaxSnapshotCount     = 3
-	defaultMaxPendingPeerCount  = 16
-	defaultMaxMergeRegionSize   = 20
-	defaultMaxMergeRegionKeys   = 200000
-	defaultSplitMergeInterval   = 1 * time.Hour
-	defaultPatrolRegionInterval = 100 * time.Millisecond
-	defaultMaxStoreDownTime     = 30 * time.Minute
-	defaultLeaderScheduleLimit  = 4
-	defaultRegionScheduleLimit  = 4
-	defaultReplicaScheduleLimit = 8
-	defaultMergeScheduleLimit   = 8
-	defaultTolerantSizeRatio    = 5
-	defaultLowSpaceRatio        = 0.8
-	defaultHighSpaceRatio       = 0.6
+	defaultMaxReplicas            = 3
+	defaultMaxSnapshotCount       = 3
+	defaultMaxPendingPeerCount    = 16
+	defaultMaxMergeRegionSize     = 20
+	defaultMaxMergeRegionKeys     = 200000
+	defaultSplitMergeInterval     = 1 * time.Hour
+	defaultPatrolRegionInterval   = 100 * time.Millisecond
+	defaultMaxStoreDownTime       = 30 * time.Minute
+	defaultLeaderScheduleLimit    = 4
+	defaultRegionScheduleLimit    = 4
+	defaultReplicaScheduleLimit   = 8
+	defaultMergeScheduleLimit     = 8
+	defaultSplitScheduleLimit     = 4
+	defaultIsolationScheduleLimit = 4
+	defaultAdaptiveLimitMinRatio  = 0.25
+	defaultAdaptiveLimitMaxRatio  = 2
+	defaultLoadSplitMinFlow       = 1024 * 1024
+	defaultLoadSplitCooldown      = 10 * time.Minute
+	defaultHotRegionWindowSize    = 5
+	defaultHotHistoryInterval     = 10 * time.Minute
+	defaultHotHistoryRetention    = 7 * 24 * time.Hour
+	defaultTolerantSizeRatio      = 5
+	defaultLowSpaceRatio          = 0.8
+	defaultHighSpaceRatio         = 0.6
 )
 
 func (c *ScheduleConfig) adjust() error {
@@ -494,7 +564,18 @@ func (c *ScheduleConfig) adjust() error {
 	adjustUint64(&c.RegionScheduleLimit, defaultRegionScheduleLimit)
 	adjustUint64(&c.ReplicaScheduleLimit, defaultReplicaScheduleLimit)
 	adjustUint64(&c.MergeScheduleLimit, defaultMergeScheduleLimit)
+	adjustUint64(&c.SplitScheduleLimit, defaultSplitScheduleLimit)
+	adjustUint64(&c.IsolationScheduleLimit, defaultIsolationScheduleLimit)
+	adjustFloat64(&c.AdaptiveLimitMinRatio, defaultAdaptiveLimitMinRatio)
+	adjustFloat64(&c.AdaptiveLimitMaxRatio, defaultAdaptiveLimitMaxRatio)
+	adjustUint64(&c.LoadSplitMinFlowBytes, defaultLoadSplitMinFlow)
+	adjustDuration(&c.LoadSplitCooldown, defaultLoadSplitCooldown)
+	adjustUint64(&c.HotRegionWindowSize, defaultHotRegionWindowSize)
+	adjustDuration(&c.HotRegionsWriteInterval, defaultHotHistoryInterval)
+	adjustDuration(&c.HotRegionsRetention, defaultHotHistoryRetention)
 	adjustFloat64(&c.TolerantSizeRatio, defaultTolerantSizeRatio)
+	adjustString(&c.LeaderScoreStrategy, core.ScoreBySize)
+	adjustString(&c.RegionScoreStrategy, core.ScoreBySize)
 	adjustFloat64(&c.LowSpaceRatio, defaultLowSpaceRatio)
 	adjustFloat64(&c.HighSpaceRatio, defaultHighSpaceRatio)
 	adjustSchedulers(&c.Schedulers, defaultSchedulers)
@@ -515,9 +596,146 @@ func (c *ScheduleConfig) validate() error {
 	if c.LowSpaceRatio <= c.HighSpaceRatio {
 		return errors.New("low-space-ratio should be larger than high-space-ratio")
 	}
+	if c.AdaptiveLimitMinRatio <= 0 || c.AdaptiveLimitMinRatio > 1 {
+		return errors.New("adaptive-limit-min-ratio should be larger than 0 and not larger than 1")
+	}
+	if c.AdaptiveLimitMaxRatio < 1 {
+		return errors.New("adaptive-limit-max-ratio should not be less than 1")
+	}
+	if err := core.ValidateScoreStrategy(core.LeaderKind, c.LeaderScoreStrategy); err != nil {
+		return err
+	}
+	if err := core.ValidateScoreStrategy(core.RegionKind, c.RegionScoreStrategy); err != nil {
+		return err
+	}
+	names := make(map[string]struct{}, len(c.TimeWindows))
+	for i := range c.TimeWindows {
+		w := &c.TimeWindows[i]
+		if _, ok := names[w.Name]; ok {
+			return errors.Errorf("duplicated time window %s", w.Name)
+		}
+		names[w.Name] = struct{}{}
+		if err := w.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getActiveTimeWindow returns the first time window containing the time, or
+// nil if there is no such window.
+func (c *ScheduleConfig) getActiveTimeWindow(t time.Time) *TimeWindow {
+	for i := range c.TimeWindows {
+		if c.TimeWindows[i].contains(t) {
+			return &c.TimeWindows[i]
+		}
+	}
 	return nil
 }
 
+// TimeWindow is a daily period in which some of the schedule options are
+// overridden, the options not set in the window are left unchanged.
+type TimeWindow struct {
+	Name string `toml:"name" json:"name"`
+	// Start and End are the local time of the day in the format of "15:04",
+	// the window spans midnight if End is before Start.
+	Start string `toml:"start" json:"start"`
+	End   string `toml:"end" json:"end"`
+
+	LeaderScheduleLimit  *uint64 `toml:"leader-schedule-limit,omitempty" json:"leader-schedule-limit,omitempty"`
+	RegionScheduleLimit  *uint64 `toml:"region-schedule-limit,omitempty" json:"region-schedule-limit,omitempty"`
+	ReplicaScheduleLimit *uint64 `toml:"replica-schedule-limit,omitempty" json:"replica-schedule-limit,omitempty"`
+	MergeScheduleLimit   *uint64 `toml:"merge-schedule-limit,omitempty" json:"merge-schedule-limit,omitempty"`
+	// DisabledSchedulers are the names of the schedulers which do not run in
+	// the window.
+	DisabledSchedulers []string `toml:"disabled-schedulers,omitempty" json:"disabled-schedulers,omitempty"`
+}
+
+func cloneUint64(v *uint64) *uint64 {
+	if v == nil {
+		return nil
+	}
+	res := *v
+	return &res
+}
+
+func (w *TimeWindow) clone() *TimeWindow {
+	var disabledSchedulers []string
+	if w.DisabledSchedulers != nil {
+		disabledSchedulers = make([]string, len(w.DisabledSchedulers))
+		copy(disabledSchedulers, w.DisabledSchedulers)
+	}
+	return &TimeWindow{
+		Name:                 w.Name,
+		Start:                w.Start,
+		End:                  w.End,
+		LeaderScheduleLimit:  cloneUint64(w.LeaderScheduleLimit),
+		RegionScheduleLimit:  cloneUint64(w.RegionScheduleLimit),
+		ReplicaScheduleLimit: cloneUint64(w.ReplicaScheduleLimit),
+		MergeScheduleLimit:   cloneUint64(w.MergeScheduleLimit),
+		DisabledSchedulers:   disabledSchedulers,
+	}
+}
+
+const timeOfDayFormat = "15:04"
+
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse(timeOfDayFormat, s)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func (w *TimeWindow) validate() error {
+	if w.Name == "" {
+		return errors.New("time window name should not be empty")
+	}
+	start, err := parseTimeOfDay(w.Start)
+	if err != nil {
+		return errors.Errorf("invalid start %q of time window %s", w.Start, w.Name)
+	}
+	end, err := parseTimeOfDay(w.End)
+	if err != nil {
+		return errors.Errorf("invalid end %q of time window %s", w.End, w.Name)
+	}
+	if start == end {
+		return errors.Errorf("time window %s should not be empty", w.Name)
+	}
+	return nil
+}
+
+// contains returns true if the local time of the day of t is in the window.
+func (w *TimeWindow) contains(t time.Time) bool {
+	start, err := parseTimeOfDay(w.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseTimeOfDay(w.End)
+	if err != nil {
+		return false
+	}
+	now := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	if start < end {
+		return start <= now && now < end
+	}
+	return now >= start || now < end
+}
+
+// EffectiveScheduleConfig is the schedule config with the options overridden
+// by the active time window and scaled by the adaptive limit factor.
+type EffectiveScheduleConfig struct {
+	ScheduleConfig
+	// ActiveTimeWindow is the name of the time window in effect.
+	ActiveTimeWindow string `json:"active-time-window,omitempty"`
+	// DisabledSchedulers are the names of the schedulers disabled by the
+	// active time window.
+	DisabledSchedulers []string `json:"disabled-schedulers,omitempty"`
+	// AdaptiveLimitFactor is the ratio of the adaptive schedule limits to the
+	// configured ones.
+	AdaptiveLimitFactor float64 `json:"adaptive-limit-factor"`
+}
+
 // SchedulerConfigs is a slice of customized scheduler configuration.
 type SchedulerConfigs []SchedulerConfig
 
@@ -526,6 +744,10 @@ type SchedulerConfig struct {
 	Type    string   `toml:"type" json:"type"`
 	Args    []string `toml:"args,omitempty" json:"args"`
 	Disable bool     `toml:"disable" json:"disable"`
+	// PausedUntil is the unix timestamp in seconds until which the scheduler is paused.
+	PausedUntil int64 `toml:"paused-until,omitempty" json:"paused-until,omitempty"`
+	// Config is the JSON encoded runtime config of the scheduler.
+	Config json.RawMessage `toml:"-" json:"config,omitempty"`
 }
 
 var defaultSchedulers = SchedulerConfigs{
@@ -555,14 +777,37 @@ type ReplicationConfig struct {
 	// For example, ["zone", "rack"] means that we should place replicas to
 	// different zones first, then to different racks if we don't have enough zones.
 	LocationLabels typeutil.StringSlice `toml:"location-labels,omitempty" json:"location-labels"`
+
+	// EnablePlacementRules is the option to place the replicas by the
+	// placement rules instead of MaxReplicas and LocationLabels. The default
+	// rule is created from them when there is no rule.
+	EnablePlacementRules bool `toml:"enable-placement-rules" json:"enable-placement-rules,string"`
+
+	// LearnerReplicas is the number of read-only learners for each region,
+	// the learners are never promoted to voters.
+	LearnerReplicas uint64 `toml:"learner-replicas" json:"learner-replicas"`
+	// LearnerLabels selects the stores to place the learners, a store is
+	// selected if it has all the labels. The selected stores only hold
+	// learners.
+	LearnerLabels map[string]string `toml:"learner-labels" json:"learner-labels"`
 }
 
 func (c *ReplicationConfig) clone() *ReplicationConfig {
 	locationLabels := make(typeutil.StringSlice, len(c.LocationLabels))
 	copy(locationLabels, c.LocationLabels)
+	var learnerLabels map[string]string
+	if c.LearnerLabels != nil {
+		learnerLabels = make(map[string]string, len(c.LearnerLabels))
+		for k, v := range c.LearnerLabels {
+			learnerLabels[k] = v
+		}
+	}
 	return &ReplicationConfig{
-		MaxReplicas:    c.MaxReplicas,
-		LocationLabels: locationLabels,
+		MaxReplicas:          c.MaxReplicas,
+		LocationLabels:       locationLabels,
+		EnablePlacementRules: c.EnablePlacementRules,
+		LearnerReplicas:      c.LearnerReplicas,
+		LearnerLabels:        learnerLabels,
 	}
 }
 
@@ -573,6 +818,14 @@ func (c *ReplicationConfig) validate() error {
 			return err
 		}
 	}
+	for key := range c.LearnerLabels {
+		if err := ValidateLabelString(key); err != nil {
+			return err
+		}
+	}
+	if c.LearnerReplicas > 0 && len(c.LearnerLabels) == 0 {
+		return errors.New("learner-labels is required to place the learner replicas")
+	}
 	return nil
 }
 
@@ -581,7 +834,10 @@ func (c *ReplicationConfig) adjust() error {
 	return c.validate()
 }
 
-// NamespaceConfig is to overwrite the global setting for specific namespace
+// NamespaceConfig is to overwrite the global setting for specific namespace.
+// Besides the fields, any option of ScheduleConfig or ReplicationConfig
+// which is not global-only can be overridden by the namespace, the zero
+// value of a field means it is not set.
 type NamespaceConfig struct {
 	// LeaderScheduleLimit is the max coexist leader schedules.
 	LeaderScheduleLimit uint64 `json:"leader-schedule-limit"`
@@ -593,14 +849,239 @@ type NamespaceConfig struct {
 	MergeScheduleLimit uint64 `json:"merge-schedule-limit"`
 	// MaxReplicas is the number of replicas for each region.
 	MaxReplicas uint64 `json:"max-replicas"`
+	// LearnerReplicas is the number of read-only learners for each region.
+	LearnerReplicas uint64 `json:"learner-replicas"`
+	// LeaderScoreStrategy is how to score the stores to balance leaders.
+	LeaderScoreStrategy string `json:"leader-score-strategy"`
+	// RegionScoreStrategy is how to score the stores to balance regions.
+	RegionScoreStrategy string `json:"region-score-strategy"`
+	// DisabledSchedulers are the names of the schedulers which do not
+	// schedule the namespace.
+	DisabledSchedulers []string `json:"disabled-schedulers,omitempty"`
+	// Overrides are the other overridden options keyed by their JSON names,
+	// they are flattened into the JSON of the namespace config.
+	Overrides map[string]interface{} `json:"-"`
+}
+
+// globalOnlyOptions are the options which can't be overridden by namespaces,
+// as they are not used in the scope of a namespace.
+var globalOnlyOptions = map[string]struct{}{
+	"patrol-region-interval":         {},
+	"max-store-down-time":            {},
+	"enable-adaptive-schedule-limit": {},
+	"adaptive-limit-min-ratio":       {},
+	"adaptive-limit-max-ratio":       {},
+	"load-split-hot-degree":          {},
+	"load-split-min-flow-bytes":      {},
+	"load-split-cooldown":            {},
+	"hot-region-window-size":         {},
+	"hot-regions-write-interval":     {},
+	"hot-regions-retention":          {},
+	"schedulers-v2":                  {},
+	"time-windows":                   {},
+	"enable-placement-rules":         {},
 }
 
-func (c *NamespaceConfig) adjust(opt *scheduleOption) {
-	adjustUint64(&c.LeaderScheduleLimit, opt.GetLeaderScheduleLimit(namespace.DefaultNamespace))
-	adjustUint64(&c.RegionScheduleLimit, opt.GetRegionScheduleLimit(namespace.DefaultNamespace))
-	adjustUint64(&c.ReplicaScheduleLimit, opt.GetReplicaScheduleLimit(namespace.DefaultNamespace))
-	adjustUint64(&c.MergeScheduleLimit, opt.GetMergeScheduleLimit(namespace.DefaultNamespace))
-	adjustUint64(&c.MaxReplicas, uint64(opt.GetMaxReplicas(namespace.DefaultNamespace)))
+var (
+	namespaceConfigFields = jsonFieldNames(reflect.TypeOf(NamespaceConfig{}))
+	scheduleConfigFields  = jsonFieldNames(reflect.TypeOf(ScheduleConfig{}))
+	replicationFields     = jsonFieldNames(reflect.TypeOf(ReplicationConfig{}))
+)
+
+// jsonFieldNames returns the JSON names of the struct fields.
+func jsonFieldNames(typ reflect.Type) map[string]struct{} {
+	names := make(map[string]struct{})
+	for i := 0; i < typ.NumField(); i++ {
+		name := strings.Split(typ.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			names[name] = struct{}{}
+		}
+	}
+	return names
+}
+
+// IsNamespaceOverridable returns true if the option of ScheduleConfig or
+// ReplicationConfig can be overridden by namespaces.
+func IsNamespaceOverridable(option string) bool {
+	if _, ok := globalOnlyOptions[option]; ok {
+		return false
+	}
+	_, ok1 := scheduleConfigFields[option]
+	_, ok2 := replicationFields[option]
+	return ok1 || ok2
+}
+
+// rawNamespaceConfig is NamespaceConfig without the custom JSON methods.
+type rawNamespaceConfig NamespaceConfig
+
+// MarshalJSON flattens the overrides into the fields of the namespace config.
+func (c NamespaceConfig) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(rawNamespaceConfig(c))
+	if err != nil || len(c.Overrides) == 0 {
+		return data, errors.Trace(err)
+	}
+	m := make(map[string]interface{})
+	if err = json.Unmarshal(data, &m); err != nil {
+		return nil, errors.Trace(err)
+	}
+	for k, v := range c.Overrides {
+		m[k] = v
+	}
+	data, err = json.Marshal(m)
+	return data, errors.Trace(err)
+}
+
+// UnmarshalJSON merges the JSON object into the namespace config. The keys
+// which are not the fields of NamespaceConfig are taken as the overrides, a
+// null value resets the field or removes the override.
+func (c *NamespaceConfig) UnmarshalJSON(data []byte) error {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return errors.Trace(err)
+	}
+	fields := make(map[string]json.RawMessage)
+	for k, v := range m {
+		null := string(v) == "null"
+		if _, ok := namespaceConfigFields[k]; ok {
+			if null {
+				resetJSONField(c, k)
+			} else {
+				fields[k] = v
+			}
+			continue
+		}
+		if !IsNamespaceOverridable(k) {
+			return errors.Errorf("%s can't be overridden by namespace", k)
+		}
+		if null {
+			delete(c.Overrides, k)
+			continue
+		}
+		value, err := normalizeOverride(k, v)
+		if err != nil {
+			return err
+		}
+		if c.Overrides == nil {
+			c.Overrides = make(map[string]interface{})
+		}
+		c.Overrides[k] = value
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(json.Unmarshal(data, (*rawNamespaceConfig)(c)))
+}
+
+// resetJSONField sets the field of the struct pointer with the JSON name to
+// the zero value.
+func resetJSONField(v interface{}, name string) {
+	rv := reflect.ValueOf(v).Elem()
+	for i := 0; i < rv.NumField(); i++ {
+		if strings.Split(rv.Type().Field(i).Tag.Get("json"), ",")[0] == name {
+			rv.Field(i).Set(reflect.Zero(rv.Field(i).Type()))
+		}
+	}
+}
+
+func overrideUint64(v *uint64, override uint64) {
+	if override > 0 {
+		*v = override
+	}
+}
+
+// normalizeOverride checks the type of the overridden value and converts it
+// to the form marshaled by ScheduleConfig or ReplicationConfig. The bool
+// options are also accepted as JSON bools.
+func normalizeOverride(option string, value json.RawMessage) (interface{}, error) {
+	var cfg interface{} = &ScheduleConfig{}
+	if _, ok := replicationFields[option]; ok {
+		cfg = &ReplicationConfig{}
+	}
+	data := []byte(fmt.Sprintf(`{"%s":%s}`, option, value))
+	if err := json.Unmarshal(data, cfg); err != nil {
+		if b, e := strconv.ParseBool(string(value)); e != nil || json.Unmarshal([]byte(fmt.Sprintf(`{"%s":"%t"}`, option, b)), cfg) != nil {
+			return nil, errors.Errorf("invalid value %s of %s: %v", value, option, err)
+		}
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	m := make(map[string]interface{})
+	if err = json.Unmarshal(data, &m); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return m[option], nil
+}
+
+func (c *NamespaceConfig) clone() *NamespaceConfig {
+	cfg := *c
+	cfg.DisabledSchedulers = append([]string(nil), c.DisabledSchedulers...)
+	if c.Overrides != nil {
+		cfg.Overrides = make(map[string]interface{}, len(c.Overrides))
+		for k, v := range c.Overrides {
+			cfg.Overrides[k] = v
+		}
+	}
+	return &cfg
+}
+
+// resolve returns the schedule and replication configs of the namespace,
+// which are the global ones overridden by the namespace config.
+func (c *NamespaceConfig) resolve(schedule *ScheduleConfig, replication *ReplicationConfig) (*ScheduleConfig, *ReplicationConfig, error) {
+	sc, rc := schedule.clone(), replication.clone()
+	scheduleOverrides := make(map[string]interface{})
+	replicationOverrides := make(map[string]interface{})
+	for k, v := range c.Overrides {
+		if _, ok := replicationFields[k]; ok {
+			replicationOverrides[k] = v
+		} else {
+			scheduleOverrides[k] = v
+		}
+	}
+	for _, o := range []struct {
+		overrides map[string]interface{}
+		cfg       interface{}
+	}{{scheduleOverrides, sc}, {replicationOverrides, rc}} {
+		// The maps are replaced instead of merged.
+		for k := range o.overrides {
+			resetJSONField(o.cfg, k)
+		}
+		data, err := json.Marshal(o.overrides)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		if err = json.Unmarshal(data, o.cfg); err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+	}
+
+	overrideUint64(&sc.LeaderScheduleLimit, c.LeaderScheduleLimit)
+	overrideUint64(&sc.RegionScheduleLimit, c.RegionScheduleLimit)
+	overrideUint64(&sc.ReplicaScheduleLimit, c.ReplicaScheduleLimit)
+	overrideUint64(&sc.MergeScheduleLimit, c.MergeScheduleLimit)
+	overrideUint64(&rc.MaxReplicas, c.MaxReplicas)
+	overrideUint64(&rc.LearnerReplicas, c.LearnerReplicas)
+	if c.LeaderScoreStrategy != "" {
+		sc.LeaderScoreStrategy = c.LeaderScoreStrategy
+	}
+	if c.RegionScoreStrategy != "" {
+		sc.RegionScoreStrategy = c.RegionScoreStrategy
+	}
+	return sc, rc, nil
+}
+
+func (c *NamespaceConfig) validate() error {
+	if c.LeaderScoreStrategy != "" {
+		if err := core.ValidateScoreStrategy(core.LeaderKind, c.LeaderScoreStrategy); err != nil {
+			return err
+		}
+	}
+	if c.RegionScoreStrategy != "" {
+		return core.ValidateScoreStrategy(core.RegionKind, c.RegionScoreStrategy)
+	}
+	return nil
 }
 
 // SecurityConfig is the configuration for supporting tls.
@@ -14,7 +14,9 @@
 package server
 
 import (
+	"encoding/json"
 	"path"
+	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/pd/server/core"
@@ -80,4 +82,141 @@ func (s *testConfigSuite) TestValidation(c *C) {
 	c.Assert(cfg.Schedule.validate(), IsNil)
 	cfg.Schedule.TolerantSizeRatio = -0.6
 	c.Assert(cfg.Schedule.validate(), NotNil)
+	cfg.Schedule.TolerantSizeRatio = 5
+	c.Assert(cfg.Schedule.validate(), IsNil)
+
+	// check score strategies
+	cfg.Schedule.LeaderScoreStrategy = core.ScoreBySpace
+	c.Assert(cfg.Schedule.validate(), NotNil)
+	cfg.Schedule.LeaderScoreStrategy = core.ScoreByCount
+	cfg.Schedule.RegionScoreStrategy = core.ScoreBySpace
+	c.Assert(cfg.Schedule.validate(), IsNil)
+	cfg.Schedule.RegionScoreStrategy = "unknown"
+	c.Assert(cfg.Schedule.validate(), NotNil)
+	cfg.Schedule.RegionScoreStrategy = core.ScoreBySize
+
+	// check adaptive limit ratios
+	cfg.Schedule.AdaptiveLimitMinRatio = 0
+	c.Assert(cfg.Schedule.validate(), NotNil)
+	cfg.Schedule.AdaptiveLimitMinRatio = 0.5
+	cfg.Schedule.AdaptiveLimitMaxRatio = 0.8
+	c.Assert(cfg.Schedule.validate(), NotNil)
+	cfg.Schedule.AdaptiveLimitMaxRatio = 1
+	c.Assert(cfg.Schedule.validate(), IsNil)
+
+	nsCfg := &NamespaceConfig{}
+	c.Assert(nsCfg.validate(), IsNil)
+	nsCfg.LeaderScoreStrategy = core.ScoreBySpace
+	c.Assert(nsCfg.validate(), NotNil)
+}
+
+func (s *testConfigSuite) TestNamespaceConfigOverride(c *C) {
+	cfg := NewConfig()
+	c.Assert(cfg.adjust(nil), IsNil)
+
+	nsCfg := &NamespaceConfig{}
+	data := `{"leader-schedule-limit": 8, "disabled-schedulers": ["balance-region-scheduler"],
+		"tolerant-size-ratio": 10, "location-labels": "zone,host", "disable-raft-learner": true}`
+	c.Assert(json.Unmarshal([]byte(data), nsCfg), IsNil)
+	c.Assert(nsCfg.LeaderScheduleLimit, Equals, uint64(8))
+	c.Assert(nsCfg.DisabledSchedulers, DeepEquals, []string{"balance-region-scheduler"})
+	c.Assert(nsCfg.Overrides, DeepEquals, map[string]interface{}{
+		"tolerant-size-ratio":  float64(10),
+		"location-labels":      "zone,host",
+		"disable-raft-learner": "true",
+	})
+
+	// The unset options are inherited from the global configs.
+	sc, rc, err := nsCfg.resolve(&cfg.Schedule, &cfg.Replication)
+	c.Assert(err, IsNil)
+	c.Assert(sc.LeaderScheduleLimit, Equals, uint64(8))
+	c.Assert(sc.RegionScheduleLimit, Equals, cfg.Schedule.RegionScheduleLimit)
+	c.Assert(sc.TolerantSizeRatio, Equals, float64(10))
+	c.Assert(sc.DisableLearner, IsTrue)
+	c.Assert(sc.Schedulers, DeepEquals, cfg.Schedule.Schedulers)
+	c.Assert([]string(rc.LocationLabels), DeepEquals, []string{"zone", "host"})
+	c.Assert(rc.MaxReplicas, Equals, cfg.Replication.MaxReplicas)
+	c.Assert(cfg.Schedule.TolerantSizeRatio, Not(Equals), float64(10))
+	c.Assert(cfg.Replication.LocationLabels, HasLen, 0)
+
+	// The overrides are flattened in JSON.
+	data2, err := json.Marshal(nsCfg)
+	c.Assert(err, IsNil)
+	nsCfg2 := &NamespaceConfig{}
+	c.Assert(json.Unmarshal(data2, nsCfg2), IsNil)
+	c.Assert(nsCfg2, DeepEquals, nsCfg)
+
+	// A null value resets the option.
+	c.Assert(json.Unmarshal([]byte(`{"leader-schedule-limit": null, "tolerant-size-ratio": null}`), nsCfg), IsNil)
+	c.Assert(nsCfg.LeaderScheduleLimit, Equals, uint64(0))
+	c.Assert(nsCfg.Overrides, HasLen, 2)
+	sc, _, err = nsCfg.resolve(&cfg.Schedule, &cfg.Replication)
+	c.Assert(err, IsNil)
+	c.Assert(sc.LeaderScheduleLimit, Equals, cfg.Schedule.LeaderScheduleLimit)
+	c.Assert(sc.TolerantSizeRatio, Equals, cfg.Schedule.TolerantSizeRatio)
+
+	// The global-only, unknown and mistyped options are rejected.
+	c.Assert(json.Unmarshal([]byte(`{"time-windows": []}`), nsCfg), NotNil)
+	c.Assert(json.Unmarshal([]byte(`{"max-store-down-time": "1h"}`), nsCfg), NotNil)
+	c.Assert(json.Unmarshal([]byte(`{"unknown-option": 1}`), nsCfg), NotNil)
+	c.Assert(json.Unmarshal([]byte(`{"tolerant-size-ratio": "abc"}`), nsCfg), NotNil)
+}
+
+func (s *testConfigSuite) TestTimeWindow(c *C) {
+	cfg := NewConfig()
+	c.Assert(cfg.adjust(nil), IsNil)
+
+	// check time windows
+	cfg.Schedule.TimeWindows = []TimeWindow{{Name: "night", Start: "22:00", End: "06:00"}}
+	c.Assert(cfg.Schedule.validate(), IsNil)
+	cfg.Schedule.TimeWindows = append(cfg.Schedule.TimeWindows, TimeWindow{Name: "night", Start: "06:00", End: "08:00"})
+	c.Assert(cfg.Schedule.validate(), NotNil)
+	cfg.Schedule.TimeWindows[1].Name = ""
+	c.Assert(cfg.Schedule.validate(), NotNil)
+	cfg.Schedule.TimeWindows[1].Name = "morning"
+	c.Assert(cfg.Schedule.validate(), IsNil)
+	cfg.Schedule.TimeWindows[1].End = "25:00"
+	c.Assert(cfg.Schedule.validate(), NotNil)
+	cfg.Schedule.TimeWindows[1].End = "06:00"
+	c.Assert(cfg.Schedule.validate(), NotNil)
+	cfg.Schedule.TimeWindows[1].End = "08:00"
+
+	at := func(hour, minute int) time.Time {
+		return time.Date(2019, 1, 1, hour, minute, 0, 0, time.Local)
+	}
+	c.Assert(cfg.Schedule.getActiveTimeWindow(at(23, 0)).Name, Equals, "night")
+	c.Assert(cfg.Schedule.getActiveTimeWindow(at(5, 59)).Name, Equals, "night")
+	c.Assert(cfg.Schedule.getActiveTimeWindow(at(6, 0)).Name, Equals, "morning")
+	c.Assert(cfg.Schedule.getActiveTimeWindow(at(8, 0)), IsNil)
+	c.Assert(cfg.Schedule.getActiveTimeWindow(at(21, 59)), IsNil)
+
+	// The options not set in the active window are left unchanged.
+	limit := uint64(0)
+	now := time.Now()
+	cfg.Schedule.TimeWindows = []TimeWindow{{
+		Name:                "busy",
+		Start:               now.Add(-time.Hour).Format(timeOfDayFormat),
+		End:                 now.Add(time.Hour).Format(timeOfDayFormat),
+		RegionScheduleLimit: &limit,
+		DisabledSchedulers:  []string{"balance-region-scheduler"},
+	}}
+	opt := newScheduleOption(cfg)
+	c.Assert(opt.GetRegionScheduleLimit(""), Equals, uint64(0))
+	c.Assert(opt.GetLeaderScheduleLimit(""), Equals, cfg.Schedule.LeaderScheduleLimit)
+	// The window throttles the namespaces too.
+	opt.ns["ns1"] = newNamespaceOption(&NamespaceConfig{RegionScheduleLimit: 8, LeaderScheduleLimit: 8})
+	c.Assert(opt.GetRegionScheduleLimit("ns1"), Equals, uint64(0))
+	c.Assert(opt.GetLeaderScheduleLimit("ns1"), Equals, uint64(8))
+	c.Assert(opt.IsSchedulerDisabledByTimeWindow("balance-region-scheduler"), IsTrue)
+	c.Assert(opt.IsSchedulerDisabledByTimeWindow("balance-leader-scheduler"), IsFalse)
+	effective := opt.GetEffectiveScheduleConfig()
+	c.Assert(effective.ActiveTimeWindow, Equals, "busy")
+	c.Assert(effective.RegionScheduleLimit, Equals, uint64(0))
+	c.Assert(effective.LeaderScheduleLimit, Equals, cfg.Schedule.LeaderScheduleLimit)
+	c.Assert(effective.DisabledSchedulers, DeepEquals, []string{"balance-region-scheduler"})
+
+	cfg.Schedule.TimeWindows = nil
+	opt = newScheduleOption(cfg)
+	c.Assert(opt.GetRegionScheduleLimit(""), Equals, cfg.Schedule.RegionScheduleLimit)
+	c.Assert(opt.GetEffectiveScheduleConfig().ActiveTimeWindow, Equals, "")
 }
@@ -16,8 +16,11 @@ package server
 import (
 	"container/list"
 	"context"
+	"encoding/json"
 	"fmt"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/juju/errors"
@@ -35,17 +38,21 @@ const (
 	runSchedulerCheckInterval = 3 * time.Second
 	collectFactor             = 0.8
 	historyKeepTime           = 5 * time.Minute
+	scatterGroupKeepTime      = 30 * time.Minute
 	maxScheduleRetries        = 10
 
 	regionheartbeatSendChanCap = 1024
 	hotRegionScheduleName      = "balance-hot-region-scheduler"
+	replicaCheckerName         = "replica-checker"
 
 	patrolScanRegionLimit = 128 // It takes about 14 minutes to iterate 1 million regions.
 )
 
 var (
-	errSchedulerExisted  = errors.New("scheduler existed")
-	errSchedulerNotFound = errors.New("scheduler not found")
+	errSchedulerExisted         = errors.New("scheduler existed")
+	errSchedulerNotFound        = errors.New("scheduler not found")
+	errSchedulerNotConfigurable = errors.New("scheduler is not configurable")
+	errSchedulerNotExplainable  = errors.New("scheduler is not explainable")
 )
 
 type coordinator struct {
@@ -61,11 +68,15 @@ type coordinator struct {
 	regionScatterer  *schedule.RegionScatterer
 	namespaceChecker *schedule.NamespaceChecker
 	mergeChecker     *schedule.MergeChecker
+	splitChecker     *schedule.SplitChecker
+	isolationChecker *schedule.IsolationChecker
+	limitController  *adaptiveLimitController
 	operators        map[uint64]*schedule.Operator
 	schedulers       map[string]*scheduleController
 	classifier       namespace.Classifier
 	histories        *list.List
 	hbStreams        *heartbeatStreams
+	scatterProgress  map[string]*ScatterProgress
 }
 
 func newCoordinator(cluster *clusterInfo, hbStreams *heartbeatStreams, classifier namespace.Classifier) *coordinator {
@@ -79,11 +90,15 @@ func newCoordinator(cluster *clusterInfo, hbStreams *heartbeatStreams, classifie
 		regionScatterer:  schedule.NewRegionScatterer(cluster, classifier),
 		namespaceChecker: schedule.NewNamespaceChecker(cluster, classifier),
 		mergeChecker:     schedule.NewMergeChecker(cluster, classifier),
+		splitChecker:     schedule.NewSplitChecker(cluster),
+		isolationChecker: schedule.NewIsolationChecker(cluster, classifier),
+		limitController:  newAdaptiveLimitController(cluster),
 		operators:        make(map[uint64]*schedule.Operator),
 		schedulers:       make(map[string]*scheduleController),
 		classifier:       classifier,
 		histories:        list.New(),
 		hbStreams:        hbStreams,
+		scatterProgress:  make(map[string]*ScatterProgress),
 	}
 }
 
@@ -102,10 +117,12 @@ func (c *coordinator) dispatch(region *core.RegionInfo) {
 			operatorDuration.WithLabelValues(op.Desc()).Observe(op.ElapsedTime().Seconds())
 			c.pushHistory(op)
 			c.removeOperator(op)
+			c.limitController.recordOperator(false)
 		} else if timeout {
 			log.Infof("[region %v] operator timeout: %s", region.GetId(), op)
 			operatorCounter.WithLabelValues(op.Desc(), "timeout").Inc()
 			c.removeOperator(op)
+			c.limitController.recordOperator(true)
 		}
 	}
 }
@@ -159,10 +176,15 @@ func (c *coordinator) patrolRegions() {
 func (c *coordinator) checkRegion(region *core.RegionInfo) bool {
 	// If PD has restarted, it need to check learners added before and promote them.
 	// Don't check isRaftLearnerEnabled cause it may be disable learner feature but still some learners to promote.
+	// The read-only learners are never promoted.
+	_, learnerConstraints := schedule.GetLearnerReplicas(c.cluster, c.classifier, region)
 	for _, p := range region.GetLearners() {
 		if region.GetPendingLearner(p.GetId()) != nil {
 			continue
 		}
+		if store := c.cluster.GetStore(p.GetStoreId()); store != nil && schedule.IsLearnerStore(store, learnerConstraints) {
+			continue
+		}
 		step := schedule.PromoteLearner{
 			ToStore: p.GetStoreId(),
 			PeerID:  p.GetId(),
@@ -173,20 +195,41 @@ func (c *coordinator) checkRegion(region *core.RegionInfo) bool {
 		}
 	}
 
-	if op := c.namespaceChecker.Check(region); op != nil {
-		if c.addOperator(op) {
-			return true
+	// The migration of namespaces is throttled by the replica schedule limit.
+	if !c.namespaceChecker.CheckMigration(region) || c.limiter.OperatorCount(schedule.OpReplica) < c.cluster.GetReplicaScheduleLimit() {
+		if op := c.namespaceChecker.Check(region); op != nil {
+			if c.addOperator(op) {
+				return true
+			}
 		}
 	}
+	// The checkers read the options overridden by the namespace of the region.
+	cluster := c.regionCluster(region)
 	if c.limiter.OperatorCount(schedule.OpReplica) < c.cluster.GetReplicaScheduleLimit() {
-		if op := c.replicaChecker.Check(region); op != nil {
+		if op := c.replicaChecker.WithCluster(cluster).Check(region); op != nil {
 			if c.addOperator(op) {
 				return true
 			}
 		}
 	}
+	if c.limiter.OperatorCount(schedule.OpIsolation) < c.cluster.GetIsolationScheduleLimit() {
+		if op := c.isolationChecker.WithCluster(cluster).Check(region); op != nil {
+			if c.addOperator(op) {
+				return true
+			}
+		}
+	}
+	if c.limiter.OperatorCount(schedule.OpSplit) < c.cluster.GetSplitScheduleLimit() {
+		if op := c.splitChecker.Check(region); op != nil {
+			if c.addOperator(op) {
+				// The halves should not be merged back before they cool down.
+				c.mergeChecker.WithCluster(cluster).RecordRegionSplit(region.GetId())
+				return true
+			}
+		}
+	}
 	if c.cluster.IsFeatureSupported(RegionMerge) && c.limiter.OperatorCount(schedule.OpMerge) < c.cluster.GetMergeScheduleLimit() {
-		if op1, op2 := c.mergeChecker.Check(region); op1 != nil && op2 != nil {
+		if op1, op2 := c.mergeChecker.WithCluster(cluster).Check(region); op1 != nil && op2 != nil {
 			// make sure two operators can add successfully altogether
 			if c.addOperator(op1, op2) {
 				return true
@@ -196,6 +239,24 @@ func (c *coordinator) checkRegion(region *core.RegionInfo) bool {
 	return false
 }
 
+// regionCluster returns the cluster with the options overridden by the
+// namespace of the region.
+func (c *coordinator) regionCluster(region *core.RegionInfo) schedule.Cluster {
+	return newNamespaceOptionCluster(c.cluster, c.classifier.GetRegionNamespace(region))
+}
+
+// getUnderIsolatedRegions returns the regions which can be moved to improve
+// their isolation level.
+func (c *coordinator) getUnderIsolatedRegions() []*core.RegionInfo {
+	var res []*core.RegionInfo
+	for _, region := range c.cluster.getRegions() {
+		if c.isolationChecker.WithCluster(c.regionCluster(region)).IsUnderIsolated(region) {
+			res = append(res, region)
+		}
+	}
+	return res
+}
+
 func (c *coordinator) run() {
 	ticker := time.NewTicker(runSchedulerCheckInterval)
 	defer ticker.Stop()
@@ -216,6 +277,7 @@ func (c *coordinator) run() {
 
 	k := 0
 	scheduleCfg := c.cluster.opt.load()
+	scheduleCfg.Schedulers = mergeEvictLeaderSchedulers(scheduleCfg.Schedulers)
 	for _, schedulerCfg := range scheduleCfg.Schedulers {
 		if schedulerCfg.Disable {
 			scheduleCfg.Schedulers[k] = schedulerCfg
@@ -230,6 +292,8 @@ func (c *coordinator) run() {
 			log.Infof("create scheduler %s", s.GetName())
 			if err = c.addScheduler(s, schedulerCfg.Args...); err != nil {
 				log.Errorf("can not add scheduler %s: %v", s.GetName(), err)
+			} else {
+				c.restoreSchedulerState(s.GetName(), schedulerCfg)
 			}
 		}
 
@@ -248,44 +312,152 @@ func (c *coordinator) run() {
 
 	c.wg.Add(1)
 	go c.patrolRegions()
+	c.wg.Add(1)
+	go c.persistHotRegions()
+	c.wg.Add(1)
+	go c.adjustScheduleLimits()
 }
 
-func (c *coordinator) stop() {
-	c.cancel()
-	c.wg.Wait()
+// adjustScheduleLimits adjusts the adaptive schedule limits periodically.
+func (c *coordinator) adjustScheduleLimits() {
+	defer logutil.LogPanic()
+
+	defer c.wg.Done()
+	ticker := time.NewTicker(adaptiveLimitInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.limitController.adjust()
+		case <-c.ctx.Done():
+			return
+		}
+	}
 }
 
-// Hack to retrive info from scheduler.
-// TODO: remove it.
-type hasHotStatus interface {
-	GetHotReadStatus() *core.StoreHotRegionInfos
-	GetHotWriteStatus() *core.StoreHotRegionInfos
+// persistHotRegions saves the hot regions as history periodically.
+func (c *coordinator) persistHotRegions() {
+	defer logutil.LogPanic()
+
+	defer c.wg.Done()
+	timer := time.NewTimer(c.cluster.GetHotRegionsWriteInterval())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			timer.Reset(c.cluster.GetHotRegionsWriteInterval())
+		case <-c.ctx.Done():
+			return
+		}
+		if err := c.cluster.saveHistoryHotRegions(time.Now()); err != nil {
+			log.Errorf("failed to save hot regions history: %v", err)
+		}
+	}
 }
 
-func (c *coordinator) getHotWriteRegions() *core.StoreHotRegionInfos {
-	c.RLock()
-	defer c.RUnlock()
-	s, ok := c.schedulers[hotRegionScheduleName]
-	if !ok {
-		return nil
+// evictLeaderSchedulerName is the name of the evict-leader scheduler, which
+// evicts leaders from all the stores in its config.
+const evictLeaderSchedulerName = "evict-leader-scheduler"
+
+// evictLeaderConfig is the runtime config of the evict-leader scheduler.
+type evictLeaderConfig struct {
+	StoreIDs []uint64 `json:"store-id-list"`
+}
+
+// mergeEvictLeaderSchedulers merges the enabled evict-leader schedulers into
+// the first one, since they used to be created for each store.
+func mergeEvictLeaderSchedulers(cfgs SchedulerConfigs) SchedulerConfigs {
+	var (
+		merged = make(SchedulerConfigs, 0, len(cfgs))
+		first  = -1
+		count  int
+		conf   evictLeaderConfig
+	)
+	for _, cfg := range cfgs {
+		if cfg.Type != "evict-leader" || cfg.Disable {
+			merged = append(merged, cfg)
+			continue
+		}
+		storeIDs, err := getEvictLeaderStores(cfg)
+		if err != nil {
+			log.Errorf("can not merge evict-leader scheduler %v: %v", cfg.Args, err)
+			merged = append(merged, cfg)
+			continue
+		}
+		if first < 0 {
+			first = len(merged)
+			merged = append(merged, cfg)
+		}
+		conf.StoreIDs = append(conf.StoreIDs, storeIDs...)
+		count++
 	}
-	if h, ok := s.Scheduler.(hasHotStatus); ok {
-		return h.GetHotWriteStatus()
+	if count > 1 {
+		data, err := json.Marshal(conf)
+		if err != nil {
+			log.Errorf("can not merge evict-leader schedulers: %v", err)
+			return cfgs
+		}
+		merged[first].Config = data
+		log.Infof("merge %d evict-leader schedulers, stores %v", count, conf.StoreIDs)
 	}
-	return nil
+	return merged
 }
 
-func (c *coordinator) getHotReadRegions() *core.StoreHotRegionInfos {
+// getEvictLeaderStores returns the stores of the evict-leader scheduler from
+// its config or its argument.
+func getEvictLeaderStores(cfg SchedulerConfig) ([]uint64, error) {
+	if len(cfg.Config) > 0 {
+		var conf evictLeaderConfig
+		if err := json.Unmarshal(cfg.Config, &conf); err != nil {
+			return nil, errors.Trace(err)
+		}
+		return conf.StoreIDs, nil
+	}
+	if len(cfg.Args) != 1 {
+		return nil, errors.New("evict-leader needs 1 argument")
+	}
+	id, err := strconv.ParseUint(cfg.Args[0], 10, 64)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return []uint64{id}, nil
+}
+
+// restoreSchedulerState restores the persisted runtime config and pause state,
+// which survive the leader change.
+func (c *coordinator) restoreSchedulerState(name string, cfg SchedulerConfig) {
 	c.RLock()
-	defer c.RUnlock()
-	s, ok := c.schedulers[hotRegionScheduleName]
+	s, ok := c.schedulers[name]
+	c.RUnlock()
 	if !ok {
-		return nil
+		return
 	}
-	if h, ok := s.Scheduler.(hasHotStatus); ok {
-		return h.GetHotReadStatus()
+	if len(cfg.Config) > 0 {
+		if cs, ok := s.Scheduler.(schedule.ConfigurableScheduler); ok {
+			if err := cs.SetConfig(c.cluster, cfg.Config); err != nil {
+				log.Errorf("can not restore config of scheduler %s: %v", name, err)
+			}
+		}
+	}
+	if cfg.PausedUntil > time.Now().Unix() {
+		s.setPausedUntil(cfg.PausedUntil)
+		log.Infof("scheduler %s is paused until %v", name, time.Unix(cfg.PausedUntil, 0))
 	}
-	return nil
+}
+
+func (c *coordinator) stop() {
+	c.cancel()
+	c.wg.Wait()
+}
+
+func (c *coordinator) getHotWriteRegions() *core.StoreHotRegionInfos {
+	return c.cluster.getHotWriteRegions()
+}
+
+func (c *coordinator) getHotReadRegions() *core.StoreHotRegionInfos {
+	return c.cluster.getHotReadRegions()
 }
 
 func (c *coordinator) getSchedulers() []string {
@@ -299,28 +471,152 @@ func (c *coordinator) getSchedulers() []string {
 	return names
 }
 
+// SchedulerStatus is the running status of a scheduler.
+type SchedulerStatus struct {
+	Name string `json:"name"`
+	// PausedUntil is the unix timestamp in seconds until which the scheduler
+	// is paused, it is zero if the scheduler is not paused.
+	PausedUntil int64 `json:"paused_until,omitempty"`
+	// RemainingPauseTime is the remaining pause time in seconds.
+	RemainingPauseTime int64 `json:"remaining_pause_time,omitempty"`
+}
+
+func (c *coordinator) getSchedulersStatus() []SchedulerStatus {
+	c.RLock()
+	defer c.RUnlock()
+
+	now := time.Now().Unix()
+	status := make([]SchedulerStatus, 0, len(c.schedulers))
+	for name, s := range c.schedulers {
+		st := SchedulerStatus{Name: name}
+		if until := s.getPausedUntil(); until > now {
+			st.PausedUntil = until
+			st.RemainingPauseTime = until - now
+		}
+		status = append(status, st)
+	}
+	return status
+}
+
+// ScatterProgress is the progress of scattering the regions in a group.
+type ScatterProgress struct {
+	Group string `json:"group"`
+	// Total is the number of regions requested to be scattered.
+	Total int `json:"total"`
+	// Scattered is the number of regions with a scatter operator created.
+	Scattered int `json:"scattered"`
+	// Running is the number of scatter operators not finished yet.
+	Running int `json:"running"`
+	// Finished is the number of scatter operators finished successfully.
+	Finished int `json:"finished"`
+	// Failed is the number of scatter operators canceled, replaced or timed
+	// out.
+	Failed int `json:"failed"`
+	// Skipped are the regions which are hot, unhealthy or already scattered.
+	Skipped []uint64 `json:"skipped_regions,omitempty"`
+
+	operators  []*schedule.Operator
+	lastUpdate time.Time
+}
+
+func (p *ScatterProgress) clone() *ScatterProgress {
+	progress := *p
+	progress.Skipped = append([]uint64(nil), p.Skipped...)
+	progress.operators = nil
+	return &progress
+}
+
+// scatterRegions scatters the regions within the group, the progress of the
+// group is accumulated across calls.
+func (c *coordinator) scatterRegions(regions []*core.RegionInfo, group string) *ScatterProgress {
+	var (
+		scattered []*schedule.Operator
+		skipped   []uint64
+	)
+	for _, region := range regions {
+		op := c.regionScatterer.ScatterWithGroup(region, group)
+		if op == nil || !c.addOperator(op) {
+			skipped = append(skipped, region.GetId())
+			continue
+		}
+		scattered = append(scattered, op)
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	progress, ok := c.scatterProgress[group]
+	if !ok {
+		progress = &ScatterProgress{Group: group}
+		c.scatterProgress[group] = progress
+	}
+	progress.Total += len(regions)
+	progress.Scattered += len(scattered)
+	progress.Skipped = append(progress.Skipped, skipped...)
+	progress.operators = append(progress.operators, scattered...)
+	progress.lastUpdate = time.Now()
+	return c.getScatterProgressLocked(progress)
+}
+
+// getScatterProgress returns the progress of the group, or nil if the group
+// has never been scattered.
+func (c *coordinator) getScatterProgress(group string) *ScatterProgress {
+	c.RLock()
+	defer c.RUnlock()
+	progress, ok := c.scatterProgress[group]
+	if !ok {
+		return nil
+	}
+	return c.getScatterProgressLocked(progress)
+}
+
+func (c *coordinator) getScatterProgressLocked(progress *ScatterProgress) *ScatterProgress {
+	res := progress.clone()
+	for _, op := range progress.operators {
+		switch {
+		case c.operators[op.RegionID()] == op:
+			res.Running++
+		case op.IsFinish():
+			res.Finished++
+		default:
+			res.Failed++
+		}
+	}
+	return res
+}
+
+// pruneScatterGroups removes the progress and the scatter groups which have
+// no running operator and are not updated in the keep time.
+func (c *coordinator) pruneScatterGroups() {
+	c.Lock()
+	defer c.Unlock()
+	for group, progress := range c.scatterProgress {
+		if time.Since(progress.lastUpdate) > scatterGroupKeepTime && c.getScatterProgressLocked(progress).Running == 0 {
+			delete(c.scatterProgress, group)
+		}
+	}
+	c.regionScatterer.PruneGroups(scatterGroupKeepTime)
+}
+
 func (c *coordinator) collectSchedulerMetrics() {
 	c.RLock()
 	defer c.RUnlock()
 	for _, s := range c.schedulers {
-		var allowScheduler float64
+		var allowScheduler, pausedScheduler float64
 		if s.AllowSchedule() {
 			allowScheduler = 1
 		}
+		if s.IsPaused() {
+			pausedScheduler = 1
+		}
 		schedulerStatusGauge.WithLabelValues(s.GetName(), "allow").Set(allowScheduler)
+		schedulerStatusGauge.WithLabelValues(s.GetName(), "paused").Set(pausedScheduler)
 	}
 }
 
 func (c *coordinator) collectHotSpotMetrics() {
-	c.RLock()
-	defer c.RUnlock()
 	// collect hot write region metrics
-	s, ok := c.schedulers[hotRegionScheduleName]
-	if !ok {
-		return
-	}
 	stores := c.cluster.GetStores()
-	status := s.Scheduler.(hasHotStatus).GetHotWriteStatus()
+	status := c.cluster.getHotWriteRegions()
 	for _, s := range stores {
 		store := fmt.Sprintf("store_%d", s.GetId())
 		stat, ok := status.AsPeer[s.GetId()]
@@ -349,7 +645,7 @@ func (c *coordinator) collectHotSpotMetrics() {
 	}
 
 	// collect hot read region metrics
-	status = s.Scheduler.(hasHotStatus).GetHotReadStatus()
+	status = c.cluster.getHotReadRegions()
 	for _, s := range stores {
 		store := fmt.Sprintf("store_%d", s.GetId())
 		stat, ok := status.AsLeader[s.GetId()]
@@ -395,7 +691,10 @@ func (c *coordinator) addScheduler(scheduler schedule.Scheduler, args ...string)
 func (c *coordinator) removeScheduler(name string) error {
 	c.Lock()
 	defer c.Unlock()
+	return c.removeSchedulerLocked(name)
+}
 
+func (c *coordinator) removeSchedulerLocked(name string) error {
 	s, ok := c.schedulers[name]
 	if !ok {
 		return errSchedulerNotFound
@@ -411,6 +710,152 @@ func (c *coordinator) removeScheduler(name string) error {
 	return nil
 }
 
+// pauseOrResumeScheduler pauses the scheduler for delay seconds, or resumes
+// it if delay is not positive.
+func (c *coordinator) pauseOrResumeScheduler(name string, delay int64) error {
+	c.Lock()
+	defer c.Unlock()
+
+	s, ok := c.schedulers[name]
+	if !ok {
+		return errSchedulerNotFound
+	}
+
+	var until int64
+	if delay > 0 {
+		until = time.Now().Unix() + delay
+	}
+	s.setPausedUntil(until)
+
+	if err := c.cluster.opt.SetSchedulerPausedUntil(name, until); err != nil {
+		return errors.Trace(err)
+	}
+
+	return nil
+}
+
+func (c *coordinator) getSchedulerConfig(name string) (interface{}, error) {
+	c.RLock()
+	defer c.RUnlock()
+
+	s, ok := c.schedulers[name]
+	if !ok {
+		return nil, errSchedulerNotFound
+	}
+	cs, ok := s.Scheduler.(schedule.ConfigurableScheduler)
+	if !ok {
+		return nil, errSchedulerNotConfigurable
+	}
+	return cs.GetConfig(), nil
+}
+
+// setSchedulerConfig updates the runtime config of the scheduler with the JSON
+// encoded data and records the result.
+func (c *coordinator) setSchedulerConfig(name string, data []byte) error {
+	c.Lock()
+	defer c.Unlock()
+	return c.setSchedulerConfigLocked(name, data)
+}
+
+func (c *coordinator) setSchedulerConfigLocked(name string, data []byte) error {
+	s, ok := c.schedulers[name]
+	if !ok {
+		return errSchedulerNotFound
+	}
+	cs, ok := s.Scheduler.(schedule.ConfigurableScheduler)
+	if !ok {
+		return errSchedulerNotConfigurable
+	}
+	if err := cs.SetConfig(c.cluster, data); err != nil {
+		return errors.NewNotValid(err, "invalid scheduler config")
+	}
+	cfg, err := json.Marshal(cs.GetConfig())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(c.cluster.opt.SetSchedulerConfig(name, cfg))
+}
+
+// updateEvictLeaderStores updates the stores of the evict-leader scheduler
+// with f, and removes the scheduler if no store is left.
+func (c *coordinator) updateEvictLeaderStores(f func(storeIDs []uint64) ([]uint64, error)) error {
+	c.Lock()
+	defer c.Unlock()
+
+	s, ok := c.schedulers[evictLeaderSchedulerName]
+	if !ok {
+		return errSchedulerNotFound
+	}
+	cs, ok := s.Scheduler.(schedule.ConfigurableScheduler)
+	if !ok {
+		return errSchedulerNotConfigurable
+	}
+	var conf evictLeaderConfig
+	data, err := json.Marshal(cs.GetConfig())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = json.Unmarshal(data, &conf); err != nil {
+		return errors.Trace(err)
+	}
+	if conf.StoreIDs, err = f(conf.StoreIDs); err != nil {
+		return errors.Trace(err)
+	}
+	if len(conf.StoreIDs) == 0 {
+		return c.removeSchedulerLocked(evictLeaderSchedulerName)
+	}
+	if data, err = json.Marshal(conf); err != nil {
+		return errors.Trace(err)
+	}
+	return c.setSchedulerConfigLocked(evictLeaderSchedulerName, data)
+}
+
+// explain returns how the scheduler or the replica checker evaluates the
+// region or the store. The stores and regions are limited to the namespace of
+// the region or the store.
+func (c *coordinator) explain(name string, regionID, storeID uint64) (*schedule.Explanation, error) {
+	var region *core.RegionInfo
+	if regionID != 0 {
+		if region = c.cluster.GetRegion(regionID); region == nil {
+			return nil, ErrRegionNotFound(regionID)
+		}
+	}
+	var store *core.StoreInfo
+	if storeID != 0 {
+		if store = c.cluster.GetStore(storeID); store == nil {
+			return nil, core.NewStoreNotFoundErr(storeID)
+		}
+	}
+
+	if name == replicaCheckerName {
+		if region == nil {
+			return nil, errors.New("region is required by replica checker")
+		}
+		return c.replicaChecker.WithCluster(c.regionCluster(region)).Explain(region), nil
+	}
+
+	c.RLock()
+	s, ok := c.schedulers[name]
+	c.RUnlock()
+	if !ok {
+		return nil, errSchedulerNotFound
+	}
+	es, ok := s.Scheduler.(schedule.ExplainableScheduler)
+	if !ok {
+		return nil, errSchedulerNotExplainable
+	}
+
+	ns := namespace.DefaultNamespace
+	if region != nil {
+		ns = c.classifier.GetRegionNamespace(region)
+	} else if store != nil {
+		ns = c.classifier.GetStoreNamespace(store)
+	}
+	cluster := newNamespaceCluster(c.cluster, c.classifier, ns)
+	opInfluence := schedule.NewOpInfluence(c.getOperators(), c.cluster)
+	return es.Explain(cluster, opInfluence, regionID, storeID), nil
+}
+
 func (c *coordinator) runScheduler(s *scheduleController) {
 	defer logutil.LogPanic()
 	defer c.wg.Done()
@@ -657,6 +1102,8 @@ type scheduleController struct {
 	nextInterval time.Duration
 	ctx          context.Context
 	cancel       context.CancelFunc
+	// pausedUntil is the unix timestamp in seconds, accessed atomically.
+	pausedUntil int64
 }
 
 func newScheduleController(c *coordinator, s schedule.Scheduler) *scheduleController {
@@ -697,5 +1144,18 @@ func (s *scheduleController) GetInterval() time.Duration {
 }
 
 func (s *scheduleController) AllowSchedule() bool {
-	return s.Scheduler.IsScheduleAllowed(s.cluster)
+	return !s.IsPaused() && !s.cluster.opt.IsSchedulerDisabledByTimeWindow(s.GetName()) && s.Scheduler.IsScheduleAllowed(s.cluster)
+}
+
+// IsPaused returns whether the scheduler is paused.
+func (s *scheduleController) IsPaused() bool {
+	return time.Now().Unix() < s.getPausedUntil()
+}
+
+func (s *scheduleController) getPausedUntil() int64 {
+	return atomic.LoadInt64(&s.pausedUntil)
+}
+
+func (s *scheduleController) setPausedUntil(until int64) {
+	atomic.StoreInt64(&s.pausedUntil, until)
 }
@@ -14,6 +14,7 @@
 package server
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/rand"
 	"time"
@@ -243,6 +244,46 @@ func (s *testCoordinatorSuite) TestDispatch(c *C) {
 	waitNoResponse(c, stream)
 }
 
+func (s *testCoordinatorSuite) TestScatterProgress(c *C) {
+	_, opt := newTestScheduleConfig()
+	tc := newTestClusterInfo(opt)
+	hbStreams := newHeartbeatStreams(tc.getClusterID())
+	defer hbStreams.Close()
+
+	co := newCoordinator(tc.clusterInfo, hbStreams, namespace.DefaultClassifier)
+	for i := uint64(1); i <= 3; i++ {
+		tc.addLeaderRegion(i, 1, 2)
+	}
+	step := schedule.TransferLeader{FromStore: 1, ToStore: 2}
+	running := schedule.NewOperator("scatter-region", 1, tc.GetRegion(1).GetRegionEpoch(), schedule.OpAdmin, step)
+	finished := schedule.NewOperator("scatter-region", 2, tc.GetRegion(2).GetRegionEpoch(), schedule.OpAdmin)
+	replaced := schedule.NewOperator("scatter-region", 3, tc.GetRegion(3).GetRegionEpoch(), schedule.OpAdmin, step)
+	c.Assert(co.addOperator(running, replaced), IsTrue)
+	replacement := schedule.NewOperator("test", 3, tc.GetRegion(3).GetRegionEpoch(), schedule.OpAdmin, step)
+	replacement.SetPriorityLevel(core.HighPriority)
+	c.Assert(co.addOperator(replacement), IsTrue)
+	co.scatterProgress["g"] = &ScatterProgress{
+		Group:      "g",
+		Total:      3,
+		Scattered:  3,
+		operators:  []*schedule.Operator{running, finished, replaced},
+		lastUpdate: time.Now().Add(-scatterGroupKeepTime - time.Minute),
+	}
+
+	// Only the operators which finish successfully are counted as finished.
+	progress := co.getScatterProgress("g")
+	c.Assert(progress.Running, Equals, 1)
+	c.Assert(progress.Finished, Equals, 1)
+	c.Assert(progress.Failed, Equals, 1)
+
+	// The expired group is pruned after all its operators are done.
+	co.pruneScatterGroups()
+	c.Assert(co.getScatterProgress("g"), NotNil)
+	co.removeOperator(running)
+	co.pruneScatterGroups()
+	c.Assert(co.getScatterProgress("g"), IsNil)
+}
+
 func dispatchHeartbeat(c *C, co *coordinator, region *core.RegionInfo, stream *mockHeartbeatStream) {
 	co.hbStreams.bindStream(region.Leader.GetStoreId(), stream)
 	co.cluster.putRegion(region)
@@ -614,6 +655,133 @@ func (s *testCoordinatorSuite) TestPersistScheduler(c *C) {
 	c.Assert(co.schedulers, HasLen, 3)
 }
 
+func (s *testCoordinatorSuite) TestPauseScheduler(c *C) {
+	_, opt := newTestScheduleConfig()
+	tc := newTestClusterInfo(opt)
+	hbStreams := newHeartbeatStreams(tc.getClusterID())
+	defer hbStreams.Close()
+
+	co := newCoordinator(tc.clusterInfo, hbStreams, namespace.DefaultClassifier)
+	co.run()
+
+	c.Assert(co.pauseOrResumeScheduler("no-such-scheduler", 60), Equals, errSchedulerNotFound)
+	sc := co.schedulers["balance-leader-scheduler"]
+	c.Assert(sc.IsPaused(), IsFalse)
+	c.Assert(co.pauseOrResumeScheduler("balance-leader-scheduler", 60), IsNil)
+	c.Assert(sc.IsPaused(), IsTrue)
+	c.Assert(sc.AllowSchedule(), IsFalse)
+	for _, st := range co.getSchedulersStatus() {
+		if st.Name == "balance-leader-scheduler" {
+			c.Assert(st.RemainingPauseTime, Greater, int64(0))
+			c.Assert(st.RemainingPauseTime, LessEqual, int64(60))
+		} else {
+			c.Assert(st.PausedUntil, Equals, int64(0))
+		}
+	}
+	c.Assert(co.cluster.opt.persist(co.cluster.kv), IsNil)
+	co.stop()
+
+	// The pause state should survive the restart of coordinator.
+	_, newOpt := newTestScheduleConfig()
+	c.Assert(newOpt.reload(tc.kv), IsNil)
+	tc.clusterInfo.opt = newOpt
+	co = newCoordinator(tc.clusterInfo, hbStreams, namespace.DefaultClassifier)
+	co.run()
+	defer co.stop()
+	sc = co.schedulers["balance-leader-scheduler"]
+	c.Assert(sc.IsPaused(), IsTrue)
+	c.Assert(co.schedulers["balance-region-scheduler"].IsPaused(), IsFalse)
+
+	c.Assert(co.pauseOrResumeScheduler("balance-leader-scheduler", 0), IsNil)
+	c.Assert(sc.IsPaused(), IsFalse)
+	for _, cfg := range co.cluster.opt.GetSchedulers() {
+		c.Assert(cfg.PausedUntil, Equals, int64(0))
+	}
+}
+
+func (s *testCoordinatorSuite) TestSchedulerConfig(c *C) {
+	_, opt := newTestScheduleConfig()
+	tc := newTestClusterInfo(opt)
+	hbStreams := newHeartbeatStreams(tc.getClusterID())
+	defer hbStreams.Close()
+
+	co := newCoordinator(tc.clusterInfo, hbStreams, namespace.DefaultClassifier)
+	co.run()
+
+	tc.addLeaderStore(1, 1)
+	tc.addLeaderStore(2, 1)
+	els, err := schedule.CreateScheduler("evict-leader", co.limiter, "1")
+	c.Assert(err, IsNil)
+	c.Assert(co.addScheduler(els, "1"), IsNil)
+
+	_, err = co.getSchedulerConfig("no-such-scheduler")
+	c.Assert(err, Equals, errSchedulerNotFound)
+	_, err = co.getSchedulerConfig("label-scheduler")
+	c.Assert(err, Equals, errSchedulerNotConfigurable)
+	c.Assert(co.setSchedulerConfig("evict-leader-scheduler", []byte(`{"store-id-list":[1,2]}`)), IsNil)
+	c.Assert(tc.GetStore(2).IsBlocked(), IsTrue)
+	c.Assert(co.setSchedulerConfig("balance-leader-scheduler", []byte(`{"retry-limit":3}`)), IsNil)
+	c.Assert(co.cluster.opt.persist(co.cluster.kv), IsNil)
+	co.stop()
+	c.Assert(tc.GetStore(1).IsBlocked(), IsFalse)
+	c.Assert(tc.GetStore(2).IsBlocked(), IsFalse)
+
+	// The config should survive the restart of coordinator.
+	_, newOpt := newTestScheduleConfig()
+	c.Assert(newOpt.reload(tc.kv), IsNil)
+	tc.clusterInfo.opt = newOpt
+	co = newCoordinator(tc.clusterInfo, hbStreams, namespace.DefaultClassifier)
+	co.run()
+	defer co.stop()
+	cfg, err := co.getSchedulerConfig("evict-leader-scheduler")
+	c.Assert(err, IsNil)
+	data, err := json.Marshal(cfg)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, `{"store-id-list":[1,2]}`)
+	c.Assert(tc.GetStore(2).IsBlocked(), IsTrue)
+	cfg, err = co.getSchedulerConfig("balance-leader-scheduler")
+	c.Assert(err, IsNil)
+	data, err = json.Marshal(cfg)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, `{"retry-limit":3}`)
+}
+
+func (s *testCoordinatorSuite) TestMergeEvictLeaderSchedulers(c *C) {
+	cfg, opt := newTestScheduleConfig()
+	cfg.Schedulers = append(cfg.Schedulers,
+		SchedulerConfig{Type: "evict-leader", Args: []string{"1"}},
+		SchedulerConfig{Type: "evict-leader", Args: []string{"2"}, Config: []byte(`{"store-id-list":[2,3]}`)},
+	)
+	opt.store(cfg)
+	tc := newTestClusterInfo(opt)
+	hbStreams := newHeartbeatStreams(tc.getClusterID())
+	defer hbStreams.Close()
+
+	for i := uint64(1); i <= 3; i++ {
+		tc.addLeaderStore(i, 1)
+	}
+	co := newCoordinator(tc.clusterInfo, hbStreams, namespace.DefaultClassifier)
+	co.run()
+	defer co.stop()
+
+	// The evict-leader schedulers created for each store are merged.
+	conf, err := co.getSchedulerConfig("evict-leader-scheduler")
+	c.Assert(err, IsNil)
+	data, err := json.Marshal(conf)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, `{"store-id-list":[1,2,3]}`)
+	for i := uint64(1); i <= 3; i++ {
+		c.Assert(tc.GetStore(i).IsBlocked(), IsTrue)
+	}
+	var count int
+	for _, cfg := range opt.load().Schedulers {
+		if cfg.Type == "evict-leader" {
+			count++
+		}
+	}
+	c.Assert(count, Equals, 1)
+}
+
 func (s *testCoordinatorSuite) TestRestart(c *C) {
 	// Turn off balance, we test add replica only.
 	cfg, opt := newTestScheduleConfig()
@@ -30,6 +30,7 @@ const (
 	configPath   = "config"
 	schedulePath = "schedule"
 	gcPath       = "gc"
+	hotPath      = "hot_regions"
 )
 
 const (
@@ -57,6 +58,14 @@ func (kv *KV) regionPath(regionID uint64) string {
 	return path.Join(clusterPath, "r", fmt.Sprintf("%020d", regionID))
 }
 
+func (kv *KV) historyHotRegionPath(hotType string, updateTime int64, regionID, storeID uint64) string {
+	return path.Join(kv.historyHotRegionTimePath(hotType, updateTime), fmt.Sprintf("%020d", regionID), fmt.Sprintf("%020d", storeID))
+}
+
+func (kv *KV) historyHotRegionTimePath(hotType string, updateTime int64) string {
+	return path.Join(hotPath, hotType, fmt.Sprintf("%020d", updateTime))
+}
+
 // ClusterStatePath returns the path to save an option.
 func (kv *KV) ClusterStatePath(option string) string {
 	return path.Join(clusterPath, "status", option)
@@ -235,6 +244,66 @@ func (kv *KV) LoadRegions(regions *RegionsInfo) error {
 	}
 }
 
+// SaveHistoryHotRegions saves the sampled hot peers to KV in batches.
+func (kv *KV) SaveHistoryHotRegions(records []*HistoryHotRegion) error {
+	kvs := make(map[string]string, len(records))
+	for _, record := range records {
+		value, err := json.Marshal(record)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		kvs[kv.historyHotRegionPath(record.HotType, record.UpdateTime, record.RegionID, record.StoreID)] = string(value)
+	}
+	return kv.SaveBatch(kvs)
+}
+
+// LoadHistoryHotRegions loads at most limit sampled hot peers of the hot
+// type, whose update time is in [start, end). If after is not nil, the
+// records are loaded from the one right after it. If filter is not nil, only
+// the records it accepts are loaded, the scan goes on until limit records
+// are accepted.
+func (kv *KV) LoadHistoryHotRegions(hotType string, start, end int64, after *HistoryHotRegion, limit int, filter func(*HistoryHotRegion) bool) ([]*HistoryHotRegion, error) {
+	key := kv.historyHotRegionTimePath(hotType, start)
+	if after != nil && after.HotType == hotType && after.UpdateTime >= start {
+		key = kv.historyHotRegionPath(hotType, after.UpdateTime, after.RegionID, after.StoreID) + "\x00"
+	}
+	endKey := kv.historyHotRegionTimePath(hotType, end)
+	var records []*HistoryHotRegion
+	for len(records) < limit {
+		rangeLimit := limit - len(records)
+		if rangeLimit > maxKVRangeLimit {
+			rangeLimit = maxKVRangeLimit
+		}
+		res, err := kv.LoadRange(key, endKey, rangeLimit)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		var last *HistoryHotRegion
+		for _, s := range res {
+			record := &HistoryHotRegion{}
+			if err := json.Unmarshal([]byte(s), record); err != nil {
+				return nil, errors.Trace(err)
+			}
+			last = record
+			if filter == nil || filter(record) {
+				records = append(records, record)
+			}
+		}
+		if len(res) < rangeLimit {
+			break
+		}
+		// Continue from the key right after the last scanned one.
+		key = kv.historyHotRegionPath(hotType, last.UpdateTime, last.RegionID, last.StoreID) + "\x00"
+	}
+	return records, nil
+}
+
+// DeleteHistoryHotRegions deletes the sampled hot peers of the hot type, whose
+// update time is before the given time.
+func (kv *KV) DeleteHistoryHotRegions(hotType string, before int64) error {
+	return kv.DeleteRange(kv.historyHotRegionTimePath(hotType, 0), kv.historyHotRegionTimePath(hotType, before))
+}
+
 // SaveGCSafePoint saves new GC safe point to KV.
 func (kv *KV) SaveGCSafePoint(safePoint uint64) error {
 	key := path.Join(gcPath, "safe_point")
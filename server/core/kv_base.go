@@ -24,7 +24,12 @@ type KVBase interface {
 	Load(key string) (string, error)
 	LoadRange(key, endKey string, limit int) ([]string, error)
 	Save(key, value string) error
+	// SaveBatch saves the key-value pairs, it is not atomic if there are too
+	// many pairs to save in a transaction.
+	SaveBatch(kvs map[string]string) error
 	Delete(key string) error
+	// DeleteRange deletes the keys in [key, endKey).
+	DeleteRange(key, endKey string) error
 }
 
 type memoryKV struct {
@@ -75,6 +80,15 @@ func (kv *memoryKV) Save(key, value string) error {
 	return nil
 }
 
+func (kv *memoryKV) SaveBatch(kvs map[string]string) error {
+	kv.Lock()
+	defer kv.Unlock()
+	for key, value := range kvs {
+		kv.tree.ReplaceOrInsert(memoryKVItem{key, value})
+	}
+	return nil
+}
+
 func (kv *memoryKV) Delete(key string) error {
 	kv.Lock()
 	defer kv.Unlock()
@@ -82,3 +96,18 @@ func (kv *memoryKV) Delete(key string) error {
 	kv.tree.Delete(memoryKVItem{key, ""})
 	return nil
 }
+
+func (kv *memoryKV) DeleteRange(key, endKey string) error {
+	kv.Lock()
+	defer kv.Unlock()
+
+	var items []btree.Item
+	kv.tree.AscendRange(memoryKVItem{key, ""}, memoryKVItem{endKey, ""}, func(item btree.Item) bool {
+		items = append(items, item)
+		return true
+	})
+	for _, item := range items {
+		kv.tree.Delete(item)
+	}
+	return nil
+}
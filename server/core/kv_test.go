@@ -174,6 +174,62 @@ func (s *testKVSuite) TestLoadGCSafePoint(c *C) {
 	}
 }
 
+func (s *testKVSuite) TestHistoryHotRegions(c *C) {
+	kv := NewKV(NewMemoryKV())
+	var records []*HistoryHotRegion
+	for t := int64(1); t <= 3; t++ {
+		for _, hotType := range []string{HotTypeWrite, HotTypeRead} {
+			for regionID := uint64(1); regionID <= 2; regionID++ {
+				records = append(records, &HistoryHotRegion{UpdateTime: t, RegionID: regionID, StoreID: 1, HotType: hotType})
+			}
+		}
+	}
+	c.Assert(kv.SaveHistoryHotRegions(records), IsNil)
+
+	records, err := kv.LoadHistoryHotRegions(HotTypeWrite, 2, 4, nil, 10, nil)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 4)
+	for i, record := range records {
+		c.Assert(record.HotType, Equals, HotTypeWrite)
+		c.Assert(record.UpdateTime, Equals, int64(i/2+2))
+		c.Assert(record.RegionID, Equals, uint64(i%2+1))
+	}
+	records, err = kv.LoadHistoryHotRegions(HotTypeRead, 0, 2, nil, 10, nil)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 2)
+
+	// The records are loaded in pages.
+	records, err = kv.LoadHistoryHotRegions(HotTypeWrite, 0, 4, nil, 4, nil)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 4)
+	records, err = kv.LoadHistoryHotRegions(HotTypeWrite, 0, 4, records[3], 4, nil)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 2)
+	c.Assert(records[0].UpdateTime, Equals, int64(3))
+	c.Assert(records[0].RegionID, Equals, uint64(1))
+
+	// The filtered pages are full until the last one.
+	region2 := func(record *HistoryHotRegion) bool { return record.RegionID == 2 }
+	records, err = kv.LoadHistoryHotRegions(HotTypeWrite, 0, 4, nil, 2, region2)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 2)
+	c.Assert(records[1].UpdateTime, Equals, int64(2))
+	records, err = kv.LoadHistoryHotRegions(HotTypeWrite, 0, 4, records[1], 2, region2)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 1)
+	c.Assert(records[0].UpdateTime, Equals, int64(3))
+	c.Assert(records[0].RegionID, Equals, uint64(2))
+
+	// The records before the time are deleted.
+	c.Assert(kv.DeleteHistoryHotRegions(HotTypeRead, 3), IsNil)
+	records, err = kv.LoadHistoryHotRegions(HotTypeRead, 0, 4, nil, 10, nil)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 2)
+	records, err = kv.LoadHistoryHotRegions(HotTypeWrite, 0, 4, nil, 10, nil)
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 6)
+}
+
 type KVWithMaxRangeLimit struct {
 	KVBase
 	rangeLimit int
@@ -36,6 +36,14 @@ func HealthRegion() RegionOption {
 	}
 }
 
+// HealthRegionAllowLearner checks if the region is healthy, the region with
+// learners is allowed.
+func HealthRegionAllowLearner() RegionOption {
+	return func(region *RegionInfo) bool {
+		return len(region.DownPeers) == 0 && len(region.PendingPeers) == 0
+	}
+}
+
 // RegionInfo records detail region info.
 type RegionInfo struct {
 	*metapb.Region
@@ -45,7 +53,9 @@ type RegionInfo struct {
 	DownPeers       []*pdpb.PeerStats
 	PendingPeers    []*metapb.Peer
 	WrittenBytes    uint64
+	WrittenKeys     uint64
 	ReadBytes       uint64
+	ReadKeys        uint64
 	ApproximateSize int64
 	ApproximateKeys int64
 }
@@ -95,7 +105,9 @@ func RegionFromHeartbeat(heartbeat *pdpb.RegionHeartbeatRequest) *RegionInfo {
 		DownPeers:       heartbeat.GetDownPeers(),
 		PendingPeers:    heartbeat.GetPendingPeers(),
 		WrittenBytes:    heartbeat.GetBytesWritten(),
+		WrittenKeys:     heartbeat.GetKeysWritten(),
 		ReadBytes:       heartbeat.GetBytesRead(),
+		ReadKeys:        heartbeat.GetKeysRead(),
 		ApproximateSize: int64(regionSize),
 		ApproximateKeys: int64(heartbeat.GetApproximateKeys()),
 	}
@@ -121,7 +133,9 @@ func (r *RegionInfo) Clone() *RegionInfo {
 		DownPeers:       downPeers,
 		PendingPeers:    pendingPeers,
 		WrittenBytes:    r.WrittenBytes,
+		WrittenKeys:     r.WrittenKeys,
 		ReadBytes:       r.ReadBytes,
+		ReadKeys:        r.ReadKeys,
 		ApproximateSize: r.ApproximateSize,
 		ApproximateKeys: r.ApproximateKeys,
 	}
@@ -313,21 +327,29 @@ func (r *RegionInfo) GetDiffFollowers(other *RegionInfo) []*metapb.Peer {
 	return res
 }
 
-// RegionStat records each hot region's statistics
+// RegionStat records the statistics of each hot peer of a region, the flow is
+// the moving average of the recent reports.
 type RegionStat struct {
 	RegionID  uint64 `json:"region_id"`
 	FlowBytes uint64 `json:"flow_bytes"`
+	FlowKeys  uint64 `json:"flow_keys"`
 	// HotDegree records the hot region update times
 	HotDegree int `json:"hot_degree"`
 	// LastUpdateTime used to calculate average write
 	LastUpdateTime time.Time `json:"last_update_time"`
 	StoreID        uint64    `json:"-"`
+	// IsLeader is true if the peer is the leader of the region.
+	IsLeader bool `json:"-"`
+	// NeedDelete is true if the peer should be removed from the hot cache.
+	NeedDelete bool `json:"-"`
 	// AntiCount used to eliminate some noise when remove region in cache
 	AntiCount int
 	// Version used to check the region split times
 	Version uint64
 	// Stats is a rolling statistics, recording some recently added records.
 	Stats *RollingStats
+	// KeysStats is the rolling statistics of the flow keys.
+	KeysStats *RollingStats
 }
 
 // RegionsStat is a list of a group region state type
@@ -340,6 +362,7 @@ func (m RegionsStat) Less(i, j int) bool { return m[i].FlowBytes < m[j].FlowByte
 // HotRegionsStat records all hot regions statistics
 type HotRegionsStat struct {
 	TotalFlowBytes uint64      `json:"total_flow_bytes"`
+	TotalFlowKeys  uint64      `json:"total_flow_keys"`
 	RegionsCount   int         `json:"regions_count"`
 	RegionsStat    RegionsStat `json:"statistics"`
 }
@@ -653,6 +676,11 @@ func (r *RegionsInfo) RandFollowerRegion(storeID uint64, opts ...RegionOption) *
 	return randRegion(r.followers[storeID], opts...)
 }
 
+// RandLearnerRegion get a store's learner region by random
+func (r *RegionsInfo) RandLearnerRegion(storeID uint64, opts ...RegionOption) *RegionInfo {
+	return randRegion(r.learners[storeID], opts...)
+}
+
 // GetLeader return leader RegionInfo by storeID and regionID(now only used in test)
 func (r *RegionsInfo) GetLeader(storeID uint64, regionID uint64) *RegionInfo {
 	return r.leaders[storeID].Get(regionID)
@@ -709,7 +737,8 @@ type RegionStats struct {
 	StorePeerKeys    map[uint64]int64 `json:"store_peer_keys"`
 }
 
-func newRegionStats() *RegionStats {
+// NewRegionStats creates an empty RegionStats.
+func NewRegionStats() *RegionStats {
 	return &RegionStats{
 		StoreLeaderCount: make(map[uint64]int),
 		StorePeerCount:   make(map[uint64]int),
@@ -743,7 +772,7 @@ func (s *RegionStats) Observe(r *RegionInfo) {
 // GetRegionStats scans regions that inside range [startKey, endKey) and sums up
 // their statistics.
 func (r *RegionsInfo) GetRegionStats(startKey, endKey []byte) *RegionStats {
-	stats := newRegionStats()
+	stats := NewRegionStats()
 	r.tree.scanRange(startKey, func(meta *metapb.Region) bool {
 		if len(endKey) > 0 && (len(meta.EndKey) == 0 || bytes.Compare(meta.EndKey, endKey) >= 0) {
 			return false
@@ -53,3 +53,28 @@ func (r *RollingStats) Median() float64 {
 	median, _ := stats.Median(records)
 	return median
 }
+
+// Mean returns the average of the records, it is the moving average of the
+// added elements in the window.
+func (r *RollingStats) Mean() float64 {
+	if r.count == 0 {
+		return 0
+	}
+	records := r.records
+	if r.count < r.size {
+		records = r.records[:r.count]
+	}
+	mean, _ := stats.Mean(records)
+	return mean
+}
+
+// Clone returns a copy of the RollingStats.
+func (r *RollingStats) Clone() *RollingStats {
+	records := make([]float64, r.size)
+	copy(records, r.records)
+	return &RollingStats{
+		records: records,
+		size:    r.size,
+		count:   r.count,
+	}
+}
@@ -30,3 +30,18 @@ func (t *testRollingStats) TestRollingMedian(c *C) {
 		c.Assert(stats.Median(), Equals, expected[i])
 	}
 }
+
+func (t *testRollingStats) TestRollingMean(c *C) {
+	data := []float64{2, 4, 6, 8, 10, 12, 14}
+	expected := []float64{2, 3, 4, 5, 6, 8, 10}
+	stats := NewRollingStats(5)
+	for i, e := range data {
+		stats.Add(e)
+		c.Assert(stats.Mean(), Equals, expected[i])
+	}
+
+	clone := stats.Clone()
+	clone.Add(100)
+	c.Assert(stats.Mean(), Equals, float64(10))
+	c.Assert(clone.Mean(), Equals, 28.8)
+}
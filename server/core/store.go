@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/gogo/protobuf/proto"
+	"github.com/juju/errors"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/pd/pkg/error_code"
@@ -32,9 +33,12 @@ type StoreInfo struct {
 	*metapb.Store
 	Stats *pdpb.StoreStats
 	// Blocked means that the store is blocked from balance.
-	blocked           bool
+	blocked bool
+	// leaderBlocked means that the store is blocked from receiving leaders.
+	leaderBlocked     bool
 	LeaderCount       int
 	RegionCount       int
+	LearnerCount      int
 	LeaderSize        int64
 	RegionSize        int64
 	PendingPeerCount  int
@@ -61,8 +65,10 @@ func (s *StoreInfo) Clone() *StoreInfo {
 		Store:             proto.Clone(s.Store).(*metapb.Store),
 		Stats:             proto.Clone(s.Stats).(*pdpb.StoreStats),
 		blocked:           s.blocked,
+		leaderBlocked:     s.leaderBlocked,
 		LeaderCount:       s.LeaderCount,
 		RegionCount:       s.RegionCount,
+		LearnerCount:      s.LearnerCount,
 		LeaderSize:        s.LeaderSize,
 		RegionSize:        s.RegionSize,
 		PendingPeerCount:  s.PendingPeerCount,
@@ -88,6 +94,21 @@ func (s *StoreInfo) IsBlocked() bool {
 	return s.blocked
 }
 
+// BlockLeader stops balancer from transferring leaders to the store.
+func (s *StoreInfo) BlockLeader() {
+	s.leaderBlocked = true
+}
+
+// UnblockLeader allows balancer to transfer leaders to the store.
+func (s *StoreInfo) UnblockLeader() {
+	s.leaderBlocked = false
+}
+
+// IsLeaderBlocked returns if the store is blocked from receiving leaders.
+func (s *StoreInfo) IsLeaderBlocked() bool {
+	return s.leaderBlocked
+}
+
 // IsUp checks if the store's state is Up.
 func (s *StoreInfo) IsUp() bool {
 	return s.GetState() == metapb.StoreState_Up
@@ -111,13 +132,44 @@ func (s *StoreInfo) DownTime() time.Duration {
 const minWeight = 1e-6
 const maxScore = 1024 * 1024 * 1024
 
-// LeaderScore returns the store's leader score: leaderSize / leaderWeight.
-func (s *StoreInfo) LeaderScore(delta int64) float64 {
+// Score strategies of the stores.
+const (
+	// ScoreBySize scores the stores by the size of the resources, the region
+	// score also grows rapidly as the available space gets low.
+	ScoreBySize = "size"
+	// ScoreByCount scores the stores by the count of the resources.
+	ScoreByCount = "count"
+	// ScoreBySpace scores the stores by the available space only, it is only
+	// used for regions.
+	ScoreBySpace = "space"
+)
+
+// ValidateScoreStrategy checks if the score strategy is valid for the
+// resource kind.
+func ValidateScoreStrategy(kind ResourceKind, strategy string) error {
+	switch strategy {
+	case ScoreBySize, ScoreByCount:
+		return nil
+	case ScoreBySpace:
+		if kind == RegionKind {
+			return nil
+		}
+	}
+	return errors.Errorf("invalid %s score strategy %s", kind, strategy)
+}
+
+// LeaderScore returns the store's leader score by the strategy, it is
+// leaderCount / leaderWeight when scoring by count, leaderSize / leaderWeight
+// otherwise.
+func (s *StoreInfo) LeaderScore(strategy string, delta int64) float64 {
+	if strategy == ScoreByCount {
+		return float64(int64(s.LeaderCount)+delta) / math.Max(s.LeaderWeight, minWeight)
+	}
 	return float64(s.LeaderSize+delta) / math.Max(s.LeaderWeight, minWeight)
 }
 
-// RegionScore returns the store's region score.
-func (s *StoreInfo) RegionScore(highSpaceRatio, lowSpaceRatio float64, delta int64) float64 {
+// RegionScore returns the store's region score by the strategy.
+func (s *StoreInfo) RegionScore(strategy string, highSpaceRatio, lowSpaceRatio float64, delta int64) float64 {
 	var score float64
 	var amplification float64
 	available := float64(s.Stats.GetAvailable()) / (1 << 20)
@@ -131,7 +183,11 @@ func (s *StoreInfo) RegionScore(highSpaceRatio, lowSpaceRatio float64, delta int
 		amplification = float64(s.RegionSize) / used
 	}
 
-	if available-float64(delta)/amplification >= (1-highSpaceRatio)*capacity {
+	if strategy == ScoreByCount {
+		score = float64(int64(s.RegionCount) + delta)
+	} else if strategy == ScoreBySpace {
+		score = maxScore - (available - float64(delta)/amplification)
+	} else if available-float64(delta)/amplification >= (1-highSpaceRatio)*capacity {
 		score = float64(s.RegionSize + delta)
 	} else if available-float64(delta)/amplification <= (1-lowSpaceRatio)*capacity {
 		score = maxScore - (available - float64(delta)/amplification)
@@ -200,13 +256,14 @@ func (s *StoreInfo) ResourceSize(kind ResourceKind) int64 {
 	}
 }
 
-// ResourceScore reutrns score of leader/region in the store.
-func (s *StoreInfo) ResourceScore(kind ResourceKind, highSpaceRatio, lowSpaceRatio float64, delta int64) float64 {
+// ResourceScore reutrns score of leader/region in the store by the strategy
+// of the kind.
+func (s *StoreInfo) ResourceScore(kind ResourceKind, strategy string, highSpaceRatio, lowSpaceRatio float64, delta int64) float64 {
 	switch kind {
 	case LeaderKind:
-		return s.LeaderScore(delta)
+		return s.LeaderScore(strategy, delta)
 	case RegionKind:
-		return s.RegionScore(highSpaceRatio, lowSpaceRatio, delta)
+		return s.RegionScore(strategy, highSpaceRatio, lowSpaceRatio, delta)
 	default:
 		return 0
 	}
@@ -312,6 +369,30 @@ type StoreHotRegionInfos struct {
 // StoreHotRegionsStat used to record the hot region statistics group by store
 type StoreHotRegionsStat map[uint64]*HotRegionsStat
 
+// Hot types of the history hot regions.
+const (
+	HotTypeWrite = "write"
+	HotTypeRead  = "read"
+)
+
+// HistoryHotRegion records a hot peer sampled periodically.
+type HistoryHotRegion struct {
+	// UpdateTime is the unix time in seconds when the peer is sampled.
+	UpdateTime int64  `json:"update_time"`
+	RegionID   uint64 `json:"region_id"`
+	StoreID    uint64 `json:"store_id"`
+	PeerID     uint64 `json:"peer_id"`
+	IsLeader   bool   `json:"is_leader"`
+	// HotType is either "read" or "write".
+	HotType   string `json:"hot_type"`
+	HotDegree int    `json:"hot_degree"`
+	FlowBytes uint64 `json:"flow_bytes"`
+	FlowKeys  uint64 `json:"flow_keys"`
+	// StartKey and EndKey are hex encoded.
+	StartKey string `json:"start_key"`
+	EndKey   string `json:"end_key"`
+}
+
 type storeNotFoundErr struct {
 	storeID uint64
 }
@@ -330,6 +411,8 @@ type StoresInfo struct {
 	stores         map[uint64]*StoreInfo
 	bytesReadRate  float64
 	bytesWriteRate float64
+	keysReadRate   float64
+	keysWriteRate  float64
 }
 
 // NewStoresInfo create a StoresInfo with map of storeID to StoreInfo
@@ -363,6 +446,8 @@ func (s *StoresInfo) SetStore(store *StoreInfo) {
 	store.RollingStoreStats.Observe(store.Stats)
 	s.updateTotalBytesReadRate()
 	s.updateTotalBytesWriteRate()
+	s.updateTotalKeysReadRate()
+	s.updateTotalKeysWriteRate()
 }
 
 // BlockStore block a StoreInfo with storeID
@@ -388,6 +473,29 @@ func (s *StoresInfo) UnblockStore(storeID uint64) {
 	store.Unblock()
 }
 
+// BlockStoreLeader blocks a StoreInfo with storeID from receiving leaders.
+func (s *StoresInfo) BlockStoreLeader(storeID uint64) errcode.ErrorCode {
+	op := errcode.Op("store.block_leader")
+	store, ok := s.stores[storeID]
+	if !ok {
+		return op.AddTo(NewStoreNotFoundErr(storeID))
+	}
+	if store.IsLeaderBlocked() {
+		return op.AddTo(StoreBlockedErr{StoreID: storeID})
+	}
+	store.BlockLeader()
+	return nil
+}
+
+// UnblockStoreLeader allows a StoreInfo with storeID to receive leaders.
+func (s *StoresInfo) UnblockStoreLeader(storeID uint64) {
+	store, ok := s.stores[storeID]
+	if !ok {
+		log.Fatalf("store %d is unblocked, but it is not found", storeID)
+	}
+	store.UnblockLeader()
+}
+
 // GetStores get a complete set of StoreInfo
 func (s *StoresInfo) GetStores() []*StoreInfo {
 	stores := make([]*StoreInfo, 0, len(s.stores))
@@ -425,6 +533,13 @@ func (s *StoresInfo) SetRegionCount(storeID uint64, regionCount int) {
 	}
 }
 
+// SetLearnerCount sets the learner count to a storeInfo
+func (s *StoresInfo) SetLearnerCount(storeID uint64, learnerCount int) {
+	if store, ok := s.stores[storeID]; ok {
+		store.LearnerCount = learnerCount
+	}
+}
+
 // SetPendingPeerCount sets the pending count to a storeInfo
 func (s *StoresInfo) SetPendingPeerCount(storeID uint64, pendingPeerCount int) {
 	if store, ok := s.stores[storeID]; ok {
@@ -476,6 +591,36 @@ func (s *StoresInfo) TotalBytesReadRate() float64 {
 	return s.bytesReadRate
 }
 
+func (s *StoresInfo) updateTotalKeysWriteRate() {
+	var totalKeysWriteRate float64
+	for _, s := range s.stores {
+		if s.IsUp() {
+			totalKeysWriteRate += s.RollingStoreStats.GetKeysWriteRate()
+		}
+	}
+	s.keysWriteRate = totalKeysWriteRate
+}
+
+// TotalKeysWriteRate returns the total written keys rate of all StoreInfo.
+func (s *StoresInfo) TotalKeysWriteRate() float64 {
+	return s.keysWriteRate
+}
+
+func (s *StoresInfo) updateTotalKeysReadRate() {
+	var totalKeysReadRate float64
+	for _, s := range s.stores {
+		if s.IsUp() {
+			totalKeysReadRate += s.RollingStoreStats.GetKeysReadRate()
+		}
+	}
+	s.keysReadRate = totalKeysReadRate
+}
+
+// TotalKeysReadRate returns the total read keys rate of all StoreInfo.
+func (s *StoresInfo) TotalKeysReadRate() float64 {
+	return s.keysReadRate
+}
+
 // GetStoresBytesWriteStat returns the bytes write stat of all StoreInfo.
 func (s *StoresInfo) GetStoresBytesWriteStat() map[uint64]uint64 {
 	res := make(map[uint64]uint64, len(s.stores))
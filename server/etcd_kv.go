@@ -26,6 +26,8 @@ import (
 const (
 	kvRequestTimeout  = time.Second * 10
 	kvSlowRequestTime = time.Second * 1
+	// maxTxnOps is the default max number of operations in an etcd txn.
+	maxTxnOps = 128
 )
 
 var (
@@ -92,6 +94,34 @@ func (kv *etcdKVBase) Save(key, value string) error {
 	return nil
 }
 
+func (kv *etcdKVBase) SaveBatch(kvs map[string]string) error {
+	ops := make([]clientv3.Op, 0, maxTxnOps)
+	commit := func() error {
+		resp, err := kv.server.leaderTxn().Then(ops...).Commit()
+		if err != nil {
+			log.Errorf("save to etcd error: %v", err)
+			return errors.Trace(err)
+		}
+		if !resp.Succeeded {
+			return errors.Trace(errTxnFailed)
+		}
+		ops = ops[:0]
+		return nil
+	}
+	for key, value := range kvs {
+		ops = append(ops, clientv3.OpPut(path.Join(kv.rootPath, key), value))
+		if len(ops) == maxTxnOps {
+			if err := commit(); err != nil {
+				return err
+			}
+		}
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+	return commit()
+}
+
 func (kv *etcdKVBase) Delete(key string) error {
 	key = path.Join(kv.rootPath, key)
 
@@ -106,6 +136,21 @@ func (kv *etcdKVBase) Delete(key string) error {
 	return nil
 }
 
+func (kv *etcdKVBase) DeleteRange(key, endKey string) error {
+	key = path.Join(kv.rootPath, key)
+	endKey = path.Join(kv.rootPath, endKey)
+
+	resp, err := kv.server.leaderTxn().Then(clientv3.OpDelete(key, clientv3.WithRange(endKey))).Commit()
+	if err != nil {
+		log.Errorf("delete from etcd error: %v", err)
+		return errors.Trace(err)
+	}
+	if !resp.Succeeded {
+		return errors.Trace(errTxnFailed)
+	}
+	return nil
+}
+
 func kvGet(c *clientv3.Client, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
 	ctx, cancel := context.WithTimeout(c.Ctx(), kvRequestTimeout)
 	defer cancel()
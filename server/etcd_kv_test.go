@@ -13,7 +13,11 @@
 
 package server
 
-import . "github.com/pingcap/check"
+import (
+	"fmt"
+
+	. "github.com/pingcap/check"
+)
 
 type testEtcdKVSuite struct{}
 
@@ -57,4 +61,19 @@ func (s *testEtcdKVSuite) TestEtcdKV(c *C) {
 	v, err = kv.Load(keys[1])
 	c.Assert(err, IsNil)
 	c.Assert(v, Equals, "")
+
+	// The batch is split into transactions of limited size.
+	batch := make(map[string]string)
+	for i := 0; i < maxTxnOps*2+1; i++ {
+		batch[fmt.Sprintf("batch/%04d", i)] = fmt.Sprintf("%d", i)
+	}
+	c.Assert(kv.SaveBatch(batch), IsNil)
+	values, err = kv.LoadRange("batch/", "batch/zzz", 1000)
+	c.Assert(err, IsNil)
+	c.Assert(values, HasLen, maxTxnOps*2+1)
+	c.Assert(kv.DeleteRange("batch/0001", "batch/0100"), IsNil)
+	values, err = kv.LoadRange("batch/", "batch/zzz", 1000)
+	c.Assert(err, IsNil)
+	c.Assert(values, HasLen, maxTxnOps*2+1-99)
+	c.Assert(values[:2], DeepEquals, []string{"0", "100"})
 }
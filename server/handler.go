@@ -15,6 +15,7 @@ package server
 
 import (
 	"bytes"
+	"fmt"
 	"strconv"
 	"strings"
 	"time"
@@ -24,6 +25,7 @@ import (
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/server/schedule"
+	"github.com/pingcap/pd/server/schedule/placement"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -77,6 +79,33 @@ func (h *Handler) GetSchedulers() ([]string, error) {
 	return c.getSchedulers(), nil
 }
 
+// GetSchedulersStatus returns the running status of all schedulers.
+func (h *Handler) GetSchedulersStatus() ([]SchedulerStatus, error) {
+	c, err := h.getCoordinator()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return c.getSchedulersStatus(), nil
+}
+
+// GetRuleManager returns the placement rule manager of the cluster.
+func (h *Handler) GetRuleManager() (*placement.RuleManager, error) {
+	cluster := h.s.GetRaftCluster()
+	if cluster == nil {
+		return nil, errors.Trace(ErrNotBootstrapped)
+	}
+	return cluster.cachedCluster.GetRuleManager(), nil
+}
+
+// GetRangeConstraintManager returns the range constraint manager of the cluster.
+func (h *Handler) GetRangeConstraintManager() (*placement.RangeConstraintManager, error) {
+	cluster := h.s.GetRaftCluster()
+	if cluster == nil {
+		return nil, errors.Trace(ErrNotBootstrapped)
+	}
+	return cluster.cachedCluster.GetRangeConstraintManager(), nil
+}
+
 // GetStores returns all stores in the cluster.
 func (h *Handler) GetStores() ([]*core.StoreInfo, error) {
 	cluster := h.s.GetRaftCluster()
@@ -113,6 +142,41 @@ func (h *Handler) GetHotReadRegions() *core.StoreHotRegionInfos {
 	return c.getHotReadRegions()
 }
 
+// GetHistoryHotRegions gets at most limit records of the hot regions of the
+// hot type sampled in [start, end), hotType is either read or write, or empty
+// for both. If after is not nil, the records are got from the one right after
+// it, in the order of the write records and then the read records. Only the
+// records accepted by filter are got if it is not nil.
+func (h *Handler) GetHistoryHotRegions(hotType string, start, end time.Time, after *core.HistoryHotRegion, limit int, filter func(*core.HistoryHotRegion) bool) ([]*core.HistoryHotRegion, error) {
+	c, err := h.getCoordinator()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	hotTypes := []string{core.HotTypeWrite, core.HotTypeRead}
+	if hotType != "" {
+		if hotType != core.HotTypeWrite && hotType != core.HotTypeRead {
+			return nil, errors.Errorf("unknown hot type %s", hotType)
+		}
+		hotTypes = []string{hotType}
+	}
+	if after != nil && after.HotType == core.HotTypeRead && len(hotTypes) == 2 {
+		// The write records are all got in the previous pages.
+		hotTypes = hotTypes[1:]
+	}
+	var records []*core.HistoryHotRegion
+	for _, typ := range hotTypes {
+		if len(records) >= limit {
+			break
+		}
+		res, err := c.cluster.kv.LoadHistoryHotRegions(typ, start.Unix(), end.Unix(), after, limit-len(records), filter)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		records = append(records, res...)
+	}
+	return records, nil
+}
+
 // GetHotBytesWriteStores gets all hot write stores stats.
 func (h *Handler) GetHotBytesWriteStores() map[uint64]uint64 {
 	return h.s.cluster.cachedCluster.getStoresBytesWriteStat()
@@ -152,12 +216,28 @@ func (h *Handler) AddScheduler(name string, args ...string) error {
 	return errors.Trace(err)
 }
 
-// RemoveScheduler removes a scheduler by name.
+// RemoveScheduler removes a scheduler by name. For compatibility, the name
+// evict-leader-scheduler-<store_id> removes the store from the evict-leader
+// scheduler only.
 func (h *Handler) RemoveScheduler(name string) error {
 	c, err := h.getCoordinator()
 	if err != nil {
 		return errors.Trace(err)
 	}
+	if strings.HasPrefix(name, evictLeaderSchedulerName+"-") {
+		storeID, err := strconv.ParseUint(strings.TrimPrefix(name, evictLeaderSchedulerName+"-"), 10, 64)
+		if err != nil {
+			return errSchedulerNotFound
+		}
+		return h.updateEvictLeaderStores(c, func(storeIDs []uint64) ([]uint64, error) {
+			for i, id := range storeIDs {
+				if id == storeID {
+					return append(storeIDs[:i], storeIDs[i+1:]...), nil
+				}
+			}
+			return nil, errSchedulerNotFound
+		})
+	}
 	if err = c.removeScheduler(name); err != nil {
 		log.Errorf("can not remove scheduler %v: %v", name, err)
 	} else if err = h.opt.persist(c.cluster.kv); err != nil {
@@ -166,6 +246,57 @@ func (h *Handler) RemoveScheduler(name string) error {
 	return errors.Trace(err)
 }
 
+// PauseOrResumeScheduler pauses a scheduler for delay seconds, or resumes it
+// if delay is not positive.
+func (h *Handler) PauseOrResumeScheduler(name string, delay int64) error {
+	c, err := h.getCoordinator()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = c.pauseOrResumeScheduler(name, delay); err != nil {
+		log.Errorf("can not pause or resume scheduler %v: %v", name, err)
+	} else if err = h.opt.persist(c.cluster.kv); err != nil {
+		log.Errorf("can not persist scheduler config: %v", err)
+	}
+	return errors.Trace(err)
+}
+
+// GetSchedulerConfig returns the runtime config of a scheduler.
+func (h *Handler) GetSchedulerConfig(name string) (interface{}, error) {
+	c, err := h.getCoordinator()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	cfg, err := c.getSchedulerConfig(name)
+	return cfg, errors.Trace(err)
+}
+
+// Explain returns how the scheduler or the replica checker evaluates the
+// region or the store.
+func (h *Handler) Explain(name string, regionID, storeID uint64) (*schedule.Explanation, error) {
+	c, err := h.getCoordinator()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	e, err := c.explain(name, regionID, storeID)
+	return e, errors.Trace(err)
+}
+
+// SetSchedulerConfig updates the runtime config of a scheduler with the JSON
+// encoded data.
+func (h *Handler) SetSchedulerConfig(name string, data []byte) error {
+	c, err := h.getCoordinator()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = c.setSchedulerConfig(name, data); err != nil {
+		log.Errorf("can not set config of scheduler %v: %v", name, err)
+	} else if err = h.opt.persist(c.cluster.kv); err != nil {
+		log.Errorf("can not persist scheduler config: %v", err)
+	}
+	return errors.Trace(err)
+}
+
 // AddBalanceLeaderScheduler adds a balance-leader-scheduler.
 func (h *Handler) AddBalanceLeaderScheduler() error {
 	return h.AddScheduler("balance-leader")
@@ -201,9 +332,44 @@ func (h *Handler) AddGrantLeaderScheduler(storeID uint64) error {
 	return h.AddScheduler("grant-leader", strconv.FormatUint(storeID, 10))
 }
 
-// AddEvictLeaderScheduler adds an evict-leader-scheduler.
+// AddEvictLeaderScheduler adds the store to the evict-leader-scheduler, which
+// is created if it does not exist.
 func (h *Handler) AddEvictLeaderScheduler(storeID uint64) error {
-	return h.AddScheduler("evict-leader", strconv.FormatUint(storeID, 10))
+	c, err := h.getCoordinator()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	err = h.updateEvictLeaderStores(c, func(storeIDs []uint64) ([]uint64, error) {
+		return append(storeIDs, storeID), nil
+	})
+	if errors.Cause(err) == errSchedulerNotFound {
+		return h.AddScheduler("evict-leader", strconv.FormatUint(storeID, 10))
+	}
+	return errors.Trace(err)
+}
+
+func (h *Handler) updateEvictLeaderStores(c *coordinator, f func(storeIDs []uint64) ([]uint64, error)) error {
+	err := c.updateEvictLeaderStores(f)
+	if err != nil {
+		log.Errorf("can not update stores of %v: %v", evictLeaderSchedulerName, err)
+	} else if err = h.opt.persist(c.cluster.kv); err != nil {
+		log.Errorf("can not persist scheduler config: %v", err)
+	}
+	return errors.Trace(err)
+}
+
+// AddPreferredLeaderScheduler adds a preferred-leader-scheduler.
+func (h *Handler) AddPreferredLeaderScheduler(preference schedule.LeaderPreference) error {
+	args := make([]string, 0, len(preference))
+	for _, label := range preference {
+		args = append(args, label.GetKey()+"="+label.GetValue())
+	}
+	return h.AddScheduler("preferred-leader", args...)
+}
+
+// AddEvictSlowStoreScheduler adds an evict-slow-store-scheduler.
+func (h *Handler) AddEvictSlowStoreScheduler() error {
+	return h.AddScheduler("evict-slow-store")
 }
 
 // AddShuffleLeaderScheduler adds a shuffle-leader-scheduler.
@@ -492,12 +658,12 @@ func (h *Handler) AddMergeRegionOperator(regionID uint64, targetID uint64) error
 	}
 
 	if len(region.DownPeers) > 0 || len(region.PendingPeers) > 0 || len(region.Learners) > 0 ||
-		len(region.Region.GetPeers()) != c.cluster.GetMaxReplicas() {
+		len(region.Region.GetPeers()) != schedule.GetExpectedReplicas(c.cluster, region) {
 		return ErrRegionAbnormalPeer(regionID)
 	}
 
 	if len(target.DownPeers) > 0 || len(target.PendingPeers) > 0 || len(target.Learners) > 0 ||
-		len(target.Region.GetPeers()) != c.cluster.GetMaxReplicas() {
+		len(target.Region.GetPeers()) != schedule.GetExpectedReplicas(c.cluster, target) {
 		return ErrRegionAbnormalPeer(targetID)
 	}
 
@@ -563,6 +729,81 @@ func (h *Handler) AddScatterRegionOperator(regionID uint64) error {
 	return nil
 }
 
+// ScatterRegions scatters the regions in the key range [startKey, endKey) and
+// the regions of the given IDs within the group.
+func (h *Handler) ScatterRegions(startKey, endKey []byte, regionIDs []uint64, group string) (*ScatterProgress, error) {
+	c, err := h.getCoordinator()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	errTooManyRegions := errors.NewNotValid(nil, fmt.Sprintf("at most %d regions can be scattered at once", maxScatterRegions))
+	if len(regionIDs) > maxScatterRegions {
+		return nil, errTooManyRegions
+	}
+	var regions []*core.RegionInfo
+	for _, id := range regionIDs {
+		region := c.cluster.GetRegion(id)
+		if region == nil {
+			return nil, ErrRegionNotFound(id)
+		}
+		regions = append(regions, region)
+	}
+	if len(startKey) > 0 || len(endKey) > 0 {
+		regions = append(regions, scanRegionsInRange(c.cluster, startKey, endKey, maxScatterRegions+1)...)
+	}
+	if len(regions) > maxScatterRegions {
+		return nil, errTooManyRegions
+	}
+	return c.scatterRegions(regions, group), nil
+}
+
+const (
+	// maxScatterRegions is the max number of regions to scatter in a request,
+	// as the scatter operators are not restricted by the schedule limits.
+	maxScatterRegions       = 128
+	scanRegionsInRangeLimit = 1024
+)
+
+// scanRegionsInRange returns at most limit regions overlapping with the key
+// range [startKey, endKey), an empty endKey means the end of the key space.
+func scanRegionsInRange(cluster *clusterInfo, startKey, endKey []byte, limit int) []*core.RegionInfo {
+	var res []*core.RegionInfo
+	key := startKey
+	for {
+		regions := cluster.ScanRegions(key, scanRegionsInRangeLimit)
+		for _, region := range regions {
+			if len(endKey) > 0 && bytes.Compare(region.GetStartKey(), endKey) >= 0 {
+				return res
+			}
+			if len(res) >= limit {
+				return res
+			}
+			res = append(res, region)
+			key = region.GetEndKey()
+			if len(key) == 0 {
+				return res
+			}
+		}
+		if len(regions) < scanRegionsInRangeLimit {
+			return res
+		}
+	}
+}
+
+// GetScatterProgress returns the scatter progress of the group.
+func (h *Handler) GetScatterProgress(group string) (*ScatterProgress, error) {
+	c, err := h.getCoordinator()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	progress := c.getScatterProgress(group)
+	if progress == nil {
+		return nil, errors.Errorf("scatter group %s not found", group)
+	}
+	return progress, nil
+}
+
 // GetDownPeerRegions gets the region with down peer.
 func (h *Handler) GetDownPeerRegions() ([]*core.RegionInfo, error) {
 	c := h.s.GetRaftCluster()
@@ -590,6 +831,26 @@ func (h *Handler) GetMissPeerRegions() ([]*core.RegionInfo, error) {
 	return c.cachedCluster.GetRegionStatsByType(missPeer), nil
 }
 
+// GetMissLearnerRegions gets the regions with fewer read-only learners than
+// expected.
+func (h *Handler) GetMissLearnerRegions() ([]*core.RegionInfo, error) {
+	c := h.s.GetRaftCluster()
+	if c == nil {
+		return nil, ErrNotBootstrapped
+	}
+	return c.cachedCluster.GetRegionStatsByType(missLearner), nil
+}
+
+// GetExtraLearnerRegions gets the regions with more read-only learners than
+// expected.
+func (h *Handler) GetExtraLearnerRegions() ([]*core.RegionInfo, error) {
+	c := h.s.GetRaftCluster()
+	if c == nil {
+		return nil, ErrNotBootstrapped
+	}
+	return c.cachedCluster.GetRegionStatsByType(extraLearner), nil
+}
+
 // GetPendingPeerRegions gets the region with pending peer.
 func (h *Handler) GetPendingPeerRegions() ([]*core.RegionInfo, error) {
 	c := h.s.GetRaftCluster()
@@ -607,3 +868,22 @@ func (h *Handler) GetIncorrectNamespaceRegions() ([]*core.RegionInfo, error) {
 	}
 	return c.cachedCluster.GetRegionStatsByType(incorrectNamespace), nil
 }
+
+// GetConstraintViolatedRegions gets the regions violating the range constraints.
+func (h *Handler) GetConstraintViolatedRegions() ([]*core.RegionInfo, error) {
+	c := h.s.GetRaftCluster()
+	if c == nil {
+		return nil, ErrNotBootstrapped
+	}
+	return c.cachedCluster.getConstraintViolatedRegions(), nil
+}
+
+// GetUnderIsolatedRegions gets the regions isolated at a worse label level
+// than the store topology allows.
+func (h *Handler) GetUnderIsolatedRegions() ([]*core.RegionInfo, error) {
+	c, err := h.getCoordinator()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return c.getUnderIsolatedRegions(), nil
+}
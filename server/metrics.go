@@ -155,6 +155,14 @@ var (
 			Help:      "Etcd raft states.",
 		}, []string{"type"})
 
+	adaptiveLimitGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "pd",
+			Subsystem: "schedule",
+			Name:      "adaptive_limit",
+			Help:      "Factor of the adaptive schedule limits and the pressure signals.",
+		}, []string{"type"})
+
 	patrolCheckRegionsHistogram = prometheus.NewHistogram(
 		prometheus.HistogramOpts{
 			Namespace: "pd",
@@ -183,4 +191,5 @@ func init() {
 	prometheus.MustRegister(metadataGauge)
 	prometheus.MustRegister(etcdStateGauge)
 	prometheus.MustRegister(patrolCheckRegionsHistogram)
+	prometheus.MustRegister(adaptiveLimitGauge)
 }
@@ -38,6 +38,49 @@ type Classifier interface {
 	AllowMerge(*core.RegionInfo, *core.RegionInfo) bool
 }
 
+// Migrator is implemented by the classifiers which support migrating regions
+// between namespaces, the namespace checker reports the placement of the
+// migrating regions to it.
+type Migrator interface {
+	// IsMigrating returns true if the region is being migrated to another
+	// namespace.
+	IsMigrating(*core.RegionInfo) bool
+	// ReportRegion reports whether the migrating region is placed on the
+	// stores of its namespace.
+	ReportRegion(region *core.RegionInfo, placed bool)
+}
+
+// RangeBounded is implemented by the classifiers whose namespaces are bounded
+// by keys. A region containing the bounds straddles several namespaces, it is
+// reported rather than placed by the namespace of its start key.
+type RangeBounded interface {
+	// GetBoundaries returns the namespace bounds strictly inside the region.
+	GetBoundaries(*core.RegionInfo) [][]byte
+}
+
+// IsStraddling returns true if the region straddles several namespaces of
+// the classifier.
+func IsStraddling(classifier Classifier, region *core.RegionInfo) bool {
+	bounded, ok := classifier.(RangeBounded)
+	return ok && len(bounded.GetBoundaries(region)) > 0
+}
+
+// Hook is provided by the server to the classifiers which need the cluster
+// information or bind data to the namespace names.
+type Hook interface {
+	// GetRegionCount returns the number of the regions in [startKey, endKey).
+	GetRegionCount(startKey, endKey []byte) int
+	// OnNamespaceRenamed is called after a namespace is renamed.
+	OnNamespaceRenamed(oldName, newName string)
+	// OnNamespaceDeleted is called after a namespace is deleted.
+	OnNamespaceDeleted(name string)
+}
+
+// Hookable is implemented by the classifiers which accept a Hook.
+type Hookable interface {
+	SetHook(Hook)
+}
+
 type defaultClassifier struct{}
 
 func (c defaultClassifier) GetAllNamespaces() []string {
@@ -15,18 +15,32 @@ package server
 
 import (
 	"math/rand"
+	"time"
 
 	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/server/namespace"
 	"github.com/pingcap/pd/server/schedule"
 )
 
+// namespaceOptionCluster is a global cluster with the options overridden by a
+// specific namespace.
+type namespaceOptionCluster struct {
+	schedule.Cluster
+	namespace string
+}
+
+func newNamespaceOptionCluster(c schedule.Cluster, namespace string) *namespaceOptionCluster {
+	return &namespaceOptionCluster{
+		Cluster:   c,
+		namespace: namespace,
+	}
+}
+
 // namespaceCluster is part of a global cluster that contains stores and regions
 // within a specific namespace.
 type namespaceCluster struct {
-	schedule.Cluster
+	*namespaceOptionCluster
 	classifier namespace.Classifier
-	namespace  string
 	stores     map[uint64]*core.StoreInfo
 }
 
@@ -38,10 +52,9 @@ func newNamespaceCluster(c schedule.Cluster, classifier namespace.Classifier, na
 		}
 	}
 	return &namespaceCluster{
-		Cluster:    c,
-		classifier: classifier,
-		namespace:  namespace,
-		stores:     stores,
+		namespaceOptionCluster: newNamespaceOptionCluster(c, namespace),
+		classifier:             classifier,
+		stores:                 stores,
 	}
 }
 
@@ -73,6 +86,20 @@ func (c *namespaceCluster) RandFollowerRegion(storeID uint64, opts ...core.Regio
 	return nil
 }
 
+// RandLearnerRegion returns a random region that has a learner on the store.
+func (c *namespaceCluster) RandLearnerRegion(storeID uint64, opts ...core.RegionOption) *core.RegionInfo {
+	for i := 0; i < randRegionMaxRetry; i++ {
+		r := c.Cluster.RandLearnerRegion(storeID, opts...)
+		if r == nil {
+			return nil
+		}
+		if c.checkRegion(r) {
+			return r
+		}
+	}
+	return nil
+}
+
 // RandLeaderRegion returns a random region that has leader on the store.
 func (c *namespaceCluster) RandLeaderRegion(storeID uint64, opts ...core.RegionOption) *core.RegionInfo {
 	for i := 0; i < randRegionMaxRetry; i++ {
@@ -123,6 +150,185 @@ func (c *namespaceCluster) GetRegion(id uint64) *core.RegionInfo {
 	return r
 }
 
+// namespaceOptions provides the options overridden by namespaces.
+type namespaceOptions interface {
+	getLeaderScoreStrategy(name string) string
+	getRegionScoreStrategy(name string) string
+	getNamespaceConfigs(name string) (*ScheduleConfig, *ReplicationConfig)
+	isSchedulerDisabledByNamespace(name, scheduler string) bool
+}
+
+// getConfigs returns the schedule and replication configs of the namespace,
+// the last return value is false if the cluster doesn't support namespace
+// options.
+func (c *namespaceOptionCluster) getConfigs() (*ScheduleConfig, *ReplicationConfig, bool) {
+	if opt, ok := c.Cluster.(namespaceOptions); ok {
+		sc, rc := opt.getNamespaceConfigs(c.namespace)
+		return sc, rc, true
+	}
+	return nil, nil, false
+}
+
+// GetLeaderScoreStrategy returns the leader score strategy of the namespace.
+func (c *namespaceOptionCluster) GetLeaderScoreStrategy() string {
+	if opt, ok := c.Cluster.(namespaceOptions); ok {
+		return opt.getLeaderScoreStrategy(c.namespace)
+	}
+	return c.Cluster.GetLeaderScoreStrategy()
+}
+
+// GetRegionScoreStrategy returns the region score strategy of the namespace.
+func (c *namespaceOptionCluster) GetRegionScoreStrategy() string {
+	if opt, ok := c.Cluster.(namespaceOptions); ok {
+		return opt.getRegionScoreStrategy(c.namespace)
+	}
+	return c.Cluster.GetRegionScoreStrategy()
+}
+
+// GetMaxSnapshotCount returns the max snapshot count of the namespace.
+func (c *namespaceOptionCluster) GetMaxSnapshotCount() uint64 {
+	if sc, _, ok := c.getConfigs(); ok {
+		return sc.MaxSnapshotCount
+	}
+	return c.Cluster.GetMaxSnapshotCount()
+}
+
+// GetMaxPendingPeerCount returns the max pending peer count of the namespace.
+func (c *namespaceOptionCluster) GetMaxPendingPeerCount() uint64 {
+	if sc, _, ok := c.getConfigs(); ok {
+		return sc.MaxPendingPeerCount
+	}
+	return c.Cluster.GetMaxPendingPeerCount()
+}
+
+// GetMaxMergeRegionSize returns the max region size to merge of the namespace.
+func (c *namespaceOptionCluster) GetMaxMergeRegionSize() uint64 {
+	if sc, _, ok := c.getConfigs(); ok {
+		return sc.MaxMergeRegionSize
+	}
+	return c.Cluster.GetMaxMergeRegionSize()
+}
+
+// GetMaxMergeRegionKeys returns the max region keys to merge of the namespace.
+func (c *namespaceOptionCluster) GetMaxMergeRegionKeys() uint64 {
+	if sc, _, ok := c.getConfigs(); ok {
+		return sc.MaxMergeRegionKeys
+	}
+	return c.Cluster.GetMaxMergeRegionKeys()
+}
+
+// GetSplitMergeInterval returns the interval between split and merge of the namespace.
+func (c *namespaceOptionCluster) GetSplitMergeInterval() time.Duration {
+	if sc, _, ok := c.getConfigs(); ok {
+		return sc.SplitMergeInterval.Duration
+	}
+	return c.Cluster.GetSplitMergeInterval()
+}
+
+// GetSplitScheduleLimit returns the limit for split schedule of the namespace.
+func (c *namespaceOptionCluster) GetSplitScheduleLimit() uint64 {
+	if sc, _, ok := c.getConfigs(); ok {
+		return sc.SplitScheduleLimit
+	}
+	return c.Cluster.GetSplitScheduleLimit()
+}
+
+// GetIsolationScheduleLimit returns the limit for isolation schedule of the namespace.
+func (c *namespaceOptionCluster) GetIsolationScheduleLimit() uint64 {
+	if sc, _, ok := c.getConfigs(); ok {
+		return sc.IsolationScheduleLimit
+	}
+	return c.Cluster.GetIsolationScheduleLimit()
+}
+
+// GetTolerantSizeRatio returns the tolerant size ratio of the namespace.
+func (c *namespaceOptionCluster) GetTolerantSizeRatio() float64 {
+	if sc, _, ok := c.getConfigs(); ok {
+		return sc.TolerantSizeRatio
+	}
+	return c.Cluster.GetTolerantSizeRatio()
+}
+
+// GetLowSpaceRatio returns the low space ratio of the namespace.
+func (c *namespaceOptionCluster) GetLowSpaceRatio() float64 {
+	if sc, _, ok := c.getConfigs(); ok {
+		return sc.LowSpaceRatio
+	}
+	return c.Cluster.GetLowSpaceRatio()
+}
+
+// GetHighSpaceRatio returns the high space ratio of the namespace.
+func (c *namespaceOptionCluster) GetHighSpaceRatio() float64 {
+	if sc, _, ok := c.getConfigs(); ok {
+		return sc.HighSpaceRatio
+	}
+	return c.Cluster.GetHighSpaceRatio()
+}
+
+// IsRaftLearnerEnabled returns whether raft learner is enabled for the namespace.
+func (c *namespaceOptionCluster) IsRaftLearnerEnabled() bool {
+	if sc, _, ok := c.getConfigs(); ok {
+		return !sc.DisableLearner
+	}
+	return c.Cluster.IsRaftLearnerEnabled()
+}
+
+// IsRemoveDownReplicaEnabled returns whether removing down replicas is enabled for the namespace.
+func (c *namespaceOptionCluster) IsRemoveDownReplicaEnabled() bool {
+	if sc, _, ok := c.getConfigs(); ok {
+		return !sc.DisableRemoveDownReplica
+	}
+	return c.Cluster.IsRemoveDownReplicaEnabled()
+}
+
+// IsReplaceOfflineReplicaEnabled returns whether replacing offline replicas is enabled for the namespace.
+func (c *namespaceOptionCluster) IsReplaceOfflineReplicaEnabled() bool {
+	if sc, _, ok := c.getConfigs(); ok {
+		return !sc.DisableReplaceOfflineReplica
+	}
+	return c.Cluster.IsReplaceOfflineReplicaEnabled()
+}
+
+// IsMakeUpReplicaEnabled returns whether making up replicas is enabled for the namespace.
+func (c *namespaceOptionCluster) IsMakeUpReplicaEnabled() bool {
+	if sc, _, ok := c.getConfigs(); ok {
+		return !sc.DisableMakeUpReplica
+	}
+	return c.Cluster.IsMakeUpReplicaEnabled()
+}
+
+// IsRemoveExtraReplicaEnabled returns whether removing extra replicas is enabled for the namespace.
+func (c *namespaceOptionCluster) IsRemoveExtraReplicaEnabled() bool {
+	if sc, _, ok := c.getConfigs(); ok {
+		return !sc.DisableRemoveExtraReplica
+	}
+	return c.Cluster.IsRemoveExtraReplicaEnabled()
+}
+
+// IsLocationReplacementEnabled returns whether location replacement is enabled for the namespace.
+func (c *namespaceOptionCluster) IsLocationReplacementEnabled() bool {
+	if sc, _, ok := c.getConfigs(); ok {
+		return !sc.DisableLocationReplacement
+	}
+	return c.Cluster.IsLocationReplacementEnabled()
+}
+
+// GetLocationLabels returns the location labels of the namespace.
+func (c *namespaceOptionCluster) GetLocationLabels() []string {
+	if _, rc, ok := c.getConfigs(); ok {
+		return rc.LocationLabels
+	}
+	return c.Cluster.GetLocationLabels()
+}
+
+// GetLearnerLabels returns the labels of the learner stores of the namespace.
+func (c *namespaceOptionCluster) GetLearnerLabels() map[string]string {
+	if _, rc, ok := c.getConfigs(); ok {
+		return rc.LearnerLabels
+	}
+	return c.Cluster.GetLearnerLabels()
+}
+
 // RegionWriteStats returns hot region's write stats.
 func (c *namespaceCluster) RegionWriteStats() []*core.RegionStat {
 	allStats := c.Cluster.RegionWriteStats()
@@ -135,9 +341,24 @@ func (c *namespaceCluster) RegionWriteStats() []*core.RegionStat {
 	return stats
 }
 
+// RegionReadStats returns hot region's read stats.
+func (c *namespaceCluster) RegionReadStats() []*core.RegionStat {
+	allStats := c.Cluster.RegionReadStats()
+	stats := make([]*core.RegionStat, 0, len(allStats))
+	for _, s := range allStats {
+		if c.GetRegion(s.RegionID) != nil {
+			stats = append(stats, s)
+		}
+	}
+	return stats
+}
+
 func scheduleByNamespace(cluster schedule.Cluster, classifier namespace.Classifier, scheduler schedule.Scheduler, opInfluence schedule.OpInfluence) []*schedule.Operator {
 	namespaces := classifier.GetAllNamespaces()
 	for _, i := range rand.Perm(len(namespaces)) {
+		if opt, ok := cluster.(namespaceOptions); ok && opt.isSchedulerDisabledByNamespace(namespaces[i], scheduler.GetName()) {
+			continue
+		}
 		nc := newNamespaceCluster(cluster, classifier, namespaces[i])
 		if op := scheduler.Schedule(nc, opInfluence); op != nil {
 			return op
@@ -146,22 +367,26 @@ func scheduleByNamespace(cluster schedule.Cluster, classifier namespace.Classifi
 	return nil
 }
 
-func (c *namespaceCluster) GetLeaderScheduleLimit() uint64 {
+func (c *namespaceOptionCluster) GetLeaderScheduleLimit() uint64 {
 	return c.GetOpt().GetLeaderScheduleLimit(c.namespace)
 }
 
-func (c *namespaceCluster) GetRegionScheduleLimit() uint64 {
+func (c *namespaceOptionCluster) GetRegionScheduleLimit() uint64 {
 	return c.GetOpt().GetRegionScheduleLimit(c.namespace)
 }
 
-func (c *namespaceCluster) GetReplicaScheduleLimit() uint64 {
+func (c *namespaceOptionCluster) GetReplicaScheduleLimit() uint64 {
 	return c.GetOpt().GetReplicaScheduleLimit(c.namespace)
 }
 
-func (c *namespaceCluster) GetMergeScheduleLimit() uint64 {
+func (c *namespaceOptionCluster) GetMergeScheduleLimit() uint64 {
 	return c.GetOpt().GetMergeScheduleLimit(c.namespace)
 }
 
-func (c *namespaceCluster) GetMaxReplicas() int {
+func (c *namespaceOptionCluster) GetMaxReplicas() int {
 	return c.GetOpt().GetMaxReplicas(c.namespace)
 }
+
+func (c *namespaceOptionCluster) GetLearnerReplicas() int {
+	return c.GetOpt().GetLearnerReplicas(c.namespace)
+}
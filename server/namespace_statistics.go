@@ -0,0 +1,109 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/namespace"
+	"github.com/pingcap/pd/server/schedule"
+)
+
+// NamespaceStats is the statistics of the regions and stores of a namespace.
+type NamespaceStats struct {
+	Name string `json:"name"`
+	// Regions is the statistics of the regions classified into the
+	// namespace, including the leader and peer distribution over stores.
+	Regions *core.RegionStats `json:"regions"`
+	// MisplacedRegionCount is the number of the regions of the namespace
+	// which have peers on the stores out of the namespace.
+	MisplacedRegionCount int `json:"misplaced_region_count"`
+	// ForeignRegionCount is the number of the regions of other namespaces
+	// which have peers on the stores of the namespace.
+	ForeignRegionCount int `json:"foreign_region_count"`
+	// StraddlingRegionCount is the number of the regions of the namespace
+	// which also contain the keys of other namespaces, they are not placed
+	// until they are split at the namespace bounds.
+	StraddlingRegionCount int `json:"straddling_region_count"`
+
+	StoreCount       int     `json:"store_count"`
+	StorageCapacity  uint64  `json:"storage_capacity"`
+	StorageAvailable uint64  `json:"storage_available"`
+	StorageSize      uint64  `json:"storage_size"`
+	UsedRatio        float64 `json:"used_ratio"`
+
+	HotWriteRegionCount int    `json:"hot_write_region_count"`
+	HotWriteFlowBytes   uint64 `json:"hot_write_flow_bytes"`
+	HotWriteFlowKeys    uint64 `json:"hot_write_flow_keys"`
+	HotReadRegionCount  int    `json:"hot_read_region_count"`
+	HotReadFlowBytes    uint64 `json:"hot_read_flow_bytes"`
+	HotReadFlowKeys     uint64 `json:"hot_read_flow_keys"`
+}
+
+// getNamespaceStats scans the stores and regions to sum up the statistics
+// of the namespace.
+func (c *clusterInfo) getNamespaceStats(classifier namespace.Classifier, name string) *NamespaceStats {
+	stats := &NamespaceStats{
+		Name:    name,
+		Regions: core.NewRegionStats(),
+	}
+	stores := make(map[uint64]struct{})
+	for _, store := range c.GetStores() {
+		if store.IsTombstone() || classifier.GetStoreNamespace(store) != name {
+			continue
+		}
+		stores[store.GetId()] = struct{}{}
+		stats.StoreCount++
+		stats.StorageCapacity += store.Stats.GetCapacity()
+		stats.StorageAvailable += store.Stats.GetAvailable()
+		stats.StorageSize += store.StorageSize()
+	}
+	if stats.StorageCapacity > 0 {
+		stats.UsedRatio = float64(stats.StorageSize) / float64(stats.StorageCapacity)
+	}
+
+	for _, region := range c.getRegions() {
+		var inside, outside bool
+		for _, p := range region.GetPeers() {
+			if _, ok := stores[p.GetStoreId()]; ok {
+				inside = true
+			} else {
+				outside = true
+			}
+		}
+		if classifier.GetRegionNamespace(region) != name {
+			if inside {
+				stats.ForeignRegionCount++
+			}
+			continue
+		}
+		stats.Regions.Observe(region)
+		if outside {
+			stats.MisplacedRegionCount++
+		}
+		if namespace.IsStraddling(classifier, region) {
+			stats.StraddlingRegionCount++
+		}
+		if stat := c.core.HotCache.RegionStat(region.GetId(), schedule.WriteFlow); stat != nil {
+			stats.HotWriteRegionCount++
+			stats.HotWriteFlowBytes += stat.FlowBytes
+			stats.HotWriteFlowKeys += stat.FlowKeys
+		}
+		if stat := c.core.HotCache.RegionStat(region.GetId(), schedule.ReadFlow); stat != nil {
+			stats.HotReadRegionCount++
+			stats.HotReadFlowBytes += stat.FlowBytes
+			stats.HotReadFlowKeys += stat.FlowKeys
+		}
+	}
+	return stats
+}
@@ -14,6 +14,8 @@
 package server
 
 import (
+	"time"
+
 	. "github.com/pingcap/check"
 	"github.com/pingcap/pd/pkg/testutil"
 	"github.com/pingcap/pd/server/core"
@@ -107,6 +109,202 @@ func (s *testNamespaceSuite) TestNamespaceChecker(c *C) {
 	testutil.CheckTransferPeer(c, op, schedule.OpReplica, 3, 2)
 }
 
+func (s *testNamespaceSuite) TestNamespaceMigration(c *C) {
+	s.tc.addRegionStore(1, 0)
+	s.tc.addRegionStore(2, 0)
+	s.classifier.setStore(1, "ns1")
+	s.classifier.setStore(2, "ns2")
+	classifier := &migrationClassifier{
+		mapClassifer: s.classifier,
+		migrating:    map[uint64]bool{1: true, 2: true},
+		placed:       make(map[uint64]bool),
+	}
+	checker := schedule.NewNamespaceChecker(s.tc, classifier)
+
+	s.classifier.setRegion(1, "ns2")
+	s.tc.addLeaderRegion(1, 1)
+	s.classifier.setRegion(2, "ns2")
+	s.tc.addLeaderRegion(2, 2)
+	s.classifier.setRegion(3, "ns1")
+	s.tc.addLeaderRegion(3, 2)
+	c.Assert(checker.CheckMigration(s.tc.GetRegion(1)), IsTrue)
+	c.Assert(checker.CheckMigration(s.tc.GetRegion(2)), IsTrue)
+	c.Assert(checker.CheckMigration(s.tc.GetRegion(3)), IsFalse)
+	c.Assert(classifier.placed, DeepEquals, map[uint64]bool{1: false, 2: true})
+
+	// The classifiers which don't support migration are skipped.
+	checker = schedule.NewNamespaceChecker(s.tc, s.classifier)
+	c.Assert(checker.CheckMigration(s.tc.GetRegion(1)), IsFalse)
+}
+
+func (s *testNamespaceSuite) TestNamespaceStraddling(c *C) {
+	s.tc.addRegionStore(1, 0)
+	s.tc.addRegionStore(2, 0)
+	s.classifier.setStore(1, "ns1")
+	s.classifier.setStore(2, "ns2")
+	classifier := &boundedClassifier{
+		mapClassifer: s.classifier,
+		boundaries:   map[uint64][][]byte{1: {[]byte("bound")}},
+	}
+	checker := schedule.NewNamespaceChecker(s.tc, classifier)
+
+	// The region straddling the namespaces is reported instead of being moved.
+	s.classifier.setRegion(1, "ns2")
+	s.tc.addLeaderRegion(1, 1)
+	c.Assert(checker.Check(s.tc.GetRegion(1)), IsNil)
+	c.Assert(s.tc.getNamespaceStats(classifier, "ns2").StraddlingRegionCount, Equals, 1)
+
+	s.classifier.setRegion(2, "ns2")
+	s.tc.addLeaderRegion(2, 1)
+	testutil.CheckTransferPeer(c, checker.Check(s.tc.GetRegion(2)), schedule.OpReplica, 1, 2)
+}
+
+func (s *testNamespaceSuite) TestNamespaceStats(c *C) {
+	// store regionCount namespace
+	//     1           0       ns1
+	//     2           0       ns1
+	//     3           0       ns2
+	s.tc.addRegionStore(1, 0)
+	s.tc.addRegionStore(2, 0)
+	s.tc.addRegionStore(3, 0)
+	for _, id := range []uint64{1, 2, 3} {
+		store := s.tc.GetStore(id)
+		store.Stats.UsedSize = 250 * (1 << 20)
+		s.tc.putStore(store)
+	}
+	s.classifier.setStore(1, "ns1")
+	s.classifier.setStore(2, "ns1")
+	s.classifier.setStore(3, "ns2")
+
+	// region leader follower namespace
+	//      1      1        2      ns1
+	//      2      2        3      ns1
+	//      3      3        1      ns2
+	//      4      3               ns2
+	s.classifier.setRegion(1, "ns1")
+	s.tc.addLeaderRegion(1, 1, 2)
+	s.classifier.setRegion(2, "ns1")
+	s.tc.addLeaderRegion(2, 2, 3)
+	s.classifier.setRegion(3, "ns2")
+	s.tc.addLeaderRegion(3, 3, 1)
+	s.classifier.setRegion(4, "ns2")
+	s.tc.addLeaderRegion(4, 3)
+	hot := &core.RegionStat{RegionID: 2, StoreID: 2, IsLeader: true, FlowBytes: 1024, FlowKeys: 10, HotDegree: 3, LastUpdateTime: time.Now()}
+	s.tc.core.HotCache.Update([]*core.RegionStat{hot}, schedule.WriteFlow)
+
+	stats := s.tc.getNamespaceStats(s.classifier, "ns1")
+	c.Assert(stats.Regions.Count, Equals, 2)
+	c.Assert(stats.Regions.StorageSize, Equals, int64(20))
+	c.Assert(stats.Regions.StoreLeaderCount, DeepEquals, map[uint64]int{1: 1, 2: 1})
+	c.Assert(stats.MisplacedRegionCount, Equals, 1)
+	c.Assert(stats.ForeignRegionCount, Equals, 1)
+	c.Assert(stats.StoreCount, Equals, 2)
+	c.Assert(stats.StorageCapacity, Equals, uint64(2000*(1<<20)))
+	c.Assert(stats.StorageSize, Equals, uint64(500*(1<<20)))
+	c.Assert(stats.UsedRatio, Equals, 0.25)
+	c.Assert(stats.HotWriteRegionCount, Equals, 1)
+	c.Assert(stats.HotWriteFlowBytes, Equals, uint64(1024))
+	c.Assert(stats.HotWriteFlowKeys, Equals, uint64(10))
+	c.Assert(stats.HotReadRegionCount, Equals, 0)
+
+	stats = s.tc.getNamespaceStats(s.classifier, "ns2")
+	c.Assert(stats.Regions.Count, Equals, 2)
+	c.Assert(stats.MisplacedRegionCount, Equals, 1)
+	c.Assert(stats.ForeignRegionCount, Equals, 1)
+	c.Assert(stats.StoreCount, Equals, 1)
+	c.Assert(stats.HotWriteRegionCount, Equals, 0)
+
+	stats = s.tc.getNamespaceStats(s.classifier, namespace.DefaultNamespace)
+	c.Assert(stats.Regions.Count, Equals, 0)
+	c.Assert(stats.StoreCount, Equals, 0)
+	c.Assert(stats.UsedRatio, Equals, float64(0))
+}
+
+func (s *testNamespaceSuite) TestNamespaceConfig(c *C) {
+	// store regionCount namespace
+	//     1           0       ns1
+	//     2         100       ns1
+	//     3           0       ns2
+	s.tc.addRegionStore(1, 0)
+	s.tc.addRegionStore(2, 100)
+	s.tc.addRegionStore(3, 0)
+	s.classifier.setStore(1, "ns1")
+	s.classifier.setStore(2, "ns1")
+	s.classifier.setStore(3, "ns2")
+	s.opt.ns["ns1"] = newNamespaceOption(&NamespaceConfig{
+		LeaderScheduleLimit: 8,
+		DisabledSchedulers:  []string{"balance-region-scheduler"},
+		Overrides: map[string]interface{}{
+			"tolerant-size-ratio": float64(10),
+			"location-labels":     "zone,host",
+		},
+	})
+
+	// The options are overridden in the namespace cluster.
+	ns1 := newNamespaceCluster(s.tc, s.classifier, "ns1")
+	ns2 := newNamespaceCluster(s.tc, s.classifier, "ns2")
+	c.Assert(ns1.GetTolerantSizeRatio(), Equals, float64(10))
+	c.Assert(ns2.GetTolerantSizeRatio(), Equals, s.tc.GetTolerantSizeRatio())
+	c.Assert(ns1.GetLocationLabels(), DeepEquals, []string{"zone", "host"})
+	c.Assert(ns2.GetLocationLabels(), HasLen, 0)
+	c.Assert(ns1.GetLeaderScheduleLimit(), Equals, uint64(8))
+	c.Assert(ns1.GetRegionScheduleLimit(), Equals, s.tc.GetRegionScheduleLimit())
+
+	// The resolved configs are cached until the global config is replaced.
+	sc1, _ := s.opt.getNamespaceConfigs("ns1")
+	sc2, _ := s.opt.getNamespaceConfigs("ns1")
+	c.Assert(sc2, Equals, sc1)
+	s.opt.SetMaxReplicas(1)
+	_, rc := s.opt.getNamespaceConfigs("ns1")
+	c.Assert(rc.MaxReplicas, Equals, uint64(1))
+	c.Assert(ns1.GetMaxReplicas(), Equals, 1)
+
+	// The disabled scheduler skips the namespace.
+	sched, _ := schedule.CreateScheduler("balance-region", schedule.NewLimiter())
+	s.tc.addLeaderRegion(1, 2)
+	s.classifier.setRegion(1, "ns1")
+	op := scheduleByNamespace(s.tc, s.classifier, sched, schedule.NewOpInfluence(nil, s.tc))
+	c.Assert(op, IsNil)
+	s.opt.ns["ns1"].store(&NamespaceConfig{})
+	op = scheduleByNamespace(s.tc, s.classifier, sched, schedule.NewOpInfluence(nil, s.tc))
+	testutil.CheckTransferPeer(c, op[0], schedule.OpBalance, 2, 1)
+}
+
+func (s *testNamespaceSuite) TestNamespaceConfigCheckers(c *C) {
+	// store regionCount namespace
+	//     1           0       ns1
+	//     2           0       ns1
+	//     3           0       ns2
+	//     4           0       ns2
+	for i := uint64(1); i <= 4; i++ {
+		s.tc.addRegionStore(i, 0)
+	}
+	s.classifier.setStore(1, "ns1")
+	s.classifier.setStore(2, "ns1")
+	s.classifier.setStore(3, "ns2")
+	s.classifier.setStore(4, "ns2")
+	s.opt.ns["ns1"] = newNamespaceOption(&NamespaceConfig{
+		Overrides: map[string]interface{}{
+			"disable-make-up-replica": "true",
+		},
+	})
+	hbStreams := newHeartbeatStreams(s.tc.getClusterID())
+	defer hbStreams.Close()
+	co := newCoordinator(s.tc.clusterInfo, hbStreams, s.classifier)
+
+	// The replica checker reads the options of the region's namespace.
+	s.classifier.setRegion(1, "ns1")
+	s.tc.addLeaderRegion(1, 1)
+	s.classifier.setRegion(2, "ns2")
+	s.tc.addLeaderRegion(2, 3)
+	c.Assert(co.checkRegion(s.tc.GetRegion(1)), IsFalse)
+	c.Assert(co.checkRegion(s.tc.GetRegion(2)), IsTrue)
+	testutil.CheckAddPeer(c, co.getOperator(2), schedule.OpReplica, 4)
+	s.opt.ns["ns1"].store(&NamespaceConfig{})
+	c.Assert(co.checkRegion(s.tc.GetRegion(1)), IsTrue)
+	testutil.CheckAddPeer(c, co.getOperator(1), schedule.OpReplica, 2)
+}
+
 func (s *testNamespaceSuite) TestSchedulerBalanceRegion(c *C) {
 	// store regionCount namespace
 	//     1           0       ns1
@@ -234,6 +432,29 @@ func (c *mapClassifer) AllowMerge(one *core.RegionInfo, other *core.RegionInfo)
 	return c.GetRegionNamespace(one) == c.GetRegionNamespace(other)
 }
 
+type migrationClassifier struct {
+	*mapClassifer
+	migrating map[uint64]bool
+	placed    map[uint64]bool
+}
+
+func (c *migrationClassifier) IsMigrating(region *core.RegionInfo) bool {
+	return c.migrating[region.GetId()]
+}
+
+func (c *migrationClassifier) ReportRegion(region *core.RegionInfo, placed bool) {
+	c.placed[region.GetId()] = placed
+}
+
+type boundedClassifier struct {
+	*mapClassifer
+	boundaries map[uint64][][]byte
+}
+
+func (c *boundedClassifier) GetBoundaries(region *core.RegionInfo) [][]byte {
+	return c.boundaries[region.GetId()]
+}
+
 func (c *mapClassifer) setStore(id uint64, namespace string) {
 	c.stores[id] = namespace
 }
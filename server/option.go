@@ -14,6 +14,7 @@
 package server
 
 import (
+	"encoding/json"
 	"reflect"
 	"sync/atomic"
 	"time"
@@ -23,6 +24,7 @@ import (
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/server/schedule"
+	log "github.com/sirupsen/logrus"
 )
 
 // scheduleOption is a wrapper to access the configuration safely.
@@ -32,6 +34,9 @@ type scheduleOption struct {
 	ns             map[string]*namespaceOption
 	labelProperty  atomic.Value
 	clusterVersion atomic.Value
+	// adaptiveFactor is the ratio of the adaptive schedule limits to the
+	// configured ones, it is adjusted by the adaptive limit controller.
+	adaptiveFactor atomic.Value
 }
 
 func newScheduleOption(cfg *Config) *scheduleOption {
@@ -45,6 +50,7 @@ func newScheduleOption(cfg *Config) *scheduleOption {
 	o.rep = newReplication(&cfg.Replication)
 	o.labelProperty.Store(cfg.LabelProperty)
 	o.clusterVersion.Store(cfg.ClusterVersion)
+	o.adaptiveFactor.Store(float64(1))
 	return o
 }
 
@@ -61,12 +67,23 @@ func (o *scheduleOption) GetReplication() *Replication {
 }
 
 func (o *scheduleOption) GetMaxReplicas(name string) int {
-	if n, ok := o.ns[name]; ok {
+	if n, ok := o.ns[name]; ok && n.GetMaxReplicas() > 0 {
 		return n.GetMaxReplicas()
 	}
 	return o.rep.GetMaxReplicas()
 }
 
+func (o *scheduleOption) GetLearnerReplicas(name string) int {
+	if n, ok := o.ns[name]; ok && n.GetLearnerReplicas() > 0 {
+		return n.GetLearnerReplicas()
+	}
+	return o.rep.GetLearnerReplicas()
+}
+
+func (o *scheduleOption) GetLearnerLabels() map[string]string {
+	return o.rep.GetLearnerLabels()
+}
+
 func (o *scheduleOption) SetMaxReplicas(replicas int) {
 	o.rep.SetMaxReplicas(replicas)
 }
@@ -99,36 +116,110 @@ func (o *scheduleOption) GetPatrolRegionInterval() time.Duration {
 	return o.load().PatrolRegionInterval.Duration
 }
 
+func (o *scheduleOption) GetSplitScheduleLimit() uint64 {
+	return o.load().SplitScheduleLimit
+}
+
+func (o *scheduleOption) GetIsolationScheduleLimit() uint64 {
+	return o.load().IsolationScheduleLimit
+}
+
+func (o *scheduleOption) GetLoadSplitHotDegree() int {
+	return int(o.load().LoadSplitHotDegree)
+}
+
+func (o *scheduleOption) GetLoadSplitMinFlowBytes() uint64 {
+	return o.load().LoadSplitMinFlowBytes
+}
+
+func (o *scheduleOption) GetLoadSplitCooldown() time.Duration {
+	return o.load().LoadSplitCooldown.Duration
+}
+
+func (o *scheduleOption) GetHotRegionWindowSize() int {
+	return int(o.load().HotRegionWindowSize)
+}
+
+func (o *scheduleOption) GetHotRegionsWriteInterval() time.Duration {
+	return o.load().HotRegionsWriteInterval.Duration
+}
+
+func (o *scheduleOption) GetHotRegionsRetention() time.Duration {
+	return o.load().HotRegionsRetention.Duration
+}
+
 func (o *scheduleOption) GetMaxStoreDownTime() time.Duration {
 	return o.load().MaxStoreDownTime.Duration
 }
 
 func (o *scheduleOption) GetLeaderScheduleLimit(name string) uint64 {
-	if n, ok := o.ns[name]; ok {
-		return n.GetLeaderScheduleLimit()
+	c := o.load()
+	if w := c.getActiveTimeWindow(time.Now()); w != nil && w.LeaderScheduleLimit != nil {
+		return o.adaptLimit(*w.LeaderScheduleLimit)
 	}
-	return o.load().LeaderScheduleLimit
+	if n, ok := o.ns[name]; ok && n.GetLeaderScheduleLimit() > 0 {
+		return o.adaptLimit(n.GetLeaderScheduleLimit())
+	}
+	return o.adaptLimit(c.LeaderScheduleLimit)
 }
 
 func (o *scheduleOption) GetRegionScheduleLimit(name string) uint64 {
-	if n, ok := o.ns[name]; ok {
-		return n.GetRegionScheduleLimit()
+	c := o.load()
+	if w := c.getActiveTimeWindow(time.Now()); w != nil && w.RegionScheduleLimit != nil {
+		return o.adaptLimit(*w.RegionScheduleLimit)
+	}
+	if n, ok := o.ns[name]; ok && n.GetRegionScheduleLimit() > 0 {
+		return o.adaptLimit(n.GetRegionScheduleLimit())
 	}
-	return o.load().RegionScheduleLimit
+	return o.adaptLimit(c.RegionScheduleLimit)
 }
 
 func (o *scheduleOption) GetReplicaScheduleLimit(name string) uint64 {
-	if n, ok := o.ns[name]; ok {
-		return n.GetReplicaScheduleLimit()
+	c := o.load()
+	if w := c.getActiveTimeWindow(time.Now()); w != nil && w.ReplicaScheduleLimit != nil {
+		return o.adaptLimit(*w.ReplicaScheduleLimit)
+	}
+	if n, ok := o.ns[name]; ok && n.GetReplicaScheduleLimit() > 0 {
+		return o.adaptLimit(n.GetReplicaScheduleLimit())
 	}
-	return o.load().ReplicaScheduleLimit
+	return o.adaptLimit(c.ReplicaScheduleLimit)
 }
 
 func (o *scheduleOption) GetMergeScheduleLimit(name string) uint64 {
-	if n, ok := o.ns[name]; ok {
-		return n.GetMergeScheduleLimit()
+	c := o.load()
+	if w := c.getActiveTimeWindow(time.Now()); w != nil && w.MergeScheduleLimit != nil {
+		return o.adaptLimit(*w.MergeScheduleLimit)
+	}
+	if n, ok := o.ns[name]; ok && n.GetMergeScheduleLimit() > 0 {
+		return o.adaptLimit(n.GetMergeScheduleLimit())
+	}
+	return o.adaptLimit(c.MergeScheduleLimit)
+}
+
+// GetAdaptiveLimitFactor returns the ratio of the adaptive schedule limits to
+// the configured ones, it is 1 if the adaptive schedule limit is disabled.
+func (o *scheduleOption) GetAdaptiveLimitFactor() float64 {
+	if !o.load().EnableAdaptiveScheduleLimit {
+		return 1
+	}
+	return o.adaptiveFactor.Load().(float64)
+}
+
+func (o *scheduleOption) setAdaptiveLimitFactor(factor float64) {
+	o.adaptiveFactor.Store(factor)
+}
+
+// adaptLimit scales the schedule limit by the adaptive factor, a non-zero
+// limit is never scaled down to zero.
+func (o *scheduleOption) adaptLimit(limit uint64) uint64 {
+	factor := o.GetAdaptiveLimitFactor()
+	if limit == 0 || factor == 1 {
+		return limit
+	}
+	if adapted := uint64(float64(limit)*factor + 0.5); adapted > 0 {
+		return adapted
 	}
-	return o.load().MergeScheduleLimit
+	return 1
 }
 
 func (o *scheduleOption) GetTolerantSizeRatio() float64 {
@@ -143,6 +234,20 @@ func (o *scheduleOption) GetHighSpaceRatio() float64 {
 	return o.load().HighSpaceRatio
 }
 
+func (o *scheduleOption) GetLeaderScoreStrategy(name string) string {
+	if n, ok := o.ns[name]; ok && n.GetLeaderScoreStrategy() != "" {
+		return n.GetLeaderScoreStrategy()
+	}
+	return o.load().LeaderScoreStrategy
+}
+
+func (o *scheduleOption) GetRegionScoreStrategy(name string) string {
+	if n, ok := o.ns[name]; ok && n.GetRegionScoreStrategy() != "" {
+		return n.GetRegionScoreStrategy()
+	}
+	return o.load().RegionScoreStrategy
+}
+
 func (o *scheduleOption) IsRaftLearnerEnabled() bool {
 	return !o.load().DisableLearner
 }
@@ -167,27 +272,97 @@ func (o *scheduleOption) IsLocationReplacementEnabled() bool {
 	return !o.load().DisableLocationReplacement
 }
 
+func (o *scheduleOption) IsPlacementRulesEnabled() bool {
+	return o.rep.IsPlacementRulesEnabled()
+}
+
 func (o *scheduleOption) GetSchedulers() SchedulerConfigs {
 	return o.load().Schedulers
 }
 
+// IsSchedulerDisabledByTimeWindow returns true if the scheduler should not
+// run in the active time window.
+func (o *scheduleOption) IsSchedulerDisabledByTimeWindow(name string) bool {
+	if w := o.load().getActiveTimeWindow(time.Now()); w != nil {
+		for _, s := range w.DisabledSchedulers {
+			if s == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsSchedulerDisabledByNamespace returns true if the scheduler should not
+// schedule the namespace.
+func (o *scheduleOption) IsSchedulerDisabledByNamespace(name, scheduler string) bool {
+	if n, ok := o.ns[name]; ok {
+		for _, s := range n.load().DisabledSchedulers {
+			if s == scheduler {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getNamespaceConfigs returns the schedule and replication configs resolved
+// for the namespace, they are the global ones if the namespace has no config.
+func (o *scheduleOption) getNamespaceConfigs(name string) (*ScheduleConfig, *ReplicationConfig) {
+	if n, ok := o.ns[name]; ok {
+		return n.resolve(o.load(), o.rep.load())
+	}
+	return o.load(), o.rep.load()
+}
+
+// GetEffectiveScheduleConfig returns the schedule config with the options
+// overridden by the active time window and scaled by the adaptive factor.
+func (o *scheduleOption) GetEffectiveScheduleConfig() *EffectiveScheduleConfig {
+	c := o.load()
+	cfg := &EffectiveScheduleConfig{ScheduleConfig: *c}
+	if w := c.getActiveTimeWindow(time.Now()); w != nil {
+		cfg.ActiveTimeWindow = w.Name
+		if w.LeaderScheduleLimit != nil {
+			cfg.LeaderScheduleLimit = *w.LeaderScheduleLimit
+		}
+		if w.RegionScheduleLimit != nil {
+			cfg.RegionScheduleLimit = *w.RegionScheduleLimit
+		}
+		if w.ReplicaScheduleLimit != nil {
+			cfg.ReplicaScheduleLimit = *w.ReplicaScheduleLimit
+		}
+		if w.MergeScheduleLimit != nil {
+			cfg.MergeScheduleLimit = *w.MergeScheduleLimit
+		}
+		cfg.DisabledSchedulers = append(cfg.DisabledSchedulers, w.DisabledSchedulers...)
+	}
+	cfg.AdaptiveLimitFactor = o.GetAdaptiveLimitFactor()
+	cfg.LeaderScheduleLimit = o.adaptLimit(cfg.LeaderScheduleLimit)
+	cfg.RegionScheduleLimit = o.adaptLimit(cfg.RegionScheduleLimit)
+	cfg.ReplicaScheduleLimit = o.adaptLimit(cfg.ReplicaScheduleLimit)
+	cfg.MergeScheduleLimit = o.adaptLimit(cfg.MergeScheduleLimit)
+	return cfg
+}
+
 func (o *scheduleOption) AddSchedulerCfg(tp string, args []string) error {
 	c := o.load()
 	v := c.clone()
 	for i, schedulerCfg := range v.Schedulers {
 		// comparing args is to cover the case that there are schedulers in same type but not with same name
-		// such as two schedulers of type "evict-leader",
-		// one name is "evict-leader-scheduler-1" and the other is "evict-leader-scheduler-2"
-		if reflect.DeepEqual(schedulerCfg, SchedulerConfig{Type: tp, Args: args, Disable: false}) {
-			return nil
+		// such as two schedulers of type "grant-leader",
+		// one name is "grant-leader-scheduler-1" and the other is "grant-leader-scheduler-2"
+		if schedulerCfg.Type != tp || !reflect.DeepEqual(schedulerCfg.Args, args) {
+			continue
 		}
-
-		if reflect.DeepEqual(schedulerCfg, SchedulerConfig{Type: tp, Args: args, Disable: true}) {
-			schedulerCfg.Disable = false
-			v.Schedulers[i] = schedulerCfg
-			o.store(v)
+		if !schedulerCfg.Disable {
 			return nil
 		}
+		schedulerCfg.Disable = false
+		schedulerCfg.PausedUntil = 0
+		schedulerCfg.Config = nil
+		v.Schedulers[i] = schedulerCfg
+		o.store(v)
+		return nil
 	}
 	v.Schedulers = append(v.Schedulers, SchedulerConfig{Type: tp, Args: args, Disable: false})
 	o.store(v)
@@ -206,6 +381,8 @@ func (o *scheduleOption) RemoveSchedulerCfg(name string) error {
 		if tmp.GetName() == name {
 			if IsDefaultScheduler(tmp.GetType()) {
 				schedulerCfg.Disable = true
+				schedulerCfg.PausedUntil = 0
+				schedulerCfg.Config = nil
 				v.Schedulers[i] = schedulerCfg
 			} else {
 				v.Schedulers = append(v.Schedulers[:i], v.Schedulers[i+1:]...)
@@ -217,6 +394,39 @@ func (o *scheduleOption) RemoveSchedulerCfg(name string) error {
 	return nil
 }
 
+// SetSchedulerPausedUntil records the time until which the scheduler is paused.
+func (o *scheduleOption) SetSchedulerPausedUntil(name string, until int64) error {
+	return o.updateSchedulerCfg(name, func(cfg *SchedulerConfig) {
+		cfg.PausedUntil = until
+	})
+}
+
+// SetSchedulerConfig records the JSON encoded runtime config of the scheduler.
+func (o *scheduleOption) SetSchedulerConfig(name string, data json.RawMessage) error {
+	return o.updateSchedulerCfg(name, func(cfg *SchedulerConfig) {
+		cfg.Config = data
+	})
+}
+
+func (o *scheduleOption) updateSchedulerCfg(name string, f func(*SchedulerConfig)) error {
+	c := o.load()
+	v := c.clone()
+	for i, schedulerCfg := range v.Schedulers {
+		// To create a temporary scheduler is just used to get scheduler's name
+		tmp, err := schedule.CreateScheduler(schedulerCfg.Type, schedule.NewLimiter(), schedulerCfg.Args...)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if tmp.GetName() == name {
+			f(&schedulerCfg)
+			v.Schedulers[i] = schedulerCfg
+			o.store(v)
+			return nil
+		}
+	}
+	return nil
+}
+
 func (o *scheduleOption) SetLabelProperty(typ, labelKey, labelValue string) {
 	cfg := o.loadLabelPropertyConfig().clone()
 	for _, l := range cfg[typ] {
@@ -308,6 +518,8 @@ func (o *scheduleOption) adjustScheduleCfg(persistentCfg *Config) {
 		for _, ps := range persistentCfg.Schedule.Schedulers {
 			if s.Type == ps.Type && reflect.DeepEqual(s.Args, ps.Args) {
 				scheduleCfg.Schedulers[i].Disable = ps.Disable
+				scheduleCfg.Schedulers[i].PausedUntil = ps.PausedUntil
+				scheduleCfg.Schedulers[i].Config = ps.Config
 				break
 			}
 		}
@@ -378,14 +590,44 @@ func (r *Replication) SetMaxReplicas(replicas int) {
 	r.store(v)
 }
 
+// GetLearnerReplicas returns the number of read-only learners for each
+// region.
+func (r *Replication) GetLearnerReplicas() int {
+	return int(r.load().LearnerReplicas)
+}
+
+// GetLearnerLabels returns the labels to select the stores of the learners.
+func (r *Replication) GetLearnerLabels() map[string]string {
+	return r.load().LearnerLabels
+}
+
 // GetLocationLabels returns the location labels for each region
 func (r *Replication) GetLocationLabels() []string {
 	return r.load().LocationLabels
 }
 
+// IsPlacementRulesEnabled returns whether the replicas are placed by the
+// placement rules.
+func (r *Replication) IsPlacementRulesEnabled() bool {
+	return r.load().EnablePlacementRules
+}
+
 // namespaceOption is a wrapper to access the configuration safely.
 type namespaceOption struct {
 	namespaceCfg atomic.Value
+	// resolved caches the configs resolved from the global configs.
+	resolved atomic.Value
+}
+
+// resolvedNamespaceConfig is the configs of a namespace resolved from the
+// namespace config and the global configs, it is valid until any of them
+// is replaced.
+type resolvedNamespaceConfig struct {
+	namespace   *NamespaceConfig
+	global      *ScheduleConfig
+	globalRep   *ReplicationConfig
+	schedule    *ScheduleConfig
+	replication *ReplicationConfig
 }
 
 func newNamespaceOption(cfg *NamespaceConfig) *namespaceOption {
@@ -402,11 +644,39 @@ func (n *namespaceOption) store(cfg *NamespaceConfig) {
 	n.namespaceCfg.Store(cfg)
 }
 
+// resolve returns the schedule and replication configs of the namespace
+// overridden on the global ones.
+func (n *namespaceOption) resolve(schedule *ScheduleConfig, replication *ReplicationConfig) (*ScheduleConfig, *ReplicationConfig) {
+	cfg := n.load()
+	if r, ok := n.resolved.Load().(*resolvedNamespaceConfig); ok && r.namespace == cfg && r.global == schedule && r.globalRep == replication {
+		return r.schedule, r.replication
+	}
+	sc, rc, err := cfg.resolve(schedule, replication)
+	if err != nil {
+		log.Errorf("failed to resolve namespace config %+v: %v", cfg, err)
+		sc, rc = schedule, replication
+	}
+	n.resolved.Store(&resolvedNamespaceConfig{
+		namespace:   cfg,
+		global:      schedule,
+		globalRep:   replication,
+		schedule:    sc,
+		replication: rc,
+	})
+	return sc, rc
+}
+
 // GetMaxReplicas returns the number of replicas for each region.
 func (n *namespaceOption) GetMaxReplicas() int {
 	return int(n.load().MaxReplicas)
 }
 
+// GetLearnerReplicas returns the number of read-only learners for each
+// region.
+func (n *namespaceOption) GetLearnerReplicas() int {
+	return int(n.load().LearnerReplicas)
+}
+
 // GetLeaderScheduleLimit returns the limit for leader schedule.
 func (n *namespaceOption) GetLeaderScheduleLimit() uint64 {
 	return n.load().LeaderScheduleLimit
@@ -426,3 +696,15 @@ func (n *namespaceOption) GetReplicaScheduleLimit() uint64 {
 func (n *namespaceOption) GetMergeScheduleLimit() uint64 {
 	return n.load().MergeScheduleLimit
 }
+
+// GetLeaderScoreStrategy returns the strategy to score the stores to balance
+// leaders.
+func (n *namespaceOption) GetLeaderScoreStrategy() string {
+	return n.load().LeaderScoreStrategy
+}
+
+// GetRegionScoreStrategy returns the strategy to score the stores to balance
+// regions.
+func (n *namespaceOption) GetRegionScoreStrategy() string {
+	return n.load().RegionScoreStrategy
+}
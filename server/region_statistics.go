@@ -18,6 +18,8 @@ import (
 
 	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/server/namespace"
+	"github.com/pingcap/pd/server/schedule"
+	"github.com/pingcap/pd/server/schedule/placement"
 )
 
 type regionStatisticType uint32
@@ -30,21 +32,25 @@ const (
 	offlinePeer
 	incorrectNamespace
 	learnerPeer
+	missLearner
+	extraLearner
 )
 
 type regionStatistics struct {
-	opt        *scheduleOption
-	classifier namespace.Classifier
-	stats      map[regionStatisticType]map[uint64]*core.RegionInfo
-	index      map[uint64]regionStatisticType
+	opt              *scheduleOption
+	classifier       namespace.Classifier
+	rangeConstraints *placement.RangeConstraintManager
+	stats            map[regionStatisticType]map[uint64]*core.RegionInfo
+	index            map[uint64]regionStatisticType
 }
 
-func newRegionStatistics(opt *scheduleOption, classifier namespace.Classifier) *regionStatistics {
+func newRegionStatistics(opt *scheduleOption, classifier namespace.Classifier, rangeConstraints *placement.RangeConstraintManager) *regionStatistics {
 	r := &regionStatistics{
-		opt:        opt,
-		classifier: classifier,
-		stats:      make(map[regionStatisticType]map[uint64]*core.RegionInfo),
-		index:      make(map[uint64]regionStatisticType),
+		opt:              opt,
+		classifier:       classifier,
+		rangeConstraints: rangeConstraints,
+		stats:            make(map[regionStatisticType]map[uint64]*core.RegionInfo),
+		index:            make(map[uint64]regionStatisticType),
 	}
 	r.stats[missPeer] = make(map[uint64]*core.RegionInfo)
 	r.stats[extraPeer] = make(map[uint64]*core.RegionInfo)
@@ -53,6 +59,8 @@ func newRegionStatistics(opt *scheduleOption, classifier namespace.Classifier) *
 	r.stats[offlinePeer] = make(map[uint64]*core.RegionInfo)
 	r.stats[incorrectNamespace] = make(map[uint64]*core.RegionInfo)
 	r.stats[learnerPeer] = make(map[uint64]*core.RegionInfo)
+	r.stats[missLearner] = make(map[uint64]*core.RegionInfo)
+	r.stats[extraLearner] = make(map[uint64]*core.RegionInfo)
 	return r
 }
 
@@ -80,14 +88,31 @@ func (r *regionStatistics) Observe(region *core.RegionInfo, stores []*core.Store
 		peerTypeIndex regionStatisticType
 		deleteIndex   regionStatisticType
 	)
-	if len(region.Peers) < r.opt.GetMaxReplicas(namespace) {
+	// The read-only learners are counted separately.
+	learnerReplicas, learnerConstraints := r.rangeConstraints.GetLearnerReplicasForRegion(region,
+		r.opt.GetLearnerReplicas(namespace), placement.SelectorConstraints(r.opt.GetLearnerLabels()))
+	var learners int
+	for _, store := range stores {
+		if region.GetStorePeer(store.GetId()).GetIsLearner() && schedule.IsLearnerStore(store, learnerConstraints) {
+			learners++
+		}
+	}
+	if replicas := len(region.Peers) - learners; replicas < r.opt.GetMaxReplicas(namespace) {
 		r.stats[missPeer][regionID] = region
 		peerTypeIndex |= missPeer
-	} else if len(region.Peers) > r.opt.GetMaxReplicas(namespace) {
+	} else if replicas > r.opt.GetMaxReplicas(namespace) {
 		r.stats[extraPeer][regionID] = region
 		peerTypeIndex |= extraPeer
 	}
 
+	if learners < learnerReplicas {
+		r.stats[missLearner][regionID] = region
+		peerTypeIndex |= missLearner
+	} else if learners > learnerReplicas {
+		r.stats[extraLearner][regionID] = region
+		peerTypeIndex |= extraLearner
+	}
+
 	if len(region.DownPeers) > 0 {
 		r.stats[downPeer][regionID] = region
 		peerTypeIndex |= downPeer
@@ -141,6 +166,8 @@ func (r *regionStatistics) Collect() {
 	regionStatusGauge.WithLabelValues("offline_peer_region_count").Set(float64(len(r.stats[offlinePeer])))
 	regionStatusGauge.WithLabelValues("incorrect_namespace_region_count").Set(float64(len(r.stats[incorrectNamespace])))
 	regionStatusGauge.WithLabelValues("learner_peer_region_count").Set(float64(len(r.stats[learnerPeer])))
+	regionStatusGauge.WithLabelValues("miss_learner_region_count").Set(float64(len(r.stats[missLearner])))
+	regionStatusGauge.WithLabelValues("extra_learner_region_count").Set(float64(len(r.stats[extraLearner])))
 }
 
 type labelLevelStatistics struct {
@@ -157,7 +184,7 @@ func newLabelLevelStatistics() *labelLevelStatistics {
 
 func (l *labelLevelStatistics) Observe(region *core.RegionInfo, stores []*core.StoreInfo, labels []string) {
 	regionID := region.GetId()
-	regionLabelLevel := getRegionLabelIsolationLevel(stores, labels)
+	regionLabelLevel := schedule.GetIsolationLevel(stores, labels)
 	if level, ok := l.regionLabelLevelStats[regionID]; ok {
 		if level == regionLabelLevel {
 			return
@@ -181,42 +208,3 @@ func (l *labelLevelStatistics) clearDefunctRegion(regionID uint64) {
 		delete(l.regionLabelLevelStats, regionID)
 	}
 }
-
-func getRegionLabelIsolationLevel(stores []*core.StoreInfo, labels []string) int {
-	if len(stores) == 0 || len(labels) == 0 {
-		return 0
-	}
-	queueStores := [][]*core.StoreInfo{stores}
-	for level, label := range labels {
-		newQueueStores := make([][]*core.StoreInfo, 0, len(stores))
-		for _, stores := range queueStores {
-			notIsolatedStores := notIsolatedStoresWithLabel(stores, label)
-			if len(notIsolatedStores) > 0 {
-				newQueueStores = append(newQueueStores, notIsolatedStores...)
-			}
-		}
-		queueStores = newQueueStores
-		if len(queueStores) == 0 {
-			return level + 1
-		}
-	}
-	return 0
-}
-
-func notIsolatedStoresWithLabel(stores []*core.StoreInfo, label string) [][]*core.StoreInfo {
-	m := make(map[string][]*core.StoreInfo)
-	for _, s := range stores {
-		labelValue := s.GetLabelValue(label)
-		if labelValue == "" {
-			continue
-		}
-		m[labelValue] = append(m[labelValue], s)
-	}
-	var res [][]*core.StoreInfo
-	for _, stores := range m {
-		if len(stores) > 1 {
-			res = append(res, stores)
-		}
-	}
-	return res
-}
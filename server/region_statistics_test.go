@@ -18,6 +18,8 @@ import (
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/schedule"
+	"github.com/pingcap/pd/server/schedule/placement"
 )
 
 type mockClassifier struct{}
@@ -79,7 +81,7 @@ func (t *testRegionStatisticsSuite) TestRegionStatistics(c *C) {
 	r2 := &metapb.Region{Id: 2, Peers: peers[0:2], StartKey: []byte("cc"), EndKey: []byte("dd")}
 	region1 := core.NewRegionInfo(r1, peers[0])
 	region2 := core.NewRegionInfo(r2, peers[0])
-	regionStats := newRegionStatistics(opt, mockClassifier{})
+	regionStats := newRegionStatistics(opt, mockClassifier{}, placement.NewRangeConstraintManager(core.NewKV(core.NewMemoryKV())))
 	regionStats.Observe(region1, stores)
 	c.Assert(len(regionStats.stats[extraPeer]), Equals, 1)
 	c.Assert(len(regionStats.stats[learnerPeer]), Equals, 1)
@@ -119,6 +121,62 @@ func (t *testRegionStatisticsSuite) TestRegionStatistics(c *C) {
 	c.Assert(len(regionStats.stats[offlinePeer]), Equals, 0)
 }
 
+func (t *testRegionStatisticsSuite) TestLearnerStatistics(c *C) {
+	_, opt := newTestScheduleConfig()
+	cfg := opt.GetReplication().load().clone()
+	cfg.LearnerReplicas = 1
+	cfg.LearnerLabels = map[string]string{"engine": "analytic"}
+	opt.GetReplication().store(cfg)
+	var stores []*core.StoreInfo
+	for id := uint64(1); id <= 5; id++ {
+		store := core.NewStoreInfo(&metapb.Store{Id: id})
+		if id > 3 {
+			store.Labels = []*metapb.StoreLabel{{Key: "engine", Value: "analytic"}}
+		}
+		stores = append(stores, store)
+	}
+	peers := []*metapb.Peer{
+		{Id: 11, StoreId: 1},
+		{Id: 12, StoreId: 2},
+		{Id: 13, StoreId: 3},
+		{Id: 14, StoreId: 4, IsLearner: true},
+		{Id: 15, StoreId: 5, IsLearner: true},
+	}
+	rangeConstraints := placement.NewRangeConstraintManager(core.NewKV(core.NewMemoryKV()))
+	regionStats := newRegionStatistics(opt, mockClassifier{}, rangeConstraints)
+
+	// The read-only learners are not counted as the replicas.
+	region := core.NewRegionInfo(&metapb.Region{Id: 1, Peers: peers[:4]}, peers[0])
+	regionStats.Observe(region, stores[:4])
+	c.Assert(regionStats.stats[missPeer], HasLen, 0)
+	c.Assert(regionStats.stats[extraPeer], HasLen, 0)
+	c.Assert(regionStats.stats[missLearner], HasLen, 0)
+	c.Assert(regionStats.stats[extraLearner], HasLen, 0)
+
+	region = core.NewRegionInfo(&metapb.Region{Id: 1, Peers: peers}, peers[0])
+	regionStats.Observe(region, stores)
+	c.Assert(regionStats.stats[extraPeer], HasLen, 0)
+	c.Assert(regionStats.stats[extraLearner], HasLen, 1)
+
+	region = core.NewRegionInfo(&metapb.Region{Id: 1, Peers: peers[:3]}, peers[0])
+	regionStats.Observe(region, stores[:3])
+	c.Assert(regionStats.stats[extraLearner], HasLen, 0)
+	c.Assert(regionStats.stats[missLearner], HasLen, 1)
+
+	// The range constraint overrides the number of learners.
+	err := rangeConstraints.SetConstraint(&placement.RangeConstraint{
+		ID:                      "two-learners",
+		LearnerReplicas:         2,
+		LearnerLabelConstraints: []placement.LabelConstraint{{Key: "engine", Op: placement.In, Values: []string{"analytic"}}},
+	})
+	c.Assert(err, IsNil)
+	region = core.NewRegionInfo(&metapb.Region{Id: 1, Peers: peers}, peers[0])
+	regionStats.Observe(region, stores)
+	c.Assert(regionStats.stats[missLearner], HasLen, 0)
+	c.Assert(regionStats.stats[extraLearner], HasLen, 0)
+	c.Assert(regionStats.stats[missPeer], HasLen, 0)
+}
+
 func (t *testRegionStatisticsSuite) TestRegionLabelIsolationLevel(c *C) {
 	labelLevelStats := newLabelLevelStatistics()
 	labelsSet := [][]map[string]string{
@@ -166,7 +224,7 @@ func (t *testRegionStatisticsSuite) TestRegionLabelIsolationLevel(c *C) {
 			stores = append(stores, s)
 		}
 		region := core.NewRegionInfo(&metapb.Region{Id: uint64(regionID)}, nil)
-		level := getRegionLabelIsolationLevel(stores, []string{"zone", "rack", "host"})
+		level := schedule.GetIsolationLevel(stores, []string{"zone", "rack", "host"})
 		labelLevelStats.Observe(region, stores, []string{"zone", "rack", "host"})
 		c.Assert(level, Equals, res)
 		regionID++
@@ -179,8 +237,8 @@ func (t *testRegionStatisticsSuite) TestRegionLabelIsolationLevel(c *C) {
 		c.Assert(labelLevelStats.labelLevelCounter[i], Equals, res)
 	}
 
-	level := getRegionLabelIsolationLevel(nil, []string{"zone", "rack", "host"})
+	level := schedule.GetIsolationLevel(nil, []string{"zone", "rack", "host"})
 	c.Assert(level, Equals, 0)
-	level = getRegionLabelIsolationLevel(nil, nil)
+	level = schedule.GetIsolationLevel(nil, nil)
 	c.Assert(level, Equals, 0)
 }
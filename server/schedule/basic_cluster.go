@@ -30,9 +30,12 @@ const (
 	statCacheMaxLen              = 1000
 	hotWriteRegionMinFlowRate    = 16 * 1024
 	hotReadRegionMinFlowRate     = 128 * 1024
+	hotWriteRegionMinKeyRate     = 256
+	hotReadRegionMinKeyRate      = 512
 	storeHeartBeatReportInterval = 10
 	minHotRegionReportInterval   = 3
 	hotRegionAntiCount           = 1
+	storeHotRegionsDefaultLen    = 100
 )
 
 // BasicCluster provides basic data member and interface for a tikv cluster.
@@ -40,6 +43,9 @@ type BasicCluster struct {
 	Stores   *core.StoresInfo
 	Regions  *core.RegionsInfo
 	HotCache *HotSpotCache
+	// LeaderPreference is set by the preferred leader scheduler, other leader
+	// schedulers should not go against it.
+	LeaderPreference LeaderPreference
 }
 
 // NewOpInfluence creates a OpInfluence.
@@ -103,6 +109,27 @@ func (s StoreInfluence) ResourceSize(kind core.ResourceKind) int64 {
 	}
 }
 
+// ResourceCount returns delta count of leader/region by influence.
+func (s StoreInfluence) ResourceCount(kind core.ResourceKind) int64 {
+	switch kind {
+	case core.LeaderKind:
+		return s.LeaderCount
+	case core.RegionKind:
+		return s.RegionCount
+	default:
+		return 0
+	}
+}
+
+// ResourceDelta returns the delta of leader/region score by influence, which
+// is the count when scoring by count and the size otherwise.
+func (s StoreInfluence) ResourceDelta(kind core.ResourceKind, strategy string) int64 {
+	if strategy == core.ScoreByCount {
+		return s.ResourceCount(kind)
+	}
+	return s.ResourceSize(kind)
+}
+
 // NewBasicCluster creates a BasicCluster.
 func NewBasicCluster() *BasicCluster {
 	return &BasicCluster{
@@ -169,11 +196,36 @@ func (bc *BasicCluster) UnblockStore(storeID uint64) {
 	bc.Stores.UnblockStore(storeID)
 }
 
+// BlockStoreLeader stops balancer from transferring leaders to the store.
+func (bc *BasicCluster) BlockStoreLeader(storeID uint64) error {
+	return errors.Trace(bc.Stores.BlockStoreLeader(storeID))
+}
+
+// UnblockStoreLeader allows balancer to transfer leaders to the store.
+func (bc *BasicCluster) UnblockStoreLeader(storeID uint64) {
+	bc.Stores.UnblockStoreLeader(storeID)
+}
+
+// SetLeaderPreference sets the preference of the stores to place leaders.
+func (bc *BasicCluster) SetLeaderPreference(preference LeaderPreference) {
+	bc.LeaderPreference = preference
+}
+
+// GetLeaderPreference returns the preference of the stores to place leaders.
+func (bc *BasicCluster) GetLeaderPreference() LeaderPreference {
+	return bc.LeaderPreference
+}
+
 // RandFollowerRegion returns a random region that has a follower on the store.
 func (bc *BasicCluster) RandFollowerRegion(storeID uint64, opts ...core.RegionOption) *core.RegionInfo {
 	return bc.Regions.RandFollowerRegion(storeID, opts...)
 }
 
+// RandLearnerRegion returns a random region that has a learner on the store.
+func (bc *BasicCluster) RandLearnerRegion(storeID uint64, opts ...core.RegionOption) *core.RegionInfo {
+	return bc.Regions.RandLearnerRegion(storeID, opts...)
+}
+
 // RandLeaderRegion returns a random region that has leader on the store.
 func (bc *BasicCluster) RandLeaderRegion(storeID uint64, opts ...core.RegionOption) *core.RegionInfo {
 	return bc.Regions.RandLeaderRegion(storeID, opts...)
@@ -189,6 +241,11 @@ func (bc *BasicCluster) IsRegionHot(id uint64, hotThreshold int) bool {
 	return bc.HotCache.isRegionHot(id, hotThreshold)
 }
 
+// GetHotRegionStat returns the flow statistics of the region in the hot cache.
+func (bc *BasicCluster) GetHotRegionStat(id uint64, kind FlowKind) *core.RegionStat {
+	return bc.HotCache.RegionStat(id, kind)
+}
+
 // RegionWriteStats returns hot region's write stats.
 func (bc *BasicCluster) RegionWriteStats() []*core.RegionStat {
 	return bc.HotCache.RegionStats(WriteFlow)
@@ -211,12 +268,12 @@ func (bc *BasicCluster) PutRegion(region *core.RegionInfo) error {
 	return nil
 }
 
-// CheckWriteStatus checks the write status, returns whether need update statistics and item.
-func (bc *BasicCluster) CheckWriteStatus(region *core.RegionInfo) (bool, *core.RegionStat) {
-	return bc.HotCache.CheckWrite(region, bc.Stores)
+// CheckWriteStatus checks the write status, returns the peer statistics need to be updated.
+func (bc *BasicCluster) CheckWriteStatus(region *core.RegionInfo, windowSize int) []*core.RegionStat {
+	return bc.HotCache.CheckWrite(region, bc.Stores, windowSize)
 }
 
-// CheckReadStatus checks the read status, returns whether need update statistics and item.
-func (bc *BasicCluster) CheckReadStatus(region *core.RegionInfo) (bool, *core.RegionStat) {
-	return bc.HotCache.CheckRead(region, bc.Stores)
+// CheckReadStatus checks the read status, returns the peer statistics need to be updated.
+func (bc *BasicCluster) CheckReadStatus(region *core.RegionInfo, windowSize int) []*core.RegionStat {
+	return bc.HotCache.CheckRead(region, bc.Stores, windowSize)
 }
@@ -0,0 +1,133 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"sort"
+
+	"github.com/pingcap/pd/server/core"
+)
+
+// Explanation is the trace of how a scheduler or checker evaluates a region
+// or a store. It is produced without creating any operator.
+type Explanation struct {
+	Scheduler string `json:"scheduler"`
+	// Type distinguishes the different paths of a scheduler, such as moving
+	// the leader or moving the peer of a hot region.
+	Type     string `json:"type,omitempty"`
+	RegionID uint64 `json:"region_id,omitempty"`
+	StoreID  uint64 `json:"store_id,omitempty"`
+	// Sources are the candidates of the source store.
+	Sources []*StoreExplanation `json:"sources,omitempty"`
+	// Targets are the candidates of the target store.
+	Targets []*StoreExplanation `json:"targets,omitempty"`
+	// Selected source and target store, they are zero if no store is selected.
+	SourceStoreID uint64 `json:"source_store_id,omitempty"`
+	TargetStoreID uint64 `json:"target_store_id,omitempty"`
+	// Result is the conclusion, such as why no operator will be created.
+	Result string `json:"result"`
+	// Details are the explanations of each path if the scheduler has more
+	// than one.
+	Details []*Explanation `json:"details,omitempty"`
+}
+
+// StoreExplanation describes how a candidate store is evaluated.
+type StoreExplanation struct {
+	StoreID uint64 `json:"store_id"`
+	// Filter is the type of the filter which rejects the store, it is empty
+	// if the store passes all filters.
+	Filter string  `json:"filter,omitempty"`
+	Score  float64 `json:"score"`
+	// Influence is the resource size that pending operators will change on
+	// the store.
+	Influence int64 `json:"influence"`
+	// Balance is the result of checking whether a region should be moved
+	// between the selected source store and this target store.
+	Balance *BalanceExplanation `json:"balance,omitempty"`
+	// Extra describes the factors other than resource score, such as the
+	// distinct score or the hot region statistics.
+	Extra map[string]interface{} `json:"extra,omitempty"`
+}
+
+// BalanceExplanation shows the scores used to decide whether a region should
+// be moved between two stores.
+type BalanceExplanation struct {
+	RegionSize        int64   `json:"region_size"`
+	AverageRegionSize int64   `json:"average_region_size"`
+	TolerantSizeRatio float64 `json:"tolerant_size_ratio"`
+	// TolerantSize is max(RegionSize, AverageRegionSize) * TolerantSizeRatio.
+	TolerantSize int64 `json:"tolerant_size"`
+	// The scores after counting the influence and the tolerant size.
+	SourceScore   float64 `json:"source_score"`
+	TargetScore   float64 `json:"target_score"`
+	ShouldBalance bool    `json:"should_balance"`
+}
+
+// ExplainableScheduler is a scheduler which can explain its decision for a
+// region or a store.
+type ExplainableScheduler interface {
+	Scheduler
+	// Explain evaluates the region or the store specified by a non-zero ID.
+	Explain(cluster Cluster, opInfluence OpInfluence, regionID, storeID uint64) *Explanation
+}
+
+// FilterSourceType returns the type of the first filter which rejects the
+// store as a source store, or an empty string if there is none. Unlike
+// FilterSource, it does not update the filter metrics.
+func FilterSourceType(opt Options, store *core.StoreInfo, filters []Filter) string {
+	for _, filter := range filters {
+		if filter.FilterSource(opt, store) {
+			return filter.Type()
+		}
+	}
+	return ""
+}
+
+// FilterTargetType returns the type of the first filter which rejects the
+// store as a target store, or an empty string if there is none. Unlike
+// FilterTarget, it does not update the filter metrics.
+func FilterTargetType(opt Options, store *core.StoreInfo, filters []Filter) string {
+	for _, filter := range filters {
+		if filter.FilterTarget(opt, store) {
+			return filter.Type()
+		}
+	}
+	return ""
+}
+
+// ExplainStores evaluates the stores with the filters and the resource
+// score, as a source if isSource is true or as a target otherwise.
+func ExplainStores(opt Options, stores []*core.StoreInfo, filters []Filter, kind core.ResourceKind, opInfluence OpInfluence, isSource bool) []*StoreExplanation {
+	res := make([]*StoreExplanation, 0, len(stores))
+	for _, store := range stores {
+		e := &StoreExplanation{
+			StoreID:   store.GetId(),
+			Score:     StoreScore(opt, store, kind, 0),
+			Influence: opInfluence.GetStoreInfluence(store.GetId()).ResourceDelta(kind, ScoreStrategy(opt, kind)),
+		}
+		if isSource {
+			e.Filter = FilterSourceType(opt, store, filters)
+		} else {
+			e.Filter = FilterTargetType(opt, store, filters)
+		}
+		res = append(res, e)
+	}
+	SortStoreExplanations(res)
+	return res
+}
+
+// SortStoreExplanations sorts the store explanations by store ID.
+func SortStoreExplanations(stores []*StoreExplanation) {
+	sort.Slice(stores, func(i, j int) bool { return stores[i].StoreID < stores[j].StoreID })
+}
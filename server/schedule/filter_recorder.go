@@ -0,0 +1,147 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pingcap/pd/server/core"
+)
+
+const (
+	filterSourceAction = "filter-source"
+	filterTargetAction = "filter-target"
+
+	// filterRecordCapacity is the max number of the sampled records.
+	filterRecordCapacity = 1024
+	// filterRecordSampleInterval is the min interval between two sampled
+	// records of the same scope, action, filter and store.
+	filterRecordSampleInterval = time.Second
+)
+
+// FilterRecord is a sampled record of a store rejected by a filter.
+type FilterRecord struct {
+	Time    time.Time `json:"time"`
+	Scope   string    `json:"scope"`
+	Type    string    `json:"type"`
+	Action  string    `json:"action"`
+	StoreID uint64    `json:"store_id"`
+	// Count is the number of rejections represented by the record, including
+	// the ones skipped by sampling since the previous record.
+	Count uint64 `json:"count"`
+}
+
+type filterRecordKey struct {
+	scope   string
+	typ     string
+	action  string
+	storeID uint64
+}
+
+type filterSample struct {
+	last    time.Time
+	skipped uint64
+}
+
+// filterRecorder keeps a bounded sample of the recent rejections.
+type filterRecorder struct {
+	sync.Mutex
+	interval time.Duration
+	// records is a ring buffer, next is the position of the next record.
+	records []*FilterRecord
+	next    int
+	samples map[filterRecordKey]*filterSample
+}
+
+func newFilterRecorder(capacity int, interval time.Duration) *filterRecorder {
+	return &filterRecorder{
+		interval: interval,
+		records:  make([]*FilterRecord, 0, capacity),
+		samples:  make(map[filterRecordKey]*filterSample),
+	}
+}
+
+func (r *filterRecorder) record(action string, filter Filter, storeID uint64, now time.Time) {
+	key := filterRecordKey{scope: filter.Scope(), typ: filter.Type(), action: action, storeID: storeID}
+
+	r.Lock()
+	defer r.Unlock()
+
+	sample, ok := r.samples[key]
+	if ok && now.Sub(sample.last) < r.interval {
+		sample.skipped++
+		return
+	}
+	if !ok {
+		r.gcSamples(now)
+		sample = &filterSample{}
+		r.samples[key] = sample
+	}
+	record := &FilterRecord{
+		Time:    now,
+		Scope:   key.scope,
+		Type:    key.typ,
+		Action:  action,
+		StoreID: storeID,
+		Count:   sample.skipped + 1,
+	}
+	sample.last, sample.skipped = now, 0
+
+	if len(r.records) < cap(r.records) {
+		r.records = append(r.records, record)
+	} else {
+		r.records[r.next] = record
+	}
+	r.next = (r.next + 1) % cap(r.records)
+}
+
+// gcSamples removes the expired samples if there are too many of them.
+func (r *filterRecorder) gcSamples(now time.Time) {
+	if len(r.samples) < cap(r.records) {
+		return
+	}
+	for key, sample := range r.samples {
+		if now.Sub(sample.last) >= r.interval {
+			delete(r.samples, key)
+		}
+	}
+}
+
+// getRecords returns the records from the newest to the oldest.
+func (r *filterRecorder) getRecords() []FilterRecord {
+	r.Lock()
+	defer r.Unlock()
+
+	records := make([]FilterRecord, 0, len(r.records))
+	for i := 1; i <= len(r.records); i++ {
+		pos := (r.next - i + len(r.records)) % len(r.records)
+		records = append(records, *r.records[pos])
+	}
+	return records
+}
+
+var filterRecords = newFilterRecorder(filterRecordCapacity, filterRecordSampleInterval)
+
+func recordFilter(action string, filter Filter, store *core.StoreInfo) {
+	filterCounter.WithLabelValues(action, filter.Scope(), filter.Type(), fmt.Sprintf("store%d", store.GetId())).Inc()
+	filterRecords.record(action, filter, store.GetId(), time.Now())
+}
+
+// GetFilterRecords returns the sampled records of the recent rejections by
+// filters, from the newest to the oldest.
+func GetFilterRecords() []FilterRecord {
+	return filterRecords.getRecords()
+}
@@ -14,11 +14,11 @@
 package schedule
 
 import (
-	"fmt"
-
+	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/pd/server/cache"
 	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/server/namespace"
+	"github.com/pingcap/pd/server/schedule/placement"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -26,6 +26,8 @@ import (
 
 // Filter is an interface to filter source and target store.
 type Filter interface {
+	// Scope is the name of the scheduler or checker which uses the filter.
+	Scope() string
 	Type() string
 	// Return true if the store should not be used as a source store.
 	FilterSource(opt Options, store *core.StoreInfo) bool
@@ -35,11 +37,10 @@ type Filter interface {
 
 // FilterSource checks if store can pass all Filters as source store.
 func FilterSource(opt Options, store *core.StoreInfo, filters []Filter) bool {
-	storeID := fmt.Sprintf("store%d", store.GetId())
 	for _, filter := range filters {
 		if filter.FilterSource(opt, store) {
 			log.Debugf("[filter %T] filters store %v from source", filter, store)
-			filterCounter.WithLabelValues("filter-source", storeID, filter.Type()).Inc()
+			recordFilter(filterSourceAction, filter, store)
 			return true
 		}
 	}
@@ -48,11 +49,10 @@ func FilterSource(opt Options, store *core.StoreInfo, filters []Filter) bool {
 
 // FilterTarget checks if store can pass all Filters as target store.
 func FilterTarget(opt Options, store *core.StoreInfo, filters []Filter) bool {
-	storeID := fmt.Sprintf("store%d", store.GetId())
 	for _, filter := range filters {
 		if filter.FilterTarget(opt, store) {
 			log.Debugf("[filter %T] filters store %v from target", filter, store)
-			filterCounter.WithLabelValues("filter-target", storeID, filter.Type()).Inc()
+			recordFilter(filterTargetAction, filter, store)
 			return true
 		}
 	}
@@ -60,18 +60,24 @@ func FilterTarget(opt Options, store *core.StoreInfo, filters []Filter) bool {
 }
 
 type excludedFilter struct {
+	scope   string
 	sources map[uint64]struct{}
 	targets map[uint64]struct{}
 }
 
 // NewExcludedFilter creates a Filter that filters all specified stores.
-func NewExcludedFilter(sources, targets map[uint64]struct{}) Filter {
+func NewExcludedFilter(scope string, sources, targets map[uint64]struct{}) Filter {
 	return &excludedFilter{
+		scope:   scope,
 		sources: sources,
 		targets: targets,
 	}
 }
 
+func (f *excludedFilter) Scope() string {
+	return f.scope
+}
+
 func (f *excludedFilter) Type() string {
 	return "exclude-filter"
 }
@@ -86,11 +92,15 @@ func (f *excludedFilter) FilterTarget(opt Options, store *core.StoreInfo) bool {
 	return ok
 }
 
-type blockFilter struct{}
+type blockFilter struct{ scope string }
 
 // NewBlockFilter creates a Filter that filters all stores that are blocked from balance.
-func NewBlockFilter() Filter {
-	return &blockFilter{}
+func NewBlockFilter(scope string) Filter {
+	return &blockFilter{scope: scope}
+}
+
+func (f *blockFilter) Scope() string {
+	return f.scope
 }
 
 func (f *blockFilter) Type() string {
@@ -105,11 +115,15 @@ func (f *blockFilter) FilterTarget(opt Options, store *core.StoreInfo) bool {
 	return store.IsBlocked()
 }
 
-type stateFilter struct{}
+type stateFilter struct{ scope string }
 
 // NewStateFilter creates a Filter that filters all stores that are not UP.
-func NewStateFilter() Filter {
-	return &stateFilter{}
+func NewStateFilter(scope string) Filter {
+	return &stateFilter{scope: scope}
+}
+
+func (f *stateFilter) Scope() string {
+	return f.scope
 }
 
 func (f *stateFilter) Type() string {
@@ -124,11 +138,15 @@ func (f *stateFilter) FilterTarget(opt Options, store *core.StoreInfo) bool {
 	return !store.IsUp()
 }
 
-type healthFilter struct{}
+type healthFilter struct{ scope string }
 
 // NewHealthFilter creates a Filter that filters all stores that are Busy or Down.
-func NewHealthFilter() Filter {
-	return &healthFilter{}
+func NewHealthFilter(scope string) Filter {
+	return &healthFilter{scope: scope}
+}
+
+func (f *healthFilter) Scope() string {
+	return f.scope
 }
 
 func (f *healthFilter) Type() string {
@@ -150,11 +168,15 @@ func (f *healthFilter) FilterTarget(opt Options, store *core.StoreInfo) bool {
 	return f.filter(opt, store)
 }
 
-type disconnectFilter struct{}
+type disconnectFilter struct{ scope string }
 
 // NewDisconnectFilter creates a Filter that filters all stores that are disconnected.
-func NewDisconnectFilter() Filter {
-	return &disconnectFilter{}
+func NewDisconnectFilter(scope string) Filter {
+	return &disconnectFilter{scope: scope}
+}
+
+func (f *disconnectFilter) Scope() string {
+	return f.scope
 }
 
 func (f *disconnectFilter) Type() string {
@@ -169,12 +191,16 @@ func (f *disconnectFilter) FilterTarget(opt Options, store *core.StoreInfo) bool
 	return store.IsDisconnected()
 }
 
-type pendingPeerCountFilter struct{}
+type pendingPeerCountFilter struct{ scope string }
 
 // NewPendingPeerCountFilter creates a Filter that filters all stores that are
 // currently handling too many pending peers.
-func NewPendingPeerCountFilter() Filter {
-	return &pendingPeerCountFilter{}
+func NewPendingPeerCountFilter(scope string) Filter {
+	return &pendingPeerCountFilter{scope: scope}
+}
+
+func (p *pendingPeerCountFilter) Scope() string {
+	return p.scope
 }
 
 func (p *pendingPeerCountFilter) Type() string {
@@ -196,12 +222,16 @@ func (p *pendingPeerCountFilter) FilterTarget(opt Options, store *core.StoreInfo
 	return p.filter(opt, store)
 }
 
-type snapshotCountFilter struct{}
+type snapshotCountFilter struct{ scope string }
 
 // NewSnapshotCountFilter creates a Filter that filters all stores that are
 // currently handling too many snapshots.
-func NewSnapshotCountFilter() Filter {
-	return &snapshotCountFilter{}
+func NewSnapshotCountFilter(scope string) Filter {
+	return &snapshotCountFilter{scope: scope}
+}
+
+func (f *snapshotCountFilter) Scope() string {
+	return f.scope
 }
 
 func (f *snapshotCountFilter) Type() string {
@@ -223,12 +253,17 @@ func (f *snapshotCountFilter) FilterTarget(opt Options, store *core.StoreInfo) b
 }
 
 type cacheFilter struct {
+	scope string
 	cache *cache.TTLUint64
 }
 
 // NewCacheFilter creates a Filter that filters all stores that are in the cache.
-func NewCacheFilter(cache *cache.TTLUint64) Filter {
-	return &cacheFilter{cache: cache}
+func NewCacheFilter(scope string, cache *cache.TTLUint64) Filter {
+	return &cacheFilter{scope: scope, cache: cache}
+}
+
+func (f *cacheFilter) Scope() string {
+	return f.scope
 }
 
 func (f *cacheFilter) Type() string {
@@ -243,12 +278,16 @@ func (f *cacheFilter) FilterTarget(opt Options, store *core.StoreInfo) bool {
 	return false
 }
 
-type storageThresholdFilter struct{}
+type storageThresholdFilter struct{ scope string }
 
 // NewStorageThresholdFilter creates a Filter that filters all stores that are
 // almost full.
-func NewStorageThresholdFilter() Filter {
-	return &storageThresholdFilter{}
+func NewStorageThresholdFilter(scope string) Filter {
+	return &storageThresholdFilter{scope: scope}
+}
+
+func (f *storageThresholdFilter) Scope() string {
+	return f.scope
 }
 
 func (f *storageThresholdFilter) Type() string {
@@ -265,6 +304,7 @@ func (f *storageThresholdFilter) FilterTarget(opt Options, store *core.StoreInfo
 
 // distinctScoreFilter ensures that distinct score will not decrease.
 type distinctScoreFilter struct {
+	scope     string
 	labels    []string
 	stores    []*core.StoreInfo
 	safeScore float64
@@ -272,7 +312,7 @@ type distinctScoreFilter struct {
 
 // NewDistinctScoreFilter creates a filter that filters all stores that have
 // lower distinct score than specified store.
-func NewDistinctScoreFilter(labels []string, stores []*core.StoreInfo, source *core.StoreInfo) Filter {
+func NewDistinctScoreFilter(scope string, labels []string, stores []*core.StoreInfo, source *core.StoreInfo) Filter {
 	newStores := make([]*core.StoreInfo, 0, len(stores)-1)
 	for _, s := range stores {
 		if s.GetId() == source.GetId() {
@@ -282,12 +322,17 @@ func NewDistinctScoreFilter(labels []string, stores []*core.StoreInfo, source *c
 	}
 
 	return &distinctScoreFilter{
+		scope:     scope,
 		labels:    labels,
 		stores:    newStores,
 		safeScore: DistinctScore(labels, newStores, source),
 	}
 }
 
+func (f *distinctScoreFilter) Scope() string {
+	return f.scope
+}
+
 func (f *distinctScoreFilter) Type() string {
 	return "distinct-filter"
 }
@@ -301,19 +346,25 @@ func (f *distinctScoreFilter) FilterTarget(opt Options, store *core.StoreInfo) b
 }
 
 type namespaceFilter struct {
+	scope      string
 	classifier namespace.Classifier
 	namespace  string
 }
 
 // NewNamespaceFilter creates a Filter that filters all stores that are not
 // belong to a namespace.
-func NewNamespaceFilter(classifier namespace.Classifier, namespace string) Filter {
+func NewNamespaceFilter(scope string, classifier namespace.Classifier, namespace string) Filter {
 	return &namespaceFilter{
+		scope:      scope,
 		classifier: classifier,
 		namespace:  namespace,
 	}
 }
 
+func (f *namespaceFilter) Scope() string {
+	return f.scope
+}
+
 func (f *namespaceFilter) Type() string {
 	return "namespace-filter"
 }
@@ -330,12 +381,17 @@ func (f *namespaceFilter) FilterTarget(opt Options, store *core.StoreInfo) bool
 	return f.filter(store)
 }
 
-type rejectLeaderFilter struct{}
+type rejectLeaderFilter struct{ scope string }
 
 // NewRejectLeaderFilter creates a Filter that filters stores that marked as
-// rejectLeader from being the target of leader transfer.
-func NewRejectLeaderFilter() Filter {
-	return rejectLeaderFilter{}
+// rejectLeader or blocked from receiving leaders from being the target of
+// leader transfer.
+func NewRejectLeaderFilter(scope string) Filter {
+	return rejectLeaderFilter{scope: scope}
+}
+
+func (f rejectLeaderFilter) Scope() string {
+	return f.scope
 }
 
 func (f rejectLeaderFilter) Type() string {
@@ -347,5 +403,322 @@ func (f rejectLeaderFilter) FilterSource(opt Options, store *core.StoreInfo) boo
 }
 
 func (f rejectLeaderFilter) FilterTarget(opt Options, store *core.StoreInfo) bool {
-	return opt.CheckLabelProperty(RejectLeader, store.Labels)
+	return store.IsLeaderBlocked() || opt.CheckLabelProperty(RejectLeader, store.Labels) || opt.CheckLabelProperty(ReadOnly, store.Labels)
+}
+
+type rejectRegionFilter struct{ scope string }
+
+// NewRejectRegionFilter creates a Filter that filters stores that marked as
+// rejectRegion or readOnly from being the target of new peers.
+func NewRejectRegionFilter(scope string) Filter {
+	return rejectRegionFilter{scope: scope}
+}
+
+func (f rejectRegionFilter) Scope() string {
+	return f.scope
+}
+
+func (f rejectRegionFilter) Type() string {
+	return "reject-region-filter"
+}
+
+func (f rejectRegionFilter) FilterSource(opt Options, store *core.StoreInfo) bool {
+	return false
+}
+
+func (f rejectRegionFilter) FilterTarget(opt Options, store *core.StoreInfo) bool {
+	return opt.CheckLabelProperty(RejectRegion, store.Labels) || opt.CheckLabelProperty(ReadOnly, store.Labels)
+}
+
+type noBalanceFilter struct{ scope string }
+
+// NewNoBalanceFilter creates a Filter that filters stores that marked as
+// noBalance from being the source or target of balancing.
+func NewNoBalanceFilter(scope string) Filter {
+	return noBalanceFilter{scope: scope}
+}
+
+func (f noBalanceFilter) Scope() string {
+	return f.scope
+}
+
+func (f noBalanceFilter) Type() string {
+	return "no-balance-filter"
+}
+
+func (f noBalanceFilter) FilterSource(opt Options, store *core.StoreInfo) bool {
+	return opt.CheckLabelProperty(NoBalance, store.Labels)
+}
+
+func (f noBalanceFilter) FilterTarget(opt Options, store *core.StoreInfo) bool {
+	return opt.CheckLabelProperty(NoBalance, store.Labels)
+}
+
+type labelConstraintFilter struct {
+	scope       string
+	constraints []placement.LabelConstraint
+}
+
+// NewLabelConstraintFilter creates a Filter that filters all stores that do
+// not match the label constraints of a placement rule from being the target.
+func NewLabelConstraintFilter(scope string, constraints []placement.LabelConstraint) Filter {
+	return &labelConstraintFilter{scope: scope, constraints: constraints}
+}
+
+func (f *labelConstraintFilter) Scope() string {
+	return f.scope
+}
+
+func (f *labelConstraintFilter) Type() string {
+	return "label-constraint-filter"
+}
+
+func (f *labelConstraintFilter) FilterSource(opt Options, store *core.StoreInfo) bool {
+	return false
+}
+
+func (f *labelConstraintFilter) FilterTarget(opt Options, store *core.StoreInfo) bool {
+	return !placement.MatchLabelConstraints(store, f.constraints)
+}
+
+// isolationFilter ensures that no two stores are at the same location of the
+// isolation level.
+type isolationFilter struct {
+	scope  string
+	labels []string
+	stores []*core.StoreInfo
+}
+
+// NewIsolationFilter creates a Filter that filters all stores that are at the
+// same location of the isolation level with any of the specified stores.
+func NewIsolationFilter(scope string, isolationLevel string, locationLabels []string, stores []*core.StoreInfo) Filter {
+	f := &isolationFilter{scope: scope, stores: stores}
+	for i, label := range locationLabels {
+		if label == isolationLevel {
+			f.labels = locationLabels[:i+1]
+			break
+		}
+	}
+	return f
+}
+
+func (f *isolationFilter) Scope() string {
+	return f.scope
+}
+
+func (f *isolationFilter) Type() string {
+	return "isolation-filter"
+}
+
+func (f *isolationFilter) FilterSource(opt Options, store *core.StoreInfo) bool {
+	return false
+}
+
+func (f *isolationFilter) FilterTarget(opt Options, store *core.StoreInfo) bool {
+	if len(f.labels) == 0 {
+		return false
+	}
+	for _, s := range f.stores {
+		if s.GetId() != store.GetId() && s.CompareLocation(store, f.labels) == -1 {
+			return true
+		}
+	}
+	return false
+}
+
+// rangeConstraintFilter ensures that the peers of a region are placed on the
+// stores matching the range constraints of the region.
+type rangeConstraintFilter struct {
+	scope       string
+	constraints []placement.LabelConstraint
+}
+
+// NewRangeConstraintFilter creates a Filter that filters all stores that do
+// not match the range constraints of the region from being the target.
+func NewRangeConstraintFilter(scope string, cluster Cluster, region *core.RegionInfo) Filter {
+	return &rangeConstraintFilter{
+		scope:       scope,
+		constraints: cluster.GetRangeConstraintManager().GetLabelConstraintsForRegion(region),
+	}
+}
+
+func (f *rangeConstraintFilter) Scope() string {
+	return f.scope
+}
+
+func (f *rangeConstraintFilter) Type() string {
+	return "range-constraint-filter"
+}
+
+func (f *rangeConstraintFilter) FilterSource(opt Options, store *core.StoreInfo) bool {
+	return false
+}
+
+func (f *rangeConstraintFilter) FilterTarget(opt Options, store *core.StoreInfo) bool {
+	return !placement.MatchLabelConstraints(store, f.constraints)
+}
+
+// ruleFitFilter ensures that moving a peer of a region doesn't make the
+// region fit its placement rules worse, otherwise the replica checker would
+// move it back.
+type ruleFitFilter struct {
+	scope   string
+	cluster Cluster
+	region  *core.RegionInfo
+	oldPeer *metapb.Peer
+	rules   []*placement.Rule
+	oldFit  *placement.RegionFit
+}
+
+// NewRuleFitFilter creates a Filter that filters all stores that make the
+// region fit its placement rules worse if the peer on the old store is moved
+// to them. It filters nothing if the placement rules are disabled.
+func NewRuleFitFilter(scope string, cluster Cluster, region *core.RegionInfo, oldStoreID uint64) Filter {
+	f := &ruleFitFilter{
+		scope:   scope,
+		cluster: cluster,
+		region:  region,
+		oldPeer: region.GetStorePeer(oldStoreID),
+	}
+	if cluster.IsPlacementRulesEnabled() && f.oldPeer != nil {
+		f.rules = cluster.GetRuleManager().GetRulesForRegion(region)
+		if len(f.rules) > 0 {
+			f.oldFit = placement.FitRegion(cluster.GetRegionStores(region), region, f.rules)
+		}
+	}
+	return f
+}
+
+func (f *ruleFitFilter) Scope() string {
+	return f.scope
+}
+
+func (f *ruleFitFilter) Type() string {
+	return "rule-fit-filter"
+}
+
+func (f *ruleFitFilter) FilterSource(opt Options, store *core.StoreInfo) bool {
+	return false
+}
+
+func (f *ruleFitFilter) FilterTarget(opt Options, store *core.StoreInfo) bool {
+	if f.oldFit == nil {
+		return false
+	}
+	region := f.region.Clone()
+	region.RemoveStorePeer(f.oldPeer.GetStoreId())
+	region.AddPeer(&metapb.Peer{StoreId: store.GetId(), IsLearner: f.oldPeer.GetIsLearner()})
+	stores := f.cluster.GetRegionStores(region)
+	newFit := placement.FitRegion(stores, region, f.rules)
+	if cmp := placement.CompareRegionFit(newFit, f.oldFit); cmp != 0 {
+		return cmp < 0
+	}
+	// The rules fit equally well, the new peer should not be placed at a
+	// worse location of its rule either.
+	rf := getStoreRuleFit(newFit, store.GetId())
+	if rf == nil || len(rf.Rule.LocationLabels) == 0 {
+		return false
+	}
+	var ruleStores []*core.StoreInfo
+	for _, s := range stores {
+		if s.GetId() != store.GetId() && getStoreRuleFit(newFit, s.GetId()) == rf {
+			ruleStores = append(ruleStores, s)
+		}
+	}
+	if rf.Rule.IsolationLevel != "" &&
+		NewIsolationFilter(f.scope, rf.Rule.IsolationLevel, rf.Rule.LocationLabels, ruleStores).FilterTarget(opt, store) {
+		return true
+	}
+	oldStore := f.cluster.GetStore(f.oldPeer.GetStoreId())
+	if oldStore == nil || getStoreRuleFit(f.oldFit, oldStore.GetId()) == nil {
+		return false
+	}
+	labels := rf.Rule.LocationLabels
+	return DistinctScore(labels, ruleStores, store) < DistinctScore(labels, ruleStores, oldStore)
+}
+
+// getStoreRuleFit returns the rule fit which the peer on the store is
+// assigned to, or nil if the peer is an orphan.
+func getStoreRuleFit(fit *placement.RegionFit, storeID uint64) *placement.RuleFit {
+	for _, rf := range fit.RuleFits {
+		for _, peers := range [][]*metapb.Peer{rf.Peers, rf.PeersWithDifferentRole} {
+			for _, peer := range peers {
+				if peer.GetStoreId() == storeID {
+					return rf
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// learnerStoreFilter prevents the voters from being placed on the stores
+// reserved for the read-only learners.
+type learnerStoreFilter struct {
+	scope       string
+	constraints []placement.LabelConstraint
+}
+
+// NewLearnerStoreFilter creates a Filter that filters all stores matching the
+// label constraints of the read-only learners from being the target.
+func NewLearnerStoreFilter(scope string, constraints []placement.LabelConstraint) Filter {
+	return &learnerStoreFilter{scope: scope, constraints: constraints}
+}
+
+func (f *learnerStoreFilter) Scope() string {
+	return f.scope
+}
+
+func (f *learnerStoreFilter) Type() string {
+	return "learner-store-filter"
+}
+
+func (f *learnerStoreFilter) FilterSource(opt Options, store *core.StoreInfo) bool {
+	return false
+}
+
+func (f *learnerStoreFilter) FilterTarget(opt Options, store *core.StoreInfo) bool {
+	return IsLearnerStore(store, f.constraints)
+}
+
+// leaderPreferenceFilter prevents the leaders from being transferred to the
+// stores less preferred than the source store.
+type leaderPreferenceFilter struct {
+	scope      string
+	preference LeaderPreference
+	rank       int
+	// preferred is true if the source store is marked as preferLeader.
+	preferred bool
+}
+
+// NewLeaderPreferenceFilter creates a Filter that filters all stores less
+// preferred than the source store by the leader preference of the cluster or
+// the preferLeader label property from being the target.
+func NewLeaderPreferenceFilter(scope string, cluster Cluster, source *core.StoreInfo) Filter {
+	preference := cluster.GetLeaderPreference()
+	return &leaderPreferenceFilter{
+		scope:      scope,
+		preference: preference,
+		rank:       preference.Rank(source),
+		preferred:  cluster.CheckLabelProperty(PreferLeader, source.Labels),
+	}
+}
+
+func (f *leaderPreferenceFilter) Scope() string {
+	return f.scope
+}
+
+func (f *leaderPreferenceFilter) Type() string {
+	return "leader-preference-filter"
+}
+
+func (f *leaderPreferenceFilter) FilterSource(opt Options, store *core.StoreInfo) bool {
+	return false
+}
+
+func (f *leaderPreferenceFilter) FilterTarget(opt Options, store *core.StoreInfo) bool {
+	if f.preferred && !opt.CheckLabelProperty(PreferLeader, store.Labels) {
+		return true
+	}
+	return f.preference.Rank(store) > f.rank
 }
@@ -13,9 +13,14 @@
 package schedule
 
 import (
+	"time"
+
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/server/cache"
 	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/namespace"
+	"github.com/pingcap/pd/server/schedule/placement"
 )
 
 var _ = Suite(&testFiltersSuite{})
@@ -23,7 +28,7 @@ var _ = Suite(&testFiltersSuite{})
 type testFiltersSuite struct{}
 
 func (s *testReplicationSuite) TestPendingPeerFilter(c *C) {
-	filter := NewPendingPeerCountFilter()
+	filter := NewPendingPeerCountFilter("")
 	opt := NewMockSchedulerOptions()
 	tc := NewMockCluster(opt)
 	store := core.NewStoreInfo(&metapb.Store{Id: 1})
@@ -36,3 +41,343 @@ func (s *testReplicationSuite) TestPendingPeerFilter(c *C) {
 	c.Assert(filter.FilterSource(tc, store), IsFalse)
 	c.Assert(filter.FilterTarget(tc, store), IsFalse)
 }
+
+// checkFilter checks whether the filter rejects the store as source and target.
+func (s *testFiltersSuite) checkFilter(c *C, opt Options, filter Filter, store *core.StoreInfo, source, target bool) {
+	c.Assert(filter.FilterSource(opt, store), Equals, source)
+	c.Assert(filter.FilterTarget(opt, store), Equals, target)
+}
+
+func (s *testFiltersSuite) TestExcludedFilter(c *C) {
+	tc := NewMockCluster(NewMockSchedulerOptions())
+	tc.AddRegionStore(1, 1)
+	tc.AddRegionStore(2, 1)
+	filter := NewExcludedFilter("test", map[uint64]struct{}{1: {}}, map[uint64]struct{}{2: {}})
+	c.Assert(filter.Scope(), Equals, "test")
+	s.checkFilter(c, tc, filter, tc.GetStore(1), true, false)
+	s.checkFilter(c, tc, filter, tc.GetStore(2), false, true)
+}
+
+func (s *testFiltersSuite) TestBlockFilter(c *C) {
+	tc := NewMockCluster(NewMockSchedulerOptions())
+	tc.AddRegionStore(1, 1)
+	filter := NewBlockFilter("test")
+	store := tc.GetStore(1)
+	s.checkFilter(c, tc, filter, store, false, false)
+	store.Block()
+	s.checkFilter(c, tc, filter, store, true, true)
+}
+
+func (s *testFiltersSuite) TestStateFilter(c *C) {
+	tc := NewMockCluster(NewMockSchedulerOptions())
+	tc.AddRegionStore(1, 1)
+	filter := NewStateFilter("test")
+	s.checkFilter(c, tc, filter, tc.GetStore(1), false, false)
+	tc.SetStoreOffline(1)
+	s.checkFilter(c, tc, filter, tc.GetStore(1), false, true)
+	store := tc.GetStore(1)
+	store.State = metapb.StoreState_Tombstone
+	s.checkFilter(c, tc, filter, store, true, true)
+}
+
+func (s *testFiltersSuite) TestHealthFilter(c *C) {
+	tc := NewMockCluster(NewMockSchedulerOptions())
+	tc.AddRegionStore(1, 1)
+	filter := NewHealthFilter("test")
+	s.checkFilter(c, tc, filter, tc.GetStore(1), false, false)
+	tc.SetStoreBusy(1, true)
+	s.checkFilter(c, tc, filter, tc.GetStore(1), true, true)
+	tc.SetStoreBusy(1, false)
+	tc.SetStoreDown(1)
+	s.checkFilter(c, tc, filter, tc.GetStore(1), true, true)
+}
+
+func (s *testFiltersSuite) TestDisconnectFilter(c *C) {
+	tc := NewMockCluster(NewMockSchedulerOptions())
+	tc.AddRegionStore(1, 1)
+	filter := NewDisconnectFilter("test")
+	s.checkFilter(c, tc, filter, tc.GetStore(1), false, false)
+	tc.SetStoreDisconnect(1)
+	s.checkFilter(c, tc, filter, tc.GetStore(1), true, true)
+}
+
+func (s *testFiltersSuite) TestSnapshotCountFilter(c *C) {
+	opt := NewMockSchedulerOptions()
+	tc := NewMockCluster(opt)
+	opt.MaxSnapshotCount = 2
+	tc.AddRegionStore(1, 1)
+	filter := NewSnapshotCountFilter("test")
+	tc.UpdateSnapshotCount(1, 2)
+	s.checkFilter(c, tc, filter, tc.GetStore(1), false, false)
+	tc.UpdateSnapshotCount(1, 3)
+	s.checkFilter(c, tc, filter, tc.GetStore(1), true, true)
+}
+
+func (s *testFiltersSuite) TestCacheFilter(c *C) {
+	tc := NewMockCluster(NewMockSchedulerOptions())
+	tc.AddRegionStore(1, 1)
+	taintStores := cache.NewIDTTL(time.Minute, time.Minute)
+	filter := NewCacheFilter("test", taintStores)
+	s.checkFilter(c, tc, filter, tc.GetStore(1), false, false)
+	taintStores.Put(1)
+	s.checkFilter(c, tc, filter, tc.GetStore(1), true, false)
+}
+
+func (s *testFiltersSuite) TestStorageThresholdFilter(c *C) {
+	tc := NewMockCluster(NewMockSchedulerOptions())
+	tc.AddRegionStore(1, 1)
+	filter := NewStorageThresholdFilter("test")
+	tc.UpdateStorageRatio(1, 0.5, 0.5)
+	s.checkFilter(c, tc, filter, tc.GetStore(1), false, false)
+	tc.UpdateStorageRatio(1, 0.9, 0.1)
+	s.checkFilter(c, tc, filter, tc.GetStore(1), false, true)
+}
+
+func (s *testFiltersSuite) TestDistinctScoreFilter(c *C) {
+	tc := NewMockCluster(NewMockSchedulerOptions())
+	tc.AddLabelsStore(1, 1, map[string]string{"zone": "z1"})
+	tc.AddLabelsStore(2, 1, map[string]string{"zone": "z2"})
+	tc.AddLabelsStore(3, 1, map[string]string{"zone": "z3"})
+	tc.AddLabelsStore(4, 1, map[string]string{"zone": "z1"})
+	tc.AddLabelsStore(5, 1, map[string]string{"zone": "z4"})
+	stores := []*core.StoreInfo{tc.GetStore(1), tc.GetStore(2), tc.GetStore(3)}
+	filter := NewDistinctScoreFilter("test", []string{"zone"}, stores, tc.GetStore(3))
+	// Store 4 is in the same zone as store 1, which decreases the distinct score.
+	s.checkFilter(c, tc, filter, tc.GetStore(4), false, true)
+	s.checkFilter(c, tc, filter, tc.GetStore(5), false, false)
+}
+
+func (s *testFiltersSuite) TestNamespaceFilter(c *C) {
+	tc := NewMockCluster(NewMockSchedulerOptions())
+	tc.AddRegionStore(1, 1)
+	filter := NewNamespaceFilter("test", namespace.DefaultClassifier, namespace.DefaultNamespace)
+	s.checkFilter(c, tc, filter, tc.GetStore(1), false, false)
+	filter = NewNamespaceFilter("test", namespace.DefaultClassifier, "ns1")
+	s.checkFilter(c, tc, filter, tc.GetStore(1), true, true)
+}
+
+func (s *testFiltersSuite) TestRejectLeaderFilter(c *C) {
+	opt := NewMockSchedulerOptions()
+	tc := NewMockCluster(opt)
+	tc.AddLabelsStore(1, 1, map[string]string{"noleader": "true"})
+	tc.AddLabelsStore(2, 1, map[string]string{"noleader": "false"})
+	opt.LabelProperties = map[string][]*metapb.StoreLabel{
+		RejectLeader: {{Key: "noleader", Value: "true"}},
+	}
+	filter := NewRejectLeaderFilter("test")
+	s.checkFilter(c, tc, filter, tc.GetStore(1), false, true)
+	s.checkFilter(c, tc, filter, tc.GetStore(2), false, false)
+}
+
+func (s *testFiltersSuite) TestLabelPropertyFilters(c *C) {
+	opt := NewMockSchedulerOptions()
+	tc := NewMockCluster(opt)
+	tc.AddLabelsStore(1, 1, map[string]string{"disk": "hdd"})
+	tc.AddLabelsStore(2, 1, map[string]string{"disk": "ssd"})
+	tc.AddLabelsStore(3, 1, map[string]string{"disk": "ro"})
+	tc.AddLabelsStore(4, 1, nil)
+	opt.LabelProperties = map[string][]*metapb.StoreLabel{
+		RejectRegion: {{Key: "disk", Value: "hdd"}},
+		NoBalance:    {{Key: "disk", Value: "ssd"}},
+		ReadOnly:     {{Key: "disk", Value: "ro"}},
+	}
+
+	// The read-only store accepts neither new peers nor leaders.
+	filter := NewRejectRegionFilter("test")
+	s.checkFilter(c, tc, filter, tc.GetStore(1), false, true)
+	s.checkFilter(c, tc, filter, tc.GetStore(2), false, false)
+	s.checkFilter(c, tc, filter, tc.GetStore(3), false, true)
+	s.checkFilter(c, tc, filter, tc.GetStore(4), false, false)
+	filter = NewRejectLeaderFilter("test")
+	s.checkFilter(c, tc, filter, tc.GetStore(1), false, false)
+	s.checkFilter(c, tc, filter, tc.GetStore(3), false, true)
+	filter = NewNoBalanceFilter("test")
+	s.checkFilter(c, tc, filter, tc.GetStore(1), false, false)
+	s.checkFilter(c, tc, filter, tc.GetStore(2), true, true)
+	s.checkFilter(c, tc, filter, tc.GetStore(4), false, false)
+
+	c.Assert(ValidateLabelPropertyType(ReadOnly), IsNil)
+	c.Assert(ValidateLabelPropertyType("foo"), NotNil)
+}
+
+func (s *testFiltersSuite) TestPreferLeaderFilter(c *C) {
+	opt := NewMockSchedulerOptions()
+	tc := NewMockCluster(opt)
+	tc.AddLabelsStore(1, 1, map[string]string{"leader": "true"})
+	tc.AddLabelsStore(2, 1, map[string]string{"leader": "true"})
+	tc.AddLabelsStore(3, 1, nil)
+	opt.LabelProperties = map[string][]*metapb.StoreLabel{
+		PreferLeader: {{Key: "leader", Value: "true"}},
+	}
+
+	// The leader is not moved out of the preferred stores to the others.
+	filter := NewLeaderPreferenceFilter("test", tc, tc.GetStore(1))
+	s.checkFilter(c, tc, filter, tc.GetStore(2), false, false)
+	s.checkFilter(c, tc, filter, tc.GetStore(3), false, true)
+	filter = NewLeaderPreferenceFilter("test", tc, tc.GetStore(3))
+	s.checkFilter(c, tc, filter, tc.GetStore(1), false, false)
+}
+
+func (s *testFiltersSuite) TestLabelConstraintFilter(c *C) {
+	tc := NewMockCluster(NewMockSchedulerOptions())
+	tc.AddLabelsStore(1, 1, map[string]string{"disk": "ssd"})
+	tc.AddLabelsStore(2, 1, map[string]string{"disk": "hdd"})
+	filter := NewLabelConstraintFilter("test", []placement.LabelConstraint{
+		{Key: "disk", Op: placement.In, Values: []string{"ssd"}},
+	})
+	s.checkFilter(c, tc, filter, tc.GetStore(1), false, false)
+	s.checkFilter(c, tc, filter, tc.GetStore(2), false, true)
+}
+
+func (s *testFiltersSuite) TestIsolationFilter(c *C) {
+	tc := NewMockCluster(NewMockSchedulerOptions())
+	tc.AddLabelsStore(1, 1, map[string]string{"zone": "z1", "host": "h1"})
+	tc.AddLabelsStore(2, 1, map[string]string{"zone": "z2", "host": "h1"})
+	tc.AddLabelsStore(3, 1, map[string]string{"zone": "z1", "host": "h2"})
+	tc.AddLabelsStore(4, 1, map[string]string{"zone": "z3", "host": "h1"})
+	stores := []*core.StoreInfo{tc.GetStore(1), tc.GetStore(2)}
+	filter := NewIsolationFilter("test", "zone", []string{"zone", "host"}, stores)
+	// Store 3 is in the same zone as store 1.
+	s.checkFilter(c, tc, filter, tc.GetStore(3), false, true)
+	s.checkFilter(c, tc, filter, tc.GetStore(4), false, false)
+}
+
+func (s *testFiltersSuite) TestRangeConstraintFilter(c *C) {
+	tc := NewMockCluster(NewMockSchedulerOptions())
+	tc.AddLabelsStore(1, 1, map[string]string{"disk": "ssd"})
+	tc.AddLabelsStore(2, 1, map[string]string{"disk": "hdd"})
+	tc.AddLeaderRegionWithRange(1, "a", "b", 1)
+	tc.AddLeaderRegionWithRange(2, "b", "c", 1)
+	c.Assert(tc.RangeConstraintManager.SetConstraint(&placement.RangeConstraint{
+		ID:               "ssd",
+		StartKeyHex:      "62",
+		LabelConstraints: []placement.LabelConstraint{{Key: "disk", Op: placement.In, Values: []string{"ssd"}}},
+	}), IsNil)
+	filter := NewRangeConstraintFilter("test", tc, tc.GetRegion(1))
+	s.checkFilter(c, tc, filter, tc.GetStore(1), false, false)
+	s.checkFilter(c, tc, filter, tc.GetStore(2), false, false)
+	filter = NewRangeConstraintFilter("test", tc, tc.GetRegion(2))
+	s.checkFilter(c, tc, filter, tc.GetStore(1), false, false)
+	s.checkFilter(c, tc, filter, tc.GetStore(2), false, true)
+}
+
+func (s *testFiltersSuite) TestRuleFitFilter(c *C) {
+	opt := NewMockSchedulerOptions()
+	tc := NewMockCluster(opt)
+	tc.AddLabelsStore(1, 1, map[string]string{"zone": "z1", "disk": "ssd"})
+	tc.AddLabelsStore(2, 1, map[string]string{"zone": "z2", "disk": "ssd"})
+	tc.AddLabelsStore(3, 1, map[string]string{"zone": "z3", "disk": "ssd"})
+	tc.AddLabelsStore(4, 1, map[string]string{"zone": "z3", "disk": "hdd"})
+	tc.AddLabelsStore(5, 1, map[string]string{"zone": "z1", "disk": "ssd"})
+	tc.AddLabelsStore(6, 1, map[string]string{"zone": "z4", "disk": "ssd"})
+	tc.AddLeaderRegion(1, 1, 2, 3)
+	c.Assert(tc.RuleManager.SetRule(&placement.Rule{
+		ID:               placement.DefaultRuleID,
+		Role:             placement.Voter,
+		Count:            3,
+		LabelConstraints: []placement.LabelConstraint{{Key: "disk", Op: placement.In, Values: []string{"ssd"}}},
+		LocationLabels:   []string{"zone"},
+		IsolationLevel:   "zone",
+	}), IsNil)
+
+	// Nothing is filtered if the placement rules are disabled.
+	filter := NewRuleFitFilter("test", tc, tc.GetRegion(1), 3)
+	s.checkFilter(c, tc, filter, tc.GetStore(4), false, false)
+
+	opt.EnablePlacementRules = true
+	filter = NewRuleFitFilter("test", tc, tc.GetRegion(1), 3)
+	// Store 4 violates the label constraint.
+	s.checkFilter(c, tc, filter, tc.GetStore(4), false, true)
+	// Store 5 violates the isolation level.
+	s.checkFilter(c, tc, filter, tc.GetStore(5), false, true)
+	s.checkFilter(c, tc, filter, tc.GetStore(6), false, false)
+
+	c.Assert(HasExpectedReplicas(tc, nil, tc.GetRegion(1)), IsTrue)
+	c.Assert(GetExpectedReplicas(tc, tc.GetRegion(1)), Equals, 3)
+	c.Assert(tc.RuleManager.SetRule(&placement.Rule{
+		ID:    "extra",
+		Role:  placement.Voter,
+		Count: 1,
+	}), IsNil)
+	c.Assert(HasExpectedReplicas(tc, nil, tc.GetRegion(1)), IsFalse)
+	c.Assert(GetExpectedReplicas(tc, tc.GetRegion(1)), Equals, 4)
+}
+
+func (s *testFiltersSuite) TestFilterRecorder(c *C) {
+	r := newFilterRecorder(3, time.Second)
+	filter := NewPendingPeerCountFilter("balance-region-scheduler")
+	now := time.Now()
+
+	// The rejections in the sample interval are counted in the next record.
+	r.record(filterSourceAction, filter, 1, now)
+	r.record(filterSourceAction, filter, 1, now.Add(time.Millisecond))
+	r.record(filterSourceAction, filter, 1, now.Add(2*time.Millisecond))
+	records := r.getRecords()
+	c.Assert(records, HasLen, 1)
+	c.Assert(records[0].Count, Equals, uint64(1))
+	r.record(filterSourceAction, filter, 1, now.Add(time.Second))
+	records = r.getRecords()
+	c.Assert(records, HasLen, 2)
+	c.Assert(records[0].Count, Equals, uint64(3))
+	c.Assert(records[0].Scope, Equals, "balance-region-scheduler")
+	c.Assert(records[0].Type, Equals, "pending-peer-filter")
+	c.Assert(records[0].Action, Equals, filterSourceAction)
+	c.Assert(records[0].StoreID, Equals, uint64(1))
+
+	// Different stores and actions are sampled separately, and the oldest
+	// records are dropped.
+	r.record(filterTargetAction, filter, 1, now.Add(time.Second))
+	r.record(filterSourceAction, filter, 2, now.Add(time.Second))
+	records = r.getRecords()
+	c.Assert(records, HasLen, 3)
+	c.Assert(records[0].StoreID, Equals, uint64(2))
+	c.Assert(records[1].Action, Equals, filterTargetAction)
+	c.Assert(records[2].Count, Equals, uint64(3))
+}
+
+func (s *testFiltersSuite) TestFilterSourceAndTarget(c *C) {
+	opt := NewMockSchedulerOptions()
+	tc := NewMockCluster(opt)
+	tc.AddRegionStore(1, 1)
+	tc.AddRegionStore(2, 1)
+	tc.UpdatePendingPeerCount(1, 30)
+	filters := []Filter{NewStateFilter("test-scope"), NewPendingPeerCountFilter("test-scope")}
+	c.Assert(FilterSource(tc, tc.GetStore(1), filters), IsTrue)
+	c.Assert(FilterTarget(tc, tc.GetStore(2), filters), IsFalse)
+
+	var found bool
+	for _, r := range GetFilterRecords() {
+		if r.Scope == "test-scope" {
+			c.Assert(r.Type, Equals, "pending-peer-filter")
+			c.Assert(r.Action, Equals, filterSourceAction)
+			c.Assert(r.StoreID, Equals, uint64(1))
+			found = true
+		}
+	}
+	c.Assert(found, IsTrue)
+}
+
+func (s *testFiltersSuite) TestExplainWithoutRecords(c *C) {
+	opt := NewMockSchedulerOptions()
+	tc := NewMockCluster(opt)
+	tc.AddRegionStore(1, 1)
+	tc.AddRegionStore(2, 1)
+	tc.AddRegionStore(3, 1)
+	tc.AddLeaderRegion(1, 1)
+	tc.SetStoreOffline(2)
+
+	// Explaining doesn't record the rejections, while checking does.
+	rc := NewReplicaChecker(tc, nil, "explain-scope")
+	e := rc.Explain(tc.GetRegion(1))
+	c.Assert(e.TargetStoreID, Equals, uint64(3))
+	c.Assert(e.Targets[1].Filter, Equals, "state-filter")
+	for _, r := range GetFilterRecords() {
+		c.Assert(r.Scope, Not(Equals), "explain-scope")
+	}
+	c.Assert(rc.Check(tc.GetRegion(1)), NotNil)
+	var found bool
+	for _, r := range GetFilterRecords() {
+		found = found || r.Scope == "explain-scope"
+	}
+	c.Assert(found, IsTrue)
+}
@@ -15,6 +15,7 @@ package schedule
 
 import (
 	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/pingcap/pd/server/cache"
@@ -30,67 +31,95 @@ const (
 	ReadFlow
 )
 
-// HotSpotCache is a cache hold hot regions.
+// hotPeerMaxInactiveTime is the time after which the statistics of a peer
+// without any report are aged out.
+const hotPeerMaxInactiveTime = 3 * RegionHeartBeatReportInterval * time.Second
+
+// HotSpotCache is a cache hold the hot peers of the regions.
 type HotSpotCache struct {
-	writeFlow cache.Cache
-	readFlow  cache.Cache
+	writeFlow *hotPeerCache
+	readFlow  *hotPeerCache
 }
 
 func newHotSpotCache() *HotSpotCache {
 	return &HotSpotCache{
-		writeFlow: cache.NewCache(statCacheMaxLen, cache.TwoQueueCache),
-		readFlow:  cache.NewCache(statCacheMaxLen, cache.TwoQueueCache),
+		writeFlow: newHotPeerCache(),
+		readFlow:  newHotPeerCache(),
 	}
 }
 
-// CheckWrite checks the write status, returns whether need update statistics and item.
-func (w *HotSpotCache) CheckWrite(region *core.RegionInfo, stores *core.StoresInfo) (bool, *core.RegionStat) {
-	var (
-		WrittenBytesPerSec uint64
-		value              *core.RegionStat
-	)
-
-	WrittenBytesPerSec = uint64(float64(region.WrittenBytes) / float64(RegionHeartBeatReportInterval))
-
-	v, isExist := w.writeFlow.Peek(region.GetId())
-	if isExist {
-		value = v.(*core.RegionStat)
-		if !Simulating {
-			interval := time.Since(value.LastUpdateTime).Seconds()
-			if interval < minHotRegionReportInterval {
-				return false, nil
-			}
-			WrittenBytesPerSec = uint64(float64(region.WrittenBytes) / interval)
-		}
+// CheckWrite checks the write status of all the peers of the region, returns
+// the statistics of the peers which need to be updated. The flow is averaged
+// over the recent windowSize reports.
+func (w *HotSpotCache) CheckWrite(region *core.RegionInfo, stores *core.StoresInfo, windowSize int) []*core.RegionStat {
+	// All the peers apply the same written data.
+	storeIDs := make([]uint64, 0, len(region.GetPeers()))
+	for _, peer := range region.GetPeers() {
+		storeIDs = append(storeIDs, peer.GetStoreId())
 	}
-
 	hotRegionThreshold := calculateWriteHotThreshold(stores)
-	return w.isNeedUpdateStatCache(region, WrittenBytesPerSec, hotRegionThreshold, value, WriteFlow)
+	hotKeysThreshold := calculateWriteHotKeysThreshold(stores)
+	return w.checkPeers(w.writeFlow, region, storeIDs, region.WrittenBytes, region.WrittenKeys, hotRegionThreshold, hotKeysThreshold, windowSize, WriteFlow)
 }
 
-// CheckRead checks the read status, returns whether need update statistics and item.
-func (w *HotSpotCache) CheckRead(region *core.RegionInfo, stores *core.StoresInfo) (bool, *core.RegionStat) {
-	var (
-		ReadBytesPerSec uint64
-		value           *core.RegionStat
-	)
+// CheckRead checks the read status of the leader of the region, returns the
+// statistics of the peers which need to be updated. The flow is averaged over
+// the recent windowSize reports.
+func (w *HotSpotCache) CheckRead(region *core.RegionInfo, stores *core.StoresInfo, windowSize int) []*core.RegionStat {
+	// Only the leader serves the reads.
+	storeIDs := []uint64{region.Leader.GetStoreId()}
+	hotRegionThreshold := calculateReadHotThreshold(stores)
+	hotKeysThreshold := calculateReadHotKeysThreshold(stores)
+	return w.checkPeers(w.readFlow, region, storeIDs, region.ReadBytes, region.ReadKeys, hotRegionThreshold, hotKeysThreshold, windowSize, ReadFlow)
+}
 
-	ReadBytesPerSec = uint64(float64(region.ReadBytes) / float64(RegionHeartBeatReportInterval))
+func (w *HotSpotCache) checkPeers(c *hotPeerCache, region *core.RegionInfo, storeIDs []uint64, bytes, keys uint64, hotRegionThreshold, hotKeysThreshold uint64, windowSize int, kind FlowKind) []*core.RegionStat {
+	var items []*core.RegionStat
+	// The peers which no longer serve the flow are removed.
+	for _, storeID := range c.getStores(region.GetId()) {
+		if !containsUint64(storeIDs, storeID) {
+			w.incMetrics("remove_item", kind)
+			items = append(items, &core.RegionStat{RegionID: region.GetId(), StoreID: storeID, NeedDelete: true})
+		}
+	}
 
-	v, isExist := w.readFlow.Peek(region.GetId())
-	if isExist {
-		value = v.(*core.RegionStat)
-		if !Simulating {
-			interval := time.Since(value.LastUpdateTime).Seconds()
-			if interval < minHotRegionReportInterval {
-				return false, nil
+	for _, storeID := range storeIDs {
+		isLeader := storeID == region.Leader.GetStoreId()
+		interval := float64(RegionHeartBeatReportInterval)
+		oldItem := c.getPeer(storeID, region.GetId())
+		if oldItem != nil && !Simulating {
+			elapsed := time.Since(oldItem.LastUpdateTime)
+			if elapsed.Seconds() < minHotRegionReportInterval {
+				// Keep the role up to date even if the report is too frequent.
+				if oldItem.IsLeader != isLeader {
+					item := *oldItem
+					item.IsLeader = isLeader
+					items = append(items, &item)
+				}
+				continue
 			}
-			ReadBytesPerSec = uint64(float64(region.ReadBytes) / interval)
+			interval = elapsed.Seconds()
+			if elapsed > hotPeerMaxInactiveTime {
+				// The history of the inactive peer is out of date.
+				oldItem = nil
+			}
+		}
+		flowBytes := uint64(float64(bytes) / interval)
+		flowKeys := uint64(float64(keys) / interval)
+		if item := w.updatePeerStat(region, storeID, isLeader, flowBytes, flowKeys, hotRegionThreshold, hotKeysThreshold, oldItem, windowSize, kind); item != nil {
+			items = append(items, item)
 		}
 	}
+	return items
+}
 
-	hotRegionThreshold := calculateReadHotThreshold(stores)
-	return w.isNeedUpdateStatCache(region, ReadBytesPerSec, hotRegionThreshold, value, ReadFlow)
+func containsUint64(ids []uint64, id uint64) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
 }
 
 func (w *HotSpotCache) incMetrics(name string, kind FlowKind) {
@@ -129,77 +158,172 @@ func calculateReadHotThreshold(stores *core.StoresInfo) uint64 {
 	return hotRegionThreshold
 }
 
-const rollingWindowsSize = 5
+func calculateWriteHotKeysThreshold(stores *core.StoresInfo) uint64 {
+	// the same as the bytes, but the keys are not amplified by rocksdb
+	divisor := float64(statCacheMaxLen)
+	hotKeysThreshold := uint64(stores.TotalKeysWriteRate() / divisor)
+
+	if hotKeysThreshold < hotWriteRegionMinKeyRate {
+		hotKeysThreshold = hotWriteRegionMinKeyRate
+	}
+	return hotKeysThreshold
+}
+
+func calculateReadHotKeysThreshold(stores *core.StoresInfo) uint64 {
+	divisor := float64(statCacheMaxLen)
+	hotKeysThreshold := uint64(stores.TotalKeysReadRate() / divisor)
+
+	if hotKeysThreshold < hotReadRegionMinKeyRate {
+		hotKeysThreshold = hotReadRegionMinKeyRate
+	}
+	return hotKeysThreshold
+}
 
-func (w *HotSpotCache) isNeedUpdateStatCache(region *core.RegionInfo, flowBytes uint64, hotRegionThreshold uint64, oldItem *core.RegionStat, kind FlowKind) (bool, *core.RegionStat) {
+// updatePeerStat adds the flow to the moving average of the peer, and returns
+// the new statistics or nil if the peer is not hot and not in the cache. The
+// peer is hot if either the average flow bytes or flow keys reach the
+// threshold, and it is removed after being cold for hotRegionAntiCount times.
+func (w *HotSpotCache) updatePeerStat(region *core.RegionInfo, storeID uint64, isLeader bool, flowBytes, flowKeys uint64, hotRegionThreshold, hotKeysThreshold uint64, oldItem *core.RegionStat, windowSize int, kind FlowKind) *core.RegionStat {
 	newItem := &core.RegionStat{
 		RegionID:       region.GetId(),
-		FlowBytes:      flowBytes,
 		LastUpdateTime: time.Now(),
-		StoreID:        region.Leader.GetStoreId(),
+		StoreID:        storeID,
+		IsLeader:       isLeader,
 		Version:        region.GetRegionEpoch().GetVersion(),
 		AntiCount:      hotRegionAntiCount,
 	}
-
 	if oldItem != nil {
 		newItem.HotDegree = oldItem.HotDegree + 1
-		newItem.Stats = oldItem.Stats
+		newItem.Stats = oldItem.Stats.Clone()
+		newItem.KeysStats = oldItem.KeysStats.Clone()
+	} else {
+		newItem.Stats = core.NewRollingStats(windowSize)
+		newItem.KeysStats = core.NewRollingStats(windowSize)
 	}
-	if flowBytes >= hotRegionThreshold {
+	newItem.Stats.Add(float64(flowBytes))
+	newItem.KeysStats.Add(float64(flowKeys))
+	newItem.FlowBytes = uint64(newItem.Stats.Mean())
+	newItem.FlowKeys = uint64(newItem.KeysStats.Mean())
+
+	if newItem.FlowBytes >= hotRegionThreshold || newItem.FlowKeys >= hotKeysThreshold {
 		if oldItem == nil {
 			w.incMetrics("add_item", kind)
-			newItem.Stats = core.NewRollingStats(rollingWindowsSize)
 		}
-		newItem.Stats.Add(float64(flowBytes))
-		return true, newItem
+		return newItem
 	}
 	// smaller than hotReionThreshold
 	if oldItem == nil {
-		return false, newItem
+		return nil
 	}
 	if oldItem.AntiCount <= 0 {
 		w.incMetrics("remove_item", kind)
-		return true, nil
+		return &core.RegionStat{RegionID: region.GetId(), StoreID: storeID, NeedDelete: true}
 	}
 	// eliminate some noise
 	newItem.HotDegree = oldItem.HotDegree - 1
 	newItem.AntiCount = oldItem.AntiCount - 1
-	newItem.Stats.Add(float64(flowBytes))
-	return true, newItem
+	return newItem
 }
 
-// Update updates the cache.
-func (w *HotSpotCache) Update(key uint64, item *core.RegionStat, kind FlowKind) {
-	switch kind {
-	case WriteFlow:
-		if item == nil {
-			w.writeFlow.Remove(key)
+// Update updates the statistics of the peers in the cache.
+func (w *HotSpotCache) Update(items []*core.RegionStat, kind FlowKind) {
+	c := w.getPeerCache(kind)
+	if c == nil {
+		return
+	}
+	for _, item := range items {
+		if item.NeedDelete {
+			c.removePeer(item.StoreID, item.RegionID)
 		} else {
-			w.writeFlow.Put(key, item)
+			c.putPeer(item)
 			w.incMetrics("update_item", kind)
 		}
-	case ReadFlow:
-		if item == nil {
-			w.readFlow.Remove(key)
-		} else {
-			w.readFlow.Put(key, item)
-			w.incMetrics("update_item", kind)
+	}
+}
+
+// RemoveRegion removes the statistics of all the peers of the region, it is
+// called when the region does not exist anymore.
+func (w *HotSpotCache) RemoveRegion(regionID uint64) {
+	for _, c := range []*hotPeerCache{w.writeFlow, w.readFlow} {
+		for _, storeID := range c.getStores(regionID) {
+			c.removePeer(storeID, regionID)
 		}
 	}
 }
 
-// RegionStats returns hot items according to kind
-func (w *HotSpotCache) RegionStats(kind FlowKind) []*core.RegionStat {
-	var elements []*cache.Item
+func (w *HotSpotCache) getPeerCache(kind FlowKind) *hotPeerCache {
 	switch kind {
 	case WriteFlow:
-		elements = w.writeFlow.Elems()
+		return w.writeFlow
 	case ReadFlow:
-		elements = w.readFlow.Elems()
+		return w.readFlow
+	}
+	return nil
+}
+
+// RegionStats returns the statistics of the active hot peers according to
+// kind.
+func (w *HotSpotCache) RegionStats(kind FlowKind) []*core.RegionStat {
+	c := w.getPeerCache(kind)
+	if c == nil {
+		return nil
+	}
+	return c.activePeers()
+}
+
+// RegionStat returns the flow statistics of the region, which is the hotter
+// one of its peers, or nil if the region is not in the cache.
+func (w *HotSpotCache) RegionStat(regionID uint64, kind FlowKind) *core.RegionStat {
+	c := w.getPeerCache(kind)
+	if c == nil {
+		return nil
 	}
-	stats := make([]*core.RegionStat, len(elements))
-	for i := range elements {
-		stats[i] = elements[i].Value.(*core.RegionStat)
+	var res *core.RegionStat
+	for _, storeID := range c.getStores(regionID) {
+		stat := c.getPeer(storeID, regionID)
+		if stat != nil && isPeerActive(stat) && (res == nil || stat.HotDegree > res.HotDegree) {
+			res = stat
+		}
+	}
+	return res
+}
+
+// StoreStats sums up the flow of the hot peers on each store according to
+// kind. Only the leaders are counted unless asPeer is true.
+func (w *HotSpotCache) StoreStats(kind FlowKind, asPeer bool, hotThreshold int) core.StoreHotRegionsStat {
+	return SummarizeHotPeers(w.RegionStats(kind), asPeer, hotThreshold)
+}
+
+// SummarizeHotPeers sums up the flow of the hot peers on each store, the peers
+// with HotDegree below hotThreshold are ignored. Only the leaders are counted
+// unless asPeer is true.
+func SummarizeHotPeers(items []*core.RegionStat, asPeer bool, hotThreshold int) core.StoreHotRegionsStat {
+	stats := make(core.StoreHotRegionsStat)
+	for _, r := range items {
+		if r.HotDegree < hotThreshold || (!asPeer && !r.IsLeader) {
+			continue
+		}
+		storeStat, ok := stats[r.StoreID]
+		if !ok {
+			storeStat = &core.HotRegionsStat{
+				RegionsStat: make(core.RegionsStat, 0, storeHotRegionsDefaultLen),
+			}
+			stats[r.StoreID] = storeStat
+		}
+		storeStat.TotalFlowBytes += r.FlowBytes
+		storeStat.TotalFlowKeys += r.FlowKeys
+		storeStat.RegionsCount++
+		storeStat.RegionsStat = append(storeStat.RegionsStat, core.RegionStat{
+			RegionID:       r.RegionID,
+			FlowBytes:      r.FlowBytes,
+			FlowKeys:       r.FlowKeys,
+			HotDegree:      r.HotDegree,
+			LastUpdateTime: r.LastUpdateTime,
+			StoreID:        r.StoreID,
+			IsLeader:       r.IsLeader,
+			AntiCount:      r.AntiCount,
+			Version:        r.Version,
+		})
 	}
 	return stats
 }
@@ -217,22 +341,155 @@ func (w *HotSpotCache) RandHotRegionFromStore(storeID uint64, kind FlowKind, hot
 
 // CollectMetrics collect the hot cache metrics
 func (w *HotSpotCache) CollectMetrics(stores *core.StoresInfo) {
-	hotCacheStatusGauge.WithLabelValues("total_length", "write").Set(float64(w.writeFlow.Len()))
-	hotCacheStatusGauge.WithLabelValues("total_length", "read").Set(float64(w.readFlow.Len()))
+	hotCacheStatusGauge.WithLabelValues("total_length", "write").Set(float64(w.writeFlow.len()))
+	hotCacheStatusGauge.WithLabelValues("total_length", "read").Set(float64(w.readFlow.len()))
 	threshold := calculateWriteHotThreshold(stores)
 	hotCacheStatusGauge.WithLabelValues("hotThreshold", "write").Set(float64(threshold))
 	threshold = calculateReadHotThreshold(stores)
 	hotCacheStatusGauge.WithLabelValues("hotThreshold", "read").Set(float64(threshold))
+	threshold = calculateWriteHotKeysThreshold(stores)
+	hotCacheStatusGauge.WithLabelValues("hotKeysThreshold", "write").Set(float64(threshold))
+	threshold = calculateReadHotKeysThreshold(stores)
+	hotCacheStatusGauge.WithLabelValues("hotKeysThreshold", "read").Set(float64(threshold))
 }
 
 func (w *HotSpotCache) isRegionHot(id uint64, hotThreshold int) bool {
-	if stat, ok := w.writeFlow.Peek(id); ok {
-		if stat.(*core.RegionStat).HotDegree >= hotThreshold {
+	for _, kind := range []FlowKind{WriteFlow, ReadFlow} {
+		if stat := w.RegionStat(id, kind); stat != nil && stat.HotDegree >= hotThreshold {
 			return true
 		}
 	}
-	if stat, ok := w.readFlow.Peek(id); ok {
-		return stat.(*core.RegionStat).HotDegree >= hotThreshold
-	}
 	return false
 }
+
+// hotPeerCache records the statistics of the hot peers of a flow kind.
+type hotPeerCache struct {
+	sync.RWMutex
+	// peersOfStore maps the store id to the hot peers on the store.
+	peersOfStore map[uint64]cache.Cache
+	// storesOfRegion maps the region id to the stores of its hot peers.
+	storesOfRegion map[uint64]map[uint64]struct{}
+	// evicted is the number of the peers evicted from peersOfStore since the
+	// last prune, they are left in storesOfRegion until it is pruned.
+	evicted int
+}
+
+func newHotPeerCache() *hotPeerCache {
+	return &hotPeerCache{
+		peersOfStore:   make(map[uint64]cache.Cache),
+		storesOfRegion: make(map[uint64]map[uint64]struct{}),
+	}
+}
+
+func (c *hotPeerCache) getPeer(storeID, regionID uint64) *core.RegionStat {
+	c.RLock()
+	defer c.RUnlock()
+	peers, ok := c.peersOfStore[storeID]
+	if !ok {
+		return nil
+	}
+	v, ok := peers.Peek(regionID)
+	if !ok {
+		return nil
+	}
+	return v.(*core.RegionStat)
+}
+
+func (c *hotPeerCache) getStores(regionID uint64) []uint64 {
+	c.RLock()
+	defer c.RUnlock()
+	stores := make([]uint64, 0, len(c.storesOfRegion[regionID]))
+	for storeID := range c.storesOfRegion[regionID] {
+		stores = append(stores, storeID)
+	}
+	return stores
+}
+
+func (c *hotPeerCache) putPeer(item *core.RegionStat) {
+	c.Lock()
+	defer c.Unlock()
+	peers, ok := c.peersOfStore[item.StoreID]
+	if !ok {
+		peers = cache.NewCache(statCacheMaxLen, cache.TwoQueueCache)
+		c.peersOfStore[item.StoreID] = peers
+	}
+	if _, ok := peers.Peek(item.RegionID); !ok && peers.Len() >= statCacheMaxLen {
+		c.evicted++
+	}
+	peers.Put(item.RegionID, item)
+	stores, ok := c.storesOfRegion[item.RegionID]
+	if !ok {
+		stores = make(map[uint64]struct{})
+		c.storesOfRegion[item.RegionID] = stores
+	}
+	stores[item.StoreID] = struct{}{}
+	// Prune after enough evictions, so storesOfRegion stays bounded by the
+	// size of the caches at an amortized constant cost.
+	if c.evicted >= statCacheMaxLen {
+		c.pruneLocked()
+	}
+}
+
+// pruneLocked removes the peers which have been evicted or inactive from
+// storesOfRegion, the inactive peers are removed from peersOfStore too.
+func (c *hotPeerCache) pruneLocked() {
+	for regionID, stores := range c.storesOfRegion {
+		for storeID := range stores {
+			peers := c.peersOfStore[storeID]
+			if v, ok := peers.Peek(regionID); ok {
+				if isPeerActive(v.(*core.RegionStat)) {
+					continue
+				}
+				peers.Remove(regionID)
+			}
+			delete(stores, storeID)
+		}
+		if len(stores) == 0 {
+			delete(c.storesOfRegion, regionID)
+		}
+	}
+	c.evicted = 0
+}
+
+func (c *hotPeerCache) removePeer(storeID, regionID uint64) {
+	c.Lock()
+	defer c.Unlock()
+	if peers, ok := c.peersOfStore[storeID]; ok {
+		peers.Remove(regionID)
+	}
+	if stores, ok := c.storesOfRegion[regionID]; ok {
+		delete(stores, storeID)
+		if len(stores) == 0 {
+			delete(c.storesOfRegion, regionID)
+		}
+	}
+}
+
+// activePeers returns the peers which have been reported recently.
+func (c *hotPeerCache) activePeers() []*core.RegionStat {
+	c.RLock()
+	defer c.RUnlock()
+	var stats []*core.RegionStat
+	for _, peers := range c.peersOfStore {
+		for _, elem := range peers.Elems() {
+			if stat := elem.Value.(*core.RegionStat); isPeerActive(stat) {
+				stats = append(stats, stat)
+			}
+		}
+	}
+	return stats
+}
+
+func (c *hotPeerCache) len() int {
+	c.RLock()
+	defer c.RUnlock()
+	var n int
+	for _, peers := range c.peersOfStore {
+		n += peers.Len()
+	}
+	return n
+}
+
+func isPeerActive(stat *core.RegionStat) bool {
+	return Simulating || time.Since(stat.LastUpdateTime) <= hotPeerMaxInactiveTime
+}
@@ -0,0 +1,186 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/server/core"
+)
+
+var _ = Suite(&testHotCacheSuite{})
+
+type testHotCacheSuite struct{}
+
+func newTestHotRegion(regionID uint64, leaderStore uint64, followerStores ...uint64) *core.RegionInfo {
+	region := &metapb.Region{Id: regionID}
+	leader := &metapb.Peer{Id: regionID*10 + leaderStore, StoreId: leaderStore}
+	region.Peers = []*metapb.Peer{leader}
+	for _, storeID := range followerStores {
+		region.Peers = append(region.Peers, &metapb.Peer{Id: regionID*10 + storeID, StoreId: storeID})
+	}
+	return core.NewRegionInfo(region, leader)
+}
+
+// checkAndUpdate reports the region to the cache, and pretends the next report
+// comes after a report interval.
+func checkAndUpdate(hc *HotSpotCache, region *core.RegionInfo, kind FlowKind) []*core.RegionStat {
+	stores := core.NewStoresInfo()
+	var items []*core.RegionStat
+	if kind == WriteFlow {
+		items = hc.CheckWrite(region, stores, 3)
+	} else {
+		items = hc.CheckRead(region, stores, 3)
+	}
+	hc.Update(items, kind)
+	for _, item := range items {
+		item.LastUpdateTime = item.LastUpdateTime.Add(-RegionHeartBeatReportInterval * time.Second)
+	}
+	return items
+}
+
+func checkFlow(c *C, stat *core.RegionStat, expect uint64) {
+	// The rate may be truncated by the elapsed time slightly longer than
+	// the report interval.
+	c.Assert(stat.FlowBytes, LessEqual, expect)
+	c.Assert(stat.FlowBytes+1, GreaterEqual, expect)
+}
+
+func (s *testHotCacheSuite) TestMovingAverage(c *C) {
+	hc := newHotSpotCache()
+	region := newTestHotRegion(1, 1, 2, 3)
+
+	region.WrittenBytes = 64 * 1024 * RegionHeartBeatReportInterval
+	items := checkAndUpdate(hc, region, WriteFlow)
+	c.Assert(items, HasLen, 3)
+	checkFlow(c, hc.RegionStat(1, WriteFlow), 64*1024)
+	c.Assert(hc.RegionStat(1, WriteFlow).HotDegree, Equals, 0)
+
+	// The flow is averaged over the recent reports.
+	region.WrittenBytes = 0
+	checkAndUpdate(hc, region, WriteFlow)
+	checkFlow(c, hc.RegionStat(1, WriteFlow), 32*1024)
+	c.Assert(hc.RegionStat(1, WriteFlow).HotDegree, Equals, 1)
+	checkAndUpdate(hc, region, WriteFlow)
+	checkFlow(c, hc.RegionStat(1, WriteFlow), 64*1024/3)
+	c.Assert(hc.RegionStat(1, WriteFlow).HotDegree, Equals, 2)
+
+	// The peers are kept for hotRegionAntiCount reports after being cold.
+	checkAndUpdate(hc, region, WriteFlow)
+	checkFlow(c, hc.RegionStat(1, WriteFlow), 0)
+	c.Assert(hc.RegionStat(1, WriteFlow).HotDegree, Equals, 1)
+	items = checkAndUpdate(hc, region, WriteFlow)
+	c.Assert(items, HasLen, 3)
+	for _, item := range items {
+		c.Assert(item.NeedDelete, IsTrue)
+	}
+	c.Assert(hc.RegionStat(1, WriteFlow), IsNil)
+	c.Assert(hc.RegionStats(WriteFlow), HasLen, 0)
+}
+
+func (s *testHotCacheSuite) TestPeers(c *C) {
+	hc := newHotSpotCache()
+	region := newTestHotRegion(1, 1, 2, 3)
+	region.WrittenBytes = 64 * 1024 * RegionHeartBeatReportInterval
+	region.ReadBytes = 256 * 1024 * RegionHeartBeatReportInterval
+	checkAndUpdate(hc, region, WriteFlow)
+	checkAndUpdate(hc, region, ReadFlow)
+
+	// All the peers are tracked for write, only the leader is for read.
+	stats := hc.StoreStats(WriteFlow, true, 0)
+	c.Assert(stats, HasLen, 3)
+	for _, storeID := range []uint64{1, 2, 3} {
+		c.Assert(stats[storeID].RegionsCount, Equals, 1)
+	}
+	stats = hc.StoreStats(WriteFlow, false, 0)
+	c.Assert(stats, HasLen, 1)
+	c.Assert(stats[1].RegionsCount, Equals, 1)
+	stats = hc.StoreStats(ReadFlow, true, 0)
+	c.Assert(stats, HasLen, 1)
+	c.Assert(stats[1].RegionsCount, Equals, 1)
+
+	// The leader is transferred to store 2 and the peer on store 3 is moved
+	// to store 4.
+	region = newTestHotRegion(1, 2, 1, 4)
+	region.WrittenBytes = 64 * 1024 * RegionHeartBeatReportInterval
+	region.ReadBytes = 256 * 1024 * RegionHeartBeatReportInterval
+	checkAndUpdate(hc, region, WriteFlow)
+	checkAndUpdate(hc, region, ReadFlow)
+	stats = hc.StoreStats(WriteFlow, true, 0)
+	c.Assert(stats, HasLen, 3)
+	c.Assert(stats[3], IsNil)
+	c.Assert(stats[4].RegionsStat[0].HotDegree, Equals, 0)
+	c.Assert(stats[1].RegionsStat[0].HotDegree, Equals, 1)
+	stats = hc.StoreStats(WriteFlow, false, 0)
+	c.Assert(stats, HasLen, 1)
+	c.Assert(stats[2].RegionsCount, Equals, 1)
+	stats = hc.StoreStats(ReadFlow, false, 0)
+	c.Assert(stats, HasLen, 1)
+	c.Assert(stats[2].RegionsCount, Equals, 1)
+	c.Assert(hc.RandHotRegionFromStore(2, ReadFlow, 0).RegionID, Equals, uint64(1))
+	c.Assert(hc.RandHotRegionFromStore(1, ReadFlow, 0), IsNil)
+
+	// The region is removed.
+	hc.RemoveRegion(1)
+	c.Assert(hc.RegionStats(WriteFlow), HasLen, 0)
+	c.Assert(hc.RegionStats(ReadFlow), HasLen, 0)
+}
+
+func (s *testHotCacheSuite) TestInactivePeer(c *C) {
+	hc := newHotSpotCache()
+	region := newTestHotRegion(1, 1)
+	region.WrittenBytes = 64 * 1024 * RegionHeartBeatReportInterval
+	checkAndUpdate(hc, region, WriteFlow)
+	checkAndUpdate(hc, region, WriteFlow)
+	c.Assert(hc.RegionStat(1, WriteFlow).HotDegree, Equals, 1)
+
+	// The peer without any report for a long time is aged out.
+	stat := hc.RegionStat(1, WriteFlow)
+	stat.LastUpdateTime = time.Now().Add(-hotPeerMaxInactiveTime - time.Second)
+	c.Assert(hc.RegionStat(1, WriteFlow), IsNil)
+	c.Assert(hc.RegionStats(WriteFlow), HasLen, 0)
+	c.Assert(hc.isRegionHot(1, 0), IsFalse)
+
+	// The history is dropped when it reports again.
+	region.WrittenBytes = 64 * 1024 * 10 * RegionHeartBeatReportInterval
+	checkAndUpdate(hc, region, WriteFlow)
+	c.Assert(hc.RegionStat(1, WriteFlow).HotDegree, Equals, 0)
+}
+
+func (s *testHotCacheSuite) TestPrunePeers(c *C) {
+	pc := newHotPeerCache()
+	put := func(regionID uint64, lastUpdate time.Time) {
+		pc.putPeer(&core.RegionStat{RegionID: regionID, StoreID: 1, LastUpdateTime: lastUpdate})
+	}
+	inactive := time.Now().Add(-hotPeerMaxInactiveTime - time.Second)
+	put(1, inactive)
+	for id := uint64(2); id <= 3*statCacheMaxLen; id++ {
+		put(id, time.Now())
+	}
+
+	// The evicted peers are pruned from the index.
+	c.Assert(pc.len(), Equals, statCacheMaxLen)
+	c.Assert(len(pc.storesOfRegion), Less, 2*statCacheMaxLen)
+	c.Assert(pc.getStores(2), HasLen, 0)
+	c.Assert(pc.getStores(3*statCacheMaxLen), DeepEquals, []uint64{1})
+
+	// The inactive peers are pruned from both the cache and the index.
+	pc = newHotPeerCache()
+	put(1, inactive)
+	pc.pruneLocked()
+	c.Assert(pc.len(), Equals, 0)
+	c.Assert(pc.storesOfRegion, HasLen, 0)
+}
@@ -0,0 +1,257 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"strings"
+
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/namespace"
+	log "github.com/sirupsen/logrus"
+)
+
+const isolationCheckerName = "isolation-checker"
+
+// IsolationChecker moves the peers of the regions which are isolated at a
+// worse label level than the store topology allows, so that the replicas
+// are spread as widely as possible over the location labels.
+type IsolationChecker struct {
+	name     string
+	cluster  Cluster
+	replicas *ReplicaChecker
+}
+
+// NewIsolationChecker creates an isolation checker.
+func NewIsolationChecker(cluster Cluster, classifier namespace.Classifier) *IsolationChecker {
+	return &IsolationChecker{
+		name:     isolationCheckerName,
+		cluster:  cluster,
+		replicas: NewReplicaChecker(cluster, classifier, isolationCheckerName),
+	}
+}
+
+// WithCluster returns a copy of the checker which reads the options from the
+// cluster, such as the options overridden by the namespace of a region.
+func (i *IsolationChecker) WithCluster(cluster Cluster) *IsolationChecker {
+	return &IsolationChecker{
+		name:     i.name,
+		cluster:  cluster,
+		replicas: i.replicas.WithCluster(cluster),
+	}
+}
+
+// Check verifies the isolation level of a region, creating an Operator to
+// move one of its peers if the level can be improved.
+func (i *IsolationChecker) Check(region *core.RegionInfo) *Operator {
+	checkerCounter.WithLabelValues("isolation_checker", "check").Inc()
+	labels := i.cluster.GetLocationLabels()
+	if len(labels) == 0 || i.cluster.IsPlacementRulesEnabled() {
+		return nil
+	}
+	// skip region has down peers or pending peers
+	if len(region.DownPeers) > 0 || len(region.PendingPeers) > 0 {
+		checkerCounter.WithLabelValues("isolation_checker", "special_peer").Inc()
+		return nil
+	}
+
+	stores := i.replicas.getReplicaStores(region)
+	if len(stores) != i.cluster.GetMaxReplicas() {
+		checkerCounter.WithLabelValues("isolation_checker", "abnormal_replica").Inc()
+		return nil
+	}
+	level := GetIsolationLevel(stores, labels)
+	if !i.isUnderIsolated(region, stores, level) {
+		checkerCounter.WithLabelValues("isolation_checker", "all_right").Inc()
+		return nil
+	}
+
+	source, target := i.selectBestMove(region, stores, level)
+	if target == nil {
+		log.Debugf("[region %d] no move improves the isolation level %d", region.GetId(), level)
+		checkerCounter.WithLabelValues("isolation_checker", "no_target_store").Inc()
+		return nil
+	}
+	newPeer, err := i.cluster.AllocPeer(target.GetId())
+	if err != nil {
+		return nil
+	}
+	checkerCounter.WithLabelValues("isolation_checker", "new_operator").Inc()
+	return CreateMovePeerOperator("improveIsolation", i.cluster, region, OpIsolation, source.GetId(), newPeer.GetStoreId(), newPeer.GetId())
+}
+
+// IsUnderIsolated returns true if the region is isolated at a worse label
+// level than the best one achievable by the available stores.
+func (i *IsolationChecker) IsUnderIsolated(region *core.RegionInfo) bool {
+	labels := i.cluster.GetLocationLabels()
+	if len(labels) == 0 {
+		return false
+	}
+	stores := i.replicas.getReplicaStores(region)
+	return i.isUnderIsolated(region, stores, GetIsolationLevel(stores, labels))
+}
+
+func (i *IsolationChecker) isUnderIsolated(region *core.RegionInfo, stores []*core.StoreInfo, level int) bool {
+	labels := i.cluster.GetLocationLabels()
+	candidates := append([]*core.StoreInfo{}, stores...)
+	filters := i.availableFilters(region)
+	for _, store := range i.cluster.GetStores() {
+		if region.GetStorePeer(store.GetId()) == nil && !FilterTarget(i.cluster, store, filters) {
+			candidates = append(candidates, store)
+		}
+	}
+	best := GetBestIsolationLevel(candidates, labels, len(stores))
+	return isolationRank(level, labels) > isolationRank(best, labels)
+}
+
+// availableFilters returns the filters of the stores which can hold a peer
+// of the region in the long run, the transient conditions such as the
+// snapshot count are not taken into account.
+func (i *IsolationChecker) availableFilters(region *core.RegionInfo) []Filter {
+	_, learnerConstraints := GetLearnerReplicas(i.cluster, i.replicas.classifier, region)
+	filters := []Filter{
+		NewStateFilter(i.name),
+		NewHealthFilter(i.name),
+		NewRejectRegionFilter(i.name),
+		NewRangeConstraintFilter(i.name, i.cluster, region),
+		NewLearnerStoreFilter(i.name, learnerConstraints),
+	}
+	if i.replicas.classifier != nil {
+		filters = append(filters, NewNamespaceFilter(i.name, i.replicas.classifier, i.replicas.classifier.GetRegionNamespace(region)))
+	}
+	return filters
+}
+
+// selectBestMove returns the peer store and the target store of the move
+// which improves the isolation level of the region most. The followers are
+// preferred to be moved, and the target with the lower region score is
+// preferred if the levels are the same.
+func (i *IsolationChecker) selectBestMove(region *core.RegionInfo, stores []*core.StoreInfo, level int) (*core.StoreInfo, *core.StoreInfo) {
+	labels := i.cluster.GetLocationLabels()
+	filters := i.replicas.addReplicaFilters(region, NewStorageThresholdFilter(i.name))
+	var targets []*core.StoreInfo
+	for _, store := range i.cluster.GetStores() {
+		if !FilterTarget(i.cluster, store, filters) {
+			targets = append(targets, store)
+		}
+	}
+
+	var source, target *core.StoreInfo
+	bestRank := isolationRank(level, labels)
+	for _, s := range stores {
+		for _, t := range targets {
+			newStores := make([]*core.StoreInfo, 0, len(stores))
+			for _, store := range stores {
+				if store.GetId() != s.GetId() {
+					newStores = append(newStores, store)
+				}
+			}
+			newStores = append(newStores, t)
+			rank := isolationRank(GetIsolationLevel(newStores, labels), labels)
+			if rank < bestRank || (rank == bestRank && target != nil && i.preferMove(region, s, t, source, target)) {
+				source, target, bestRank = s, t, rank
+			}
+		}
+	}
+	return source, target
+}
+
+// preferMove returns true if moving the peer from s to t is preferred to the
+// move from source to target when they reach the same isolation level.
+func (i *IsolationChecker) preferMove(region *core.RegionInfo, s, t, source, target *core.StoreInfo) bool {
+	leader := region.Leader.GetStoreId()
+	if (s.GetId() == leader) != (source.GetId() == leader) {
+		return source.GetId() == leader
+	}
+	return StoreScore(i.cluster, t, core.RegionKind, 0) < StoreScore(i.cluster, target, core.RegionKind, 0)
+}
+
+// isolationRank converts the isolation level to a rank which is lower when
+// the replicas are isolated at a higher label level, level 0 means that the
+// replicas are not isolated at any level.
+func isolationRank(level int, labels []string) int {
+	if level == 0 {
+		return len(labels) + 1
+	}
+	return level
+}
+
+// GetIsolationLevel returns the first label level (starting from 1) at which
+// all the stores are isolated from each other, or 0 if they are not isolated
+// at any level.
+func GetIsolationLevel(stores []*core.StoreInfo, labels []string) int {
+	if len(stores) == 0 || len(labels) == 0 {
+		return 0
+	}
+	queueStores := [][]*core.StoreInfo{stores}
+	for level, label := range labels {
+		newQueueStores := make([][]*core.StoreInfo, 0, len(stores))
+		for _, stores := range queueStores {
+			notIsolatedStores := notIsolatedStoresWithLabel(stores, label)
+			if len(notIsolatedStores) > 0 {
+				newQueueStores = append(newQueueStores, notIsolatedStores...)
+			}
+		}
+		queueStores = newQueueStores
+		if len(queueStores) == 0 {
+			return level + 1
+		}
+	}
+	return 0
+}
+
+func notIsolatedStoresWithLabel(stores []*core.StoreInfo, label string) [][]*core.StoreInfo {
+	m := make(map[string][]*core.StoreInfo)
+	for _, s := range stores {
+		labelValue := s.GetLabelValue(label)
+		if labelValue == "" {
+			continue
+		}
+		m[labelValue] = append(m[labelValue], s)
+	}
+	var res [][]*core.StoreInfo
+	for _, stores := range m {
+		if len(stores) > 1 {
+			res = append(res, stores)
+		}
+	}
+	return res
+}
+
+// GetBestIsolationLevel returns the best isolation level of the given number
+// of replicas placed on the stores. Only the stores with all the labels of a
+// level are counted for the level.
+func GetBestIsolationLevel(stores []*core.StoreInfo, labels []string, replicas int) int {
+	if replicas == 0 {
+		return 0
+	}
+	for level := range labels {
+		locations := make(map[string]struct{})
+	nextStore:
+		for _, s := range stores {
+			values := make([]string, 0, level+1)
+			for _, label := range labels[:level+1] {
+				value := s.GetLabelValue(label)
+				if value == "" {
+					continue nextStore
+				}
+				values = append(values, value)
+			}
+			locations[strings.Join(values, "/")] = struct{}{}
+		}
+		if len(locations) >= replicas {
+			return level + 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,79 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/pd/server/namespace"
+)
+
+var _ = Suite(&testIsolationCheckerSuite{})
+
+type testIsolationCheckerSuite struct{}
+
+func (s *testIsolationCheckerSuite) TestBestIsolationLevel(c *C) {
+	opt := NewMockSchedulerOptions()
+	tc := NewMockCluster(opt)
+	labels := []string{"zone", "host"}
+	tc.AddLabelsStore(1, 0, map[string]string{"zone": "z1", "host": "h1"})
+	tc.AddLabelsStore(2, 0, map[string]string{"zone": "z1", "host": "h2"})
+	tc.AddLabelsStore(3, 0, map[string]string{"zone": "z2", "host": "h1"})
+	tc.AddLabelsStore(4, 0, map[string]string{"host": "h1"})
+
+	stores := tc.GetStores()
+	c.Assert(GetBestIsolationLevel(stores, labels, 2), Equals, 1)
+	c.Assert(GetBestIsolationLevel(stores, labels, 3), Equals, 2)
+	c.Assert(GetBestIsolationLevel(stores, labels, 4), Equals, 0)
+	c.Assert(GetBestIsolationLevel(stores, nil, 3), Equals, 0)
+	c.Assert(GetBestIsolationLevel(stores, labels, 0), Equals, 0)
+}
+
+func (s *testIsolationCheckerSuite) TestImproveIsolation(c *C) {
+	opt := NewMockSchedulerOptions()
+	tc := NewMockCluster(opt)
+	ic := NewIsolationChecker(tc, namespace.DefaultClassifier)
+
+	tc.AddLabelsStore(1, 1, map[string]string{"zone": "z1", "host": "h1"})
+	tc.AddLabelsStore(2, 1, map[string]string{"zone": "z1", "host": "h2"})
+	tc.AddLabelsStore(3, 1, map[string]string{"zone": "z2", "host": "h1"})
+	tc.AddLabelsStore(4, 0, map[string]string{"zone": "z3", "host": "h1"})
+	tc.AddLabelsStore(5, 5, map[string]string{"zone": "z3", "host": "h2"})
+	tc.AddLeaderRegion(1, 1, 2, 3)
+	region := tc.GetRegion(1)
+
+	// The checker does nothing without the location labels.
+	c.Assert(ic.IsUnderIsolated(region), IsFalse)
+	c.Assert(ic.Check(region), IsNil)
+
+	// The follower in the crowded zone is moved to the least loaded store
+	// of the new zone.
+	opt.LocationLabels = []string{"zone", "host"}
+	c.Assert(ic.IsUnderIsolated(region), IsTrue)
+	op := ic.Check(region)
+	c.Assert(op, NotNil)
+	c.Assert(op.Kind()&OpIsolation, Equals, OpIsolation)
+	c.Assert(op.Step(0).(AddLearner).ToStore, Equals, uint64(4))
+	c.Assert(op.Step(op.Len()-1).(RemovePeer).FromStore, Equals, uint64(2))
+
+	// The region is isolated at the best level.
+	tc.AddLeaderRegion(2, 1, 3, 4)
+	c.Assert(ic.IsUnderIsolated(tc.GetRegion(2)), IsFalse)
+	c.Assert(ic.Check(tc.GetRegion(2)), IsNil)
+
+	// The best level is not achievable when the new zone is offline.
+	tc.SetStoreOffline(4)
+	tc.SetStoreOffline(5)
+	c.Assert(ic.IsUnderIsolated(region), IsFalse)
+	c.Assert(ic.Check(region), IsNil)
+}
@@ -0,0 +1,66 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/server/core"
+)
+
+// LeaderPreference is a list of store labels in the order of preference. The
+// leaders tend to be placed on the healthy stores matching the earliest label.
+type LeaderPreference []*metapb.StoreLabel
+
+// ParseLeaderPreference parses the labels in the form of key=value.
+func ParseLeaderPreference(args []string) (LeaderPreference, error) {
+	preference := make(LeaderPreference, 0, len(args))
+	for _, arg := range args {
+		kv := strings.SplitN(arg, "=", 2)
+		if len(kv) != 2 {
+			return nil, errors.Errorf("invalid leader preference %s, it should be key=value", arg)
+		}
+		preference = append(preference, &metapb.StoreLabel{Key: kv[0], Value: kv[1]})
+	}
+	if err := preference.Validate(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return preference, nil
+}
+
+// Validate checks if the preference is valid.
+func (p LeaderPreference) Validate() error {
+	if len(p) == 0 {
+		return errors.New("leader preference is empty")
+	}
+	for _, label := range p {
+		if label.GetKey() == "" || label.GetValue() == "" {
+			return errors.Errorf("invalid leader preference label %s=%s", label.GetKey(), label.GetValue())
+		}
+	}
+	return nil
+}
+
+// Rank returns the index of the first label the store matches, a smaller rank
+// is more preferred. It returns len(p) if the store matches none of them.
+func (p LeaderPreference) Rank(store *core.StoreInfo) int {
+	for i, label := range p {
+		if strings.EqualFold(store.GetLabelValue(label.GetKey()), label.GetValue()) {
+			return i
+		}
+	}
+	return len(p)
+}
@@ -0,0 +1,93 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/namespace"
+	"github.com/pingcap/pd/server/schedule/placement"
+)
+
+// GetLearnerReplicas returns the number of the read-only learners of the
+// region and the label constraints of the stores to place them. The range
+// constraints take precedence over the namespace setting. The namespace of
+// the region is decided by the classifier if it is not nil, otherwise the
+// setting of the cluster is used.
+func GetLearnerReplicas(cluster Cluster, classifier namespace.Classifier, region *core.RegionInfo) (int, []placement.LabelConstraint) {
+	count := cluster.GetLearnerReplicas()
+	if classifier != nil {
+		count = cluster.GetOpt().GetLearnerReplicas(classifier.GetRegionNamespace(region))
+	}
+	constraints := placement.SelectorConstraints(cluster.GetLearnerLabels())
+	return cluster.GetRangeConstraintManager().GetLearnerReplicasForRegion(region, count, constraints)
+}
+
+// IsLearnerStore returns true if the store is reserved for the read-only
+// learners by the label constraints.
+func IsLearnerStore(store *core.StoreInfo, constraints []placement.LabelConstraint) bool {
+	return len(constraints) > 0 && placement.MatchLabelConstraints(store, constraints)
+}
+
+// GetPermanentLearners returns the learners of the region which are placed
+// on the stores reserved for the read-only learners. The other learners are
+// transient, they are added to be promoted to voters later.
+func GetPermanentLearners(cluster Cluster, region *core.RegionInfo, constraints []placement.LabelConstraint) []*metapb.Peer {
+	var learners []*metapb.Peer
+	for _, peer := range region.GetPeers() {
+		if !peer.GetIsLearner() {
+			continue
+		}
+		if store := cluster.GetStore(peer.GetStoreId()); store != nil && IsLearnerStore(store, constraints) {
+			learners = append(learners, peer)
+		}
+	}
+	return learners
+}
+
+// HasTransientLearner returns true if the region has a learner which is not
+// a read-only learner.
+func HasTransientLearner(cluster Cluster, region *core.RegionInfo, constraints []placement.LabelConstraint) bool {
+	for _, peer := range region.GetPeers() {
+		if !peer.GetIsLearner() {
+			continue
+		}
+		if store := cluster.GetStore(peer.GetStoreId()); store == nil || !IsLearnerStore(store, constraints) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasExpectedReplicas returns true if the region has the expected number of
+// voters and read-only learners, and has no transient learner. If the
+// placement rules are enabled, the expected number of peers is the total
+// count of the rules applied to the region.
+func HasExpectedReplicas(cluster Cluster, classifier namespace.Classifier, region *core.RegionInfo) bool {
+	if count := GetRuleReplicas(cluster, region); count > 0 {
+		return len(region.GetPeers()) == count
+	}
+	count, constraints := GetLearnerReplicas(cluster, classifier, region)
+	var voters, learners int
+	for _, peer := range region.GetPeers() {
+		if !peer.GetIsLearner() {
+			voters++
+		} else if store := cluster.GetStore(peer.GetStoreId()); store != nil && IsLearnerStore(store, constraints) {
+			learners++
+		} else {
+			return false
+		}
+	}
+	return voters == cluster.GetMaxReplicas() && learners == count
+}
@@ -14,11 +14,13 @@
 package schedule
 
 import (
+	"bytes"
 	"time"
 
 	"github.com/pingcap/pd/server/cache"
 	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/server/namespace"
+	"github.com/pingcap/pd/server/schedule/placement"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -44,6 +46,15 @@ func NewMergeChecker(cluster Cluster, classifier namespace.Classifier) *MergeChe
 	}
 }
 
+// WithCluster returns a copy of the checker which reads the options from the
+// cluster, such as the options overridden by the namespace of a region. The
+// copy shares the split cache with the checker.
+func (m *MergeChecker) WithCluster(cluster Cluster) *MergeChecker {
+	checker := *m
+	checker.cluster = cluster
+	return &checker
+}
+
 // RecordRegionSplit put the recently splitted region into cache. MergeChecker
 // will skip check it for a while.
 func (m *MergeChecker) RecordRegionSplit(regionID uint64) {
@@ -80,13 +91,14 @@ func (m *MergeChecker) Check(region *core.RegionInfo) (*Operator, *Operator) {
 		return nil, nil
 	}
 
-	// skip region has down peers or pending peers or learner peers
-	if len(region.DownPeers) > 0 || len(region.PendingPeers) > 0 || len(region.Learners) > 0 {
+	// skip region has down peers or pending peers
+	if len(region.DownPeers) > 0 || len(region.PendingPeers) > 0 {
 		checkerCounter.WithLabelValues("merge_checker", "special_peer").Inc()
 		return nil, nil
 	}
 
-	if len(region.Region.GetPeers()) != m.cluster.GetMaxReplicas() {
+	// the read-only learners are counted, transient learners are not allowed
+	if !HasExpectedReplicas(m.cluster, m.classifier, region) {
 		checkerCounter.WithLabelValues("merge_checker", "abnormal_replica").Inc()
 		return nil, nil
 	}
@@ -121,14 +133,62 @@ func (m *MergeChecker) checkTarget(region, adjacent, target *core.RegionInfo) *c
 	// if is not hot region and under same namesapce
 	if adjacent != nil && !m.cluster.IsRegionHot(adjacent.GetId()) &&
 		m.classifier.AllowMerge(region, adjacent) &&
-		len(adjacent.DownPeers) == 0 && len(adjacent.PendingPeers) == 0 && len(adjacent.Learners) == 0 {
+		len(adjacent.DownPeers) == 0 && len(adjacent.PendingPeers) == 0 &&
+		m.matchRangeConstraints(region, adjacent) && m.matchRules(region, adjacent) {
 		// if both region is not hot, prefer the one with smaller size
 		if target == nil || target.ApproximateSize > adjacent.ApproximateSize {
 			// peer count should equal
-			if len(adjacent.Region.GetPeers()) == m.cluster.GetMaxReplicas() {
+			if HasExpectedReplicas(m.cluster, m.classifier, adjacent) {
 				target = adjacent
 			}
 		}
 	}
 	return target
 }
+
+// matchRules checks if the same placement rules are applied to the regions
+// and the merged region, so that the merged region which is placed on the
+// stores of the adjacent region still fits the rules.
+func (m *MergeChecker) matchRules(region, adjacent *core.RegionInfo) bool {
+	if !m.cluster.IsPlacementRulesEnabled() {
+		return true
+	}
+	startKey, endKey := region.GetStartKey(), adjacent.GetEndKey()
+	if bytes.Compare(adjacent.GetStartKey(), startKey) < 0 {
+		startKey, endKey = adjacent.GetStartKey(), region.GetEndKey()
+	}
+	manager := m.cluster.GetRuleManager()
+	rules := manager.GetRulesForRange(startKey, endKey)
+	return sameRules(rules, manager.GetRulesForRegion(region)) && sameRules(rules, manager.GetRulesForRegion(adjacent)) &&
+		placement.FitRegion(m.cluster.GetRegionStores(adjacent), adjacent, rules).IsSatisfied()
+}
+
+func sameRules(a, b []*placement.Rule) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].ID != b[i].ID {
+			return false
+		}
+	}
+	return true
+}
+
+// matchRangeConstraints checks if the stores of the voters of the adjacent
+// region match the range constraints of the merged region, as the region is
+// merged to the stores of the adjacent region.
+func (m *MergeChecker) matchRangeConstraints(region, adjacent *core.RegionInfo) bool {
+	startKey, endKey := region.GetStartKey(), adjacent.GetEndKey()
+	if bytes.Compare(adjacent.GetStartKey(), startKey) < 0 {
+		startKey, endKey = adjacent.GetStartKey(), region.GetEndKey()
+	}
+	constraints := m.cluster.GetRangeConstraintManager().GetLabelConstraintsForRange(startKey, endKey)
+	for _, peer := range adjacent.GetPeers() {
+		store := m.cluster.GetStore(peer.GetStoreId())
+		if !peer.GetIsLearner() && store != nil && !placement.MatchLabelConstraints(store, constraints) {
+			return false
+		}
+	}
+	return true
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/server/namespace"
+	"github.com/pingcap/pd/server/schedule/placement"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -30,14 +31,27 @@ type MockCluster struct {
 	*BasicCluster
 	id *core.MockIDAllocator
 	*MockSchedulerOptions
+	RuleManager            *placement.RuleManager
+	RangeConstraintManager *placement.RangeConstraintManager
 }
 
 // NewMockCluster creates a new MockCluster
 func NewMockCluster(opt *MockSchedulerOptions) *MockCluster {
+	kv := core.NewKV(core.NewMemoryKV())
+	ruleManager := placement.NewRuleManager(kv)
+	if err := ruleManager.Initialize(opt.MaxReplicas, opt.LocationLabels); err != nil {
+		log.Fatal(err)
+	}
+	rangeConstraintManager := placement.NewRangeConstraintManager(kv)
+	if err := rangeConstraintManager.Initialize(); err != nil {
+		log.Fatal(err)
+	}
 	return &MockCluster{
-		BasicCluster:         NewBasicCluster(),
-		id:                   core.NewMockIDAllocator(),
-		MockSchedulerOptions: opt,
+		BasicCluster:           NewBasicCluster(),
+		id:                     core.NewMockIDAllocator(),
+		MockSchedulerOptions:   opt,
+		RuleManager:            ruleManager,
+		RangeConstraintManager: rangeConstraintManager,
 	}
 }
 
@@ -167,6 +181,15 @@ func (mc *MockCluster) AddLeaderRegion(regionID uint64, leaderID uint64, followe
 	mc.PutRegion(regionInfo)
 }
 
+// AddRegionLearner adds a learner on the store to the region.
+func (mc *MockCluster) AddRegionLearner(regionID uint64, storeID uint64) {
+	region := mc.GetRegion(regionID)
+	peer, _ := mc.AllocPeer(storeID)
+	peer.IsLearner = true
+	region.AddPeer(peer)
+	mc.PutRegion(region)
+}
+
 // AddLeaderRegionWithRange adds region with specified leader, followers and key range.
 func (mc *MockCluster) AddLeaderRegionWithRange(regionID uint64, startKey string, endKey string, leaderID uint64, followerIds ...uint64) {
 	r := mc.newMockRegionInfo(regionID, leaderID, followerIds...)
@@ -179,10 +202,8 @@ func (mc *MockCluster) AddLeaderRegionWithRange(regionID uint64, startKey string
 func (mc *MockCluster) AddLeaderRegionWithReadInfo(regionID uint64, leaderID uint64, readBytes uint64, followerIds ...uint64) {
 	r := mc.newMockRegionInfo(regionID, leaderID, followerIds...)
 	r.ReadBytes = readBytes
-	isUpdate, item := mc.BasicCluster.CheckReadStatus(r)
-	if isUpdate {
-		mc.HotCache.Update(regionID, item, ReadFlow)
-	}
+	items := mc.BasicCluster.CheckReadStatus(r, mc.GetHotRegionWindowSize())
+	mc.HotCache.Update(items, ReadFlow)
 	mc.PutRegion(r)
 }
 
@@ -190,10 +211,18 @@ func (mc *MockCluster) AddLeaderRegionWithReadInfo(regionID uint64, leaderID uin
 func (mc *MockCluster) AddLeaderRegionWithWriteInfo(regionID uint64, leaderID uint64, writtenBytes uint64, followerIds ...uint64) {
 	r := mc.newMockRegionInfo(regionID, leaderID, followerIds...)
 	r.WrittenBytes = writtenBytes
-	isUpdate, item := mc.BasicCluster.CheckWriteStatus(r)
-	if isUpdate {
-		mc.HotCache.Update(regionID, item, WriteFlow)
-	}
+	items := mc.BasicCluster.CheckWriteStatus(r, mc.GetHotRegionWindowSize())
+	mc.HotCache.Update(items, WriteFlow)
+	mc.PutRegion(r)
+}
+
+// AddLeaderRegionWithReadKeysInfo adds region with specified leader, followers and read bytes and keys.
+func (mc *MockCluster) AddLeaderRegionWithReadKeysInfo(regionID uint64, leaderID uint64, readBytes, readKeys uint64, followerIds ...uint64) {
+	r := mc.newMockRegionInfo(regionID, leaderID, followerIds...)
+	r.ReadBytes = readBytes
+	r.ReadKeys = readKeys
+	items := mc.BasicCluster.CheckReadStatus(r, mc.GetHotRegionWindowSize())
+	mc.HotCache.Update(items, ReadFlow)
 	mc.PutRegion(r)
 }
 
@@ -290,6 +319,7 @@ func (mc *MockCluster) UpdateStorageReadBytes(storeID uint64, BytesRead uint64)
 func (mc *MockCluster) UpdateStoreStatus(id uint64) {
 	mc.Stores.SetLeaderCount(id, mc.Regions.GetStoreLeaderCount(id))
 	mc.Stores.SetRegionCount(id, mc.Regions.GetStoreRegionCount(id))
+	mc.Stores.SetLearnerCount(id, mc.Regions.GetStoreLearnerCount(id))
 	mc.Stores.SetPendingPeerCount(id, mc.Regions.GetStorePendingPeerCount(id))
 	mc.Stores.SetLeaderSize(id, mc.Regions.GetStoreLeaderRegionSize(id))
 	mc.Stores.SetRegionSize(id, mc.Regions.GetStoreRegionSize(id))
@@ -400,6 +430,21 @@ func (mc *MockCluster) GetMaxReplicas() int {
 	return mc.MockSchedulerOptions.GetMaxReplicas(namespace.DefaultNamespace)
 }
 
+// GetLearnerReplicas mocks method.
+func (mc *MockCluster) GetLearnerReplicas() int {
+	return mc.MockSchedulerOptions.GetLearnerReplicas(namespace.DefaultNamespace)
+}
+
+// GetRuleManager mocks method.
+func (mc *MockCluster) GetRuleManager() *placement.RuleManager {
+	return mc.RuleManager
+}
+
+// GetRangeConstraintManager mocks method.
+func (mc *MockCluster) GetRangeConstraintManager() *placement.RangeConstraintManager {
+	return mc.RangeConstraintManager
+}
+
 // CheckLabelProperty checks label property.
 func (mc *MockCluster) CheckLabelProperty(typ string, labels []*metapb.StoreLabel) bool {
 	for _, cfg := range mc.LabelProperties[typ] {
@@ -413,20 +458,25 @@ func (mc *MockCluster) CheckLabelProperty(typ string, labels []*metapb.StoreLabe
 }
 
 const (
-	defaultMaxReplicas          = 3
-	defaultMaxSnapshotCount     = 3
-	defaultMaxPendingPeerCount  = 16
-	defaultMaxMergeRegionSize   = 0
-	defaultMaxMergeRegionKeys   = 0
-	defaultSplitMergeInterval   = 0
-	defaultMaxStoreDownTime     = 30 * time.Minute
-	defaultLeaderScheduleLimit  = 4
-	defaultRegionScheduleLimit  = 4
-	defaultReplicaScheduleLimit = 8
-	defaultMergeScheduleLimit   = 8
-	defaultTolerantSizeRatio    = 2.5
-	defaultLowSpaceRatio        = 0.8
-	defaultHighSpaceRatio       = 0.6
+	defaultMaxReplicas            = 3
+	defaultMaxSnapshotCount       = 3
+	defaultMaxPendingPeerCount    = 16
+	defaultMaxMergeRegionSize     = 0
+	defaultMaxMergeRegionKeys     = 0
+	defaultSplitMergeInterval     = 0
+	defaultMaxStoreDownTime       = 30 * time.Minute
+	defaultLeaderScheduleLimit    = 4
+	defaultRegionScheduleLimit    = 4
+	defaultReplicaScheduleLimit   = 8
+	defaultMergeScheduleLimit     = 8
+	defaultSplitScheduleLimit     = 4
+	defaultIsolationScheduleLimit = 4
+	defaultLoadSplitMinFlow       = 1024 * 1024
+	defaultLoadSplitCooldown      = 10 * time.Minute
+	defaultHotRegionWindowSize    = 5
+	defaultTolerantSizeRatio      = 2.5
+	defaultLowSpaceRatio          = 0.8
+	defaultHighSpaceRatio         = 0.6
 )
 
 // MockSchedulerOptions is a mock of SchedulerOptions
@@ -441,19 +491,30 @@ type MockSchedulerOptions struct {
 	MaxMergeRegionSize           uint64
 	MaxMergeRegionKeys           uint64
 	SplitMergeInterval           time.Duration
+	SplitScheduleLimit           uint64
+	IsolationScheduleLimit       uint64
+	LoadSplitHotDegree           int
+	LoadSplitMinFlowBytes        uint64
+	LoadSplitCooldown            time.Duration
+	HotRegionWindowSize          int
 	MaxStoreDownTime             time.Duration
 	MaxReplicas                  int
+	LearnerReplicas              int
+	LearnerLabels                map[string]string
 	LocationLabels               []string
 	HotRegionLowThreshold        int
 	TolerantSizeRatio            float64
 	LowSpaceRatio                float64
 	HighSpaceRatio               float64
+	LeaderScoreStrategy          string
+	RegionScoreStrategy          string
 	DisableLearner               bool
 	DisableRemoveDownReplica     bool
 	DisableReplaceOfflineReplica bool
 	DisableMakeUpReplica         bool
 	DisableRemoveExtraReplica    bool
 	DisableLocationReplacement   bool
+	EnablePlacementRules         bool
 	LabelProperties              map[string][]*metapb.StoreLabel
 }
 
@@ -468,11 +529,18 @@ func NewMockSchedulerOptions() *MockSchedulerOptions {
 	mso.MaxMergeRegionSize = defaultMaxMergeRegionSize
 	mso.MaxMergeRegionKeys = defaultMaxMergeRegionKeys
 	mso.SplitMergeInterval = defaultSplitMergeInterval
+	mso.SplitScheduleLimit = defaultSplitScheduleLimit
+	mso.IsolationScheduleLimit = defaultIsolationScheduleLimit
+	mso.LoadSplitMinFlowBytes = defaultLoadSplitMinFlow
+	mso.LoadSplitCooldown = defaultLoadSplitCooldown
+	mso.HotRegionWindowSize = defaultHotRegionWindowSize
 	mso.MaxStoreDownTime = defaultMaxStoreDownTime
 	mso.MaxReplicas = defaultMaxReplicas
 	mso.HotRegionLowThreshold = HotRegionLowThreshold
 	mso.MaxPendingPeerCount = defaultMaxPendingPeerCount
 	mso.TolerantSizeRatio = defaultTolerantSizeRatio
+	mso.LeaderScoreStrategy = core.ScoreBySize
+	mso.RegionScoreStrategy = core.ScoreBySize
 	mso.LowSpaceRatio = defaultLowSpaceRatio
 	mso.HighSpaceRatio = defaultHighSpaceRatio
 	return mso
@@ -523,6 +591,36 @@ func (mso *MockSchedulerOptions) GetSplitMergeInterval() time.Duration {
 	return mso.SplitMergeInterval
 }
 
+// GetSplitScheduleLimit mock method
+func (mso *MockSchedulerOptions) GetSplitScheduleLimit() uint64 {
+	return mso.SplitScheduleLimit
+}
+
+// GetIsolationScheduleLimit mock method
+func (mso *MockSchedulerOptions) GetIsolationScheduleLimit() uint64 {
+	return mso.IsolationScheduleLimit
+}
+
+// GetLoadSplitHotDegree mock method
+func (mso *MockSchedulerOptions) GetLoadSplitHotDegree() int {
+	return mso.LoadSplitHotDegree
+}
+
+// GetLoadSplitMinFlowBytes mock method
+func (mso *MockSchedulerOptions) GetLoadSplitMinFlowBytes() uint64 {
+	return mso.LoadSplitMinFlowBytes
+}
+
+// GetLoadSplitCooldown mock method
+func (mso *MockSchedulerOptions) GetLoadSplitCooldown() time.Duration {
+	return mso.LoadSplitCooldown
+}
+
+// GetHotRegionWindowSize mock method
+func (mso *MockSchedulerOptions) GetHotRegionWindowSize() int {
+	return mso.HotRegionWindowSize
+}
+
 // GetMaxStoreDownTime mock method
 func (mso *MockSchedulerOptions) GetMaxStoreDownTime() time.Duration {
 	return mso.MaxStoreDownTime
@@ -533,6 +631,16 @@ func (mso *MockSchedulerOptions) GetMaxReplicas(name string) int {
 	return mso.MaxReplicas
 }
 
+// GetLearnerReplicas mock method
+func (mso *MockSchedulerOptions) GetLearnerReplicas(name string) int {
+	return mso.LearnerReplicas
+}
+
+// GetLearnerLabels mock method
+func (mso *MockSchedulerOptions) GetLearnerLabels() map[string]string {
+	return mso.LearnerLabels
+}
+
 // GetLocationLabels mock method
 func (mso *MockSchedulerOptions) GetLocationLabels() []string {
 	return mso.LocationLabels
@@ -558,6 +666,16 @@ func (mso *MockSchedulerOptions) GetHighSpaceRatio() float64 {
 	return mso.HighSpaceRatio
 }
 
+// GetLeaderScoreStrategy mock method
+func (mso *MockSchedulerOptions) GetLeaderScoreStrategy() string {
+	return mso.LeaderScoreStrategy
+}
+
+// GetRegionScoreStrategy mock method
+func (mso *MockSchedulerOptions) GetRegionScoreStrategy() string {
+	return mso.RegionScoreStrategy
+}
+
 // SetMaxReplicas mock method
 func (mso *MockSchedulerOptions) SetMaxReplicas(replicas int) {
 	mso.MaxReplicas = replicas
@@ -592,3 +710,8 @@ func (mso *MockSchedulerOptions) IsRemoveExtraReplicaEnabled() bool {
 func (mso *MockSchedulerOptions) IsLocationReplacementEnabled() bool {
 	return !mso.DisableLocationReplacement
 }
+
+// IsPlacementRulesEnabled mock method.
+func (mso *MockSchedulerOptions) IsPlacementRulesEnabled() bool {
+	return mso.EnablePlacementRules
+}
@@ -20,6 +20,8 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+const namespaceCheckerName = "namespace-checker"
+
 // NamespaceChecker ensures region to go to the right place.
 type NamespaceChecker struct {
 	cluster    Cluster
@@ -30,8 +32,8 @@ type NamespaceChecker struct {
 // NewNamespaceChecker creates a namespace checker.
 func NewNamespaceChecker(cluster Cluster, classifier namespace.Classifier) *NamespaceChecker {
 	filters := []Filter{
-		NewHealthFilter(),
-		NewSnapshotCountFilter(),
+		NewHealthFilter(namespaceCheckerName),
+		NewSnapshotCountFilter(namespaceCheckerName),
 	}
 
 	return &NamespaceChecker{
@@ -51,6 +53,14 @@ func (n *NamespaceChecker) Check(region *core.RegionInfo) *Operator {
 		return nil
 	}
 
+	// a region straddling the namespaces can't be placed by the namespace of
+	// its start key, or the keys of the other namespaces go along with it
+	if namespace.IsStraddling(n.classifier, region) {
+		log.Debugf("[region %d] straddles namespaces, skip placing it until it is split", region.GetId())
+		checkerCounter.WithLabelValues("namespace_checker", "straddling_region").Inc()
+		return nil
+	}
+
 	// get all the stores belong to the namespace
 	targetStores := n.getNamespaceStores(region)
 	if len(targetStores) == 0 {
@@ -76,6 +86,25 @@ func (n *NamespaceChecker) Check(region *core.RegionInfo) *Operator {
 	return nil
 }
 
+// CheckMigration reports the placement of the region to the classifier if the
+// region is being migrated to another namespace, it returns true if so.
+func (n *NamespaceChecker) CheckMigration(region *core.RegionInfo) bool {
+	migrator, ok := n.classifier.(namespace.Migrator)
+	if !ok || !migrator.IsMigrating(region) {
+		return false
+	}
+	targetStores := n.getNamespaceStores(region)
+	placed := len(targetStores) > 0
+	for _, peer := range region.GetPeers() {
+		if !n.isExists(targetStores, peer.GetStoreId()) {
+			placed = false
+			break
+		}
+	}
+	migrator.ReportRegion(region, placed)
+	return true
+}
+
 // SelectBestPeerToRelocate return a new peer that to be used to move a region
 func (n *NamespaceChecker) SelectBestPeerToRelocate(region *core.RegionInfo, targets []*core.StoreInfo, filters ...Filter) *metapb.Peer {
 	storeID := n.SelectBestStoreToRelocate(region, targets, filters...)
@@ -93,8 +122,8 @@ func (n *NamespaceChecker) SelectBestPeerToRelocate(region *core.RegionInfo, tar
 // SelectBestStoreToRelocate randomly returns the store to relocate
 func (n *NamespaceChecker) SelectBestStoreToRelocate(region *core.RegionInfo, targets []*core.StoreInfo, filters ...Filter) uint64 {
 	newFilters := []Filter{
-		NewStateFilter(),
-		NewExcludedFilter(nil, region.GetStoreIds()),
+		NewStateFilter(namespaceCheckerName),
+		NewExcludedFilter(namespaceCheckerName, nil, region.GetStoreIds()),
 	}
 	filters = append(filters, newFilters...)
 
@@ -117,7 +146,7 @@ func (n *NamespaceChecker) isExists(stores []*core.StoreInfo, storeID uint64) bo
 
 func (n *NamespaceChecker) getNamespaceStores(region *core.RegionInfo) []*core.StoreInfo {
 	ns := n.classifier.GetRegionNamespace(region)
-	filteredStores := n.filter(n.cluster.GetStores(), NewNamespaceFilter(n.classifier, ns))
+	filteredStores := n.filter(n.cluster.GetStores(), NewNamespaceFilter(namespaceCheckerName, n.classifier, ns))
 
 	return filteredStores
 }
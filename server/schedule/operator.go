@@ -211,6 +211,11 @@ func (mr MergeRegion) Influence(opInfluence OpInfluence, region *core.RegionInfo
 }
 
 // SplitRegion is an OperatorStep that splits a region.
+//
+// TODO: support splitting at the given keys. The pdpb.SplitRegion of the
+// vendored kvproto only carries the check policy, so TiKV always decides the
+// split key itself. The admin API and pd-ctl command to split a region or a
+// key range at explicit keys should be added after kvproto and TiKV support it.
 type SplitRegion struct {
 	StartKey, EndKey []byte
 	Policy           pdpb.CheckPolicy
@@ -444,12 +449,22 @@ func CreateMovePeerOperator(desc string, cluster Cluster, region *core.RegionInf
 	return NewOperator(desc, region.GetId(), region.GetRegionEpoch(), removeKind|kind|OpRegion, steps...)
 }
 
+// CreateMoveLearnerOperator creates an Operator that replaces an old learner
+// with a new learner, the new learner is never promoted.
+func CreateMoveLearnerOperator(desc string, region *core.RegionInfo, kind OperatorKind, oldStore, newStore uint64, peerID uint64) *Operator {
+	steps := []OperatorStep{
+		AddLearner{ToStore: newStore, PeerID: peerID},
+		RemovePeer{FromStore: oldStore},
+	}
+	return NewOperator(desc, region.GetId(), region.GetRegionEpoch(), kind|OpRegion, steps...)
+}
+
 // removePeerSteps returns the steps to safely remove a peer. It prevents removing leader by transfer its leadership first.
 func removePeerSteps(cluster Cluster, region *core.RegionInfo, storeID uint64) (kind OperatorKind, steps []OperatorStep) {
 	if region.Leader != nil && region.Leader.GetStoreId() == storeID {
 		for id := range region.GetFollowers() {
 			follower := cluster.GetStore(id)
-			if follower != nil && !cluster.CheckLabelProperty(RejectLeader, follower.Labels) {
+			if follower != nil && !cluster.CheckLabelProperty(RejectLeader, follower.Labels) && !cluster.CheckLabelProperty(ReadOnly, follower.Labels) {
 				steps = append(steps, TransferLeader{FromStore: storeID, ToStore: id})
 				kind = OpLeader
 				break
@@ -497,9 +512,18 @@ func matchPeerSteps(cluster Cluster, source *core.RegionInfo, target *core.Regio
 		storeIDs[peer.GetStoreId()] = struct{}{}
 	}
 
-	// Add missing peers.
-	for id := range storeIDs {
-		if source.GetStorePeer(id) != nil {
+	// Add missing peers, the learners of the target region are added as
+	// learners, and the learners of the source region on the stores of the
+	// target voters are promoted.
+	for _, targetPeer := range targetPeers {
+		id, isLearner := targetPeer.GetStoreId(), targetPeer.GetIsLearner()
+		if p := source.GetStorePeer(id); p != nil {
+			if p.GetIsLearner() && !isLearner {
+				steps = append(steps, PromoteLearner{ToStore: id, PeerID: p.GetId()})
+				kind |= OpRegion
+			} else if !p.GetIsLearner() && isLearner {
+				return nil, kind, errors.Errorf("cannot demote the voter on store %d to learner", id)
+			}
 			continue
 		}
 		peer, err := cluster.AllocPeer(id)
@@ -507,7 +531,9 @@ func matchPeerSteps(cluster Cluster, source *core.RegionInfo, target *core.Regio
 			log.Debugf("peer alloc failed: %v", err)
 			return nil, kind, errors.Trace(err)
 		}
-		if cluster.IsRaftLearnerEnabled() {
+		if isLearner {
+			steps = append(steps, AddLearner{ToStore: id, PeerID: peer.Id})
+		} else if cluster.IsRaftLearnerEnabled() {
 			steps = append(steps,
 				AddLearner{ToStore: id, PeerID: peer.Id},
 				PromoteLearner{ToStore: id, PeerID: peer.Id},
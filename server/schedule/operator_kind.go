@@ -33,6 +33,8 @@ const (
 	OpBalance                            // Initiated by balancers.
 	OpMerge                              // Initiated by merge checkers or merge schedulers.
 	OpRange                              // Initiated by range scheduler.
+	OpSplit                              // Initiated by split checkers.
+	OpIsolation                          // Initiated by isolation checkers.
 	opMax
 )
 
@@ -46,6 +48,8 @@ var flagToName = map[OperatorKind]string{
 	OpBalance:   "balance",
 	OpMerge:     "merge",
 	OpRange:     "range",
+	OpSplit:     "split",
+	OpIsolation: "isolation",
 }
 
 var nameToFlag = map[string]OperatorKind{
@@ -58,6 +62,8 @@ var nameToFlag = map[string]OperatorKind{
 	"balance":   OpBalance,
 	"merge":     OpMerge,
 	"range":     OpRange,
+	"split":     OpSplit,
+	"isolation": OpIsolation,
 }
 
 func (k OperatorKind) String() string {
@@ -16,6 +16,7 @@ package schedule
 import (
 	"time"
 
+	"github.com/juju/errors"
 	"github.com/pingcap/kvproto/pkg/metapb"
 )
 
@@ -36,14 +37,24 @@ type Options interface {
 	GetMaxMergeRegionSize() uint64
 	GetMaxMergeRegionKeys() uint64
 	GetSplitMergeInterval() time.Duration
+	GetSplitScheduleLimit() uint64
+	GetIsolationScheduleLimit() uint64
+	GetLoadSplitHotDegree() int
+	GetLoadSplitMinFlowBytes() uint64
+	GetLoadSplitCooldown() time.Duration
+	GetHotRegionWindowSize() int
 
 	GetMaxReplicas() int
+	GetLearnerReplicas() int
+	GetLearnerLabels() map[string]string
 	GetLocationLabels() []string
 
 	GetHotRegionLowThreshold() int
 	GetTolerantSizeRatio() float64
 	GetLowSpaceRatio() float64
 	GetHighSpaceRatio() float64
+	GetLeaderScoreStrategy() string
+	GetRegionScoreStrategy() string
 
 	IsRaftLearnerEnabled() bool
 
@@ -52,6 +63,7 @@ type Options interface {
 	IsMakeUpReplicaEnabled() bool
 	IsRemoveExtraReplicaEnabled() bool
 	IsLocationReplacementEnabled() bool
+	IsPlacementRulesEnabled() bool
 
 	CheckLabelProperty(typ string, labels []*metapb.StoreLabel) bool
 }
@@ -63,10 +75,37 @@ type NamespaceOptions interface {
 	GetReplicaScheduleLimit(name string) uint64
 	GetMergeScheduleLimit(name string) uint64
 	GetMaxReplicas(name string) int
+	GetLearnerReplicas(name string) int
 }
 
 const (
 	// RejectLeader is the label property type that sugguests a store should not
 	// have any region leaders.
 	RejectLeader = "reject-leader"
+	// RejectRegion is the label property type that sugguests a store should not
+	// have any region peers, the existing peers are moved out.
+	RejectRegion = "reject-region"
+	// PreferLeader is the label property type that sugguests the leaders should
+	// be placed on the store if it holds a peer of the region.
+	PreferLeader = "prefer-leader"
+	// NoBalance is the label property type that sugguests a store should be
+	// excluded from balancing, while the replicas can still be repaired on it.
+	NoBalance = "no-balance"
+	// ReadOnly is the label property type that sugguests a store should not
+	// accept any new peers or leaders, the existing ones are kept.
+	ReadOnly = "read-only"
 )
+
+// ValidateLabelPropertyType checks if the label property type is supported.
+func ValidateLabelPropertyType(typ string) error {
+	switch typ {
+	case RejectLeader, RejectRegion, PreferLeader, NoBalance, ReadOnly:
+		return nil
+	}
+	return errors.Errorf("unknown label property type %s", typ)
+}
+
+// LabelPropertyTypes returns all the supported label property types.
+func LabelPropertyTypes() []string {
+	return []string{RejectLeader, RejectRegion, PreferLeader, NoBalance, ReadOnly}
+}
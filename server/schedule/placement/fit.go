@@ -0,0 +1,163 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placement
+
+import (
+	"sort"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/server/core"
+)
+
+// RuleFit is the peers of a region assigned to a rule.
+type RuleFit struct {
+	Rule *Rule
+	// Peers are the peers which match both the label constraints and the
+	// role of the rule.
+	Peers []*metapb.Peer
+	// PeersWithDifferentRole are the learners which match the label
+	// constraints of a voter rule, they fit the rule after promoted.
+	PeersWithDifferentRole []*metapb.Peer
+}
+
+// IsSatisfied returns true if the rule has enough peers in the right role.
+func (f *RuleFit) IsSatisfied() bool {
+	return len(f.Peers) == f.Rule.Count && len(f.PeersWithDifferentRole) == 0
+}
+
+// MissingCount returns the number of peers to add to fit the rule.
+func (f *RuleFit) MissingCount() int {
+	return f.Rule.Count - len(f.Peers) - len(f.PeersWithDifferentRole)
+}
+
+// RegionFit is the result of assigning the peers of a region to its rules.
+type RegionFit struct {
+	RuleFits []*RuleFit
+	// OrphanPeers are the peers which are not assigned to any rule.
+	OrphanPeers []*metapb.Peer
+}
+
+// IsSatisfied returns true if all the rules are satisfied and there is no
+// orphan peer.
+func (f *RegionFit) IsSatisfied() bool {
+	return f.IsRulesSatisfied() && len(f.OrphanPeers) == 0
+}
+
+// IsRulesSatisfied returns true if all the rules are satisfied.
+func (f *RegionFit) IsRulesSatisfied() bool {
+	for _, rf := range f.RuleFits {
+		if !rf.IsSatisfied() {
+			return false
+		}
+	}
+	return true
+}
+
+// CompareRegionFit compares how well the peers fit the rules, it returns a
+// positive number if a is better than b, a negative number if b is better,
+// or 0 if they are the same. The fit with more satisfied rules is better,
+// then the one with more peers fitting the rules.
+func CompareRegionFit(a, b *RegionFit) int {
+	if d := a.satisfiedRuleCount() - b.satisfiedRuleCount(); d != 0 {
+		return d
+	}
+	return a.fittedPeerCount() - b.fittedPeerCount()
+}
+
+func (f *RegionFit) satisfiedRuleCount() int {
+	var count int
+	for _, rf := range f.RuleFits {
+		if rf.IsSatisfied() {
+			count++
+		}
+	}
+	return count
+}
+
+func (f *RegionFit) fittedPeerCount() int {
+	var count int
+	for _, rf := range f.RuleFits {
+		count += len(rf.Peers)
+	}
+	return count
+}
+
+// FitRegion assigns the peers of the region to the rules in order. The stores
+// should contain all the stores of the region, a peer on a missing store is
+// considered as an orphan.
+func FitRegion(stores []*core.StoreInfo, region *core.RegionInfo, rules []*Rule) *RegionFit {
+	storeMap := make(map[uint64]*core.StoreInfo, len(stores))
+	for _, s := range stores {
+		storeMap[s.GetId()] = s
+	}
+	assigned := make(map[uint64]struct{})
+	fit := &RegionFit{}
+	for _, rule := range rules {
+		rf := &RuleFit{Rule: rule}
+		candidates := make([]*metapb.Peer, 0, len(region.GetPeers()))
+		for _, peer := range region.GetPeers() {
+			if _, ok := assigned[peer.GetId()]; ok {
+				continue
+			}
+			store := storeMap[peer.GetStoreId()]
+			if store == nil || !MatchLabelConstraints(store, rule.LabelConstraints) {
+				continue
+			}
+			candidates = append(candidates, peer)
+		}
+		sortCandidates(candidates, storeMap, region, rule)
+		for _, peer := range candidates {
+			if len(rf.Peers)+len(rf.PeersWithDifferentRole) >= rule.Count {
+				break
+			}
+			isLearner := region.GetStoreLearner(peer.GetStoreId()) != nil
+			switch {
+			case isLearner == !rule.Role.IsVoter():
+				rf.Peers = append(rf.Peers, peer)
+			case isLearner:
+				rf.PeersWithDifferentRole = append(rf.PeersWithDifferentRole, peer)
+			default:
+				// A voter can not be demoted to fit a learner rule.
+				continue
+			}
+			assigned[peer.GetId()] = struct{}{}
+		}
+		fit.RuleFits = append(fit.RuleFits, rf)
+	}
+	for _, peer := range region.GetPeers() {
+		if _, ok := assigned[peer.GetId()]; !ok {
+			fit.OrphanPeers = append(fit.OrphanPeers, peer)
+		}
+	}
+	return fit
+}
+
+// sortCandidates puts the better peers for the rule in front, so the peers
+// on the stores which are not up are more likely to become orphans.
+func sortCandidates(peers []*metapb.Peer, stores map[uint64]*core.StoreInfo, region *core.RegionInfo, rule *Rule) {
+	score := func(peer *metapb.Peer) int {
+		var s int
+		if stores[peer.GetStoreId()].IsUp() {
+			s += 4
+		}
+		if (region.GetStoreLearner(peer.GetStoreId()) != nil) == !rule.Role.IsVoter() {
+			s += 2
+		}
+		if rule.Role == Leader && region.Leader.GetStoreId() == peer.GetStoreId() {
+			s++
+		}
+		return s
+	}
+	sort.SliceStable(peers, func(i, j int) bool { return score(peers[i]) > score(peers[j]) })
+}
@@ -0,0 +1,120 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placement
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/server/core"
+)
+
+var _ = Suite(&testFitSuite{})
+
+type testFitSuite struct{}
+
+// newTestRegion creates a region with a peer on each store, the peer IDs are
+// the store IDs plus 100 and the first peer is the leader.
+func (s *testFitSuite) newTestRegion(voters []uint64, learners []uint64) *core.RegionInfo {
+	region := &metapb.Region{Id: 1}
+	for _, id := range voters {
+		region.Peers = append(region.Peers, &metapb.Peer{Id: id + 100, StoreId: id})
+	}
+	for _, id := range learners {
+		region.Peers = append(region.Peers, &metapb.Peer{Id: id + 100, StoreId: id, IsLearner: true})
+	}
+	return core.NewRegionInfo(region, region.Peers[0])
+}
+
+func (s *testFitSuite) newTestRules(c *C, rules ...*Rule) []*Rule {
+	for _, rule := range rules {
+		c.Assert(rule.Adjust(), IsNil)
+	}
+	return rules
+}
+
+func peerStores(peers []*metapb.Peer) []uint64 {
+	var res []uint64
+	for _, p := range peers {
+		res = append(res, p.GetStoreId())
+	}
+	return res
+}
+
+func (s *testFitSuite) TestFitRegion(c *C) {
+	stores := []*core.StoreInfo{
+		newTestStore(1, map[string]string{"disk": "ssd"}),
+		newTestStore(2, map[string]string{"disk": "ssd"}),
+		newTestStore(3, map[string]string{"disk": "hdd"}),
+		newTestStore(4, map[string]string{"engine": "tiflash"}),
+		newTestStore(5, map[string]string{"disk": "hdd"}),
+	}
+	rules := s.newTestRules(c,
+		&Rule{ID: "voter", Role: Voter, Count: 3, LabelConstraints: []LabelConstraint{{Key: "engine", Op: NotExists}}},
+		&Rule{ID: "learner", Role: Learner, Count: 1, LabelConstraints: []LabelConstraint{{Key: "engine", Op: In, Values: []string{"tiflash"}}}},
+	)
+
+	fit := FitRegion(stores, s.newTestRegion([]uint64{1, 2, 3}, []uint64{4}), rules)
+	c.Assert(fit.IsSatisfied(), IsTrue)
+	c.Assert(peerStores(fit.RuleFits[0].Peers), DeepEquals, []uint64{1, 2, 3})
+	c.Assert(peerStores(fit.RuleFits[1].Peers), DeepEquals, []uint64{4})
+
+	// The learner is missing, the extra voter is an orphan.
+	fit = FitRegion(stores, s.newTestRegion([]uint64{1, 2, 3, 5}, nil), rules)
+	c.Assert(fit.IsSatisfied(), IsFalse)
+	c.Assert(fit.RuleFits[0].IsSatisfied(), IsTrue)
+	c.Assert(fit.RuleFits[1].MissingCount(), Equals, 1)
+	c.Assert(peerStores(fit.OrphanPeers), DeepEquals, []uint64{5})
+
+	// The learner matching the voter rule can be promoted.
+	fit = FitRegion(stores, s.newTestRegion([]uint64{1, 2}, []uint64{3}), rules)
+	c.Assert(fit.RuleFits[0].IsSatisfied(), IsFalse)
+	c.Assert(fit.RuleFits[0].MissingCount(), Equals, 0)
+	c.Assert(peerStores(fit.RuleFits[0].PeersWithDifferentRole), DeepEquals, []uint64{3})
+
+	// A voter can not fit the learner rule.
+	fit = FitRegion(stores, s.newTestRegion([]uint64{1, 2, 3, 4}, nil), rules)
+	c.Assert(fit.RuleFits[1].MissingCount(), Equals, 1)
+	c.Assert(peerStores(fit.OrphanPeers), DeepEquals, []uint64{4})
+
+	// A peer on the missing store is an orphan.
+	fit = FitRegion(stores[:2], s.newTestRegion([]uint64{1, 2, 3}, nil), rules[:1])
+	c.Assert(fit.RuleFits[0].MissingCount(), Equals, 1)
+	c.Assert(peerStores(fit.OrphanPeers), DeepEquals, []uint64{3})
+}
+
+func (s *testFitSuite) TestFitPreference(c *C) {
+	stores := []*core.StoreInfo{
+		newTestStore(1, map[string]string{"zone": "z1"}),
+		newTestStore(2, map[string]string{"zone": "z1"}),
+		newTestStore(3, map[string]string{"zone": "z2"}),
+	}
+	stores[0].State = metapb.StoreState_Offline
+	rules := s.newTestRules(c,
+		&Rule{ID: "leader", Role: Leader, Count: 1, LabelConstraints: []LabelConstraint{{Key: "zone", Op: In, Values: []string{"z1"}}}},
+		&Rule{ID: "voter", Role: Voter, Count: 1},
+	)
+
+	// The peer on the offline store is the last choice.
+	fit := FitRegion(stores, s.newTestRegion([]uint64{1, 2, 3}, nil), rules)
+	c.Assert(peerStores(fit.RuleFits[0].Peers), DeepEquals, []uint64{2})
+	c.Assert(peerStores(fit.RuleFits[1].Peers), DeepEquals, []uint64{3})
+	c.Assert(peerStores(fit.OrphanPeers), DeepEquals, []uint64{1})
+
+	// The leader is preferred by the leader rule.
+	stores[0].State = metapb.StoreState_Up
+	region := s.newTestRegion([]uint64{1, 2, 3}, nil)
+	region.Leader = region.GetStorePeer(2)
+	fit = FitRegion(stores, region, rules)
+	c.Assert(peerStores(fit.RuleFits[0].Peers), DeepEquals, []uint64{2})
+}
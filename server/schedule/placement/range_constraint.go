@@ -0,0 +1,264 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placement
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/table"
+	log "github.com/sirupsen/logrus"
+)
+
+const rangeConstraintsPath = "range_constraints"
+
+// RangeConstraint restricts the stores which the voters of the regions in the
+// key range can be placed on, and overrides the number and the stores of the
+// read-only learners of the regions.
+type RangeConstraint struct {
+	ID string `json:"id"`
+	// TableID is resolved to the key range of the table if it is not 0, the
+	// StartKeyHex and EndKeyHex are overwritten by the range of the table.
+	TableID int64 `json:"table_id,omitempty"`
+	// StartKeyHex and EndKeyHex are the hex encoded key range, an empty
+	// EndKeyHex means the end of the key space.
+	StartKeyHex      string            `json:"start_key"`
+	EndKeyHex        string            `json:"end_key"`
+	LabelConstraints []LabelConstraint `json:"label_constraints"`
+	// LearnerReplicas is the number of the read-only learners placed on the
+	// stores matching LearnerLabelConstraints, 0 means the cluster setting
+	// is used.
+	LearnerReplicas         int               `json:"learner_replicas,omitempty"`
+	LearnerLabelConstraints []LabelConstraint `json:"learner_label_constraints,omitempty"`
+
+	startKey []byte
+	endKey   []byte
+}
+
+// Adjust validates the constraint and decodes its key range.
+func (c *RangeConstraint) Adjust() error {
+	if c.ID == "" {
+		return errors.New("range constraint id is empty")
+	}
+	if strings.Contains(c.ID, "/") {
+		return errors.Errorf("range constraint id %s contains '/'", c.ID)
+	}
+	if c.TableID < 0 {
+		return errors.Errorf("invalid table id %d of range constraint %s", c.TableID, c.ID)
+	}
+	if c.TableID != 0 {
+		startKey, endKey := table.EncodeRange(c.TableID)
+		c.StartKeyHex, c.EndKeyHex = hex.EncodeToString(startKey), hex.EncodeToString(endKey)
+	}
+	var err error
+	if c.startKey, err = hex.DecodeString(c.StartKeyHex); err != nil {
+		return errors.Errorf("invalid start key %s of range constraint %s", c.StartKeyHex, c.ID)
+	}
+	if c.endKey, err = hex.DecodeString(c.EndKeyHex); err != nil {
+		return errors.Errorf("invalid end key %s of range constraint %s", c.EndKeyHex, c.ID)
+	}
+	if len(c.endKey) > 0 && bytes.Compare(c.startKey, c.endKey) >= 0 {
+		return errors.Errorf("start key is not less than end key of range constraint %s", c.ID)
+	}
+	if c.LearnerReplicas < 0 {
+		return errors.Errorf("invalid learner replicas %d of range constraint %s", c.LearnerReplicas, c.ID)
+	}
+	if len(c.LabelConstraints) == 0 && c.LearnerReplicas == 0 {
+		return errors.Errorf("range constraint %s has no label constraint", c.ID)
+	}
+	if c.LearnerReplicas > 0 && len(c.LearnerLabelConstraints) == 0 {
+		return errors.Errorf("range constraint %s has no learner label constraint", c.ID)
+	}
+	if err := validateLabelConstraints(c.LabelConstraints); err != nil {
+		return errors.Errorf("%v of range constraint %s", err, c.ID)
+	}
+	if err := validateLabelConstraints(c.LearnerLabelConstraints); err != nil {
+		return errors.Errorf("%v of range constraint %s", err, c.ID)
+	}
+	return nil
+}
+
+// OverlapsRange returns true if the key range [startKey, endKey) overlaps
+// the key range of the constraint. An empty endKey means the end of the key
+// space.
+func (c *RangeConstraint) OverlapsRange(startKey, endKey []byte) bool {
+	if len(c.endKey) > 0 && bytes.Compare(startKey, c.endKey) >= 0 {
+		return false
+	}
+	return len(endKey) == 0 || bytes.Compare(c.startKey, endKey) < 0
+}
+
+// Clone returns a copy of the constraint.
+func (c *RangeConstraint) Clone() *RangeConstraint {
+	constraint := *c
+	constraint.LabelConstraints = cloneLabelConstraints(c.LabelConstraints)
+	if len(c.LearnerLabelConstraints) > 0 {
+		constraint.LearnerLabelConstraints = cloneLabelConstraints(c.LearnerLabelConstraints)
+	}
+	return &constraint
+}
+
+func cloneLabelConstraints(constraints []LabelConstraint) []LabelConstraint {
+	res := make([]LabelConstraint, 0, len(constraints))
+	for _, lc := range constraints {
+		lc.Values = append([]string(nil), lc.Values...)
+		res = append(res, lc)
+	}
+	return res
+}
+
+// RangeConstraintManager keeps the range constraints and persists them in
+// the KV.
+type RangeConstraintManager struct {
+	sync.RWMutex
+	kv          *core.KV
+	constraints map[string]*RangeConstraint
+}
+
+// NewRangeConstraintManager creates a RangeConstraintManager.
+func NewRangeConstraintManager(kv *core.KV) *RangeConstraintManager {
+	return &RangeConstraintManager{
+		kv:          kv,
+		constraints: make(map[string]*RangeConstraint),
+	}
+}
+
+// Initialize loads the range constraints from the KV.
+func (m *RangeConstraintManager) Initialize() error {
+	m.Lock()
+	defer m.Unlock()
+	err := loadJSONItems(m.kv, rangeConstraintsPath, func(value string) (string, error) {
+		constraint := &RangeConstraint{}
+		if err := json.Unmarshal([]byte(value), constraint); err != nil {
+			return "", errors.Trace(err)
+		}
+		if err := constraint.Adjust(); err != nil {
+			return "", errors.Trace(err)
+		}
+		m.constraints[constraint.ID] = constraint
+		return constraint.ID, nil
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	log.Infof("load %v range constraints", len(m.constraints))
+	return nil
+}
+
+// GetConstraint returns a copy of the constraint with the ID, or nil if it
+// does not exist.
+func (m *RangeConstraintManager) GetConstraint(id string) *RangeConstraint {
+	m.RLock()
+	defer m.RUnlock()
+	if constraint, ok := m.constraints[id]; ok {
+		return constraint.Clone()
+	}
+	return nil
+}
+
+// GetAllConstraints returns copies of all constraints ordered by ID.
+func (m *RangeConstraintManager) GetAllConstraints() []*RangeConstraint {
+	m.RLock()
+	defer m.RUnlock()
+	constraints := make([]*RangeConstraint, 0, len(m.constraints))
+	for _, constraint := range m.constraints {
+		constraints = append(constraints, constraint.Clone())
+	}
+	sort.Slice(constraints, func(i, j int) bool { return constraints[i].ID < constraints[j].ID })
+	return constraints
+}
+
+// SetConstraint adds a constraint or replaces the constraint with the same ID.
+func (m *RangeConstraintManager) SetConstraint(constraint *RangeConstraint) error {
+	constraint = constraint.Clone()
+	if err := constraint.Adjust(); err != nil {
+		return errors.Trace(err)
+	}
+	value, err := json.Marshal(constraint)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	if err := m.kv.Save(rangeConstraintsPath+"/"+constraint.ID, string(value)); err != nil {
+		return errors.Trace(err)
+	}
+	m.constraints[constraint.ID] = constraint
+	log.Infof("range constraint is updated: %+v", constraint)
+	return nil
+}
+
+// DeleteConstraint removes the constraint with the ID.
+func (m *RangeConstraintManager) DeleteConstraint(id string) error {
+	m.Lock()
+	defer m.Unlock()
+	if _, ok := m.constraints[id]; !ok {
+		return errors.Errorf("range constraint %s not found", id)
+	}
+	if err := m.kv.Delete(rangeConstraintsPath + "/" + id); err != nil {
+		return errors.Trace(err)
+	}
+	delete(m.constraints, id)
+	log.Infof("range constraint %s is deleted", id)
+	return nil
+}
+
+// GetLabelConstraintsForRange returns the label constraints of all the range
+// constraints overlapping the key range. The returned label constraints are
+// shared and should not be modified.
+func (m *RangeConstraintManager) GetLabelConstraintsForRange(startKey, endKey []byte) []LabelConstraint {
+	m.RLock()
+	defer m.RUnlock()
+	var res []LabelConstraint
+	for _, constraint := range m.constraints {
+		if constraint.OverlapsRange(startKey, endKey) {
+			res = append(res, constraint.LabelConstraints...)
+		}
+	}
+	return res
+}
+
+// GetLabelConstraintsForRegion returns the label constraints applied to the
+// region.
+func (m *RangeConstraintManager) GetLabelConstraintsForRegion(region *core.RegionInfo) []LabelConstraint {
+	return m.GetLabelConstraintsForRange(region.GetStartKey(), region.GetEndKey())
+}
+
+// GetLearnerReplicasForRegion returns the number of the read-only learners of
+// the region and the label constraints of the stores to place them. The
+// setting of the overlapping range constraint with the smallest ID is used,
+// the given default values are returned if no range constraint sets the
+// learners. The returned label constraints are shared and should not be
+// modified.
+func (m *RangeConstraintManager) GetLearnerReplicasForRegion(region *core.RegionInfo, count int, constraints []LabelConstraint) (int, []LabelConstraint) {
+	m.RLock()
+	defer m.RUnlock()
+	var id string
+	for _, constraint := range m.constraints {
+		if constraint.LearnerReplicas == 0 || (id != "" && id < constraint.ID) {
+			continue
+		}
+		if constraint.OverlapsRange(region.GetStartKey(), region.GetEndKey()) {
+			id, count, constraints = constraint.ID, constraint.LearnerReplicas, constraint.LearnerLabelConstraints
+		}
+	}
+	return count, constraints
+}
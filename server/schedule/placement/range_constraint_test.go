@@ -0,0 +1,129 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placement
+
+import (
+	"encoding/hex"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/table"
+)
+
+var _ = Suite(&testRangeConstraintSuite{})
+
+type testRangeConstraintSuite struct{}
+
+func (s *testRangeConstraintSuite) TestAdjust(c *C) {
+	ssd := []LabelConstraint{{Key: "disk", Op: In, Values: []string{"ssd"}}}
+	constraint := &RangeConstraint{ID: "t45", TableID: 45, LabelConstraints: ssd}
+	c.Assert(constraint.Adjust(), IsNil)
+	startKey, endKey := table.EncodeRange(45)
+	c.Assert(constraint.StartKeyHex, Equals, hex.EncodeToString(startKey))
+	c.Assert(constraint.EndKeyHex, Equals, hex.EncodeToString(endKey))
+
+	invalid := []*RangeConstraint{
+		{ID: "", LabelConstraints: ssd},
+		{ID: "a/b", LabelConstraints: ssd},
+		{ID: "c1", TableID: -1, LabelConstraints: ssd},
+		{ID: "c1", StartKeyHex: "62", EndKeyHex: "61", LabelConstraints: ssd},
+		{ID: "c1", StartKeyHex: "xyz", LabelConstraints: ssd},
+		{ID: "c1"},
+		{ID: "c1", LabelConstraints: []LabelConstraint{{Key: "disk", Op: NotIn}}},
+		{ID: "c1", LearnerReplicas: -1, LabelConstraints: ssd},
+		{ID: "c1", LearnerReplicas: 1},
+		{ID: "c1", LearnerReplicas: 1, LearnerLabelConstraints: []LabelConstraint{{Op: Exists}}},
+	}
+	for _, constraint := range invalid {
+		c.Assert(constraint.Adjust(), NotNil)
+	}
+}
+
+func (s *testRangeConstraintSuite) TestOverlapsRange(c *C) {
+	constraint := &RangeConstraint{
+		ID:               "c1",
+		StartKeyHex:      "62",
+		EndKeyHex:        "64",
+		LabelConstraints: []LabelConstraint{{Key: "disk", Op: Exists}},
+	}
+	c.Assert(constraint.Adjust(), IsNil)
+	c.Assert(constraint.OverlapsRange([]byte("a"), []byte("b")), IsFalse)
+	c.Assert(constraint.OverlapsRange([]byte("a"), []byte("c")), IsTrue)
+	c.Assert(constraint.OverlapsRange([]byte("c"), []byte("")), IsTrue)
+	c.Assert(constraint.OverlapsRange([]byte("d"), []byte("")), IsFalse)
+	c.Assert(constraint.OverlapsRange([]byte(""), []byte("")), IsTrue)
+}
+
+func (s *testRangeConstraintSuite) TestManager(c *C) {
+	kv := core.NewKV(core.NewMemoryKV())
+	m := NewRangeConstraintManager(kv)
+	c.Assert(m.Initialize(), IsNil)
+	c.Assert(m.GetAllConstraints(), HasLen, 0)
+
+	c.Assert(m.SetConstraint(&RangeConstraint{
+		ID:               "c1",
+		StartKeyHex:      "61",
+		EndKeyHex:        "63",
+		LabelConstraints: []LabelConstraint{{Key: "disk", Op: In, Values: []string{"ssd"}}},
+	}), IsNil)
+	c.Assert(m.SetConstraint(&RangeConstraint{
+		ID:               "c2",
+		StartKeyHex:      "62",
+		LabelConstraints: []LabelConstraint{{Key: "zone", Op: NotIn, Values: []string{"z3"}}},
+	}), IsNil)
+	c.Assert(m.SetConstraint(&RangeConstraint{ID: "c3"}), NotNil)
+	c.Assert(m.GetLabelConstraintsForRange([]byte(""), []byte("a")), HasLen, 0)
+	c.Assert(m.GetLabelConstraintsForRange([]byte("a"), []byte("b")), HasLen, 1)
+	c.Assert(m.GetLabelConstraintsForRange([]byte("b"), []byte("c")), HasLen, 2)
+
+	// The constraints are loaded from the KV.
+	m = NewRangeConstraintManager(kv)
+	c.Assert(m.Initialize(), IsNil)
+	constraints := m.GetAllConstraints()
+	c.Assert(constraints, HasLen, 2)
+	c.Assert(constraints[0].ID, Equals, "c1")
+	c.Assert(m.GetConstraint("c2").LabelConstraints[0].Key, Equals, "zone")
+	c.Assert(m.GetLabelConstraintsForRange([]byte("b"), []byte("c")), HasLen, 2)
+
+	c.Assert(m.DeleteConstraint("c1"), IsNil)
+	c.Assert(m.DeleteConstraint("c1"), NotNil)
+	c.Assert(m.GetConstraint("c1"), IsNil)
+	c.Assert(m.GetLabelConstraintsForRange([]byte("a"), []byte("b")), HasLen, 0)
+}
+
+func (s *testRangeConstraintSuite) TestLearnerReplicas(c *C) {
+	m := NewRangeConstraintManager(core.NewKV(core.NewMemoryKV()))
+	c.Assert(m.Initialize(), IsNil)
+	analytic := []LabelConstraint{{Key: "engine", Op: In, Values: []string{"analytic"}}}
+	c.Assert(m.SetConstraint(&RangeConstraint{ID: "c1", StartKeyHex: "61", EndKeyHex: "63", LearnerReplicas: 2, LearnerLabelConstraints: analytic}), IsNil)
+	c.Assert(m.SetConstraint(&RangeConstraint{ID: "c2", StartKeyHex: "62", LearnerReplicas: 3, LearnerLabelConstraints: analytic}), IsNil)
+	// The constraint without label constraints does not restrict the voters.
+	c.Assert(m.GetLabelConstraintsForRange([]byte("a"), []byte("b")), HasLen, 0)
+
+	newRegion := func(startKey, endKey string) *core.RegionInfo {
+		return core.NewRegionInfo(&metapb.Region{StartKey: []byte(startKey), EndKey: []byte(endKey)}, nil)
+	}
+	count, constraints := m.GetLearnerReplicasForRegion(newRegion("", "a"), 1, nil)
+	c.Assert(count, Equals, 1)
+	c.Assert(constraints, HasLen, 0)
+	count, constraints = m.GetLearnerReplicasForRegion(newRegion("a", "b"), 1, nil)
+	c.Assert(count, Equals, 2)
+	c.Assert(constraints, DeepEquals, analytic)
+	// The constraint with the smallest ID is used.
+	count, _ = m.GetLearnerReplicasForRegion(newRegion("b", "c"), 1, nil)
+	c.Assert(count, Equals, 2)
+	count, _ = m.GetLearnerReplicasForRegion(newRegion("c", ""), 1, nil)
+	c.Assert(count, Equals, 3)
+}
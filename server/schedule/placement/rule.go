@@ -0,0 +1,247 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placement
+
+import (
+	"bytes"
+	"encoding/hex"
+	"sort"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/pd/server/core"
+)
+
+// PeerRoleType is the expected role of the peers placed by a rule.
+type PeerRoleType string
+
+const (
+	// Voter is a peer which takes part in the raft election.
+	Voter PeerRoleType = "voter"
+	// Leader is a voter which is preferred to be the leader of the region.
+	Leader PeerRoleType = "leader"
+	// Learner is a peer which only replicates the data.
+	Learner PeerRoleType = "learner"
+)
+
+func validateRole(role PeerRoleType) bool {
+	return role == Voter || role == Leader || role == Learner
+}
+
+// IsVoter returns true if the peers of the role should be voters.
+func (r PeerRoleType) IsVoter() bool {
+	return r == Voter || r == Leader
+}
+
+// LabelConstraintOp defines how a LabelConstraint matches a store.
+type LabelConstraintOp string
+
+const (
+	// In restricts the store label value should be in the value list.
+	In LabelConstraintOp = "in"
+	// NotIn restricts the store label value should not be in the value list.
+	NotIn LabelConstraintOp = "notIn"
+	// Exists restricts the store should have the label.
+	Exists LabelConstraintOp = "exists"
+	// NotExists restricts the store should not have the label.
+	NotExists LabelConstraintOp = "notExists"
+)
+
+func validateOp(op LabelConstraintOp) bool {
+	return op == In || op == NotIn || op == Exists || op == NotExists
+}
+
+// LabelConstraint is a constraint on the labels of the stores which the
+// peers can be placed on.
+type LabelConstraint struct {
+	Key    string            `json:"key"`
+	Op     LabelConstraintOp `json:"op"`
+	Values []string          `json:"values,omitempty"`
+}
+
+// MatchStore checks if the store matches the constraint.
+func (c *LabelConstraint) MatchStore(store *core.StoreInfo) bool {
+	value := store.GetLabelValue(c.Key)
+	switch c.Op {
+	case In:
+		return value != "" && containsString(c.Values, value)
+	case NotIn:
+		return value == "" || !containsString(c.Values, value)
+	case Exists:
+		return value != ""
+	case NotExists:
+		return value == ""
+	}
+	return false
+}
+
+// MatchLabelConstraints checks if the store matches all the constraints.
+func MatchLabelConstraints(store *core.StoreInfo, constraints []LabelConstraint) bool {
+	for i := range constraints {
+		if !constraints[i].MatchStore(store) {
+			return false
+		}
+	}
+	return true
+}
+
+func validateLabelConstraints(constraints []LabelConstraint) error {
+	for _, c := range constraints {
+		if c.Key == "" {
+			return errors.New("empty label key in the constraints")
+		}
+		if !validateOp(c.Op) {
+			return errors.Errorf("invalid label constraint op %s", c.Op)
+		}
+		if (c.Op == In || c.Op == NotIn) && len(c.Values) == 0 {
+			return errors.Errorf("label constraint %s %s has no value", c.Key, c.Op)
+		}
+	}
+	return nil
+}
+
+// SelectorConstraints converts the label selector to the label constraints,
+// a store matches the constraints if it has all the labels of the selector.
+func SelectorConstraints(labels map[string]string) []LabelConstraint {
+	constraints := make([]LabelConstraint, 0, len(labels))
+	for k, v := range labels {
+		constraints = append(constraints, LabelConstraint{Key: k, Op: In, Values: []string{v}})
+	}
+	sort.Slice(constraints, func(i, j int) bool { return constraints[i].Key < constraints[j].Key })
+	return constraints
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Rule describes how many peers of which role should be placed on the stores
+// matching the label constraints, for the regions in the key range.
+type Rule struct {
+	ID string `json:"id"`
+	// Index decides the order to apply the rules, the rules with the same
+	// index are ordered by ID.
+	Index int `json:"index"`
+	// Override means the rules applied before this one are ignored.
+	Override bool `json:"override"`
+	// StartKeyHex and EndKeyHex are the hex encoded key range of the rule, an
+	// empty EndKeyHex means the end of the key space.
+	StartKeyHex      string            `json:"start_key"`
+	EndKeyHex        string            `json:"end_key"`
+	Role             PeerRoleType      `json:"role"`
+	Count            int               `json:"count"`
+	LabelConstraints []LabelConstraint `json:"label_constraints,omitempty"`
+	// LocationLabels are used to spread the peers of the rule, like the
+	// location labels of the replication config.
+	LocationLabels []string `json:"location_labels,omitempty"`
+	// IsolationLevel is one of the location labels, no two peers of the rule
+	// can be placed at the same location of this level.
+	IsolationLevel string `json:"isolation_level,omitempty"`
+
+	startKey []byte
+	endKey   []byte
+}
+
+// Adjust validates the rule and decodes its key range.
+func (r *Rule) Adjust() error {
+	if r.ID == "" {
+		return errors.New("rule id is empty")
+	}
+	if strings.Contains(r.ID, "/") {
+		return errors.Errorf("rule id %s contains '/'", r.ID)
+	}
+	if !validateRole(r.Role) {
+		return errors.Errorf("invalid role %s of rule %s", r.Role, r.ID)
+	}
+	if r.Count <= 0 {
+		return errors.Errorf("invalid count %d of rule %s", r.Count, r.ID)
+	}
+	var err error
+	if r.startKey, err = hex.DecodeString(r.StartKeyHex); err != nil {
+		return errors.Errorf("invalid start key %s of rule %s", r.StartKeyHex, r.ID)
+	}
+	if r.endKey, err = hex.DecodeString(r.EndKeyHex); err != nil {
+		return errors.Errorf("invalid end key %s of rule %s", r.EndKeyHex, r.ID)
+	}
+	if len(r.endKey) > 0 && bytes.Compare(r.startKey, r.endKey) >= 0 {
+		return errors.Errorf("start key is not less than end key of rule %s", r.ID)
+	}
+	if err := validateLabelConstraints(r.LabelConstraints); err != nil {
+		return errors.Errorf("%v of rule %s", err, r.ID)
+	}
+	if r.IsolationLevel != "" && !containsString(r.LocationLabels, r.IsolationLevel) {
+		return errors.Errorf("isolation level %s of rule %s is not a location label", r.IsolationLevel, r.ID)
+	}
+	return nil
+}
+
+// GetStartKey returns the start key of the rule.
+func (r *Rule) GetStartKey() []byte {
+	return r.startKey
+}
+
+// GetEndKey returns the end key of the rule.
+func (r *Rule) GetEndKey() []byte {
+	return r.endKey
+}
+
+// ContainsRange returns true if the key range [startKey, endKey) is in the
+// key range of the rule. An empty endKey means the end of the key space.
+func (r *Rule) ContainsRange(startKey, endKey []byte) bool {
+	if bytes.Compare(startKey, r.startKey) < 0 {
+		return false
+	}
+	if len(r.endKey) == 0 {
+		return true
+	}
+	return len(endKey) > 0 && bytes.Compare(endKey, r.endKey) <= 0
+}
+
+// Clone returns a copy of the rule.
+func (r *Rule) Clone() *Rule {
+	rule := *r
+	rule.LabelConstraints = make([]LabelConstraint, 0, len(r.LabelConstraints))
+	for _, c := range r.LabelConstraints {
+		c.Values = append([]string(nil), c.Values...)
+		rule.LabelConstraints = append(rule.LabelConstraints, c)
+	}
+	rule.LocationLabels = append([]string(nil), r.LocationLabels...)
+	return &rule
+}
+
+// sortRules sorts the rules by index and ID.
+func sortRules(rules []*Rule) {
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Index != rules[j].Index {
+			return rules[i].Index < rules[j].Index
+		}
+		return rules[i].ID < rules[j].ID
+	})
+}
+
+// applyOverride drops the rules which are overridden by the latter ones, the
+// rules should be sorted.
+func applyOverride(rules []*Rule) []*Rule {
+	for i := len(rules) - 1; i > 0; i-- {
+		if rules[i].Override {
+			return rules[i:]
+		}
+	}
+	return rules
+}
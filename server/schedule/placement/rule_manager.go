@@ -0,0 +1,229 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placement
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/pd/server/core"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	rulesPath = "rules"
+	// DefaultRuleID is the ID of the rule created from the replication
+	// config when there is no rule.
+	DefaultRuleID = "default"
+	loadRangeSize = 100
+)
+
+// RuleManager keeps the placement rules and persists them in the KV.
+type RuleManager struct {
+	sync.RWMutex
+	kv    *core.KV
+	rules map[string]*Rule
+}
+
+// NewRuleManager creates a RuleManager.
+func NewRuleManager(kv *core.KV) *RuleManager {
+	return &RuleManager{
+		kv:    kv,
+		rules: make(map[string]*Rule),
+	}
+}
+
+// Initialize loads the rules from the KV. If there is no rule, it creates
+// the default rule with the max replicas and location labels.
+func (m *RuleManager) Initialize(maxReplicas int, locationLabels []string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if err := m.loadRules(); err != nil {
+		return errors.Trace(err)
+	}
+	if len(m.rules) != 0 {
+		return nil
+	}
+	rule := &Rule{
+		ID:             DefaultRuleID,
+		Role:           Voter,
+		Count:          maxReplicas,
+		LocationLabels: append([]string(nil), locationLabels...),
+	}
+	if err := rule.Adjust(); err != nil {
+		return errors.Trace(err)
+	}
+	if err := m.saveRule(rule); err != nil {
+		return errors.Trace(err)
+	}
+	m.rules[rule.ID] = rule
+	return nil
+}
+
+// UpdateDefaultRule makes the default rule follow the max replicas and the
+// location labels of the replication config. It does nothing if the default
+// rule has been deleted.
+func (m *RuleManager) UpdateDefaultRule(maxReplicas int, locationLabels []string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	old, ok := m.rules[DefaultRuleID]
+	if !ok {
+		return nil
+	}
+	rule := old.Clone()
+	rule.Count = maxReplicas
+	rule.LocationLabels = append([]string(nil), locationLabels...)
+	if err := rule.Adjust(); err != nil {
+		return errors.Trace(err)
+	}
+	if err := m.saveRule(rule); err != nil {
+		return errors.Trace(err)
+	}
+	m.rules[rule.ID] = rule
+	log.Infof("default placement rule is updated: %+v", rule)
+	return nil
+}
+
+func rulePath(id string) string {
+	return rulesPath + "/" + id
+}
+
+func (m *RuleManager) loadRules() error {
+	err := loadJSONItems(m.kv, rulesPath, func(value string) (string, error) {
+		rule := &Rule{}
+		if err := json.Unmarshal([]byte(value), rule); err != nil {
+			return "", errors.Trace(err)
+		}
+		if err := rule.Adjust(); err != nil {
+			return "", errors.Trace(err)
+		}
+		m.rules[rule.ID] = rule
+		return rule.ID, nil
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	log.Infof("load %v placement rules", len(m.rules))
+	return nil
+}
+
+// loadJSONItems loads the items saved under the path in pages, f handles an
+// item and returns its ID, which is the last part of the key.
+func loadJSONItems(kv *core.KV, path string, f func(value string) (string, error)) error {
+	// '0' is next to '/', so the end key covers all the item keys.
+	endKey := path + "0"
+	key := path + "/"
+	for {
+		res, err := kv.LoadRange(key, endKey, loadRangeSize)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		for _, value := range res {
+			id, err := f(value)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			key = path + "/" + id + "\x00"
+		}
+		if len(res) < loadRangeSize {
+			return nil
+		}
+	}
+}
+
+func (m *RuleManager) saveRule(rule *Rule) error {
+	value, err := json.Marshal(rule)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(m.kv.Save(rulePath(rule.ID), string(value)))
+}
+
+// GetRule returns a copy of the rule with the ID, or nil if it does not exist.
+func (m *RuleManager) GetRule(id string) *Rule {
+	m.RLock()
+	defer m.RUnlock()
+	if rule, ok := m.rules[id]; ok {
+		return rule.Clone()
+	}
+	return nil
+}
+
+// GetAllRules returns copies of all rules ordered by index and ID.
+func (m *RuleManager) GetAllRules() []*Rule {
+	m.RLock()
+	defer m.RUnlock()
+	rules := make([]*Rule, 0, len(m.rules))
+	for _, rule := range m.rules {
+		rules = append(rules, rule.Clone())
+	}
+	sortRules(rules)
+	return rules
+}
+
+// SetRule adds a rule or replaces the rule with the same ID.
+func (m *RuleManager) SetRule(rule *Rule) error {
+	rule = rule.Clone()
+	if err := rule.Adjust(); err != nil {
+		return errors.Trace(err)
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	if err := m.saveRule(rule); err != nil {
+		return errors.Trace(err)
+	}
+	m.rules[rule.ID] = rule
+	log.Infof("placement rule is updated: %+v", rule)
+	return nil
+}
+
+// DeleteRule removes the rule with the ID.
+func (m *RuleManager) DeleteRule(id string) error {
+	m.Lock()
+	defer m.Unlock()
+	if _, ok := m.rules[id]; !ok {
+		return errors.Errorf("placement rule %s not found", id)
+	}
+	if err := m.kv.Delete(rulePath(id)); err != nil {
+		return errors.Trace(err)
+	}
+	delete(m.rules, id)
+	log.Infof("placement rule %s is deleted", id)
+	return nil
+}
+
+// GetRulesForRange returns the rules applied to the key range in order. A
+// rule is applied only if its key range contains the whole range. The
+// returned rules are shared and should not be modified.
+func (m *RuleManager) GetRulesForRange(startKey, endKey []byte) []*Rule {
+	m.RLock()
+	defer m.RUnlock()
+	var rules []*Rule
+	for _, rule := range m.rules {
+		if rule.ContainsRange(startKey, endKey) {
+			rules = append(rules, rule)
+		}
+	}
+	sortRules(rules)
+	return applyOverride(rules)
+}
+
+// GetRulesForRegion returns the rules applied to the region in order.
+func (m *RuleManager) GetRulesForRegion(region *core.RegionInfo) []*Rule {
+	return m.GetRulesForRange(region.GetStartKey(), region.GetEndKey())
+}
@@ -0,0 +1,163 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placement
+
+import (
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/server/core"
+)
+
+func TestPlacement(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testRuleSuite{})
+
+type testRuleSuite struct{}
+
+func newTestStore(id uint64, labels map[string]string) *core.StoreInfo {
+	store := core.NewStoreInfo(&metapb.Store{Id: id})
+	for k, v := range labels {
+		store.Labels = append(store.Labels, &metapb.StoreLabel{Key: k, Value: v})
+	}
+	return store
+}
+
+func (s *testRuleSuite) TestLabelConstraint(c *C) {
+	ssd := newTestStore(1, map[string]string{"disk": "ssd", "zone": "z1"})
+	hdd := newTestStore(2, map[string]string{"disk": "hdd"})
+	none := newTestStore(3, nil)
+
+	testCases := []struct {
+		constraint LabelConstraint
+		expect     []bool
+	}{
+		{LabelConstraint{Key: "disk", Op: In, Values: []string{"ssd"}}, []bool{true, false, false}},
+		{LabelConstraint{Key: "disk", Op: NotIn, Values: []string{"ssd"}}, []bool{false, true, true}},
+		{LabelConstraint{Key: "zone", Op: Exists}, []bool{true, false, false}},
+		{LabelConstraint{Key: "zone", Op: NotExists}, []bool{false, true, true}},
+	}
+	for _, t := range testCases {
+		for i, store := range []*core.StoreInfo{ssd, hdd, none} {
+			c.Assert(t.constraint.MatchStore(store), Equals, t.expect[i])
+		}
+	}
+	constraints := []LabelConstraint{
+		{Key: "disk", Op: In, Values: []string{"ssd", "hdd"}},
+		{Key: "zone", Op: NotIn, Values: []string{"z1"}},
+	}
+	c.Assert(MatchLabelConstraints(ssd, constraints), IsFalse)
+	c.Assert(MatchLabelConstraints(hdd, constraints), IsTrue)
+	c.Assert(MatchLabelConstraints(none, constraints), IsFalse)
+}
+
+func (s *testRuleSuite) TestAdjust(c *C) {
+	valid := Rule{ID: "r1", Role: Voter, Count: 3, StartKeyHex: "61", EndKeyHex: "62"}
+	c.Assert(valid.Adjust(), IsNil)
+	c.Assert(valid.GetStartKey(), DeepEquals, []byte("a"))
+	c.Assert(valid.GetEndKey(), DeepEquals, []byte("b"))
+
+	invalid := []func(r *Rule){
+		func(r *Rule) { r.ID = "" },
+		func(r *Rule) { r.ID = "a/b" },
+		func(r *Rule) { r.Role = "follower" },
+		func(r *Rule) { r.Count = 0 },
+		func(r *Rule) { r.StartKeyHex = "xyz" },
+		func(r *Rule) { r.EndKeyHex = "61" },
+		func(r *Rule) { r.LabelConstraints = []LabelConstraint{{Key: "disk", Op: "eq"}} },
+		func(r *Rule) { r.LabelConstraints = []LabelConstraint{{Key: "disk", Op: In}} },
+		func(r *Rule) { r.IsolationLevel = "zone" },
+	}
+	for _, f := range invalid {
+		rule := valid.Clone()
+		f(rule)
+		c.Assert(rule.Adjust(), NotNil)
+	}
+}
+
+func (s *testRuleSuite) TestContainsRange(c *C) {
+	rule := &Rule{ID: "r1", Role: Voter, Count: 3, StartKeyHex: "61", EndKeyHex: "63"}
+	c.Assert(rule.Adjust(), IsNil)
+	c.Assert(rule.ContainsRange([]byte("a"), []byte("b")), IsTrue)
+	c.Assert(rule.ContainsRange([]byte("a"), []byte("c")), IsTrue)
+	c.Assert(rule.ContainsRange([]byte(""), []byte("b")), IsFalse)
+	c.Assert(rule.ContainsRange([]byte("b"), []byte("d")), IsFalse)
+	c.Assert(rule.ContainsRange([]byte("b"), []byte("")), IsFalse)
+
+	rule = &Rule{ID: "r2", Role: Voter, Count: 3}
+	c.Assert(rule.Adjust(), IsNil)
+	c.Assert(rule.ContainsRange([]byte(""), []byte("")), IsTrue)
+	c.Assert(rule.ContainsRange([]byte("b"), []byte("d")), IsTrue)
+}
+
+func (s *testRuleSuite) TestRuleManager(c *C) {
+	kv := core.NewKV(core.NewMemoryKV())
+	m := NewRuleManager(kv)
+	c.Assert(m.Initialize(3, []string{"zone", "host"}), IsNil)
+	rules := m.GetAllRules()
+	c.Assert(rules, HasLen, 1)
+	c.Assert(rules[0].ID, Equals, DefaultRuleID)
+	c.Assert(rules[0].Count, Equals, 3)
+	c.Assert(rules[0].LocationLabels, DeepEquals, []string{"zone", "host"})
+
+	learner := &Rule{ID: "learner", Index: 1, Role: Learner, Count: 1, StartKeyHex: "61", EndKeyHex: "63"}
+	c.Assert(m.SetRule(learner), IsNil)
+	c.Assert(m.SetRule(&Rule{ID: "invalid", Role: Voter}), NotNil)
+	c.Assert(m.GetRule("invalid"), IsNil)
+	c.Assert(m.GetRulesForRange([]byte("a"), []byte("b")), HasLen, 2)
+	c.Assert(m.GetRulesForRange([]byte("c"), []byte("d")), HasLen, 1)
+
+	// The rules with lower index are ignored if overridden.
+	ssd := &Rule{
+		ID:               "ssd",
+		Index:            1,
+		Override:         true,
+		Role:             Voter,
+		Count:            3,
+		StartKeyHex:      "61",
+		EndKeyHex:        "62",
+		LabelConstraints: []LabelConstraint{{Key: "disk", Op: In, Values: []string{"ssd"}}},
+	}
+	c.Assert(m.SetRule(ssd), IsNil)
+	rules = m.GetRulesForRange([]byte("a"), []byte("b"))
+	c.Assert(rules, HasLen, 1)
+	c.Assert(rules[0].ID, Equals, "ssd")
+
+	// The rules are loaded from the KV.
+	m = NewRuleManager(kv)
+	c.Assert(m.Initialize(5, nil), IsNil)
+	rules = m.GetAllRules()
+	c.Assert(rules, HasLen, 3)
+	c.Assert(rules[0].ID, Equals, DefaultRuleID)
+	c.Assert(rules[0].Count, Equals, 3)
+	c.Assert(rules[2].ID, Equals, "ssd")
+	c.Assert(rules[2].LabelConstraints, DeepEquals, ssd.LabelConstraints)
+
+	// The default rule follows the replication config.
+	c.Assert(m.UpdateDefaultRule(5, []string{"zone"}), IsNil)
+	rule := m.GetRule(DefaultRuleID)
+	c.Assert(rule.Count, Equals, 5)
+	c.Assert(rule.LocationLabels, DeepEquals, []string{"zone"})
+	c.Assert(m.UpdateDefaultRule(3, nil), IsNil)
+
+	c.Assert(m.DeleteRule("ssd"), IsNil)
+	c.Assert(m.DeleteRule("ssd"), NotNil)
+	m = NewRuleManager(kv)
+	c.Assert(m.Initialize(3, nil), IsNil)
+	c.Assert(m.GetAllRules(), HasLen, 2)
+	c.Assert(m.GetRule("ssd"), IsNil)
+}
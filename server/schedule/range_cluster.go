@@ -109,6 +109,11 @@ func (r *RangeCluster) RandFollowerRegion(storeID uint64, opts ...core.RegionOpt
 	return r.regions.RandFollowerRegion(storeID, opts...)
 }
 
+// RandLearnerRegion returns a random region that has a learner on the store.
+func (r *RangeCluster) RandLearnerRegion(storeID uint64, opts ...core.RegionOption) *core.RegionInfo {
+	return r.regions.RandLearnerRegion(storeID, opts...)
+}
+
 // RandLeaderRegion returns a random region that has leader on the store.
 func (r *RangeCluster) RandLeaderRegion(storeID uint64, opts ...core.RegionOption) *core.RegionInfo {
 	return r.regions.RandLeaderRegion(storeID, opts...)
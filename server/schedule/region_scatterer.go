@@ -16,6 +16,7 @@ package schedule
 import (
 	"math/rand"
 	"sync"
+	"time"
 
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/pd/server/core"
@@ -43,6 +44,13 @@ func (s *selectedStores) put(id uint64) bool {
 	return true
 }
 
+func (s *selectedStores) contains(id uint64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.stores[id]
+	return ok
+}
+
 func (s *selectedStores) reset() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -56,7 +64,17 @@ func (s *selectedStores) newFilter() Filter {
 	for id := range s.stores {
 		cloned[id] = struct{}{}
 	}
-	return NewExcludedFilter(nil, cloned)
+	return NewExcludedFilter(regionScattererName, nil, cloned)
+}
+
+const regionScattererName = "region-scatterer"
+
+// scatterGroup records the stores selected for the regions in a group, the
+// stores are selected in turn so that the group is evenly distributed.
+type scatterGroup struct {
+	peers    *selectedStores
+	leaders  *selectedStores
+	lastUsed time.Time
 }
 
 // RegionScatterer scatters regions.
@@ -64,84 +82,171 @@ type RegionScatterer struct {
 	cluster    Cluster
 	classifier namespace.Classifier
 	filters    []Filter
-	selected   *selectedStores
+
+	mu     sync.Mutex
+	groups map[string]*scatterGroup
 }
 
 // NewRegionScatterer creates a region scatterer.
 func NewRegionScatterer(cluster Cluster, classifier namespace.Classifier) *RegionScatterer {
 	filters := []Filter{
-		NewStateFilter(),
-		NewHealthFilter(),
+		NewStateFilter(regionScattererName),
+		NewHealthFilter(regionScattererName),
 	}
 
 	return &RegionScatterer{
 		cluster:    cluster,
 		classifier: classifier,
 		filters:    filters,
-		selected:   newSelectedStores(),
+		groups:     make(map[string]*scatterGroup),
+	}
+}
+
+func (r *RegionScatterer) getGroup(name string) *scatterGroup {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	group, ok := r.groups[name]
+	if !ok {
+		group = &scatterGroup{
+			peers:   newSelectedStores(),
+			leaders: newSelectedStores(),
+		}
+		r.groups[name] = group
+	}
+	group.lastUsed = time.Now()
+	return group
+}
+
+// PruneGroups removes the groups which are not used in the keep time.
+func (r *RegionScatterer) PruneGroups(keepTime time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name, group := range r.groups {
+		if time.Since(group.lastUsed) > keepTime {
+			delete(r.groups, name)
+		}
 	}
 }
 
-// Scatter relocates the region.
+// Scatter relocates the region within the default group.
 func (r *RegionScatterer) Scatter(region *core.RegionInfo) *Operator {
+	return r.ScatterWithGroup(region, "")
+}
+
+// ScatterWithGroup relocates the region. The peers and the leaders of the
+// regions in the same group are evenly distributed across the stores.
+func (r *RegionScatterer) ScatterWithGroup(region *core.RegionInfo, group string) *Operator {
 	if r.cluster.IsRegionHot(region.GetId()) {
 		return nil
 	}
 
-	if len(region.GetPeers()) != r.cluster.GetMaxReplicas() {
+	if !HasExpectedReplicas(r.cluster, r.classifier, region) {
 		return nil
 	}
 
-	return r.scatterRegion(region)
+	return r.scatterRegion(region, r.getGroup(group))
 }
 
-func (r *RegionScatterer) scatterRegion(region *core.RegionInfo) *Operator {
+func (r *RegionScatterer) scatterRegion(region *core.RegionInfo, group *scatterGroup) *Operator {
 	steps := make([]OperatorStep, 0, len(region.GetPeers()))
 
-	stores := r.collectAvailableStores(region)
+	stores := r.collectAvailableStores(region, group)
+	// The peers violating the range constraints are always replaced.
+	constraintFilter := NewRangeConstraintFilter(regionScattererName, r.cluster, region)
 	var kind OperatorKind
+	leaderStore := region.Leader.GetStoreId()
+	voterStores := make([]uint64, 0, len(region.GetPeers()))
 	for _, peer := range region.GetPeers() {
+		// The read-only learners are kept in place.
+		if peer.GetIsLearner() {
+			continue
+		}
 		if len(stores) == 0 {
 			// Reset selected stores if we have no available stores.
-			r.selected.reset()
-			stores = r.collectAvailableStores(region)
+			group.peers.reset()
+			stores = r.collectAvailableStores(region, group)
 		}
 
-		if r.selected.put(peer.GetStoreId()) {
+		store := r.cluster.GetStore(peer.GetStoreId())
+		if (store == nil || !constraintFilter.FilterTarget(r.cluster, store)) && group.peers.put(peer.GetStoreId()) {
 			delete(stores, peer.GetStoreId())
+			voterStores = append(voterStores, peer.GetStoreId())
 			continue
 		}
 		newPeer := r.selectPeerToReplace(stores, region, peer)
 		if newPeer == nil {
+			voterStores = append(voterStores, peer.GetStoreId())
 			continue
 		}
 
 		// Remove it from stores and mark it as selected.
 		delete(stores, newPeer.GetStoreId())
-		r.selected.put(newPeer.GetStoreId())
+		group.peers.put(newPeer.GetStoreId())
+		voterStores = append(voterStores, newPeer.GetStoreId())
 
 		op := CreateMovePeerOperator("scatter-peer", r.cluster, region, OpAdmin,
 			peer.GetStoreId(), newPeer.GetStoreId(), newPeer.GetId())
 		steps = append(steps, op.steps...)
-		steps = append(steps, TransferLeader{ToStore: newPeer.GetStoreId()})
+		// Keep the leader on the new peer, so that it is never removed.
+		steps = append(steps, TransferLeader{FromStore: leaderStore, ToStore: newPeer.GetStoreId()})
+		leaderStore = newPeer.GetStoreId()
 		kind |= op.Kind()
 	}
 
+	if target := r.selectLeader(group, voterStores); target != 0 && target != leaderStore {
+		steps = append(steps, TransferLeader{FromStore: leaderStore, ToStore: target})
+		kind |= OpAdmin | OpLeader
+	}
+
 	if len(steps) == 0 {
 		return nil
 	}
 	return NewOperator("scatter-region", region.GetId(), region.GetRegionEpoch(), kind, steps...)
 }
 
+// selectLeader selects the store to place the leader among the stores of the
+// voters, the stores which have not been selected in the group are preferred.
+// It returns 0 if no store can accept the leader.
+func (r *RegionScatterer) selectLeader(group *scatterGroup, storeIDs []uint64) uint64 {
+	candidates := make([]uint64, 0, len(storeIDs))
+	filters := append(r.filters[:len(r.filters):len(r.filters)], NewRejectLeaderFilter(regionScattererName))
+	for _, id := range storeIDs {
+		store := r.cluster.GetStore(id)
+		if store == nil || FilterTarget(r.cluster, store, filters) {
+			continue
+		}
+		candidates = append(candidates, id)
+	}
+	if len(candidates) == 0 {
+		return 0
+	}
+
+	available := make([]uint64, 0, len(candidates))
+	for _, id := range candidates {
+		if !group.leaders.contains(id) {
+			available = append(available, id)
+		}
+	}
+	if len(available) == 0 {
+		// Reset selected stores if all candidates have been selected.
+		group.leaders.reset()
+		available = candidates
+	}
+	target := available[rand.Intn(len(available))]
+	group.leaders.put(target)
+	return target
+}
+
 func (r *RegionScatterer) selectPeerToReplace(stores map[uint64]*core.StoreInfo, region *core.RegionInfo, oldPeer *metapb.Peer) *metapb.Peer {
 	// scoreGuard guarantees that the distinct score will not decrease.
 	regionStores := r.cluster.GetRegionStores(region)
 	sourceStore := r.cluster.GetStore(oldPeer.GetStoreId())
-	scoreGuard := NewDistinctScoreFilter(r.cluster.GetLocationLabels(), regionStores, sourceStore)
+	scoreGuard := NewDistinctScoreFilter(regionScattererName, r.cluster.GetLocationLabels(), regionStores, sourceStore)
+	ruleFit := NewRuleFitFilter(regionScattererName, r.cluster, region, oldPeer.GetStoreId())
 
 	candidates := make([]*core.StoreInfo, 0, len(stores))
 	for _, store := range stores {
-		if scoreGuard.FilterTarget(r.cluster, store) {
+		if scoreGuard.FilterTarget(r.cluster, store) || ruleFit.FilterTarget(r.cluster, store) {
 			continue
 		}
 		candidates = append(candidates, store)
@@ -159,12 +264,16 @@ func (r *RegionScatterer) selectPeerToReplace(stores map[uint64]*core.StoreInfo,
 	return newPeer
 }
 
-func (r *RegionScatterer) collectAvailableStores(region *core.RegionInfo) map[uint64]*core.StoreInfo {
+func (r *RegionScatterer) collectAvailableStores(region *core.RegionInfo, group *scatterGroup) map[uint64]*core.StoreInfo {
 	namespace := r.classifier.GetRegionNamespace(region)
+	_, learnerConstraints := GetLearnerReplicas(r.cluster, r.classifier, region)
 	filters := []Filter{
-		r.selected.newFilter(),
-		NewExcludedFilter(nil, region.GetStoreIds()),
-		NewNamespaceFilter(r.classifier, namespace),
+		group.peers.newFilter(),
+		NewExcludedFilter(regionScattererName, nil, region.GetStoreIds()),
+		NewNamespaceFilter(regionScattererName, r.classifier, namespace),
+		NewRangeConstraintFilter(regionScattererName, r.cluster, region),
+		NewLearnerStoreFilter(regionScattererName, learnerConstraints),
+		NewRejectRegionFilter(regionScattererName),
 	}
 	filters = append(filters, r.filters...)
 
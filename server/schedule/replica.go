@@ -49,12 +49,12 @@ func compareStoreScore(opt Options, storeA *core.StoreInfo, scoreA float64, stor
 		return -1
 	}
 	// The store with lower region score is better.
-	if storeA.RegionScore(opt.GetHighSpaceRatio(), opt.GetLowSpaceRatio(), 0) <
-		storeB.RegionScore(opt.GetHighSpaceRatio(), opt.GetLowSpaceRatio(), 0) {
+	if StoreScore(opt, storeA, core.RegionKind, 0) <
+		StoreScore(opt, storeB, core.RegionKind, 0) {
 		return 1
 	}
-	if storeA.RegionScore(opt.GetHighSpaceRatio(), opt.GetLowSpaceRatio(), 0) >
-		storeB.RegionScore(opt.GetHighSpaceRatio(), opt.GetLowSpaceRatio(), 0) {
+	if StoreScore(opt, storeA, core.RegionKind, 0) >
+		StoreScore(opt, storeB, core.RegionKind, 0) {
 		return -1
 	}
 	return 0
@@ -14,33 +14,57 @@
 package schedule
 
 import (
+	"fmt"
+
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/server/namespace"
 	log "github.com/sirupsen/logrus"
 )
 
+const replicaCheckerName = "replica-checker"
+
 // ReplicaChecker ensures region has the best replicas.
 type ReplicaChecker struct {
+	name       string
 	cluster    Cluster
 	classifier namespace.Classifier
 	filters    []Filter
+	// explaining is true if the checker is used to explain, the filter
+	// metrics are not updated then.
+	explaining bool
 }
 
-// NewReplicaChecker creates a replica checker.
-func NewReplicaChecker(cluster Cluster, classifier namespace.Classifier) *ReplicaChecker {
+// NewReplicaChecker creates a replica checker. The optional name is used as
+// the scope of the filters, it is useful when the checker is used by a
+// scheduler.
+func NewReplicaChecker(cluster Cluster, classifier namespace.Classifier, n ...string) *ReplicaChecker {
+	name := replicaCheckerName
+	if len(n) != 0 {
+		name = n[0]
+	}
 	filters := []Filter{
-		NewHealthFilter(),
-		NewSnapshotCountFilter(),
+		NewHealthFilter(name),
+		NewSnapshotCountFilter(name),
+		NewRejectRegionFilter(name),
 	}
 
 	return &ReplicaChecker{
+		name:       name,
 		cluster:    cluster,
 		classifier: classifier,
 		filters:    filters,
 	}
 }
 
+// WithCluster returns a copy of the checker which reads the options from the
+// cluster, such as the options overridden by the namespace of a region.
+func (r *ReplicaChecker) WithCluster(cluster Cluster) *ReplicaChecker {
+	checker := *r
+	checker.cluster = cluster
+	return &checker
+}
+
 // Check verifies a region's replicas, creating an Operator if need.
 func (r *ReplicaChecker) Check(region *core.RegionInfo) *Operator {
 	checkerCounter.WithLabelValues("replica_checker", "check").Inc()
@@ -49,15 +73,31 @@ func (r *ReplicaChecker) Check(region *core.RegionInfo) *Operator {
 		op.SetPriorityLevel(core.HighPriority)
 		return op
 	}
+	if r.cluster.IsPlacementRulesEnabled() {
+		if op := r.checkRules(region); op != nil {
+			return op
+		}
+		// The read-only learners are not placed by the rules.
+		if op := r.checkOfflineLearner(region); op != nil {
+			checkerCounter.WithLabelValues("replica_checker", "new_operator").Inc()
+			op.SetPriorityLevel(core.HighPriority)
+			return op
+		}
+		if op := r.checkLearner(region); op != nil {
+			checkerCounter.WithLabelValues("replica_checker", "new_operator").Inc()
+			return op
+		}
+		return nil
+	}
 	if op := r.checkOfflinePeer(region); op != nil {
 		checkerCounter.WithLabelValues("replica_checker", "new_operator").Inc()
 		op.SetPriorityLevel(core.HighPriority)
 		return op
 	}
 
-	if len(region.GetPeers()) < r.cluster.GetMaxReplicas() && r.cluster.IsMakeUpReplicaEnabled() {
-		log.Debugf("[region %d] has %d peers fewer than max replicas", region.GetId(), len(region.GetPeers()))
-		newPeer, _ := r.selectBestPeerToAddReplica(region, NewStorageThresholdFilter())
+	if r.getReplicaCount(region) < r.cluster.GetMaxReplicas() && r.cluster.IsMakeUpReplicaEnabled() {
+		log.Debugf("[region %d] has %d peers fewer than max replicas", region.GetId(), r.getReplicaCount(region))
+		newPeer, _ := r.selectBestPeerToAddReplica(region, NewStorageThresholdFilter(r.name))
 		if newPeer == nil {
 			checkerCounter.WithLabelValues("replica_checker", "no_target_store").Inc()
 			return nil
@@ -81,7 +121,10 @@ func (r *ReplicaChecker) Check(region *core.RegionInfo) *Operator {
 	// just comparing the the number of voters to avoid too many cancel add operator log.
 	if len(region.GetVoters()) > r.cluster.GetMaxReplicas() && r.cluster.IsRemoveExtraReplicaEnabled() {
 		log.Debugf("[region %d] has %d peers more than max replicas", region.GetId(), len(region.GetPeers()))
-		oldPeer, _ := r.selectWorstPeer(region)
+		oldPeer := r.selectConstraintViolatedPeer(region)
+		if oldPeer == nil {
+			oldPeer, _ = r.selectWorstPeer(region)
+		}
 		if oldPeer == nil {
 			checkerCounter.WithLabelValues("replica_checker", "no_worst_peer").Inc()
 			return nil
@@ -90,12 +133,22 @@ func (r *ReplicaChecker) Check(region *core.RegionInfo) *Operator {
 		return CreateRemovePeerOperator("removeExtraReplica", r.cluster, OpReplica, region, oldPeer.GetStoreId())
 	}
 
+	if op := r.checkLearner(region); op != nil {
+		checkerCounter.WithLabelValues("replica_checker", "new_operator").Inc()
+		return op
+	}
+
+	if op := r.checkRangeConstraint(region); op != nil {
+		checkerCounter.WithLabelValues("replica_checker", "new_operator").Inc()
+		return op
+	}
+
 	return r.checkBestReplacement(region)
 }
 
 // SelectBestReplacementStore returns a store id that to be used to replace the old peer and distinct score.
 func (r *ReplicaChecker) SelectBestReplacementStore(region *core.RegionInfo, oldPeer *metapb.Peer, filters ...Filter) (uint64, float64) {
-	filters = append(filters, NewExcludedFilter(nil, region.GetStoreIds()))
+	filters = append(filters, NewExcludedFilter(r.name, nil, region.GetStoreIds()))
 	newRegion := region.Clone()
 	newRegion.RemoveStorePeer(oldPeer.GetStoreId())
 	return r.selectBestStoreToAddReplica(newRegion, filters...)
@@ -117,30 +170,57 @@ func (r *ReplicaChecker) selectBestPeerToAddReplica(region *core.RegionInfo, fil
 
 // selectBestStoreToAddReplica returns the store to add a replica.
 func (r *ReplicaChecker) selectBestStoreToAddReplica(region *core.RegionInfo, filters ...Filter) (uint64, float64) {
+	filters = r.addReplicaFilters(region, filters...)
+	regionStores := r.getReplicaStores(region)
+	selector := r.newReplicaSelector(regionStores, r.cluster.GetLocationLabels(), r.filters...)
+	target := selector.SelectTarget(r.cluster, r.cluster.GetStores(), filters...)
+	if target == nil {
+		return 0, 0
+	}
+	return target.GetId(), DistinctScore(r.cluster.GetLocationLabels(), regionStores, target)
+}
+
+// newReplicaSelector creates a replica selector with the filters of the
+// checker.
+func (r *ReplicaChecker) newReplicaSelector(regionStores []*core.StoreInfo, labels []string, filters ...Filter) Selector {
+	if r.explaining {
+		return newSilentReplicaSelector(regionStores, labels, filters...)
+	}
+	return NewReplicaSelector(regionStores, labels, filters...)
+}
+
+// silent returns a copy of the checker which doesn't update the filter
+// metrics, so that explaining has no side effects.
+func (r *ReplicaChecker) silent() *ReplicaChecker {
+	checker := *r
+	checker.explaining = true
+	return &checker
+}
+
+// addReplicaFilters returns the filters used to select a store to add a
+// replica.
+func (r *ReplicaChecker) addReplicaFilters(region *core.RegionInfo, filters ...Filter) []Filter {
+	_, learnerConstraints := GetLearnerReplicas(r.cluster, r.classifier, region)
 	// Add some must have filters.
 	newFilters := []Filter{
-		NewStateFilter(),
-		NewPendingPeerCountFilter(),
-		NewExcludedFilter(nil, region.GetStoreIds()),
+		NewStateFilter(r.name),
+		NewPendingPeerCountFilter(r.name),
+		NewExcludedFilter(r.name, nil, region.GetStoreIds()),
+		NewRangeConstraintFilter(r.name, r.cluster, region),
+		NewLearnerStoreFilter(r.name, learnerConstraints),
 	}
 	filters = append(filters, r.filters...)
 	filters = append(filters, newFilters...)
 	if r.classifier != nil {
-		filters = append(filters, NewNamespaceFilter(r.classifier, r.classifier.GetRegionNamespace(region)))
+		filters = append(filters, NewNamespaceFilter(r.name, r.classifier, r.classifier.GetRegionNamespace(region)))
 	}
-	regionStores := r.cluster.GetRegionStores(region)
-	selector := NewReplicaSelector(regionStores, r.cluster.GetLocationLabels(), r.filters...)
-	target := selector.SelectTarget(r.cluster, r.cluster.GetStores(), filters...)
-	if target == nil {
-		return 0, 0
-	}
-	return target.GetId(), DistinctScore(r.cluster.GetLocationLabels(), regionStores, target)
+	return filters
 }
 
 // selectWorstPeer returns the worst peer in the region.
 func (r *ReplicaChecker) selectWorstPeer(region *core.RegionInfo) (*metapb.Peer, float64) {
-	regionStores := r.cluster.GetRegionStores(region)
-	selector := NewReplicaSelector(regionStores, r.cluster.GetLocationLabels(), r.filters...)
+	regionStores := r.getReplicaStores(region)
+	selector := r.newReplicaSelector(regionStores, r.cluster.GetLocationLabels(), r.filters...)
 	worstStore := selector.SelectSource(r.cluster, regionStores)
 	if worstStore == nil {
 		log.Debugf("[region %d] no worst store", region.GetId())
@@ -150,6 +230,16 @@ func (r *ReplicaChecker) selectWorstPeer(region *core.RegionInfo) (*metapb.Peer,
 }
 
 func (r *ReplicaChecker) checkDownPeer(region *core.RegionInfo) *Operator {
+	peer := r.selectDownPeer(region)
+	if peer == nil {
+		return nil
+	}
+	return CreateRemovePeerOperator("removeDownReplica", r.cluster, OpReplica, region, peer.GetStoreId())
+}
+
+// selectDownPeer returns a peer which has been down for long enough to be
+// removed.
+func (r *ReplicaChecker) selectDownPeer(region *core.RegionInfo) *metapb.Peer {
 	if !r.cluster.IsRemoveDownReplicaEnabled() {
 		return nil
 	}
@@ -170,18 +260,55 @@ func (r *ReplicaChecker) checkDownPeer(region *core.RegionInfo) *Operator {
 		if stats.GetDownSeconds() < uint64(r.cluster.GetMaxStoreDownTime().Seconds()) {
 			continue
 		}
-		return CreateRemovePeerOperator("removeDownReplica", r.cluster, OpReplica, region, peer.GetStoreId())
+		return peer
 	}
 	return nil
 }
 
 func (r *ReplicaChecker) checkOfflinePeer(region *core.RegionInfo) *Operator {
+	peer := r.selectOfflinePeer(region)
+	if peer == nil {
+		return nil
+	}
+
+	if r.isPermanentLearner(region, peer) {
+		return r.replaceOfflineLearner(region, peer)
+	}
+
+	// Check the number of replicas first.
+	if r.getReplicaCount(region) > r.cluster.GetMaxReplicas() {
+		return CreateRemovePeerOperator("removeExtraOfflineReplica", r.cluster, OpReplica, region, peer.GetStoreId())
+	}
+
+	// Consider we have 3 peers (A, B, C), we set the store that contains C to
+	// offline while C is pending. If we generate an operator that adds a replica
+	// D then removes C, D will not be successfully added util C is normal again.
+	// So it's better to remove C directly.
+	if region.GetPendingPeer(peer.GetId()) != nil {
+		return CreateRemovePeerOperator("removePendingOfflineReplica", r.cluster, OpReplica, region, peer.GetStoreId())
+	}
+
+	storeID, _ := r.SelectBestReplacementStore(region, peer, NewStorageThresholdFilter(r.name))
+	if storeID == 0 {
+		log.Debugf("[region %d] no best store to add replica", region.GetId())
+		return nil
+	}
+	newPeer, err := r.cluster.AllocPeer(storeID)
+	if err != nil {
+		return nil
+	}
+	return CreateMovePeerOperator("replaceOfflineReplica", r.cluster, region, OpReplica, peer.GetStoreId(), newPeer.GetStoreId(), newPeer.GetId())
+}
+
+// selectOfflinePeer returns the first peer located on a store which is not up.
+func (r *ReplicaChecker) selectOfflinePeer(region *core.RegionInfo) *metapb.Peer {
 	if !r.cluster.IsReplaceOfflineReplicaEnabled() {
 		return nil
 	}
 
-	// just skip learner
-	if len(region.Learners) != 0 {
+	// just skip the region with transient learners
+	_, constraints := GetLearnerReplicas(r.cluster, r.classifier, region)
+	if HasTransientLearner(r.cluster, region, constraints) {
 		return nil
 	}
 
@@ -191,38 +318,206 @@ func (r *ReplicaChecker) checkOfflinePeer(region *core.RegionInfo) *Operator {
 			log.Infof("lost the store %d, maybe you are recovering the PD cluster.", peer.GetStoreId())
 			return nil
 		}
-		if store.IsUp() {
-			continue
+		if !store.IsUp() {
+			return peer
 		}
+	}
+
+	return nil
+}
 
-		// Check the number of replicas first.
-		if len(region.GetPeers()) > r.cluster.GetMaxReplicas() {
-			return CreateRemovePeerOperator("removeExtraOfflineReplica", r.cluster, OpReplica, region, peer.GetStoreId())
+// checkRangeConstraint moves the peer on the store which does not match the
+// range constraints of the region.
+func (r *ReplicaChecker) checkRangeConstraint(region *core.RegionInfo) *Operator {
+	peer := r.selectConstraintViolatedPeer(region)
+	if peer == nil {
+		return nil
+	}
+	storeID, _ := r.SelectBestReplacementStore(region, peer, NewStorageThresholdFilter(r.name))
+	if storeID == 0 {
+		log.Debugf("[region %d] no store matches the range constraints", region.GetId())
+		checkerCounter.WithLabelValues("replica_checker", "no_constrained_store").Inc()
+		return nil
+	}
+	newPeer, err := r.cluster.AllocPeer(storeID)
+	if err != nil {
+		return nil
+	}
+	return CreateMovePeerOperator("moveToConstrainedStore", r.cluster, region, OpReplica, peer.GetStoreId(), newPeer.GetStoreId(), newPeer.GetId())
+}
+
+// selectConstraintViolatedPeer returns the first peer located on a store
+// which does not match the range constraints of the region. The read-only
+// learners are not restricted by the range constraints.
+func (r *ReplicaChecker) selectConstraintViolatedPeer(region *core.RegionInfo) *metapb.Peer {
+	filter := NewRangeConstraintFilter(r.name, r.cluster, region)
+	for _, store := range r.getReplicaStores(region) {
+		if filter.FilterTarget(r.cluster, store) {
+			return region.GetStorePeer(store.GetId())
 		}
+	}
+	return nil
+}
 
-		// Consider we have 3 peers (A, B, C), we set the store that contains C to
-		// offline while C is pending. If we generate an operator that adds a replica
-		// D then removes C, D will not be successfully added util C is normal again.
-		// So it's better to remove C directly.
-		if region.GetPendingPeer(peer.GetId()) != nil {
-			return CreateRemovePeerOperator("removePendingOfflineReplica", r.cluster, OpReplica, region, peer.GetStoreId())
+// getReplicaCount returns the number of the peers of the region except the
+// read-only learners.
+func (r *ReplicaChecker) getReplicaCount(region *core.RegionInfo) int {
+	_, constraints := GetLearnerReplicas(r.cluster, r.classifier, region)
+	return len(region.GetPeers()) - len(GetPermanentLearners(r.cluster, region, constraints))
+}
+
+// getReplicaStores returns the stores of the region except the ones of the
+// read-only learners.
+func (r *ReplicaChecker) getReplicaStores(region *core.RegionInfo) []*core.StoreInfo {
+	_, constraints := GetLearnerReplicas(r.cluster, r.classifier, region)
+	var stores []*core.StoreInfo
+	for _, store := range r.cluster.GetRegionStores(region) {
+		if !region.GetStorePeer(store.GetId()).GetIsLearner() || !IsLearnerStore(store, constraints) {
+			stores = append(stores, store)
 		}
+	}
+	return stores
+}
+
+// isPermanentLearner returns true if the peer is a read-only learner.
+func (r *ReplicaChecker) isPermanentLearner(region *core.RegionInfo, peer *metapb.Peer) bool {
+	if !peer.GetIsLearner() {
+		return false
+	}
+	_, constraints := GetLearnerReplicas(r.cluster, r.classifier, region)
+	store := r.cluster.GetStore(peer.GetStoreId())
+	return store != nil && IsLearnerStore(store, constraints)
+}
 
-		storeID, _ := r.SelectBestReplacementStore(region, peer, NewStorageThresholdFilter())
+// checkLearner makes up or removes the read-only learners of the region to
+// match the learner replicas setting.
+func (r *ReplicaChecker) checkLearner(region *core.RegionInfo) *Operator {
+	count, constraints := GetLearnerReplicas(r.cluster, r.classifier, region)
+	learners := GetPermanentLearners(r.cluster, region, constraints)
+	if len(learners) < count && r.cluster.IsMakeUpReplicaEnabled() && r.cluster.IsRaftLearnerEnabled() {
+		log.Debugf("[region %d] has %d learners fewer than learner replicas", region.GetId(), len(learners))
+		storeID, _ := r.selectBestStoreToAddLearner(region, NewStorageThresholdFilter(r.name))
 		if storeID == 0 {
-			log.Debugf("[region %d] no best store to add replica", region.GetId())
+			checkerCounter.WithLabelValues("replica_checker", "no_learner_store").Inc()
 			return nil
 		}
 		newPeer, err := r.cluster.AllocPeer(storeID)
 		if err != nil {
 			return nil
 		}
-		return CreateMovePeerOperator("replaceOfflineReplica", r.cluster, region, OpReplica, peer.GetStoreId(), newPeer.GetStoreId(), newPeer.GetId())
+		step := AddLearner{ToStore: newPeer.GetStoreId(), PeerID: newPeer.GetId()}
+		return NewOperator("makeUpLearner", region.GetId(), region.GetRegionEpoch(), OpReplica|OpRegion, step)
+	}
+	if len(learners) > count && r.cluster.IsRemoveExtraReplicaEnabled() {
+		log.Debugf("[region %d] has %d learners more than learner replicas", region.GetId(), len(learners))
+		storeID := r.selectWorstLearnerStore(region)
+		if storeID == 0 {
+			checkerCounter.WithLabelValues("replica_checker", "no_worst_learner").Inc()
+			return nil
+		}
+		return CreateRemovePeerOperator("removeExtraLearner", r.cluster, OpReplica, region, storeID)
 	}
+	return nil
+}
 
+// checkOfflineLearner replaces the read-only learner on the store which is not
+// up, the voters on such stores are replaced by the placement rules.
+func (r *ReplicaChecker) checkOfflineLearner(region *core.RegionInfo) *Operator {
+	if !r.cluster.IsReplaceOfflineReplicaEnabled() {
+		return nil
+	}
+	for _, peer := range region.GetLearners() {
+		store := r.cluster.GetStore(peer.GetStoreId())
+		if store == nil || store.IsUp() || !r.isPermanentLearner(region, peer) {
+			continue
+		}
+		return r.replaceOfflineLearner(region, peer)
+	}
 	return nil
 }
 
+// replaceOfflineLearner moves the read-only learner on the offline store to
+// another store reserved for the learners.
+func (r *ReplicaChecker) replaceOfflineLearner(region *core.RegionInfo, peer *metapb.Peer) *Operator {
+	if region.GetPendingPeer(peer.GetId()) != nil {
+		return CreateRemovePeerOperator("removePendingOfflineLearner", r.cluster, OpReplica, region, peer.GetStoreId())
+	}
+	storeID, _ := r.SelectBestLearnerReplacementStore(region, peer, NewStorageThresholdFilter(r.name))
+	if storeID == 0 {
+		log.Debugf("[region %d] no best store to add learner", region.GetId())
+		return nil
+	}
+	newPeer, err := r.cluster.AllocPeer(storeID)
+	if err != nil {
+		return nil
+	}
+	return CreateMoveLearnerOperator("replaceOfflineLearner", region, OpReplica, peer.GetStoreId(), newPeer.GetStoreId(), newPeer.GetId())
+}
+
+// SelectBestLearnerReplacementStore returns a store id that to be used to
+// replace the old read-only learner and distinct score.
+func (r *ReplicaChecker) SelectBestLearnerReplacementStore(region *core.RegionInfo, oldPeer *metapb.Peer, filters ...Filter) (uint64, float64) {
+	filters = append(filters, NewExcludedFilter(r.name, nil, region.GetStoreIds()))
+	newRegion := region.Clone()
+	newRegion.RemoveStorePeer(oldPeer.GetStoreId())
+	return r.selectBestStoreToAddLearner(newRegion, filters...)
+}
+
+// selectBestStoreToAddLearner returns the store to add a read-only learner,
+// the learners are spread across the locations like the voters.
+func (r *ReplicaChecker) selectBestStoreToAddLearner(region *core.RegionInfo, filters ...Filter) (uint64, float64) {
+	filters = r.addLearnerFilters(region, filters...)
+	learnerStores := r.getLearnerStores(region)
+	selector := r.newReplicaSelector(learnerStores, r.cluster.GetLocationLabels(), r.filters...)
+	target := selector.SelectTarget(r.cluster, r.cluster.GetStores(), filters...)
+	if target == nil {
+		return 0, 0
+	}
+	return target.GetId(), DistinctScore(r.cluster.GetLocationLabels(), learnerStores, target)
+}
+
+// addLearnerFilters returns the filters used to select a store to add a
+// read-only learner.
+func (r *ReplicaChecker) addLearnerFilters(region *core.RegionInfo, filters ...Filter) []Filter {
+	_, constraints := GetLearnerReplicas(r.cluster, r.classifier, region)
+	newFilters := []Filter{
+		NewStateFilter(r.name),
+		NewPendingPeerCountFilter(r.name),
+		NewExcludedFilter(r.name, nil, region.GetStoreIds()),
+		NewLabelConstraintFilter(r.name, constraints),
+	}
+	filters = append(filters, r.filters...)
+	filters = append(filters, newFilters...)
+	if r.classifier != nil {
+		filters = append(filters, NewNamespaceFilter(r.name, r.classifier, r.classifier.GetRegionNamespace(region)))
+	}
+	return filters
+}
+
+// selectWorstLearnerStore returns the store of the worst read-only learner.
+func (r *ReplicaChecker) selectWorstLearnerStore(region *core.RegionInfo) uint64 {
+	learnerStores := r.getLearnerStores(region)
+	selector := r.newReplicaSelector(learnerStores, r.cluster.GetLocationLabels(), r.filters...)
+	worstStore := selector.SelectSource(r.cluster, learnerStores)
+	if worstStore == nil {
+		return 0
+	}
+	return worstStore.GetId()
+}
+
+// getLearnerStores returns the stores of the read-only learners of the
+// region.
+func (r *ReplicaChecker) getLearnerStores(region *core.RegionInfo) []*core.StoreInfo {
+	_, constraints := GetLearnerReplicas(r.cluster, r.classifier, region)
+	var stores []*core.StoreInfo
+	for _, peer := range GetPermanentLearners(r.cluster, region, constraints) {
+		if store := r.cluster.GetStore(peer.GetStoreId()); store != nil {
+			stores = append(stores, store)
+		}
+	}
+	return stores
+}
+
 func (r *ReplicaChecker) checkBestReplacement(region *core.RegionInfo) *Operator {
 	if !r.cluster.IsLocationReplacementEnabled() {
 		return nil
@@ -233,7 +528,7 @@ func (r *ReplicaChecker) checkBestReplacement(region *core.RegionInfo) *Operator
 		checkerCounter.WithLabelValues("replica_checker", "all_right").Inc()
 		return nil
 	}
-	storeID, newScore := r.SelectBestReplacementStore(region, oldPeer, NewStorageThresholdFilter())
+	storeID, newScore := r.SelectBestReplacementStore(region, oldPeer, NewStorageThresholdFilter(r.name))
 	if storeID == 0 {
 		checkerCounter.WithLabelValues("replica_checker", "no_replacement_store").Inc()
 		return nil
@@ -251,3 +546,177 @@ func (r *ReplicaChecker) checkBestReplacement(region *core.RegionInfo) *Operator
 	checkerCounter.WithLabelValues("replica_checker", "new_operator").Inc()
 	return CreateMovePeerOperator("moveToBetterLocation", r.cluster, region, OpReplica, oldPeer.GetStoreId(), newPeer.GetStoreId(), newPeer.GetId())
 }
+
+// Explain evaluates the replicas of the region in the same order as Check,
+// without creating any operator.
+func (r *ReplicaChecker) Explain(region *core.RegionInfo) *Explanation {
+	r = r.silent()
+	e := &Explanation{Scheduler: r.name, RegionID: region.GetId()}
+	labels := r.cluster.GetLocationLabels()
+	regionStores := r.cluster.GetRegionStores(region)
+	for _, store := range regionStores {
+		e.Sources = append(e.Sources, &StoreExplanation{
+			StoreID: store.GetId(),
+			Filter:  FilterSourceType(r.cluster, store, r.filters),
+			Score:   DistinctScore(labels, regionStores, store),
+		})
+	}
+	SortStoreExplanations(e.Sources)
+
+	if peer := r.selectDownPeer(region); peer != nil {
+		e.SourceStoreID = peer.GetStoreId()
+		e.Result = fmt.Sprintf("remove the down replica on store %d", peer.GetStoreId())
+		return e
+	}
+
+	if r.cluster.IsPlacementRulesEnabled() {
+		e.Result = r.explainRules(region)
+		return e
+	}
+
+	if peer := r.selectOfflinePeer(region); peer != nil {
+		e.SourceStoreID = peer.GetStoreId()
+		if r.isPermanentLearner(region, peer) {
+			e.Result = r.explainOfflineLearner(region, peer)
+			return e
+		}
+		if r.getReplicaCount(region) > r.cluster.GetMaxReplicas() {
+			e.Result = fmt.Sprintf("remove the extra replica on offline store %d", peer.GetStoreId())
+			return e
+		}
+		if region.GetPendingPeer(peer.GetId()) != nil {
+			e.Result = fmt.Sprintf("remove the pending replica on offline store %d", peer.GetStoreId())
+			return e
+		}
+		e.Targets, e.TargetStoreID, _ = r.ExplainReplacement(region, peer, NewStorageThresholdFilter(r.name))
+		if e.TargetStoreID == 0 {
+			e.Result = fmt.Sprintf("no store to replace the replica on offline store %d", peer.GetStoreId())
+		} else {
+			e.Result = fmt.Sprintf("replace the replica on offline store %d with store %d", peer.GetStoreId(), e.TargetStoreID)
+		}
+		return e
+	}
+
+	if r.getReplicaCount(region) < r.cluster.GetMaxReplicas() && r.cluster.IsMakeUpReplicaEnabled() {
+		e.Targets, e.TargetStoreID, _ = r.explainTargets(region, NewStorageThresholdFilter(r.name))
+		if e.TargetStoreID == 0 {
+			e.Result = fmt.Sprintf("no store to make up the replica, %d of %d replicas", r.getReplicaCount(region), r.cluster.GetMaxReplicas())
+		} else {
+			e.Result = fmt.Sprintf("make up the replica on store %d", e.TargetStoreID)
+		}
+		return e
+	}
+
+	if len(region.GetVoters()) > r.cluster.GetMaxReplicas() && r.cluster.IsRemoveExtraReplicaEnabled() {
+		oldPeer := r.selectConstraintViolatedPeer(region)
+		if oldPeer == nil {
+			oldPeer, _ = r.selectWorstPeer(region)
+		}
+		if oldPeer == nil {
+			e.Result = "no replica can be removed as the extra replica"
+		} else {
+			e.SourceStoreID = oldPeer.GetStoreId()
+			e.Result = fmt.Sprintf("remove the extra replica on store %d", oldPeer.GetStoreId())
+		}
+		return e
+	}
+
+	count, constraints := GetLearnerReplicas(r.cluster, r.classifier, region)
+	learners := GetPermanentLearners(r.cluster, region, constraints)
+	if len(learners) < count && r.cluster.IsMakeUpReplicaEnabled() && r.cluster.IsRaftLearnerEnabled() {
+		e.TargetStoreID, _ = r.selectBestStoreToAddLearner(region, NewStorageThresholdFilter(r.name))
+		if e.TargetStoreID == 0 {
+			e.Result = fmt.Sprintf("no store to make up the learner, %d of %d learners", len(learners), count)
+		} else {
+			e.Result = fmt.Sprintf("make up the learner on store %d", e.TargetStoreID)
+		}
+		return e
+	}
+	if len(learners) > count && r.cluster.IsRemoveExtraReplicaEnabled() {
+		e.SourceStoreID = r.selectWorstLearnerStore(region)
+		if e.SourceStoreID == 0 {
+			e.Result = "no learner can be removed as the extra learner"
+		} else {
+			e.Result = fmt.Sprintf("remove the extra learner on store %d", e.SourceStoreID)
+		}
+		return e
+	}
+
+	if peer := r.selectConstraintViolatedPeer(region); peer != nil {
+		e.SourceStoreID = peer.GetStoreId()
+		e.Targets, e.TargetStoreID, _ = r.ExplainReplacement(region, peer, NewStorageThresholdFilter(r.name))
+		if e.TargetStoreID == 0 {
+			e.Result = fmt.Sprintf("no store matching the range constraints to replace the replica on store %d", peer.GetStoreId())
+		} else {
+			e.Result = fmt.Sprintf("move the replica from store %d to store %d for the range constraints", peer.GetStoreId(), e.TargetStoreID)
+		}
+		return e
+	}
+
+	if !r.cluster.IsLocationReplacementEnabled() {
+		e.Result = "location replacement is disabled"
+		return e
+	}
+	oldPeer, oldScore := r.selectWorstPeer(region)
+	if oldPeer == nil {
+		e.Result = "no replica can be replaced"
+		return e
+	}
+	e.SourceStoreID = oldPeer.GetStoreId()
+	var newScore float64
+	e.Targets, e.TargetStoreID, newScore = r.ExplainReplacement(region, oldPeer, NewStorageThresholdFilter(r.name))
+	switch {
+	case e.TargetStoreID == 0:
+		e.Result = fmt.Sprintf("no store to replace the replica on store %d", oldPeer.GetStoreId())
+	case newScore <= oldScore:
+		e.Result = fmt.Sprintf("distinct score %v of store %d is not better than %v of store %d",
+			newScore, e.TargetStoreID, oldScore, oldPeer.GetStoreId())
+	default:
+		e.Result = fmt.Sprintf("move the replica from store %d to store %d for better location", oldPeer.GetStoreId(), e.TargetStoreID)
+	}
+	return e
+}
+
+// explainOfflineLearner explains how the read-only learner on the offline
+// store is replaced.
+func (r *ReplicaChecker) explainOfflineLearner(region *core.RegionInfo, peer *metapb.Peer) string {
+	if region.GetPendingPeer(peer.GetId()) != nil {
+		return fmt.Sprintf("remove the pending learner on offline store %d", peer.GetStoreId())
+	}
+	storeID, _ := r.SelectBestLearnerReplacementStore(region, peer, NewStorageThresholdFilter(r.name))
+	if storeID == 0 {
+		return fmt.Sprintf("no store to replace the learner on offline store %d", peer.GetStoreId())
+	}
+	return fmt.Sprintf("replace the learner on offline store %d with store %d", peer.GetStoreId(), storeID)
+}
+
+// ExplainReplacement explains the stores to replace the old peer like
+// SelectBestReplacementStore, it returns the store explanations, the selected
+// store and its distinct score.
+func (r *ReplicaChecker) ExplainReplacement(region *core.RegionInfo, oldPeer *metapb.Peer, filters ...Filter) ([]*StoreExplanation, uint64, float64) {
+	r = r.silent()
+	filters = append(filters, NewExcludedFilter(r.name, nil, region.GetStoreIds()))
+	newRegion := region.Clone()
+	newRegion.RemoveStorePeer(oldPeer.GetStoreId())
+	return r.explainTargets(newRegion, filters...)
+}
+
+// explainTargets explains the stores to add a replica, it returns the store
+// explanations, the selected store and its distinct score.
+func (r *ReplicaChecker) explainTargets(region *core.RegionInfo, filters ...Filter) ([]*StoreExplanation, uint64, float64) {
+	storeID, score := r.selectBestStoreToAddReplica(region, filters...)
+	filters = r.addReplicaFilters(region, filters...)
+	labels := r.cluster.GetLocationLabels()
+	regionStores := r.getReplicaStores(region)
+	stores := r.cluster.GetStores()
+	res := make([]*StoreExplanation, 0, len(stores))
+	for _, store := range stores {
+		res = append(res, &StoreExplanation{
+			StoreID: store.GetId(),
+			Filter:  FilterTargetType(r.cluster, store, filters),
+			Score:   DistinctScore(labels, regionStores, store),
+		})
+	}
+	SortStoreExplanations(res)
+	return res, storeID, score
+}
@@ -0,0 +1,341 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/schedule/placement"
+	log "github.com/sirupsen/logrus"
+)
+
+// GetRuleReplicas returns the total count of the placement rules applied to
+// the region, it returns 0 if the placement rules are disabled or no rule is
+// applied to the region.
+func GetRuleReplicas(cluster Cluster, region *core.RegionInfo) int {
+	if !cluster.IsPlacementRulesEnabled() {
+		return 0
+	}
+	var count int
+	for _, rule := range cluster.GetRuleManager().GetRulesForRegion(region) {
+		count += rule.Count
+	}
+	return count
+}
+
+// GetExpectedReplicas returns the expected number of peers of the region,
+// which is derived from the placement rules if they are enabled.
+func GetExpectedReplicas(cluster Cluster, region *core.RegionInfo) int {
+	if count := GetRuleReplicas(cluster, region); count > 0 {
+		return count
+	}
+	return cluster.GetMaxReplicas()
+}
+
+// checkRules fixes the region against the placement rules applied to it. The
+// rules are fixed one by one, then the orphan peers are removed, the leader
+// is transferred to the leader rule and at last the peers are moved to
+// better locations.
+func (r *ReplicaChecker) checkRules(region *core.RegionInfo) *Operator {
+	fit := r.fitRegion(region)
+	if fit == nil {
+		checkerCounter.WithLabelValues("replica_checker", "no_rule").Inc()
+		return nil
+	}
+	for _, rf := range fit.RuleFits {
+		if op := r.fixRulePeers(region, rf); op != nil {
+			return op
+		}
+	}
+	if op := r.fixOrphanPeers(region, fit); op != nil {
+		return op
+	}
+	for _, rf := range fit.RuleFits {
+		if op := r.fixRuleConstraint(region, rf); op != nil {
+			return op
+		}
+	}
+	for _, rf := range fit.RuleFits {
+		if op := r.fixRuleLeader(region, rf); op != nil {
+			return op
+		}
+	}
+	if r.cluster.IsLocationReplacementEnabled() {
+		for _, rf := range fit.RuleFits {
+			if op := r.fixRuleLocation(region, rf); op != nil {
+				return op
+			}
+		}
+	}
+	checkerCounter.WithLabelValues("replica_checker", "all_right").Inc()
+	return nil
+}
+
+// fitRegion assigns the peers of the region to its rules, it returns nil if
+// no rule is applied to the region. The read-only learners are left out as
+// they are placed by the learner replicas setting instead of the rules.
+func (r *ReplicaChecker) fitRegion(region *core.RegionInfo) *placement.RegionFit {
+	rules := r.cluster.GetRuleManager().GetRulesForRegion(region)
+	if len(rules) == 0 {
+		return nil
+	}
+	var learners []*metapb.Peer
+	for _, peer := range region.GetLearners() {
+		if r.isPermanentLearner(region, peer) {
+			learners = append(learners, peer)
+		}
+	}
+	if len(learners) > 0 {
+		region = region.Clone()
+		for _, peer := range learners {
+			region.RemoveStorePeer(peer.GetStoreId())
+		}
+	}
+	return placement.FitRegion(r.cluster.GetRegionStores(region), region, rules)
+}
+
+// fixRulePeers replaces the peers on the stores which are not up, promotes
+// the learners of a voter rule and adds the missing peers of the rule.
+func (r *ReplicaChecker) fixRulePeers(region *core.RegionInfo, rf *placement.RuleFit) *Operator {
+	if r.cluster.IsReplaceOfflineReplicaEnabled() {
+		for _, peer := range rf.Peers {
+			if op := r.replaceOfflineRulePeer(region, rf, peer); op != nil {
+				op.SetPriorityLevel(core.HighPriority)
+				return op
+			}
+		}
+	}
+
+	if len(rf.PeersWithDifferentRole) != 0 {
+		peer := rf.PeersWithDifferentRole[0]
+		checkerCounter.WithLabelValues("replica_checker", "new_operator").Inc()
+		return NewOperator("promoteRuleLearner", region.GetId(), region.GetRegionEpoch(), OpReplica|OpRegion,
+			PromoteLearner{ToStore: peer.GetStoreId(), PeerID: peer.GetId()})
+	}
+
+	if rf.MissingCount() > 0 && r.cluster.IsMakeUpReplicaEnabled() {
+		log.Debugf("[region %d] rule %s misses %d peers", region.GetId(), rf.Rule.ID, rf.MissingCount())
+		storeID, _ := r.selectRuleStore(region, rf, nil)
+		if storeID == 0 {
+			checkerCounter.WithLabelValues("replica_checker", "no_target_store").Inc()
+			return nil
+		}
+		newPeer, err := r.cluster.AllocPeer(storeID)
+		if err != nil {
+			return nil
+		}
+		steps := r.addRulePeerSteps(rf.Rule, newPeer)
+		if steps == nil {
+			return nil
+		}
+		checkerCounter.WithLabelValues("replica_checker", "new_operator").Inc()
+		op := NewOperator("addRulePeer", region.GetId(), region.GetRegionEpoch(), OpReplica|OpRegion, steps...)
+		op.SetPriorityLevel(core.HighPriority)
+		return op
+	}
+	return nil
+}
+
+func (r *ReplicaChecker) replaceOfflineRulePeer(region *core.RegionInfo, rf *placement.RuleFit, peer *metapb.Peer) *Operator {
+	store := r.cluster.GetStore(peer.GetStoreId())
+	if store == nil || store.IsUp() {
+		return nil
+	}
+	// Like checkOfflinePeer, a pending peer on the offline store is removed
+	// directly, otherwise the new peer can not be added.
+	if region.GetPendingPeer(peer.GetId()) != nil {
+		checkerCounter.WithLabelValues("replica_checker", "new_operator").Inc()
+		return CreateRemovePeerOperator("removePendingOfflineRulePeer", r.cluster, OpReplica, region, peer.GetStoreId())
+	}
+	storeID, _ := r.selectRuleStore(region, rf, peer)
+	if storeID == 0 {
+		checkerCounter.WithLabelValues("replica_checker", "no_replacement_store").Inc()
+		return nil
+	}
+	return r.moveRulePeer("replaceOfflineRulePeer", region, rf.Rule, peer, storeID)
+}
+
+// fixOrphanPeers removes a peer which is not assigned to any rule. It is
+// done only if all the rules are satisfied, as the orphan peers still keep
+// the data available.
+func (r *ReplicaChecker) fixOrphanPeers(region *core.RegionInfo, fit *placement.RegionFit) *Operator {
+	if len(fit.OrphanPeers) == 0 || !fit.IsRulesSatisfied() || !r.cluster.IsRemoveExtraReplicaEnabled() {
+		return nil
+	}
+	peer := fit.OrphanPeers[0]
+	log.Debugf("[region %d] peer %d on store %d does not fit any rule", region.GetId(), peer.GetId(), peer.GetStoreId())
+	checkerCounter.WithLabelValues("replica_checker", "new_operator").Inc()
+	return CreateRemovePeerOperator("removeOrphanPeer", r.cluster, OpReplica, region, peer.GetStoreId())
+}
+
+// fixRuleConstraint moves the peer of the rule on the store which does not
+// match the range constraints of the region, like checkRangeConstraint.
+func (r *ReplicaChecker) fixRuleConstraint(region *core.RegionInfo, rf *placement.RuleFit) *Operator {
+	filter := NewRangeConstraintFilter(r.name, r.cluster, region)
+	for _, peer := range rf.Peers {
+		store := r.cluster.GetStore(peer.GetStoreId())
+		if store == nil || !filter.FilterTarget(r.cluster, store) {
+			continue
+		}
+		storeID, _ := r.selectRuleStore(region, rf, peer)
+		if storeID == 0 {
+			log.Debugf("[region %d] no store for rule %s matches the range constraints", region.GetId(), rf.Rule.ID)
+			checkerCounter.WithLabelValues("replica_checker", "no_constrained_store").Inc()
+			return nil
+		}
+		return r.moveRulePeer("moveRulePeerToConstrainedStore", region, rf.Rule, peer, storeID)
+	}
+	return nil
+}
+
+// fixRuleLeader transfers the leader to a peer of the leader rule.
+func (r *ReplicaChecker) fixRuleLeader(region *core.RegionInfo, rf *placement.RuleFit) *Operator {
+	if rf.Rule.Role != placement.Leader || region.Leader == nil {
+		return nil
+	}
+	for _, peer := range rf.Peers {
+		if peer.GetStoreId() == region.Leader.GetStoreId() {
+			return nil
+		}
+	}
+	for _, peer := range rf.Peers {
+		store := r.cluster.GetStore(peer.GetStoreId())
+		if store == nil || !store.IsUp() || region.GetDownPeer(peer.GetId()) != nil || region.GetPendingPeer(peer.GetId()) != nil {
+			continue
+		}
+		checkerCounter.WithLabelValues("replica_checker", "new_operator").Inc()
+		return NewOperator("transferLeaderByRule", region.GetId(), region.GetRegionEpoch(), OpLeader,
+			TransferLeader{FromStore: region.Leader.GetStoreId(), ToStore: peer.GetStoreId()})
+	}
+	return nil
+}
+
+// fixRuleLocation moves the worst peer of the rule to a store with better
+// location, like checkBestReplacement with the location labels of the rule.
+func (r *ReplicaChecker) fixRuleLocation(region *core.RegionInfo, rf *placement.RuleFit) *Operator {
+	labels := rf.Rule.LocationLabels
+	if len(labels) == 0 || len(rf.Peers) < 2 {
+		return nil
+	}
+	ruleStores := r.getRuleStores(rf, nil)
+	selector := r.newReplicaSelector(ruleStores, labels, r.filters...)
+	worstStore := selector.SelectSource(r.cluster, ruleStores)
+	if worstStore == nil {
+		return nil
+	}
+	oldPeer := region.GetStorePeer(worstStore.GetId())
+	oldScore := DistinctScore(labels, ruleStores, worstStore)
+	storeID, newScore := r.selectRuleStore(region, rf, oldPeer)
+	if storeID == 0 || newScore <= oldScore {
+		return nil
+	}
+	return r.moveRulePeer("moveRulePeerToBetterLocation", region, rf.Rule, oldPeer, storeID)
+}
+
+// selectRuleStore returns the best store to place a peer of the rule and its
+// distinct score. If the old peer is not nil, it is going to be replaced and
+// is not considered to be a peer of the rule.
+func (r *ReplicaChecker) selectRuleStore(region *core.RegionInfo, rf *placement.RuleFit, oldPeer *metapb.Peer) (uint64, float64) {
+	ruleStores := r.getRuleStores(rf, oldPeer)
+	filters := []Filter{
+		NewStorageThresholdFilter(r.name),
+		NewLabelConstraintFilter(r.name, rf.Rule.LabelConstraints),
+	}
+	if rf.Rule.IsolationLevel != "" {
+		filters = append(filters, NewIsolationFilter(r.name, rf.Rule.IsolationLevel, rf.Rule.LocationLabels, ruleStores))
+	}
+	filters = r.addReplicaFilters(region, filters...)
+	selector := r.newReplicaSelector(ruleStores, rf.Rule.LocationLabels, r.filters...)
+	target := selector.SelectTarget(r.cluster, r.cluster.GetStores(), filters...)
+	if target == nil {
+		log.Debugf("[region %d] no store for rule %s", region.GetId(), rf.Rule.ID)
+		return 0, 0
+	}
+	return target.GetId(), DistinctScore(rf.Rule.LocationLabels, ruleStores, target)
+}
+
+// getRuleStores returns the stores of the peers assigned to the rule except
+// the excluded peer.
+func (r *ReplicaChecker) getRuleStores(rf *placement.RuleFit, excluded *metapb.Peer) []*core.StoreInfo {
+	var stores []*core.StoreInfo
+	for _, peers := range [][]*metapb.Peer{rf.Peers, rf.PeersWithDifferentRole} {
+		for _, peer := range peers {
+			if excluded != nil && peer.GetId() == excluded.GetId() {
+				continue
+			}
+			if store := r.cluster.GetStore(peer.GetStoreId()); store != nil {
+				stores = append(stores, store)
+			}
+		}
+	}
+	return stores
+}
+
+// addRulePeerSteps returns the steps to add a peer in the role of the rule,
+// it returns nil if the learner can not be added.
+func (r *ReplicaChecker) addRulePeerSteps(rule *placement.Rule, peer *metapb.Peer) []OperatorStep {
+	if !rule.Role.IsVoter() {
+		if !r.cluster.IsRaftLearnerEnabled() {
+			log.Debugf("raft learner is disabled, can not add learner for rule %s", rule.ID)
+			return nil
+		}
+		return []OperatorStep{AddLearner{ToStore: peer.GetStoreId(), PeerID: peer.GetId()}}
+	}
+	if r.cluster.IsRaftLearnerEnabled() {
+		return []OperatorStep{
+			AddLearner{ToStore: peer.GetStoreId(), PeerID: peer.GetId()},
+			PromoteLearner{ToStore: peer.GetStoreId(), PeerID: peer.GetId()},
+		}
+	}
+	return []OperatorStep{AddPeer{ToStore: peer.GetStoreId(), PeerID: peer.GetId()}}
+}
+
+// moveRulePeer creates an operator to move the old peer to the store in the
+// role of the rule.
+func (r *ReplicaChecker) moveRulePeer(desc string, region *core.RegionInfo, rule *placement.Rule, oldPeer *metapb.Peer, storeID uint64) *Operator {
+	newPeer, err := r.cluster.AllocPeer(storeID)
+	if err != nil {
+		return nil
+	}
+	steps := r.addRulePeerSteps(rule, newPeer)
+	if steps == nil {
+		return nil
+	}
+	kind, removeSteps := removePeerSteps(r.cluster, region, oldPeer.GetStoreId())
+	checkerCounter.WithLabelValues("replica_checker", "new_operator").Inc()
+	return NewOperator(desc, region.GetId(), region.GetRegionEpoch(), kind|OpReplica|OpRegion, append(steps, removeSteps...)...)
+}
+
+// explainRules describes how the peers of the region fit its rules.
+func (r *ReplicaChecker) explainRules(region *core.RegionInfo) string {
+	fit := r.fitRegion(region)
+	if fit == nil {
+		return "no placement rule is applied to the region"
+	}
+	res := make([]string, 0, len(fit.RuleFits)+1)
+	for _, rf := range fit.RuleFits {
+		s := fmt.Sprintf("rule %s has %d of %d %s peers", rf.Rule.ID, len(rf.Peers), rf.Rule.Count, rf.Rule.Role)
+		if len(rf.PeersWithDifferentRole) != 0 {
+			s += fmt.Sprintf(" and %d learners to promote", len(rf.PeersWithDifferentRole))
+		}
+		res = append(res, s)
+	}
+	if len(fit.OrphanPeers) != 0 {
+		res = append(res, fmt.Sprintf("%d peers do not fit any rule", len(fit.OrphanPeers)))
+	}
+	return strings.Join(res, ", ")
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/juju/errors"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/schedule/placement"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -27,6 +28,7 @@ import (
 type Cluster interface {
 	RandFollowerRegion(storeID uint64, opts ...core.RegionOption) *core.RegionInfo
 	RandLeaderRegion(storeID uint64, opts ...core.RegionOption) *core.RegionInfo
+	RandLearnerRegion(storeID uint64, opts ...core.RegionOption) *core.RegionInfo
 	GetAverageRegionSize() int64
 
 	GetStores() []*core.StoreInfo
@@ -40,12 +42,20 @@ type Cluster interface {
 
 	BlockStore(id uint64) error
 	UnblockStore(id uint64)
+	BlockStoreLeader(id uint64) error
+	UnblockStoreLeader(id uint64)
+	SetLeaderPreference(preference LeaderPreference)
+	GetLeaderPreference() LeaderPreference
 
 	IsRegionHot(id uint64) bool
+	GetHotRegionStat(id uint64, kind FlowKind) *core.RegionStat
 	RegionWriteStats() []*core.RegionStat
 	RegionReadStats() []*core.RegionStat
 	RandHotRegionFromStore(store uint64, kind FlowKind) *core.RegionInfo
 
+	GetRuleManager() *placement.RuleManager
+	GetRangeConstraintManager() *placement.RangeConstraintManager
+
 	// get config methods
 	GetOpt() NamespaceOptions
 	Options
@@ -68,6 +78,18 @@ type Scheduler interface {
 	IsScheduleAllowed(cluster Cluster) bool
 }
 
+// ConfigurableScheduler is a scheduler whose parameters can be read and
+// updated at runtime without recreating it.
+type ConfigurableScheduler interface {
+	Scheduler
+	// GetConfig returns a copy of the current config, it should be able to be
+	// encoded as JSON.
+	GetConfig() interface{}
+	// SetConfig updates the config with the JSON encoded data, the fields
+	// absent in data keep their current values.
+	SetConfig(cluster Cluster, data []byte) error
+}
+
 // CreateSchedulerFunc is for creating scheudler.
 type CreateSchedulerFunc func(limiter *Limiter, args []string) (Scheduler, error)
 
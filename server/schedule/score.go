@@ -0,0 +1,30 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import "github.com/pingcap/pd/server/core"
+
+// ScoreStrategy returns the strategy to score the stores for the resource kind.
+func ScoreStrategy(opt Options, kind core.ResourceKind) string {
+	if kind == core.LeaderKind {
+		return opt.GetLeaderScoreStrategy()
+	}
+	return opt.GetRegionScoreStrategy()
+}
+
+// StoreScore returns the resource score of the store by the configured
+// strategy, with the delta of the resource size or count applied.
+func StoreScore(opt Options, store *core.StoreInfo, kind core.ResourceKind, delta int64) float64 {
+	return store.ResourceScore(kind, ScoreStrategy(opt, kind), opt.GetHighSpaceRatio(), opt.GetLowSpaceRatio(), delta)
+}
@@ -49,8 +49,8 @@ func (s *balanceSelector) SelectSource(opt Options, stores []*core.StoreInfo, fi
 			continue
 		}
 		if result == nil ||
-			result.ResourceScore(s.kind, opt.GetHighSpaceRatio(), opt.GetLowSpaceRatio(), 0) <
-				store.ResourceScore(s.kind, opt.GetHighSpaceRatio(), opt.GetLowSpaceRatio(), 0) {
+			StoreScore(opt, result, s.kind, 0) <
+				StoreScore(opt, store, s.kind, 0) {
 			result = store
 		}
 	}
@@ -66,8 +66,8 @@ func (s *balanceSelector) SelectTarget(opt Options, stores []*core.StoreInfo, fi
 			continue
 		}
 		if result == nil ||
-			result.ResourceScore(s.kind, opt.GetHighSpaceRatio(), opt.GetLowSpaceRatio(), 0) >
-				store.ResourceScore(s.kind, opt.GetHighSpaceRatio(), opt.GetLowSpaceRatio(), 0) {
+			StoreScore(opt, result, s.kind, 0) >
+				StoreScore(opt, store, s.kind, 0) {
 			result = store
 		}
 	}
@@ -82,6 +82,8 @@ type replicaSelector struct {
 	regionStores []*core.StoreInfo
 	labels       []string
 	filters      []Filter
+	// silent is true if the selector doesn't update the filter metrics.
+	silent bool
 }
 
 // NewReplicaSelector creates a Selector that select source/target store by their
@@ -94,13 +96,38 @@ func NewReplicaSelector(regionStores []*core.StoreInfo, labels []string, filters
 	}
 }
 
+// newSilentReplicaSelector creates a replica selector which doesn't update
+// the filter metrics, it is used to explain the selection.
+func newSilentReplicaSelector(regionStores []*core.StoreInfo, labels []string, filters ...Filter) Selector {
+	return &replicaSelector{
+		regionStores: regionStores,
+		labels:       labels,
+		filters:      filters,
+		silent:       true,
+	}
+}
+
+func (s *replicaSelector) filterSource(opt Options, store *core.StoreInfo, filters []Filter) bool {
+	if s.silent {
+		return FilterSourceType(opt, store, filters) != ""
+	}
+	return FilterSource(opt, store, filters)
+}
+
+func (s *replicaSelector) filterTarget(opt Options, store *core.StoreInfo, filters []Filter) bool {
+	if s.silent {
+		return FilterTargetType(opt, store, filters) != ""
+	}
+	return FilterTarget(opt, store, filters)
+}
+
 func (s *replicaSelector) SelectSource(opt Options, stores []*core.StoreInfo, filters ...Filter) *core.StoreInfo {
 	var (
 		best      *core.StoreInfo
 		bestScore float64
 	)
 	for _, store := range stores {
-		if FilterSource(opt, store, filters) {
+		if s.filterSource(opt, store, filters) {
 			continue
 		}
 		score := DistinctScore(s.labels, s.regionStores, store)
@@ -108,7 +135,7 @@ func (s *replicaSelector) SelectSource(opt Options, stores []*core.StoreInfo, fi
 			best, bestScore = store, score
 		}
 	}
-	if best == nil || FilterSource(opt, best, s.filters) {
+	if best == nil || s.filterSource(opt, best, s.filters) {
 		return nil
 	}
 	return best
@@ -120,7 +147,7 @@ func (s *replicaSelector) SelectTarget(opt Options, stores []*core.StoreInfo, fi
 		bestScore float64
 	)
 	for _, store := range stores {
-		if FilterTarget(opt, store, filters) {
+		if s.filterTarget(opt, store, filters) {
 			continue
 		}
 		score := DistinctScore(s.labels, s.regionStores, store)
@@ -128,7 +155,7 @@ func (s *replicaSelector) SelectTarget(opt Options, stores []*core.StoreInfo, fi
 			best, bestScore = store, score
 		}
 	}
-	if best == nil || FilterTarget(opt, best, s.filters) {
+	if best == nil || s.filterTarget(opt, best, s.filters) {
 		return nil
 	}
 	return best
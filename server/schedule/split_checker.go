@@ -0,0 +1,89 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/pingcap/pd/server/cache"
+	"github.com/pingcap/pd/server/core"
+	log "github.com/sirupsen/logrus"
+)
+
+// SplitChecker splits the regions which stay hot for several report
+// intervals, so that the halves can be moved apart by the hot region
+// scheduler.
+type SplitChecker struct {
+	cluster Cluster
+	// splitCache records the regions recently split by load.
+	splitCache *cache.TTLUint64
+}
+
+// NewSplitChecker creates a split checker.
+func NewSplitChecker(cluster Cluster) *SplitChecker {
+	return &SplitChecker{
+		cluster:    cluster,
+		splitCache: cache.NewIDTTL(time.Minute, cluster.GetLoadSplitCooldown()),
+	}
+}
+
+// Check verifies whether a region should be split by load, creating an
+// Operator if need.
+func (s *SplitChecker) Check(region *core.RegionInfo) *Operator {
+	degree := s.cluster.GetLoadSplitHotDegree()
+	if degree <= 0 {
+		return nil
+	}
+	checkerCounter.WithLabelValues("split_checker", "check").Inc()
+
+	if s.splitCache.Exists(region.GetId()) {
+		checkerCounter.WithLabelValues("split_checker", "recently_split").Inc()
+		return nil
+	}
+
+	// skip region has down peers or pending peers
+	if len(region.DownPeers) > 0 || len(region.PendingPeers) > 0 {
+		checkerCounter.WithLabelValues("split_checker", "special_peer").Inc()
+		return nil
+	}
+
+	if !s.isHot(region, degree) {
+		checkerCounter.WithLabelValues("split_checker", "no_need").Inc()
+		return nil
+	}
+
+	checkerCounter.WithLabelValues("split_checker", "new_operator").Inc()
+	log.Debugf("try to split hot region {%v}", region)
+	s.splitCache.PutWithTTL(region.GetId(), nil, s.cluster.GetLoadSplitCooldown())
+	step := SplitRegion{
+		StartKey: region.StartKey,
+		EndKey:   region.EndKey,
+		Policy:   pdpb.CheckPolicy_SCAN,
+	}
+	return NewOperator("split-hot-region", region.GetId(), region.GetRegionEpoch(), OpSplit, step)
+}
+
+// isHot returns true if the read or write flow of the region has been above
+// the threshold for the given number of report intervals.
+func (s *SplitChecker) isHot(region *core.RegionInfo, degree int) bool {
+	minFlowBytes := s.cluster.GetLoadSplitMinFlowBytes()
+	for _, kind := range []FlowKind{WriteFlow, ReadFlow} {
+		stat := s.cluster.GetHotRegionStat(region.GetId(), kind)
+		if stat != nil && stat.HotDegree >= degree && stat.FlowBytes >= minFlowBytes {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/pingcap/pd/server/core"
+)
+
+var _ = Suite(&testSplitCheckerSuite{})
+
+type testSplitCheckerSuite struct{}
+
+func (s *testSplitCheckerSuite) TestSplitHotRegion(c *C) {
+	opt := NewMockSchedulerOptions()
+	opt.LoadSplitCooldown = time.Millisecond
+	tc := NewMockCluster(opt)
+	tc.AddRegionStore(1, 1)
+	tc.AddRegionStore(2, 1)
+	tc.AddRegionStore(3, 1)
+	tc.AddLeaderRegionWithRange(1, "a", "c", 1, 2, 3)
+	region := tc.GetRegion(1)
+	sc := NewSplitChecker(tc)
+
+	// Load-based split is disabled by default.
+	tc.HotCache.Update([]*core.RegionStat{{RegionID: 1, StoreID: 1, IsLeader: true, LastUpdateTime: time.Now(), FlowBytes: 2 * 1024 * 1024, HotDegree: 5}}, WriteFlow)
+	c.Assert(sc.Check(region), IsNil)
+
+	// The region should stay hot for enough intervals.
+	opt.LoadSplitHotDegree = 3
+	tc.HotCache.Update([]*core.RegionStat{{RegionID: 1, StoreID: 1, IsLeader: true, LastUpdateTime: time.Now(), FlowBytes: 2 * 1024 * 1024, HotDegree: 2}}, WriteFlow)
+	c.Assert(sc.Check(region), IsNil)
+	tc.HotCache.Update([]*core.RegionStat{{RegionID: 1, StoreID: 1, IsLeader: true, LastUpdateTime: time.Now(), FlowBytes: 2 * 1024 * 1024, HotDegree: 3}}, WriteFlow)
+	op := sc.Check(region)
+	c.Assert(op, NotNil)
+	c.Assert(op.Kind(), Equals, OpSplit)
+	c.Assert(op.Len(), Equals, 1)
+	step := op.Step(0).(SplitRegion)
+	c.Assert(step.StartKey, DeepEquals, []byte("a"))
+	c.Assert(step.EndKey, DeepEquals, []byte("c"))
+	c.Assert(step.Policy, Equals, pdpb.CheckPolicy_SCAN)
+
+	// The region is not split again before the cooldown.
+	opt.LoadSplitCooldown = time.Hour
+	tc.AddLeaderRegionWithRange(2, "c", "e", 1, 2, 3)
+	tc.HotCache.Update([]*core.RegionStat{{RegionID: 2, StoreID: 1, IsLeader: true, LastUpdateTime: time.Now(), FlowBytes: 2 * 1024 * 1024, HotDegree: 3}}, ReadFlow)
+	c.Assert(sc.Check(tc.GetRegion(2)), NotNil)
+	c.Assert(sc.Check(tc.GetRegion(2)), IsNil)
+
+	// The flow should be above the threshold.
+	time.Sleep(5 * time.Millisecond)
+	opt.LoadSplitMinFlowBytes = 4 * 1024 * 1024
+	c.Assert(sc.Check(region), IsNil)
+	opt.LoadSplitMinFlowBytes = 1024 * 1024
+	c.Assert(sc.Check(region), NotNil)
+}
@@ -83,22 +83,22 @@ func (a *adjacentState) len() int {
 // newBalanceAdjacentRegionScheduler creates a scheduler that tends to disperse adjacent region
 // on each store.
 func newBalanceAdjacentRegionScheduler(limiter *schedule.Limiter, args ...uint64) schedule.Scheduler {
-	filters := []schedule.Filter{
-		schedule.NewBlockFilter(),
-		schedule.NewStateFilter(),
-		schedule.NewHealthFilter(),
-		schedule.NewSnapshotCountFilter(),
-		schedule.NewPendingPeerCountFilter(),
-		schedule.NewRejectLeaderFilter(),
-	}
 	base := newBaseScheduler(limiter)
 	s := &balanceAdjacentRegionScheduler{
 		baseScheduler: base,
-		selector:      schedule.NewRandomSelector(filters),
 		leaderLimit:   defaultAdjacentLeaderLimit,
 		peerLimit:     defaultAdjacentPeerLimit,
 		lastKey:       []byte(""),
 	}
+	filters := []schedule.Filter{
+		schedule.NewBlockFilter(s.GetName()),
+		schedule.NewStateFilter(s.GetName()),
+		schedule.NewHealthFilter(s.GetName()),
+		schedule.NewSnapshotCountFilter(s.GetName()),
+		schedule.NewPendingPeerCountFilter(s.GetName()),
+		schedule.NewRejectLeaderFilter(s.GetName()),
+	}
+	s.selector = schedule.NewRandomSelector(filters)
 	if len(args) == 2 {
 		s.leaderLimit = args[0]
 		s.peerLimit = args[1]
@@ -224,7 +224,7 @@ func (l *balanceAdjacentRegionScheduler) process(cluster schedule.Cluster) []*sc
 }
 
 func (l *balanceAdjacentRegionScheduler) unsafeToBalance(cluster schedule.Cluster, region *core.RegionInfo) bool {
-	if len(region.GetPeers()) != cluster.GetMaxReplicas() {
+	if len(region.GetPeers()) != schedule.GetExpectedReplicas(cluster, region) {
 		return true
 	}
 	store := cluster.GetStore(region.Leader.GetStoreId())
@@ -271,7 +271,7 @@ func (l *balanceAdjacentRegionScheduler) dispersePeer(cluster schedule.Cluster,
 	leaderStoreID := region.Leader.GetStoreId()
 	stores := cluster.GetRegionStores(region)
 	source := cluster.GetStore(leaderStoreID)
-	scoreGuard := schedule.NewDistinctScoreFilter(cluster.GetLocationLabels(), stores, source)
+	scoreGuard := schedule.NewDistinctScoreFilter(l.GetName(), cluster.GetLocationLabels(), stores, source)
 	excludeStores := region.GetStoreIds()
 	for _, storeID := range l.cacheRegions.assignedStoreIds {
 		if _, ok := excludeStores[storeID]; !ok {
@@ -280,7 +280,7 @@ func (l *balanceAdjacentRegionScheduler) dispersePeer(cluster schedule.Cluster,
 	}
 
 	filters := []schedule.Filter{
-		schedule.NewExcludedFilter(nil, excludeStores),
+		schedule.NewExcludedFilter(l.GetName(), nil, excludeStores),
 		scoreGuard,
 	}
 	target := l.selector.SelectTarget(cluster, cluster.GetStores(), filters...)
@@ -14,9 +14,12 @@
 package schedulers
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
+	"sync"
 
+	"github.com/juju/errors"
 	"github.com/pingcap/pd/server/cache"
 	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/server/schedule"
@@ -29,33 +32,52 @@ func init() {
 	})
 }
 
-// balanceLeaderRetryLimit is the limit to retry schedule for selected source store and target store.
-const balanceLeaderRetryLimit = 10
+// defaultBalanceLeaderRetryLimit is the default limit to retry schedule for
+// selected source store and target store.
+const defaultBalanceLeaderRetryLimit = 10
+
+type balanceLeaderSchedulerConfig struct {
+	// RetryLimit is the limit to retry schedule for selected source store and target store.
+	RetryLimit int `json:"retry-limit"`
+}
+
+func (c *balanceLeaderSchedulerConfig) validate() error {
+	if c.RetryLimit <= 0 {
+		return errors.New("retry-limit should be positive")
+	}
+	return nil
+}
 
 type balanceLeaderScheduler struct {
 	*baseScheduler
 	selector    schedule.Selector
 	taintStores *cache.TTLUint64
+
+	confLock sync.RWMutex
+	conf     balanceLeaderSchedulerConfig
 }
 
 // newBalanceLeaderScheduler creates a scheduler that tends to keep leaders on
 // each store balanced.
 func newBalanceLeaderScheduler(limiter *schedule.Limiter) schedule.Scheduler {
 	taintStores := newTaintCache()
-	filters := []schedule.Filter{
-		schedule.NewBlockFilter(),
-		schedule.NewStateFilter(),
-		schedule.NewHealthFilter(),
-		schedule.NewDisconnectFilter(),
-		schedule.NewRejectLeaderFilter(),
-		schedule.NewCacheFilter(taintStores),
-	}
 	base := newBaseScheduler(limiter)
-	return &balanceLeaderScheduler{
+	s := &balanceLeaderScheduler{
 		baseScheduler: base,
-		selector:      schedule.NewBalanceSelector(core.LeaderKind, filters),
 		taintStores:   taintStores,
+		conf:          balanceLeaderSchedulerConfig{RetryLimit: defaultBalanceLeaderRetryLimit},
 	}
+	filters := []schedule.Filter{
+		schedule.NewBlockFilter(s.GetName()),
+		schedule.NewStateFilter(s.GetName()),
+		schedule.NewHealthFilter(s.GetName()),
+		schedule.NewDisconnectFilter(s.GetName()),
+		schedule.NewRejectLeaderFilter(s.GetName()),
+		schedule.NewNoBalanceFilter(s.GetName()),
+		schedule.NewCacheFilter(s.GetName(), taintStores),
+	}
+	s.selector = schedule.NewBalanceSelector(core.LeaderKind, filters)
+	return s
 }
 
 func (l *balanceLeaderScheduler) GetName() string {
@@ -66,6 +88,32 @@ func (l *balanceLeaderScheduler) GetType() string {
 	return "balance-leader"
 }
 
+func (l *balanceLeaderScheduler) GetConfig() interface{} {
+	l.confLock.RLock()
+	defer l.confLock.RUnlock()
+	return l.conf
+}
+
+func (l *balanceLeaderScheduler) SetConfig(cluster schedule.Cluster, data []byte) error {
+	l.confLock.Lock()
+	defer l.confLock.Unlock()
+	conf := l.conf
+	if err := json.Unmarshal(data, &conf); err != nil {
+		return errors.Trace(err)
+	}
+	if err := conf.validate(); err != nil {
+		return errors.Trace(err)
+	}
+	l.conf = conf
+	return nil
+}
+
+func (l *balanceLeaderScheduler) getRetryLimit() int {
+	l.confLock.RLock()
+	defer l.confLock.RUnlock()
+	return l.conf.RetryLimit
+}
+
 func (l *balanceLeaderScheduler) IsScheduleAllowed(cluster schedule.Cluster) bool {
 	return l.limiter.OperatorCount(schedule.OpLeader) < cluster.GetLeaderScheduleLimit()
 }
@@ -97,7 +145,8 @@ func (l *balanceLeaderScheduler) Schedule(cluster schedule.Cluster, opInfluence
 	balanceLeaderCounter.WithLabelValues("high_score", sourceStoreLabel).Inc()
 	balanceLeaderCounter.WithLabelValues("low_score", targetStoreLabel).Inc()
 
-	for i := 0; i < balanceLeaderRetryLimit; i++ {
+	retryLimit := l.getRetryLimit()
+	for i := 0; i < retryLimit; i++ {
 		if op := l.transferLeaderOut(source, cluster, opInfluence); op != nil {
 			balanceLeaderCounter.WithLabelValues("transfer_out", sourceStoreLabel).Inc()
 			return op
@@ -118,13 +167,15 @@ func (l *balanceLeaderScheduler) Schedule(cluster schedule.Cluster, opInfluence
 }
 
 func (l *balanceLeaderScheduler) transferLeaderOut(source *core.StoreInfo, cluster schedule.Cluster, opInfluence schedule.OpInfluence) []*schedule.Operator {
-	region := cluster.RandLeaderRegion(source.GetId(), core.HealthRegion())
+	region := cluster.RandLeaderRegion(source.GetId(), core.HealthRegionAllowLearner())
 	if region == nil {
 		log.Debugf("[%s] store%d has no leader", l.GetName(), source.GetId())
 		schedulerCounter.WithLabelValues(l.GetName(), "no_leader_region").Inc()
 		return nil
 	}
-	target := l.selector.SelectTarget(cluster, cluster.GetFollowerStores(region))
+	// Do not go against the preferred leader scheduler.
+	filter := schedule.NewLeaderPreferenceFilter(l.GetName(), cluster, source)
+	target := l.selector.SelectTarget(cluster, cluster.GetFollowerStores(region), filter)
 	if target == nil {
 		log.Debugf("[%s] region %d has no target store", l.GetName(), region.GetId())
 		schedulerCounter.WithLabelValues(l.GetName(), "no_target_store").Inc()
@@ -134,7 +185,7 @@ func (l *balanceLeaderScheduler) transferLeaderOut(source *core.StoreInfo, clust
 }
 
 func (l *balanceLeaderScheduler) transferLeaderIn(target *core.StoreInfo, cluster schedule.Cluster, opInfluence schedule.OpInfluence) []*schedule.Operator {
-	region := cluster.RandFollowerRegion(target.GetId(), core.HealthRegion())
+	region := cluster.RandFollowerRegion(target.GetId(), core.HealthRegionAllowLearner())
 	if region == nil {
 		log.Debugf("[%s] store%d has no follower", l.GetName(), target.GetId())
 		schedulerCounter.WithLabelValues(l.GetName(), "no_follower_region").Inc()
@@ -146,6 +197,17 @@ func (l *balanceLeaderScheduler) transferLeaderIn(target *core.StoreInfo, cluste
 		schedulerCounter.WithLabelValues(l.GetName(), "no_leader").Inc()
 		return nil
 	}
+	if schedule.FilterSource(cluster, source, []schedule.Filter{schedule.NewNoBalanceFilter(l.GetName())}) {
+		log.Debugf("[%s] store%d is excluded from balancing", l.GetName(), source.GetId())
+		schedulerCounter.WithLabelValues(l.GetName(), "no_balance").Inc()
+		return nil
+	}
+	filter := schedule.NewLeaderPreferenceFilter(l.GetName(), cluster, source)
+	if schedule.FilterTarget(cluster, target, []schedule.Filter{filter}) {
+		log.Debugf("[%s] store%d is less preferred than store%d", l.GetName(), target.GetId(), source.GetId())
+		schedulerCounter.WithLabelValues(l.GetName(), "less_preferred").Inc()
+		return nil
+	}
 	return l.createOperator(region, source, target, cluster, opInfluence)
 }
 
@@ -159,8 +221,8 @@ func (l *balanceLeaderScheduler) createOperator(region *core.RegionInfo, source,
 	if !shouldBalance(cluster, source, target, region, core.LeaderKind, opInfluence) {
 		log.Debugf(`[%s] skip balance region %d, source %d to target %d, source size: %v, source score: %v, source influence: %v,
 			target size: %v, target score: %v, target influence: %v, average region size: %v`, l.GetName(), region.GetId(), source.GetId(), target.GetId(),
-			source.LeaderSize, source.LeaderScore(0), opInfluence.GetStoreInfluence(source.GetId()).ResourceSize(core.LeaderKind),
-			target.LeaderSize, target.LeaderScore(0), opInfluence.GetStoreInfluence(target.GetId()).ResourceSize(core.LeaderKind),
+			source.LeaderSize, schedule.StoreScore(cluster, source, core.LeaderKind, 0), opInfluence.GetStoreInfluence(source.GetId()).ResourceSize(core.LeaderKind),
+			target.LeaderSize, schedule.StoreScore(cluster, target, core.LeaderKind, 0), opInfluence.GetStoreInfluence(target.GetId()).ResourceSize(core.LeaderKind),
 			cluster.GetAverageRegionSize())
 		schedulerCounter.WithLabelValues(l.GetName(), "skip").Inc()
 		return nil
@@ -173,3 +235,43 @@ func (l *balanceLeaderScheduler) createOperator(region *core.RegionInfo, source,
 	op := schedule.NewOperator("balance-leader", region.GetId(), region.GetRegionEpoch(), schedule.OpBalance|schedule.OpLeader, step)
 	return []*schedule.Operator{op}
 }
+
+func (l *balanceLeaderScheduler) Explain(cluster schedule.Cluster, opInfluence schedule.OpInfluence, regionID, storeID uint64) *schedule.Explanation {
+	e := &schedule.Explanation{Scheduler: l.GetName(), RegionID: regionID, StoreID: storeID}
+	filters := l.selector.GetFilters()
+	if regionID == 0 {
+		explainBalanceStores(cluster, e, filters, core.LeaderKind, opInfluence)
+		return e
+	}
+
+	region := cluster.GetRegion(regionID)
+	if region == nil {
+		e.Result = fmt.Sprintf("region %d is not found", regionID)
+		return e
+	}
+	source := cluster.GetStore(region.Leader.GetStoreId())
+	if source == nil {
+		e.Result = fmt.Sprintf("region %d has no leader", regionID)
+		return e
+	}
+	e.SourceStoreID = source.GetId()
+	e.Sources = schedule.ExplainStores(cluster, []*core.StoreInfo{source}, filters, core.LeaderKind, opInfluence, true)
+	targetFilters := append(filters[:len(filters):len(filters)], schedule.NewLeaderPreferenceFilter(l.GetName(), cluster, source))
+	e.Targets = schedule.ExplainStores(cluster, cluster.GetFollowerStores(region), targetFilters, core.LeaderKind, opInfluence, false)
+	target := explainBalanceTargets(cluster, e, source, region, core.LeaderKind, opInfluence)
+	if reason := explainRegionHealth(cluster, region); reason != "" {
+		e.Result = reason
+		return e
+	}
+	if target == nil {
+		e.Result = fmt.Sprintf("no follower store of region %d can be selected as target", regionID)
+		return e
+	}
+	e.TargetStoreID = target.StoreID
+	if !target.Balance.ShouldBalance {
+		e.Result = explainSkipBalance(target.Balance)
+		return e
+	}
+	e.Result = fmt.Sprintf("transfer leader of region %d from store %d to store %d", regionID, e.SourceStoreID, e.TargetStoreID)
+	return e
+}
@@ -14,9 +14,12 @@
 package schedulers
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
+	"sync"
 
+	"github.com/juju/errors"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/pd/server/cache"
 	"github.com/pingcap/pd/server/core"
@@ -30,32 +33,51 @@ func init() {
 	})
 }
 
-// balanceRegionRetryLimit is the limit to retry schedule for selected store.
-const balanceRegionRetryLimit = 10
+// defaultBalanceRegionRetryLimit is the default limit to retry schedule for
+// selected store.
+const defaultBalanceRegionRetryLimit = 10
+
+type balanceRegionSchedulerConfig struct {
+	// RetryLimit is the limit to retry schedule for selected store.
+	RetryLimit int `json:"retry-limit"`
+}
+
+func (c *balanceRegionSchedulerConfig) validate() error {
+	if c.RetryLimit <= 0 {
+		return errors.New("retry-limit should be positive")
+	}
+	return nil
+}
 
 type balanceRegionScheduler struct {
 	*baseScheduler
 	selector    schedule.Selector
 	taintStores *cache.TTLUint64
+
+	confLock sync.RWMutex
+	conf     balanceRegionSchedulerConfig
 }
 
 // newBalanceRegionScheduler creates a scheduler that tends to keep regions on
 // each store balanced.
 func newBalanceRegionScheduler(limiter *schedule.Limiter) schedule.Scheduler {
 	taintStores := newTaintCache()
-	filters := []schedule.Filter{
-		schedule.NewCacheFilter(taintStores),
-		schedule.NewStateFilter(),
-		schedule.NewHealthFilter(),
-		schedule.NewSnapshotCountFilter(),
-		schedule.NewPendingPeerCountFilter(),
-	}
 	base := newBaseScheduler(limiter)
-	return &balanceRegionScheduler{
+	s := &balanceRegionScheduler{
 		baseScheduler: base,
-		selector:      schedule.NewBalanceSelector(core.RegionKind, filters),
 		taintStores:   taintStores,
+		conf:          balanceRegionSchedulerConfig{RetryLimit: defaultBalanceRegionRetryLimit},
 	}
+	filters := []schedule.Filter{
+		schedule.NewCacheFilter(s.GetName(), taintStores),
+		schedule.NewStateFilter(s.GetName()),
+		schedule.NewHealthFilter(s.GetName()),
+		schedule.NewSnapshotCountFilter(s.GetName()),
+		schedule.NewPendingPeerCountFilter(s.GetName()),
+		schedule.NewNoBalanceFilter(s.GetName()),
+	}
+	s.selector = schedule.NewBalanceSelector(core.RegionKind, filters)
+	return s
 }
 
 func (s *balanceRegionScheduler) GetName() string {
@@ -66,6 +88,32 @@ func (s *balanceRegionScheduler) GetType() string {
 	return "balance-region"
 }
 
+func (s *balanceRegionScheduler) GetConfig() interface{} {
+	s.confLock.RLock()
+	defer s.confLock.RUnlock()
+	return s.conf
+}
+
+func (s *balanceRegionScheduler) SetConfig(cluster schedule.Cluster, data []byte) error {
+	s.confLock.Lock()
+	defer s.confLock.Unlock()
+	conf := s.conf
+	if err := json.Unmarshal(data, &conf); err != nil {
+		return errors.Trace(err)
+	}
+	if err := conf.validate(); err != nil {
+		return errors.Trace(err)
+	}
+	s.conf = conf
+	return nil
+}
+
+func (s *balanceRegionScheduler) getRetryLimit() int {
+	s.confLock.RLock()
+	defer s.confLock.RUnlock()
+	return s.conf.RetryLimit
+}
+
 func (s *balanceRegionScheduler) IsScheduleAllowed(cluster schedule.Cluster) bool {
 	return s.limiter.OperatorCount(schedule.OpRegion) < cluster.GetRegionScheduleLimit()
 }
@@ -90,10 +138,14 @@ func (s *balanceRegionScheduler) Schedule(cluster schedule.Cluster, opInfluence
 	balanceRegionCounter.WithLabelValues("source_store", sourceLabel).Inc()
 
 	var hasPotentialTarget bool
-	for i := 0; i < balanceRegionRetryLimit; i++ {
-		region := cluster.RandFollowerRegion(source.GetId(), core.HealthRegion())
+	retryLimit := s.getRetryLimit()
+	for i := 0; i < retryLimit; i++ {
+		region := cluster.RandFollowerRegion(source.GetId(), core.HealthRegionAllowLearner())
 		if region == nil {
-			region = cluster.RandLeaderRegion(source.GetId(), core.HealthRegion())
+			region = cluster.RandLeaderRegion(source.GetId(), core.HealthRegionAllowLearner())
+		}
+		if region == nil {
+			region = cluster.RandLearnerRegion(source.GetId(), core.HealthRegionAllowLearner())
 		}
 		if region == nil {
 			schedulerCounter.WithLabelValues(s.GetName(), "no_region").Inc()
@@ -102,7 +154,7 @@ func (s *balanceRegionScheduler) Schedule(cluster schedule.Cluster, opInfluence
 		log.Debugf("[%s] select region%d", s.GetName(), region.GetId())
 
 		// We don't schedule region with abnormal number of replicas.
-		if len(region.GetPeers()) != cluster.GetMaxReplicas() {
+		if !schedule.HasExpectedReplicas(cluster, nil, region) {
 			log.Debugf("[%s] region%d has abnormal replica count", s.GetName(), region.GetId())
 			schedulerCounter.WithLabelValues(s.GetName(), "abnormal_replica").Inc()
 			continue
@@ -141,10 +193,18 @@ func (s *balanceRegionScheduler) transferPeer(cluster schedule.Cluster, region *
 	// scoreGuard guarantees that the distinct score will not decrease.
 	stores := cluster.GetRegionStores(region)
 	source := cluster.GetStore(oldPeer.GetStoreId())
-	scoreGuard := schedule.NewDistinctScoreFilter(cluster.GetLocationLabels(), stores, source)
+	scoreGuard := schedule.NewDistinctScoreFilter(s.GetName(), cluster.GetLocationLabels(), stores, source)
+
+	noBalance := schedule.NewNoBalanceFilter(s.GetName())
+	ruleFit := schedule.NewRuleFitFilter(s.GetName(), cluster, region, oldPeer.GetStoreId())
 
-	checker := schedule.NewReplicaChecker(cluster, nil)
-	storeID, _ := checker.SelectBestReplacementStore(region, oldPeer, scoreGuard)
+	checker := schedule.NewReplicaChecker(cluster, nil, s.GetName())
+	var storeID uint64
+	if oldPeer.GetIsLearner() {
+		storeID, _ = checker.SelectBestLearnerReplacementStore(region, oldPeer, noBalance, ruleFit)
+	} else {
+		storeID, _ = checker.SelectBestReplacementStore(region, oldPeer, scoreGuard, noBalance, ruleFit)
+	}
 	if storeID == 0 {
 		schedulerCounter.WithLabelValues(s.GetName(), "no_replacement").Inc()
 		return nil
@@ -156,9 +216,9 @@ func (s *balanceRegionScheduler) transferPeer(cluster schedule.Cluster, region *
 	if !shouldBalance(cluster, source, target, region, core.RegionKind, opInfluence) {
 		log.Debugf(`[%s] skip balance region %d, source %d to target %d ,source size: %v, source score: %v, source influence: %v, 
 			target size: %v, target score: %v, target influence: %v, average region size: %v`, s.GetName(), region.GetId(), source.GetId(), target.GetId(),
-			source.RegionSize, source.RegionScore(cluster.GetHighSpaceRatio(), cluster.GetLowSpaceRatio(), 0),
+			source.RegionSize, schedule.StoreScore(cluster, source, core.RegionKind, 0),
 			opInfluence.GetStoreInfluence(source.GetId()).ResourceSize(core.RegionKind),
-			target.RegionSize, target.RegionScore(cluster.GetHighSpaceRatio(), cluster.GetLowSpaceRatio(), 0),
+			target.RegionSize, schedule.StoreScore(cluster, target, core.RegionKind, 0),
 			opInfluence.GetStoreInfluence(target.GetId()).ResourceSize(core.RegionKind),
 			cluster.GetAverageRegionSize())
 		schedulerCounter.WithLabelValues(s.GetName(), "skip").Inc()
@@ -172,6 +232,9 @@ func (s *balanceRegionScheduler) transferPeer(cluster schedule.Cluster, region *
 	}
 	balanceRegionCounter.WithLabelValues("move_peer", fmt.Sprintf("store%d-out", source.GetId())).Inc()
 	balanceRegionCounter.WithLabelValues("move_peer", fmt.Sprintf("store%d-in", target.GetId())).Inc()
+	if oldPeer.GetIsLearner() {
+		return schedule.CreateMoveLearnerOperator("balance-region", region, schedule.OpBalance, oldPeer.GetStoreId(), newPeer.GetStoreId(), newPeer.GetId())
+	}
 	return schedule.CreateMovePeerOperator("balance-region", cluster, region, schedule.OpBalance, oldPeer.GetStoreId(), newPeer.GetStoreId(), newPeer.GetId())
 }
 
@@ -182,8 +245,22 @@ func (s *balanceRegionScheduler) transferPeer(cluster schedule.Cluster, region *
 // which may recover soon.
 func (s *balanceRegionScheduler) hasPotentialTarget(cluster schedule.Cluster, region *core.RegionInfo, source *core.StoreInfo, opInfluence schedule.OpInfluence) bool {
 	filters := []schedule.Filter{
-		schedule.NewExcludedFilter(nil, region.GetStoreIds()),
-		schedule.NewDistinctScoreFilter(cluster.GetLocationLabels(), cluster.GetRegionStores(region), source),
+		schedule.NewExcludedFilter(s.GetName(), nil, region.GetStoreIds()),
+		schedule.NewRejectRegionFilter(s.GetName()),
+		schedule.NewNoBalanceFilter(s.GetName()),
+		schedule.NewRuleFitFilter(s.GetName(), cluster, region, source.GetId()),
+	}
+	_, learnerConstraints := schedule.GetLearnerReplicas(cluster, nil, region)
+	if region.GetStorePeer(source.GetId()).GetIsLearner() {
+		// The read-only learner can only be moved to the stores reserved for
+		// the learners.
+		filters = append(filters, schedule.NewLabelConstraintFilter(s.GetName(), learnerConstraints))
+	} else {
+		filters = append(filters,
+			schedule.NewDistinctScoreFilter(s.GetName(), cluster.GetLocationLabels(), cluster.GetRegionStores(region), source),
+			schedule.NewRangeConstraintFilter(s.GetName(), cluster, region),
+			schedule.NewLearnerStoreFilter(s.GetName(), learnerConstraints),
+		)
 	}
 
 	for _, store := range cluster.GetStores() {
@@ -200,3 +277,73 @@ func (s *balanceRegionScheduler) hasPotentialTarget(cluster schedule.Cluster, re
 	}
 	return false
 }
+
+func (s *balanceRegionScheduler) Explain(cluster schedule.Cluster, opInfluence schedule.OpInfluence, regionID, storeID uint64) *schedule.Explanation {
+	e := &schedule.Explanation{Scheduler: s.GetName(), RegionID: regionID, StoreID: storeID}
+	filters := s.selector.GetFilters()
+	if regionID == 0 {
+		explainBalanceStores(cluster, e, filters, core.RegionKind, opInfluence)
+		return e
+	}
+
+	region := cluster.GetRegion(regionID)
+	if region == nil {
+		e.Result = fmt.Sprintf("region %d is not found", regionID)
+		return e
+	}
+	e.Sources = schedule.ExplainStores(cluster, cluster.GetRegionStores(region), filters, core.RegionKind, opInfluence, true)
+	// The source is the specified store or the store with the highest score.
+	var best *schedule.StoreExplanation
+	for _, se := range e.Sources {
+		if storeID != 0 && se.StoreID != storeID {
+			continue
+		}
+		if se.Filter == "" && (best == nil || se.Score > best.Score) {
+			best = se
+		}
+	}
+	if best == nil {
+		e.Result = fmt.Sprintf("no store of region %d can be selected as source", regionID)
+		return e
+	}
+	e.SourceStoreID = best.StoreID
+	source := cluster.GetStore(best.StoreID)
+
+	if !schedule.HasExpectedReplicas(cluster, nil, region) {
+		e.Result = fmt.Sprintf("region %d has abnormal replica count", regionID)
+		return e
+	}
+	if reason := explainRegionHealth(cluster, region); reason != "" {
+		e.Result = reason
+		return e
+	}
+
+	scoreGuard := schedule.NewDistinctScoreFilter(s.GetName(), cluster.GetLocationLabels(), cluster.GetRegionStores(region), source)
+	checker := schedule.NewReplicaChecker(cluster, nil, s.GetName())
+	e.Targets, e.TargetStoreID, _ = checker.ExplainReplacement(region, region.GetStorePeer(source.GetId()), scoreGuard, schedule.NewNoBalanceFilter(s.GetName()),
+		schedule.NewRuleFitFilter(s.GetName(), cluster, region, source.GetId()))
+	// The targets are selected by the distinct score, keep it and use the
+	// region score to check the balance.
+	for _, t := range e.Targets {
+		t.Extra = map[string]interface{}{"distinct_score": t.Score}
+		if store := cluster.GetStore(t.StoreID); store != nil {
+			t.Score = schedule.StoreScore(cluster, store, core.RegionKind, 0)
+			t.Influence = opInfluence.GetStoreInfluence(t.StoreID).ResourceDelta(core.RegionKind, cluster.GetRegionScoreStrategy())
+			if t.Filter == "" {
+				t.Balance = explainBalance(cluster, source, store, region, core.RegionKind, opInfluence)
+			}
+		}
+	}
+	if e.TargetStoreID == 0 {
+		e.Result = fmt.Sprintf("no store can replace the peer of region %d on store %d", regionID, e.SourceStoreID)
+		return e
+	}
+	for _, t := range e.Targets {
+		if t.StoreID == e.TargetStoreID && t.Balance != nil && !t.Balance.ShouldBalance {
+			e.Result = explainSkipBalance(t.Balance)
+			return e
+		}
+	}
+	e.Result = fmt.Sprintf("move peer of region %d from store %d to store %d", regionID, e.SourceStoreID, e.TargetStoreID)
+	return e
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/server/namespace"
 	"github.com/pingcap/pd/server/schedule"
+	"github.com/pingcap/pd/server/schedule/placement"
 )
 
 func newTestReplication(mso *schedule.MockSchedulerOptions, maxReplicas int, locationLabels ...string) {
@@ -101,6 +102,41 @@ func (s *testBalanceSpeedSuite) TestShouldBalance(c *C) {
 	}
 }
 
+func (s *testBalanceSpeedSuite) TestScoreStrategy(c *C) {
+	opt := schedule.NewMockSchedulerOptions()
+	tc := schedule.NewMockCluster(opt)
+	tc.AddLeaderRegion(1, 1, 2)
+	region := tc.GetRegion(1)
+	region.ApproximateSize = 10
+	tc.PutRegion(region)
+	opInfluence := schedule.NewOpInfluence(nil, tc)
+
+	// Store 1 has more leaders while store 2 has larger leaders.
+	tc.AddLeaderStore(1, 30)
+	tc.AddLeaderStore(2, 10)
+	store := tc.GetStore(2)
+	store.LeaderSize = 600
+	tc.PutStore(store)
+	c.Assert(shouldBalance(tc, tc.GetStore(1), tc.GetStore(2), region, core.LeaderKind, opInfluence), IsFalse)
+	opt.LeaderScoreStrategy = core.ScoreByCount
+	c.Assert(shouldBalance(tc, tc.GetStore(1), tc.GetStore(2), region, core.LeaderKind, opInfluence), IsTrue)
+	c.Assert(shouldBalance(tc, tc.GetStore(2), tc.GetStore(1), region, core.LeaderKind, opInfluence), IsFalse)
+	// The tolerant ratio 2.5 is rounded to 3 resources.
+	c.Assert(explainBalance(tc, tc.GetStore(1), tc.GetStore(2), region, core.LeaderKind, opInfluence).TolerantSize, Equals, int64(3))
+
+	// Store 1 has less regions while store 2 has more available space.
+	tc.AddRegionStore(1, 10)
+	tc.AddRegionStore(2, 20)
+	tc.UpdateStorageRatio(1, 0.5, 0.5)
+	tc.UpdateStorageRatio(2, 0.1, 0.9)
+	c.Assert(shouldBalance(tc, tc.GetStore(1), tc.GetStore(2), region, core.RegionKind, opInfluence), IsFalse)
+	opt.RegionScoreStrategy = core.ScoreBySpace
+	c.Assert(shouldBalance(tc, tc.GetStore(1), tc.GetStore(2), region, core.RegionKind, opInfluence), IsTrue)
+	c.Assert(shouldBalance(tc, tc.GetStore(2), tc.GetStore(1), region, core.RegionKind, opInfluence), IsFalse)
+	opt.RegionScoreStrategy = core.ScoreByCount
+	c.Assert(shouldBalance(tc, tc.GetStore(2), tc.GetStore(1), region, core.RegionKind, opInfluence), IsTrue)
+}
+
 func (s *testBalanceSpeedSuite) TestBalanceLimit(c *C) {
 	opt := schedule.NewMockSchedulerOptions()
 	tc := schedule.NewMockCluster(opt)
@@ -229,6 +265,51 @@ func (s *testBalanceLeaderSchedulerSuite) TestBalanceFilter(c *C) {
 	c.Assert(s.schedule(nil), HasLen, 0)
 }
 
+func (s *testBalanceLeaderSchedulerSuite) TestExplain(c *C) {
+	// Stores:     1    2    3    4
+	// Leaders:    1    2    3   16
+	// Region1:    F    F    F    L
+	s.tc.AddLeaderStore(1, 1)
+	s.tc.AddLeaderStore(2, 2)
+	s.tc.AddLeaderStore(3, 3)
+	s.tc.AddLeaderStore(4, 16)
+	s.tc.AddLeaderRegion(1, 4, 1, 2, 3)
+	s.tc.SetStoreBusy(1, true)
+	lb := s.lb.(schedule.ExplainableScheduler)
+
+	e := lb.Explain(s.tc, schedule.NewOpInfluence(nil, s.tc), 1, 0)
+	c.Assert(e.SourceStoreID, Equals, uint64(4))
+	c.Assert(e.TargetStoreID, Equals, uint64(2))
+	c.Assert(e.Targets, HasLen, 3)
+	c.Assert(e.Targets[0].Filter, Equals, "health-filter")
+	c.Assert(e.Targets[1].Filter, Equals, "")
+	c.Assert(e.Targets[1].Balance.ShouldBalance, IsTrue)
+	c.Assert(e.Result, Equals, "transfer leader of region 1 from store 4 to store 2")
+
+	// The influence of the pending operator is counted.
+	op := s.schedule(nil)[0]
+	e = lb.Explain(s.tc, schedule.NewOpInfluence([]*schedule.Operator{op}, s.tc), 1, 0)
+	c.Assert(e.Targets[1].Influence, Equals, s.tc.GetRegion(1).ApproximateSize)
+
+	// After the leaders are nearly balanced, shouldBalance returns false.
+	s.tc.UpdateLeaderCount(2, 14)
+	s.tc.UpdateLeaderCount(3, 14)
+	e = lb.Explain(s.tc, schedule.NewOpInfluence(nil, s.tc), 1, 0)
+	c.Assert(e.TargetStoreID, Equals, uint64(2))
+	b := e.Targets[1].Balance
+	c.Assert(b.ShouldBalance, IsFalse)
+	c.Assert(b.TolerantSize, Equals, int64(float64(b.AverageRegionSize)*b.TolerantSizeRatio))
+	c.Assert(b.SourceScore, LessEqual, b.TargetScore)
+	c.Assert(e.Result, Equals, explainSkipBalance(b))
+
+	// Explain the store.
+	e = lb.Explain(s.tc, schedule.NewOpInfluence(nil, s.tc), 0, 4)
+	c.Assert(e.Sources, HasLen, 4)
+	c.Assert(e.SourceStoreID, Equals, uint64(4))
+	c.Assert(e.TargetStoreID, Equals, uint64(2))
+	c.Assert(e.Result, Equals, "store 4 is selected as source")
+}
+
 func (s *testBalanceLeaderSchedulerSuite) TestLeaderWeight(c *C) {
 	// Stores:	1	2	3	4
 	// Leaders:    10      10      10      10
@@ -351,6 +432,108 @@ func (s *testBalanceRegionSchedulerSuite) TestBalance(c *C) {
 	c.Assert(sb.Schedule(tc, schedule.NewOpInfluence(nil, tc)), NotNil)
 }
 
+func (s *testBalanceRegionSchedulerSuite) TestRangeConstraint(c *C) {
+	opt := schedule.NewMockSchedulerOptions()
+	tc := schedule.NewMockCluster(opt)
+
+	sb, err := schedule.CreateScheduler("balance-region", schedule.NewLimiter())
+	c.Assert(err, IsNil)
+
+	opt.SetMaxReplicas(1)
+	tc.AddLabelsStore(1, 6, map[string]string{"disk": "hdd"})
+	tc.AddLabelsStore(2, 8, map[string]string{"disk": "ssd"})
+	tc.AddLabelsStore(3, 16, map[string]string{"disk": "ssd"})
+	tc.AddLeaderRegionWithRange(1, "a", "b", 3)
+	testutil.CheckTransferPeer(c, sb.Schedule(tc, schedule.NewOpInfluence(nil, tc))[0], schedule.OpBalance, 3, 1)
+
+	// The region can only be moved to the ssd stores.
+	c.Assert(tc.RangeConstraintManager.SetConstraint(&placement.RangeConstraint{
+		ID:               "ssd",
+		LabelConstraints: []placement.LabelConstraint{{Key: "disk", Op: placement.In, Values: []string{"ssd"}}},
+	}), IsNil)
+	sb.(*balanceRegionScheduler).taintStores.Clear()
+	testutil.CheckTransferPeer(c, sb.Schedule(tc, schedule.NewOpInfluence(nil, tc))[0], schedule.OpBalance, 3, 2)
+}
+
+func (s *testBalanceRegionSchedulerSuite) TestPlacementRules(c *C) {
+	opt := schedule.NewMockSchedulerOptions()
+	opt.EnablePlacementRules = true
+	tc := schedule.NewMockCluster(opt)
+
+	sb, err := schedule.CreateScheduler("balance-region", schedule.NewLimiter())
+	c.Assert(err, IsNil)
+
+	tc.AddLabelsStore(1, 6, map[string]string{"disk": "hdd"})
+	tc.AddLabelsStore(2, 8, map[string]string{"disk": "ssd"})
+	tc.AddLabelsStore(3, 16, map[string]string{"disk": "ssd"})
+	tc.AddLeaderRegion(1, 3)
+	// The region has fewer peers than the default rule requires.
+	c.Assert(sb.Schedule(tc, schedule.NewOpInfluence(nil, tc)), IsNil)
+
+	// The expected replica count is derived from the rules, and the region
+	// can only be moved to the stores fitting the rules.
+	c.Assert(tc.RuleManager.SetRule(&placement.Rule{
+		ID:               placement.DefaultRuleID,
+		Role:             placement.Voter,
+		Count:            1,
+		LabelConstraints: []placement.LabelConstraint{{Key: "disk", Op: placement.In, Values: []string{"ssd"}}},
+	}), IsNil)
+	sb.(*balanceRegionScheduler).taintStores.Clear()
+	testutil.CheckTransferPeer(c, sb.Schedule(tc, schedule.NewOpInfluence(nil, tc))[0], schedule.OpBalance, 3, 2)
+}
+
+func (s *testBalanceRegionSchedulerSuite) TestLearnerReplicas(c *C) {
+	opt := schedule.NewMockSchedulerOptions()
+	opt.LearnerReplicas = 1
+	opt.LearnerLabels = map[string]string{"engine": "analytic"}
+	tc := schedule.NewMockCluster(opt)
+
+	sb, err := schedule.CreateScheduler("balance-region", schedule.NewLimiter())
+	c.Assert(err, IsNil)
+
+	opt.SetMaxReplicas(1)
+	tc.AddRegionStore(1, 10)
+	tc.AddLabelsStore(2, 16, map[string]string{"engine": "analytic"})
+	tc.AddLabelsStore(3, 1, map[string]string{"engine": "analytic"})
+	tc.AddLeaderRegion(1, 1)
+	tc.AddRegionLearner(1, 2)
+
+	// The learner is moved between the learner stores without promotion.
+	testutil.CheckTransferLearner(c, sb.Schedule(tc, schedule.NewOpInfluence(nil, tc))[0], schedule.OpBalance, 2, 3)
+}
+
+func (s *testBalanceRegionSchedulerSuite) TestExplain(c *C) {
+	opt := schedule.NewMockSchedulerOptions()
+	tc := schedule.NewMockCluster(opt)
+
+	sb, err := schedule.CreateScheduler("balance-region", schedule.NewLimiter())
+	c.Assert(err, IsNil)
+	rb := sb.(schedule.ExplainableScheduler)
+
+	opt.SetMaxReplicas(1)
+	tc.AddRegionStore(1, 6)
+	tc.AddRegionStore(2, 8)
+	tc.AddRegionStore(3, 8)
+	tc.AddRegionStore(4, 16)
+	tc.AddLeaderRegion(1, 4)
+
+	e := rb.Explain(tc, schedule.NewOpInfluence(nil, tc), 1, 0)
+	c.Assert(e.SourceStoreID, Equals, uint64(4))
+	c.Assert(e.TargetStoreID, Equals, uint64(1))
+	c.Assert(e.Targets, HasLen, 4)
+	c.Assert(e.Targets[3].Filter, Equals, "exclude-filter")
+	c.Assert(e.Result, Equals, "move peer of region 1 from store 4 to store 1")
+
+	tc.SetStoreOffline(1)
+	e = rb.Explain(tc, schedule.NewOpInfluence(nil, tc), 1, 0)
+	c.Assert(e.Targets[0].Filter, Equals, "state-filter")
+	c.Assert(e.TargetStoreID, Not(Equals), uint64(1))
+
+	opt.SetMaxReplicas(3)
+	e = rb.Explain(tc, schedule.NewOpInfluence(nil, tc), 1, 0)
+	c.Assert(e.Result, Equals, "region 1 has abnormal replica count")
+}
+
 func (s *testBalanceRegionSchedulerSuite) TestReplicas3(c *C) {
 	opt := schedule.NewMockSchedulerOptions()
 	tc := schedule.NewMockCluster(opt)
@@ -556,6 +739,35 @@ func (s *testReplicaCheckerSuite) TestBasic(c *C) {
 	testutil.CheckTransferPeer(c, rc.Check(region), schedule.OpReplica, 3, 1)
 }
 
+func (s *testReplicaCheckerSuite) TestExplain(c *C) {
+	opt := schedule.NewMockSchedulerOptions()
+	tc := schedule.NewMockCluster(opt)
+	rc := schedule.NewReplicaChecker(tc, namespace.DefaultClassifier)
+
+	tc.AddRegionStore(1, 4)
+	tc.AddRegionStore(2, 3)
+	tc.AddRegionStore(3, 2)
+	tc.AddRegionStore(4, 1)
+	tc.AddLeaderRegion(1, 1, 2)
+	region := tc.GetRegion(1)
+
+	tc.SetStoreDown(4)
+	e := rc.Explain(region)
+	c.Assert(e.Sources, HasLen, 2)
+	c.Assert(e.Targets, HasLen, 4)
+	c.Assert(e.Targets[0].Filter, Equals, "exclude-filter")
+	c.Assert(e.Targets[3].Filter, Equals, "health-filter")
+	c.Assert(e.TargetStoreID, Equals, uint64(3))
+	c.Assert(e.Result, Equals, "make up the replica on store 3")
+
+	peer3, _ := tc.AllocPeer(3)
+	region.AddPeer(peer3)
+	tc.SetStoreOffline(3)
+	e = rc.Explain(region)
+	c.Assert(e.SourceStoreID, Equals, uint64(3))
+	c.Assert(e.Result, Equals, "no store to replace the replica on offline store 3")
+}
+
 func (s *testReplicaCheckerSuite) TestLostStore(c *C) {
 	opt := schedule.NewMockSchedulerOptions()
 	tc := schedule.NewMockCluster(opt)
@@ -802,6 +1014,227 @@ func (s *testReplicaCheckerSuite) TestOpts(c *C) {
 	c.Assert(rc.Check(region), IsNil)
 }
 
+func (s *testReplicaCheckerSuite) TestRangeConstraint(c *C) {
+	opt := schedule.NewMockSchedulerOptions()
+	tc := schedule.NewMockCluster(opt)
+	rc := schedule.NewReplicaChecker(tc, namespace.DefaultClassifier)
+
+	tc.AddLabelsStore(1, 1, map[string]string{"disk": "ssd"})
+	tc.AddLabelsStore(2, 1, map[string]string{"disk": "ssd"})
+	tc.AddLabelsStore(3, 1, map[string]string{"disk": "hdd"})
+	tc.AddLabelsStore(4, 4, map[string]string{"disk": "ssd"})
+	tc.AddLeaderRegionWithRange(1, "a", "b", 1, 2, 3)
+	tc.AddLeaderRegionWithRange(2, "b", "c", 1, 2)
+	c.Assert(tc.RangeConstraintManager.SetConstraint(&placement.RangeConstraint{
+		ID:               "ssd",
+		StartKeyHex:      "61",
+		EndKeyHex:        "63",
+		LabelConstraints: []placement.LabelConstraint{{Key: "disk", Op: placement.In, Values: []string{"ssd"}}},
+	}), IsNil)
+
+	// The peer on the hdd store is moved.
+	region := tc.GetRegion(1)
+	testutil.CheckTransferPeer(c, rc.Check(region), schedule.OpReplica, 3, 4)
+	e := rc.Explain(region)
+	c.Assert(e.SourceStoreID, Equals, uint64(3))
+	c.Assert(e.TargetStoreID, Equals, uint64(4))
+	// The peer on the hdd store is removed first if there are extra replicas.
+	peer4, _ := tc.AllocPeer(4)
+	region.AddPeer(peer4)
+	testutil.CheckRemovePeer(c, rc.Check(region), 3)
+
+	// The replica is not made up on the hdd store.
+	testutil.CheckAddPeer(c, rc.Check(tc.GetRegion(2)), schedule.OpReplica, 4)
+	tc.SetStoreBusy(4, true)
+	c.Assert(rc.Check(tc.GetRegion(2)), IsNil)
+}
+
+func (s *testReplicaCheckerSuite) TestLearnerReplicas(c *C) {
+	opt := schedule.NewMockSchedulerOptions()
+	opt.LearnerReplicas = 1
+	opt.LearnerLabels = map[string]string{"engine": "analytic"}
+	tc := schedule.NewMockCluster(opt)
+	rc := schedule.NewReplicaChecker(tc, namespace.DefaultClassifier)
+
+	tc.AddRegionStore(1, 1)
+	tc.AddRegionStore(2, 1)
+	tc.AddRegionStore(3, 1)
+	tc.AddLabelsStore(4, 1, map[string]string{"engine": "analytic"})
+	tc.AddLabelsStore(5, 2, map[string]string{"engine": "analytic"})
+	tc.AddLeaderRegion(1, 1, 2)
+
+	// The voter is not made up on the learner stores.
+	testutil.CheckAddPeer(c, rc.Check(tc.GetRegion(1)), schedule.OpReplica, 3)
+	tc.AddLeaderRegion(1, 1, 2, 3)
+
+	// The learner is made up on the learner store and never promoted.
+	testutil.CheckAddLearner(c, rc.Check(tc.GetRegion(1)), schedule.OpReplica, 4)
+	c.Assert(rc.Explain(tc.GetRegion(1)).Result, Equals, "make up the learner on store 4")
+	tc.AddRegionLearner(1, 4)
+	c.Assert(rc.Check(tc.GetRegion(1)), IsNil)
+
+	// The learner on the offline store is moved to another learner store.
+	tc.SetStoreOffline(4)
+	testutil.CheckTransferLearner(c, rc.Check(tc.GetRegion(1)), schedule.OpReplica, 4, 5)
+	tc.SetStoreUp(4)
+
+	// The range constraint overrides the number of the learners.
+	c.Assert(tc.RangeConstraintManager.SetConstraint(&placement.RangeConstraint{
+		ID:                      "learners",
+		LearnerReplicas:         2,
+		LearnerLabelConstraints: []placement.LabelConstraint{{Key: "engine", Op: placement.In, Values: []string{"analytic"}}},
+	}), IsNil)
+	testutil.CheckAddLearner(c, rc.Check(tc.GetRegion(1)), schedule.OpReplica, 5)
+	c.Assert(tc.RangeConstraintManager.DeleteConstraint("learners"), IsNil)
+
+	// The extra learner is removed.
+	opt.LearnerReplicas = 0
+	testutil.CheckRemovePeer(c, rc.Check(tc.GetRegion(1)), 4)
+}
+
+func (s *testReplicaCheckerSuite) TestPlacementRules(c *C) {
+	opt := schedule.NewMockSchedulerOptions()
+	opt.EnablePlacementRules = true
+	tc := schedule.NewMockCluster(opt)
+	rc := schedule.NewReplicaChecker(tc, namespace.DefaultClassifier)
+
+	tc.AddLabelsStore(1, 1, map[string]string{"zone": "z1", "disk": "ssd"})
+	tc.AddLabelsStore(2, 2, map[string]string{"zone": "z2", "disk": "ssd"})
+	tc.AddLabelsStore(3, 3, map[string]string{"zone": "z3", "disk": "hdd"})
+	tc.AddLabelsStore(4, 4, map[string]string{"zone": "z1", "disk": "ssd"})
+	tc.AddLabelsStore(5, 1, map[string]string{"engine": "tiflash"})
+	tc.AddLeaderRegion(1, 1, 2)
+	region := tc.GetRegion(1)
+
+	// The default rule is created from the replication config.
+	c.Assert(tc.RuleManager.SetRule(&placement.Rule{
+		ID:               placement.DefaultRuleID,
+		Role:             placement.Voter,
+		Count:            3,
+		LabelConstraints: []placement.LabelConstraint{{Key: "engine", Op: placement.NotExists}},
+		LocationLabels:   []string{"zone"},
+	}), IsNil)
+	testutil.CheckAddPeer(c, rc.Check(region), schedule.OpReplica, 3)
+	e := rc.Explain(region)
+	c.Assert(e.Result, Equals, "rule default has 2 of 3 voter peers")
+	peer3, _ := tc.AllocPeer(3)
+	region.AddPeer(peer3)
+	c.Assert(rc.Check(region), IsNil)
+
+	// A learner rule only adds a learner.
+	c.Assert(tc.RuleManager.SetRule(&placement.Rule{
+		ID:               "tiflash",
+		Role:             placement.Learner,
+		Count:            1,
+		LabelConstraints: []placement.LabelConstraint{{Key: "engine", Op: placement.In, Values: []string{"tiflash"}}},
+	}), IsNil)
+	op := rc.Check(region)
+	c.Assert(op, NotNil)
+	c.Assert(op.Len(), Equals, 1)
+	c.Assert(op.Step(0).(schedule.AddLearner).ToStore, Equals, uint64(5))
+	peer5, _ := tc.AllocPeer(5)
+	peer5.IsLearner = true
+	region.AddPeer(peer5)
+	c.Assert(rc.Check(region), IsNil)
+
+	// The peer which does not fit any rule is removed.
+	peer4, _ := tc.AllocPeer(4)
+	region.AddPeer(peer4)
+	testutil.CheckRemovePeer(c, rc.Check(region), 4)
+	region.RemoveStorePeer(4)
+
+	// The peer on the offline store is replaced.
+	tc.SetStoreOffline(3)
+	testutil.CheckTransferPeer(c, rc.Check(region), schedule.OpReplica, 3, 4)
+	tc.SetStoreUp(3)
+
+	// The leader is transferred to the leader rule, which is applied before
+	// the default rule.
+	c.Assert(tc.RuleManager.SetRule(&placement.Rule{
+		ID:               "leader",
+		Index:            -1,
+		Role:             placement.Leader,
+		Count:            1,
+		LabelConstraints: []placement.LabelConstraint{{Key: "zone", Op: placement.In, Values: []string{"z2"}}},
+	}), IsNil)
+	c.Assert(tc.RuleManager.SetRule(&placement.Rule{
+		ID:               placement.DefaultRuleID,
+		Role:             placement.Voter,
+		Count:            2,
+		LabelConstraints: []placement.LabelConstraint{{Key: "engine", Op: placement.NotExists}},
+		LocationLabels:   []string{"zone"},
+	}), IsNil)
+	testutil.CheckTransferLeader(c, rc.Check(region), schedule.OpLeader, 1, 2)
+}
+
+func (s *testReplicaCheckerSuite) TestPlacementRulesWithLearnersAndConstraints(c *C) {
+	opt := schedule.NewMockSchedulerOptions()
+	opt.EnablePlacementRules = true
+	opt.LearnerReplicas = 1
+	opt.LearnerLabels = map[string]string{"engine": "analytic"}
+	tc := schedule.NewMockCluster(opt)
+	rc := schedule.NewReplicaChecker(tc, namespace.DefaultClassifier)
+
+	tc.AddLabelsStore(1, 1, map[string]string{"disk": "ssd"})
+	tc.AddLabelsStore(2, 1, map[string]string{"disk": "ssd"})
+	tc.AddLabelsStore(3, 1, map[string]string{"disk": "hdd"})
+	tc.AddLabelsStore(4, 4, map[string]string{"disk": "ssd"})
+	tc.AddLabelsStore(5, 1, map[string]string{"engine": "analytic"})
+	tc.AddLabelsStore(6, 2, map[string]string{"engine": "analytic"})
+	tc.AddLeaderRegionWithRange(1, "a", "b", 1, 2, 3)
+
+	// The learner is made up and kept, though it fits no rule.
+	testutil.CheckAddLearner(c, rc.Check(tc.GetRegion(1)), schedule.OpReplica, 5)
+	tc.AddRegionLearner(1, 5)
+	c.Assert(rc.Check(tc.GetRegion(1)), IsNil)
+
+	// The learner on the offline store is moved to another learner store.
+	tc.SetStoreOffline(5)
+	op := rc.Check(tc.GetRegion(1))
+	testutil.CheckTransferLearner(c, op, schedule.OpReplica, 5, 6)
+	c.Assert(op.GetPriorityLevel(), Equals, core.HighPriority)
+	tc.SetStoreUp(5)
+
+	// The peer on the store not matching the range constraints is moved.
+	c.Assert(tc.RangeConstraintManager.SetConstraint(&placement.RangeConstraint{
+		ID:               "ssd",
+		StartKeyHex:      "61",
+		EndKeyHex:        "63",
+		LabelConstraints: []placement.LabelConstraint{{Key: "disk", Op: placement.In, Values: []string{"ssd"}}},
+	}), IsNil)
+	testutil.CheckTransferPeer(c, rc.Check(tc.GetRegion(1)), schedule.OpReplica, 3, 4)
+}
+
+func (s *testReplicaCheckerSuite) TestPlacementRuleIsolation(c *C) {
+	opt := schedule.NewMockSchedulerOptions()
+	opt.EnablePlacementRules = true
+	tc := schedule.NewMockCluster(opt)
+	rc := schedule.NewReplicaChecker(tc, namespace.DefaultClassifier)
+
+	tc.AddLabelsStore(1, 1, map[string]string{"zone": "z1", "host": "h1"})
+	tc.AddLabelsStore(2, 1, map[string]string{"zone": "z2", "host": "h1"})
+	tc.AddLabelsStore(3, 3, map[string]string{"zone": "z1", "host": "h2"})
+	tc.AddLeaderRegion(1, 1, 2)
+	region := tc.GetRegion(1)
+
+	c.Assert(tc.RuleManager.SetRule(&placement.Rule{
+		ID:             placement.DefaultRuleID,
+		Role:           placement.Voter,
+		Count:          3,
+		LocationLabels: []string{"zone", "host"},
+		IsolationLevel: "zone",
+	}), IsNil)
+	// Store 3 is in the same zone as store 1.
+	c.Assert(rc.Check(region), IsNil)
+	tc.AddLabelsStore(4, 5, map[string]string{"zone": "z3", "host": "h1"})
+	testutil.CheckAddPeer(c, rc.Check(region), schedule.OpReplica, 4)
+
+	// A peer violating the isolation level is moved to a better location.
+	peer3, _ := tc.AllocPeer(3)
+	region.AddPeer(peer3)
+	testutil.CheckTransferPeer(c, rc.Check(region), schedule.OpReplica, 3, 4)
+}
+
 var _ = Suite(&testRandomMergeSchedulerSuite{})
 
 type testRandomMergeSchedulerSuite struct{}
@@ -935,6 +1368,84 @@ func (s *testMergeCheckerSuite) TestBasic(c *C) {
 	c.Assert(op2, IsNil)
 }
 
+func (s *testMergeCheckerSuite) TestRangeConstraint(c *C) {
+	s.cluster.AddLabelsStore(1, 1, map[string]string{"disk": "ssd"})
+	s.cluster.AddLabelsStore(4, 1, map[string]string{"disk": "hdd"})
+	s.cluster.AddLabelsStore(5, 1, map[string]string{"disk": "ssd"})
+	s.cluster.AddLabelsStore(6, 1, map[string]string{"disk": "ssd"})
+	op1, op2 := s.mc.Check(s.regions[2])
+	c.Assert(op1, NotNil)
+	c.Assert(op2, NotNil)
+
+	// Region 2 has a peer on the hdd store, so region 3 can not be merged
+	// into it.
+	c.Assert(s.cluster.RangeConstraintManager.SetConstraint(&placement.RangeConstraint{
+		ID:               "ssd",
+		StartKeyHex:      "74",
+		EndKeyHex:        "78",
+		LabelConstraints: []placement.LabelConstraint{{Key: "disk", Op: placement.In, Values: []string{"ssd"}}},
+	}), IsNil)
+	op1, op2 = s.mc.Check(s.regions[2])
+	c.Assert(op1, IsNil)
+	c.Assert(op2, IsNil)
+}
+
+func (s *testMergeCheckerSuite) TestPlacementRules(c *C) {
+	s.cluster.MockSchedulerOptions.EnablePlacementRules = true
+	for _, id := range []uint64{1, 4, 5, 6} {
+		s.cluster.AddRegionStore(id, 1)
+	}
+	op1, op2 := s.mc.Check(s.regions[2])
+	c.Assert(op1, NotNil)
+	c.Assert(op2, NotNil)
+
+	// Region 3 has a different rule from region 2, so they are not merged.
+	c.Assert(s.cluster.RuleManager.SetRule(&placement.Rule{
+		ID:          "t",
+		Index:       1,
+		Override:    true,
+		StartKeyHex: "74",
+		EndKeyHex:   "78",
+		Role:        placement.Voter,
+		Count:       3,
+	}), IsNil)
+	op1, op2 = s.mc.Check(s.regions[2])
+	c.Assert(op1, IsNil)
+	c.Assert(op2, IsNil)
+}
+
+func (s *testMergeCheckerSuite) TestLearnerReplicas(c *C) {
+	s.cluster.MockSchedulerOptions.LearnerReplicas = 1
+	s.cluster.MockSchedulerOptions.LearnerLabels = map[string]string{"engine": "analytic"}
+	s.cluster.AddLabelsStore(7, 1, map[string]string{"engine": "analytic"})
+	s.cluster.AddLabelsStore(8, 1, map[string]string{"engine": "analytic"})
+
+	// The regions without the read-only learners are not merged.
+	op1, op2 := s.mc.Check(s.regions[2])
+	c.Assert(op1, IsNil)
+	c.Assert(op2, IsNil)
+
+	s.regions[1].Peers = append(s.regions[1].Peers, &metapb.Peer{Id: 110, StoreId: 7, IsLearner: true})
+	s.regions[2].Peers = append(s.regions[2].Peers, &metapb.Peer{Id: 111, StoreId: 8, IsLearner: true})
+	s.cluster.PutRegion(s.regions[1])
+	s.cluster.PutRegion(s.regions[2])
+	op1, op2 = s.mc.Check(s.regions[2])
+	s.checkSteps(c, op1, []schedule.OperatorStep{
+		schedule.AddLearner{ToStore: 4, PeerID: 1},
+		schedule.PromoteLearner{ToStore: 4, PeerID: 1},
+		schedule.AddLearner{ToStore: 7, PeerID: 2},
+		schedule.TransferLeader{FromStore: 6, ToStore: 4},
+		schedule.RemovePeer{FromStore: 6},
+		schedule.RemovePeer{FromStore: 8},
+		schedule.MergeRegion{
+			FromRegion: s.regions[2].Region,
+			ToRegion:   s.regions[1].Region,
+			IsPassive:  false,
+		},
+	})
+	c.Assert(op2, NotNil)
+}
+
 func (s *testMergeCheckerSuite) checkSteps(c *C, op *schedule.Operator, steps []schedule.OperatorStep) {
 	c.Assert(op.Kind()&schedule.OpMerge, Not(Equals), 0)
 	c.Assert(steps, NotNil)
@@ -1053,6 +1564,15 @@ func (s *testBalanceHotWriteRegionSchedulerSuite) TestBalance(c *C) {
 	tc.AddLeaderRegionWithWriteInfo(3, 1, 512*1024*schedule.RegionHeartBeatReportInterval, 2, 4)
 	opt.HotRegionLowThreshold = 0
 
+	e := hb.(schedule.ExplainableScheduler).Explain(tc, schedule.NewOpInfluence(nil, tc), 1, 0)
+	c.Assert(e.Details, HasLen, 2)
+	for _, d := range e.Details {
+		c.Assert(d.SourceStoreID, Equals, uint64(1))
+	}
+	c.Assert(e.Details[1].Type, Equals, "write-peer")
+	c.Assert(e.Details[1].Targets, HasLen, 7)
+	c.Assert(e.Details[1].Targets[6].Filter, Equals, "health-filter")
+
 	// Will transfer a hot region from store 1, because the total count of peers
 	// which is hot for store 1 is more larger than other stores.
 	op := hb.Schedule(tc, schedule.NewOpInfluence(nil, tc))
@@ -1113,6 +1633,79 @@ func (s *testBalanceHotWriteRegionSchedulerSuite) TestBalance(c *C) {
 	hb.Schedule(tc, schedule.NewOpInfluence(nil, tc))
 }
 
+func (s *testBalanceHotWriteRegionSchedulerSuite) TestPeerFilters(c *C) {
+	opt := schedule.NewMockSchedulerOptions()
+	opt.LearnerReplicas = 1
+	opt.LearnerLabels = map[string]string{"engine": "analytic"}
+	tc := schedule.NewMockCluster(opt)
+
+	tc.AddLabelsStore(1, 1, map[string]string{"disk": "ssd"})
+	tc.AddLabelsStore(2, 1, map[string]string{"disk": "ssd"})
+	tc.AddLabelsStore(3, 1, map[string]string{"disk": "ssd"})
+	tc.AddLabelsStore(4, 1, map[string]string{"disk": "hdd"})
+	tc.AddLabelsStore(5, 1, map[string]string{"disk": "ssd"})
+	tc.AddLabelsStore(6, 1, map[string]string{"engine": "analytic"})
+	tc.AddLabelsStore(7, 1, map[string]string{"engine": "analytic"})
+	tc.AddLeaderRegionWithRange(1, "a", "b", 1, 2, 3)
+	tc.AddRegionLearner(1, 6)
+	c.Assert(tc.RangeConstraintManager.SetConstraint(&placement.RangeConstraint{
+		ID:               "ssd",
+		LabelConstraints: []placement.LabelConstraint{{Key: "disk", Op: placement.In, Values: []string{"ssd"}}},
+	}), IsNil)
+	region := tc.GetRegion(1)
+
+	// The voter can not be moved to the stores violating the range
+	// constraints or reserved for the learners.
+	filters := hotPeerFilters("test", tc, region, tc.GetStore(1))
+	c.Assert(schedule.FilterTarget(tc, tc.GetStore(4), filters), IsTrue)
+	c.Assert(schedule.FilterTarget(tc, tc.GetStore(5), filters), IsFalse)
+	c.Assert(schedule.FilterTarget(tc, tc.GetStore(7), filters), IsTrue)
+
+	// The learner can only be moved to the stores reserved for the learners.
+	filters = hotPeerFilters("test", tc, region, tc.GetStore(6))
+	c.Assert(schedule.FilterTarget(tc, tc.GetStore(5), filters), IsTrue)
+	c.Assert(schedule.FilterTarget(tc, tc.GetStore(7), filters), IsFalse)
+}
+
+func (s *testBalanceHotWriteRegionSchedulerSuite) TestBalanceByKeys(c *C) {
+	opt := schedule.NewMockSchedulerOptions()
+	tc := schedule.NewMockCluster(opt)
+	hb, err := schedule.CreateScheduler("hot-read-region", schedule.NewLimiter())
+	c.Assert(err, IsNil)
+
+	for i := uint64(1); i <= 4; i++ {
+		tc.AddRegionStore(i, 2)
+	}
+
+	// Regions 1 and 2 are hot by bytes, regions 3 and 4 are hot by keys.
+	//| region_id | leader_store | follower_store | follower_store | read_bytes | read_keys |
+	//|-----------|--------------|----------------|----------------|------------|-----------|
+	//|     1     |       1      |        3       |       4        |   512KB    |     0     |
+	//|     2     |       1      |        3       |       4        |   512KB    |     0     |
+	//|     3     |       2      |        3       |       4        |     1KB    |   10000   |
+	//|     4     |       2      |        3       |       4        |     1KB    |   10000   |
+	interval := uint64(schedule.RegionHeartBeatReportInterval)
+	tc.AddLeaderRegionWithReadKeysInfo(1, 1, 512*1024*interval, 0, 3, 4)
+	tc.AddLeaderRegionWithReadKeysInfo(2, 1, 512*1024*interval, 0, 3, 4)
+	tc.AddLeaderRegionWithReadKeysInfo(3, 2, 1024*interval, 10000*interval, 3, 4)
+	tc.AddLeaderRegionWithReadKeysInfo(4, 2, 1024*interval, 10000*interval, 3, 4)
+	opt.HotRegionLowThreshold = 0
+	c.Assert(tc.IsRegionHot(3), IsTrue)
+	stat := tc.HotCache.RegionStat(3, schedule.ReadFlow)
+	c.Assert(stat.FlowBytes, Equals, uint64(1024))
+	c.Assert(stat.FlowKeys, Equals, uint64(10000))
+
+	// The store with more flow bytes is selected as the source by default.
+	testutil.CheckTransferLeaderFrom(c, hb.Schedule(tc, schedule.NewOpInfluence(nil, tc))[0], schedule.OpHotRegion, 1)
+
+	// The store with more flow keys is selected if keys are preferred.
+	c.Assert(hb.(schedule.ConfigurableScheduler).SetConfig(tc, []byte(`{"priorities":["keys","bytes"]}`)), IsNil)
+	testutil.CheckTransferLeaderFrom(c, hb.Schedule(tc, schedule.NewOpInfluence(nil, tc))[0], schedule.OpHotRegion, 2)
+	status := hb.(*balanceHotRegionsScheduler).GetHotReadStatus()
+	c.Assert(status.AsLeader[2].TotalFlowKeys, Equals, uint64(20000))
+	c.Assert(status.AsLeader[1].TotalFlowBytes, Equals, uint64(1024*1024))
+}
+
 type testBalanceHotReadRegionSchedulerSuite struct{}
 
 func (s *testBalanceHotReadRegionSchedulerSuite) TestBalance(c *C) {
@@ -14,8 +14,10 @@
 package schedulers
 
 import (
-	"fmt"
+	"encoding/json"
+	"math/rand"
 	"strconv"
+	"sync"
 
 	"github.com/juju/errors"
 	"github.com/pingcap/pd/server/core"
@@ -35,45 +37,131 @@ func init() {
 	})
 }
 
+type evictLeaderSchedulerConfig struct {
+	// StoreIDs are the stores to evict leaders from.
+	StoreIDs []uint64 `json:"store-id-list"`
+}
+
 type evictLeaderScheduler struct {
 	*baseScheduler
-	name     string
-	storeID  uint64
 	selector schedule.Selector
+
+	confLock sync.RWMutex
+	conf     evictLeaderSchedulerConfig
 }
 
 // newEvictLeaderScheduler creates an admin scheduler that transfers all leaders
-// out of a store.
+// out of a store, more stores can be added by updating its config.
 func newEvictLeaderScheduler(limiter *schedule.Limiter, storeID uint64) schedule.Scheduler {
-	filters := []schedule.Filter{
-		schedule.NewStateFilter(),
-		schedule.NewHealthFilter(),
-		schedule.NewDisconnectFilter(),
-		schedule.NewRejectLeaderFilter(),
-	}
 	base := newBaseScheduler(limiter)
-	return &evictLeaderScheduler{
+	s := &evictLeaderScheduler{
 		baseScheduler: base,
-		name:          fmt.Sprintf("evict-leader-scheduler-%d", storeID),
-		storeID:       storeID,
-		selector:      schedule.NewRandomSelector(filters),
+		conf:          evictLeaderSchedulerConfig{StoreIDs: []uint64{storeID}},
+	}
+	filters := []schedule.Filter{
+		// The stores of the scheduler are blocked, so leaders will not be
+		// transferred between them.
+		schedule.NewBlockFilter(s.GetName()),
+		schedule.NewStateFilter(s.GetName()),
+		schedule.NewHealthFilter(s.GetName()),
+		schedule.NewDisconnectFilter(s.GetName()),
+		schedule.NewRejectLeaderFilter(s.GetName()),
 	}
+	s.selector = schedule.NewRandomSelector(filters)
+	return s
 }
 
+// GetName returns the name of the scheduler, which does not depend on the
+// stores, since they can be changed by the config.
 func (s *evictLeaderScheduler) GetName() string {
-	return s.name
+	return "evict-leader-scheduler"
 }
 
 func (s *evictLeaderScheduler) GetType() string {
 	return "evict-leader"
 }
 
+func (s *evictLeaderScheduler) GetConfig() interface{} {
+	return evictLeaderSchedulerConfig{StoreIDs: s.getStoreIDs()}
+}
+
+// SetConfig updates the stores to evict leaders from. The newly added stores
+// are blocked and the removed stores are unblocked.
+func (s *evictLeaderScheduler) SetConfig(cluster schedule.Cluster, data []byte) error {
+	s.confLock.Lock()
+	defer s.confLock.Unlock()
+	var conf evictLeaderSchedulerConfig
+	if err := json.Unmarshal(data, &conf); err != nil {
+		return errors.Trace(err)
+	}
+	if conf.StoreIDs == nil {
+		return nil
+	}
+	if len(conf.StoreIDs) == 0 {
+		return errors.New("store-id-list should not be empty")
+	}
+
+	oldStores := make(map[uint64]struct{}, len(s.conf.StoreIDs))
+	for _, id := range s.conf.StoreIDs {
+		oldStores[id] = struct{}{}
+	}
+	newStores := make(map[uint64]struct{}, len(conf.StoreIDs))
+	storeIDs := make([]uint64, 0, len(conf.StoreIDs))
+	for _, id := range conf.StoreIDs {
+		if _, ok := newStores[id]; ok {
+			continue
+		}
+		newStores[id] = struct{}{}
+		storeIDs = append(storeIDs, id)
+	}
+	// Block the new stores first, so that nothing changes if it fails.
+	var blocked []uint64
+	for _, id := range storeIDs {
+		if _, ok := oldStores[id]; ok {
+			continue
+		}
+		if err := cluster.BlockStore(id); err != nil {
+			for _, b := range blocked {
+				cluster.UnblockStore(b)
+			}
+			return errors.Trace(err)
+		}
+		blocked = append(blocked, id)
+	}
+	for id := range oldStores {
+		if _, ok := newStores[id]; !ok {
+			cluster.UnblockStore(id)
+		}
+	}
+	s.conf.StoreIDs = storeIDs
+	return nil
+}
+
+func (s *evictLeaderScheduler) getStoreIDs() []uint64 {
+	s.confLock.RLock()
+	defer s.confLock.RUnlock()
+	storeIDs := make([]uint64, len(s.conf.StoreIDs))
+	copy(storeIDs, s.conf.StoreIDs)
+	return storeIDs
+}
+
 func (s *evictLeaderScheduler) Prepare(cluster schedule.Cluster) error {
-	return errors.Trace(cluster.BlockStore(s.storeID))
+	storeIDs := s.getStoreIDs()
+	for i, id := range storeIDs {
+		if err := cluster.BlockStore(id); err != nil {
+			for _, blocked := range storeIDs[:i] {
+				cluster.UnblockStore(blocked)
+			}
+			return errors.Trace(err)
+		}
+	}
+	return nil
 }
 
 func (s *evictLeaderScheduler) Cleanup(cluster schedule.Cluster) {
-	cluster.UnblockStore(s.storeID)
+	for _, id := range s.getStoreIDs() {
+		cluster.UnblockStore(id)
+	}
 }
 
 func (s *evictLeaderScheduler) IsScheduleAllowed(cluster schedule.Cluster) bool {
@@ -82,7 +170,13 @@ func (s *evictLeaderScheduler) IsScheduleAllowed(cluster schedule.Cluster) bool
 
 func (s *evictLeaderScheduler) Schedule(cluster schedule.Cluster, opInfluence schedule.OpInfluence) []*schedule.Operator {
 	schedulerCounter.WithLabelValues(s.GetName(), "schedule").Inc()
-	region := cluster.RandLeaderRegion(s.storeID, core.HealthRegion())
+	var region *core.RegionInfo
+	storeIDs := s.getStoreIDs()
+	for _, i := range rand.Perm(len(storeIDs)) {
+		if region = cluster.RandLeaderRegion(storeIDs[i], core.HealthRegionAllowLearner()); region != nil {
+			break
+		}
+	}
 	if region == nil {
 		schedulerCounter.WithLabelValues(s.GetName(), "no_leader").Inc()
 		return nil
@@ -0,0 +1,364 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/pd/pkg/typeutil"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/schedule"
+	log "github.com/sirupsen/logrus"
+)
+
+func init() {
+	schedule.RegisterScheduler("evict-slow-store", func(limiter *schedule.Limiter, args []string) (schedule.Scheduler, error) {
+		return newEvictSlowStoreScheduler(limiter), nil
+	})
+}
+
+const (
+	// The store heartbeat interval of TiKV is 10s by default.
+	defaultSlowHeartbeatInterval = 15 * time.Second
+	defaultEvictDuration         = time.Minute
+	defaultRecoverDuration       = 5 * time.Minute
+	defaultMaxEvictedStores      = 1
+)
+
+type evictSlowStoreSchedulerConfig struct {
+	// SlowHeartbeatInterval is the interval between two store heartbeats
+	// beyond which the latter one is regarded as late.
+	SlowHeartbeatInterval typeutil.Duration `json:"slow-heartbeat-interval"`
+	// EvictDuration is how long a store should stay busy or slow before its
+	// leaders are evicted.
+	EvictDuration typeutil.Duration `json:"evict-duration"`
+	// RecoverDuration is how long an evicted store should stay healthy before
+	// it can hold leaders again. It should not be shorter than EvictDuration
+	// to avoid flapping.
+	RecoverDuration typeutil.Duration `json:"recover-duration"`
+	// MaxEvictedStores is the maximum number of stores evicted at the same
+	// time.
+	MaxEvictedStores int `json:"max-evicted-stores"`
+}
+
+func (c *evictSlowStoreSchedulerConfig) validate() error {
+	if c.SlowHeartbeatInterval.Duration <= 0 {
+		return errors.New("slow-heartbeat-interval should be positive")
+	}
+	if c.EvictDuration.Duration < 0 {
+		return errors.New("evict-duration should not be negative")
+	}
+	if c.RecoverDuration.Duration < c.EvictDuration.Duration {
+		return errors.New("recover-duration should not be shorter than evict-duration")
+	}
+	if c.MaxEvictedStores <= 0 {
+		return errors.New("max-evicted-stores should be positive")
+	}
+	return nil
+}
+
+// slowStoreState tracks the health of a store.
+type slowStoreState struct {
+	// lastHeartbeat is the last heartbeat time seen, and lateHeartbeat is
+	// whether it arrived late against the previous one.
+	lastHeartbeat time.Time
+	lateHeartbeat bool
+	// reason is why the store is unhealthy, it is empty if it is healthy.
+	reason         string
+	unhealthySince time.Time
+	healthySince   time.Time
+	evicted        bool
+	evictedAt      time.Time
+	// leaderBlocked is true if the store is blocked from receiving leaders
+	// by the scheduler, the store may have been blocked by others when it is
+	// evicted.
+	leaderBlocked bool
+}
+
+// evictSlowStoreScheduler evicts the leaders from the stores which stay busy
+// or keep reporting heartbeats late, and recovers them after the stores are
+// healthy again for a while. The evicted stores are only blocked from
+// receiving leaders, so that the regions can still be scheduled to them.
+type evictSlowStoreScheduler struct {
+	*baseScheduler
+	selector schedule.Selector
+
+	confLock sync.RWMutex
+	conf     evictSlowStoreSchedulerConfig
+
+	mu     sync.Mutex
+	stores map[uint64]*slowStoreState
+}
+
+func newEvictSlowStoreScheduler(limiter *schedule.Limiter) schedule.Scheduler {
+	s := &evictSlowStoreScheduler{
+		baseScheduler: newBaseScheduler(limiter),
+		conf: evictSlowStoreSchedulerConfig{
+			SlowHeartbeatInterval: typeutil.NewDuration(defaultSlowHeartbeatInterval),
+			EvictDuration:         typeutil.NewDuration(defaultEvictDuration),
+			RecoverDuration:       typeutil.NewDuration(defaultRecoverDuration),
+			MaxEvictedStores:      defaultMaxEvictedStores,
+		},
+		stores: make(map[uint64]*slowStoreState),
+	}
+	filters := []schedule.Filter{
+		schedule.NewBlockFilter(s.GetName()),
+		schedule.NewStateFilter(s.GetName()),
+		schedule.NewHealthFilter(s.GetName()),
+		schedule.NewDisconnectFilter(s.GetName()),
+		schedule.NewRejectLeaderFilter(s.GetName()),
+	}
+	s.selector = schedule.NewRandomSelector(filters)
+	return s
+}
+
+func (s *evictSlowStoreScheduler) GetName() string {
+	return "evict-slow-store-scheduler"
+}
+
+func (s *evictSlowStoreScheduler) GetType() string {
+	return "evict-slow-store"
+}
+
+func (s *evictSlowStoreScheduler) GetConfig() interface{} {
+	conf := s.getConfig()
+	return &conf
+}
+
+func (s *evictSlowStoreScheduler) SetConfig(cluster schedule.Cluster, data []byte) error {
+	s.confLock.Lock()
+	defer s.confLock.Unlock()
+	conf := s.conf
+	if err := json.Unmarshal(data, &conf); err != nil {
+		return errors.Trace(err)
+	}
+	if err := conf.validate(); err != nil {
+		return errors.Trace(err)
+	}
+	s.conf = conf
+	return nil
+}
+
+func (s *evictSlowStoreScheduler) getConfig() evictSlowStoreSchedulerConfig {
+	s.confLock.RLock()
+	defer s.confLock.RUnlock()
+	return s.conf
+}
+
+func (s *evictSlowStoreScheduler) Cleanup(cluster schedule.Cluster) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, st := range s.stores {
+		if st.leaderBlocked {
+			cluster.UnblockStoreLeader(id)
+		}
+	}
+	s.stores = make(map[uint64]*slowStoreState)
+}
+
+// IsScheduleAllowed also checks the health of the stores, so that the stores
+// are evicted and recovered in time even if the leader limit is reached.
+func (s *evictSlowStoreScheduler) IsScheduleAllowed(cluster schedule.Cluster) bool {
+	s.updateStores(cluster, time.Now())
+	return s.limiter.OperatorCount(schedule.OpLeader) < cluster.GetLeaderScheduleLimit()
+}
+
+func (s *evictSlowStoreScheduler) Schedule(cluster schedule.Cluster, opInfluence schedule.OpInfluence) []*schedule.Operator {
+	schedulerCounter.WithLabelValues(s.GetName(), "schedule").Inc()
+	evicted := s.getEvictedStores()
+	if len(evicted) == 0 {
+		schedulerCounter.WithLabelValues(s.GetName(), "skip").Inc()
+		return nil
+	}
+
+	var region *core.RegionInfo
+	for _, i := range rand.Perm(len(evicted)) {
+		if region = cluster.RandLeaderRegion(evicted[i], core.HealthRegionAllowLearner()); region != nil {
+			break
+		}
+	}
+	if region == nil {
+		schedulerCounter.WithLabelValues(s.GetName(), "no_leader").Inc()
+		return nil
+	}
+	excluded := make(map[uint64]struct{}, len(evicted))
+	for _, id := range evicted {
+		excluded[id] = struct{}{}
+	}
+	filter := schedule.NewExcludedFilter(s.GetName(), nil, excluded)
+	target := s.selector.SelectTarget(cluster, cluster.GetFollowerStores(region), filter)
+	if target == nil {
+		schedulerCounter.WithLabelValues(s.GetName(), "no_target_store").Inc()
+		return nil
+	}
+	schedulerCounter.WithLabelValues(s.GetName(), "new_operator").Inc()
+	step := schedule.TransferLeader{FromStore: region.Leader.GetStoreId(), ToStore: target.GetId()}
+	op := schedule.NewOperator("evict-slow-store", region.GetId(), region.GetRegionEpoch(), schedule.OpLeader, step)
+	op.SetPriorityLevel(core.HighPriority)
+	return []*schedule.Operator{op}
+}
+
+// updateStores checks the health of the stores, and evicts or recovers them.
+func (s *evictSlowStoreScheduler) updateStores(cluster schedule.Cluster, now time.Time) {
+	conf := s.getConfig()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The stores are evicted after all the stores are checked, so that a
+	// recovered store leaves room for another one.
+	var candidates []uint64
+	for _, store := range cluster.GetStores() {
+		id := store.GetId()
+		st, ok := s.stores[id]
+		if store.IsTombstone() {
+			if ok && st.leaderBlocked {
+				cluster.UnblockStoreLeader(id)
+			}
+			delete(s.stores, id)
+			continue
+		}
+		if !ok {
+			st = &slowStoreState{lastHeartbeat: store.LastHeartbeatTS}
+			s.stores[id] = st
+		}
+		if store.LastHeartbeatTS.After(st.lastHeartbeat) {
+			st.lateHeartbeat = store.LastHeartbeatTS.Sub(st.lastHeartbeat) > conf.SlowHeartbeatInterval.Duration
+			st.lastHeartbeat = store.LastHeartbeatTS
+		}
+
+		st.reason = ""
+		if store.Stats.GetIsBusy() {
+			st.reason = "store is busy"
+		} else if st.lateHeartbeat || (!store.IsDisconnected() && store.DownTime() > conf.SlowHeartbeatInterval.Duration) {
+			st.reason = "store heartbeat is late"
+		}
+
+		if st.reason != "" {
+			st.healthySince = time.Time{}
+			if st.unhealthySince.IsZero() {
+				st.unhealthySince = now
+			}
+			if !st.evicted && now.Sub(st.unhealthySince) >= conf.EvictDuration.Duration {
+				candidates = append(candidates, id)
+			}
+		} else {
+			st.unhealthySince = time.Time{}
+			if st.healthySince.IsZero() {
+				st.healthySince = now
+			}
+			if st.evicted && now.Sub(st.healthySince) >= conf.RecoverDuration.Duration {
+				st.evicted = false
+				if st.leaderBlocked {
+					cluster.UnblockStoreLeader(id)
+					st.leaderBlocked = false
+				}
+				log.Infof("[%s] recover store %d: healthy since %v", s.GetName(), id, st.healthySince)
+				schedulerCounter.WithLabelValues(s.GetName(), "recover_store").Inc()
+			}
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i] < candidates[j] })
+	for _, id := range candidates {
+		if s.evictedCount() >= conf.MaxEvictedStores {
+			break
+		}
+		st := s.stores[id]
+		st.evicted, st.evictedAt = true, now
+		st.leaderBlocked = cluster.BlockStoreLeader(id) == nil
+		log.Warnf("[%s] evict leaders from store %d: %s since %v", s.GetName(), id, st.reason, st.unhealthySince)
+		schedulerCounter.WithLabelValues(s.GetName(), "evict_store").Inc()
+	}
+}
+
+// getEvictedStores returns the evicted stores of the cluster.
+func (s *evictSlowStoreScheduler) getEvictedStores() []uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var evicted []uint64
+	for id, st := range s.stores {
+		if st.evicted {
+			evicted = append(evicted, id)
+		}
+	}
+	return evicted
+}
+
+func (s *evictSlowStoreScheduler) evictedCount() int {
+	var count int
+	for _, st := range s.stores {
+		if st.evicted {
+			count++
+		}
+	}
+	return count
+}
+
+// Explain reports the stores which are evicted or unhealthy, or the store
+// specified by storeID.
+func (s *evictSlowStoreScheduler) Explain(cluster schedule.Cluster, opInfluence schedule.OpInfluence, regionID, storeID uint64) *schedule.Explanation {
+	e := &schedule.Explanation{Scheduler: s.GetName(), RegionID: regionID, StoreID: storeID}
+	conf := s.getConfig()
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]uint64, 0, len(s.stores))
+	for id := range s.stores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	var evicted []uint64
+	for _, id := range ids {
+		st := s.stores[id]
+		if st.evicted {
+			evicted = append(evicted, id)
+		}
+		if storeID != 0 && id != storeID {
+			continue
+		}
+		d := &schedule.Explanation{Scheduler: s.GetName(), StoreID: id}
+		switch {
+		case st.evicted && st.reason != "":
+			d.Type = "evicted"
+			d.Result = fmt.Sprintf("leaders are evicted since %v, %s", st.evictedAt, st.reason)
+		case st.evicted:
+			d.Type = "evicted"
+			d.Result = fmt.Sprintf("leaders are evicted since %v, it recovers after being healthy for %v", st.evictedAt, conf.RecoverDuration.Duration-now.Sub(st.healthySince))
+		case st.reason != "":
+			d.Type = "unhealthy"
+			d.Result = fmt.Sprintf("%s since %v, it is evicted after %v", st.reason, st.unhealthySince, conf.EvictDuration.Duration)
+		case storeID != 0:
+			d.Type = "healthy"
+			d.Result = "store is healthy"
+		default:
+			continue
+		}
+		e.Details = append(e.Details, d)
+	}
+	if storeID != 0 && len(e.Details) == 0 {
+		e.Result = fmt.Sprintf("store %d is not checked yet", storeID)
+	} else if len(evicted) == 0 {
+		e.Result = "no store is evicted"
+	} else {
+		e.Result = fmt.Sprintf("stores %v are evicted", evicted)
+	}
+	return e
+}
@@ -0,0 +1,98 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"fmt"
+
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/schedule"
+)
+
+// explainBalanceStores explains how the balance selector picks the source
+// and target store among all stores. As there is no specified region, the
+// balance check between the selected source and each target uses the
+// average region size.
+func explainBalanceStores(cluster schedule.Cluster, e *schedule.Explanation, filters []schedule.Filter, kind core.ResourceKind, opInfluence schedule.OpInfluence) {
+	stores := cluster.GetStores()
+	e.Sources = schedule.ExplainStores(cluster, stores, filters, kind, opInfluence, true)
+	e.Targets = schedule.ExplainStores(cluster, stores, filters, kind, opInfluence, false)
+
+	var source, target *schedule.StoreExplanation
+	for _, s := range e.Sources {
+		if s.Filter == "" && (source == nil || s.Score > source.Score) {
+			source = s
+		}
+	}
+	for _, t := range e.Targets {
+		if t.Filter == "" && (target == nil || t.Score < target.Score) {
+			target = t
+		}
+	}
+	if source == nil || target == nil {
+		e.Result = "no store can be selected as source or target"
+		return
+	}
+	e.SourceStoreID, e.TargetStoreID = source.StoreID, target.StoreID
+	explainBalanceTargets(cluster, e, cluster.GetStore(source.StoreID), nil, kind, opInfluence)
+
+	if e.StoreID == 0 {
+		e.Result = fmt.Sprintf("store %d is selected as source and store %d is selected as target", e.SourceStoreID, e.TargetStoreID)
+		return
+	}
+	switch e.StoreID {
+	case e.SourceStoreID:
+		e.Result = fmt.Sprintf("store %d is selected as source", e.StoreID)
+	case e.TargetStoreID:
+		e.Result = fmt.Sprintf("store %d is selected as target", e.StoreID)
+	default:
+		e.Result = fmt.Sprintf("store %d is selected as neither source nor target, the source is store %d and the target is store %d",
+			e.StoreID, e.SourceStoreID, e.TargetStoreID)
+	}
+}
+
+// explainBalanceTargets fills the balance explanation of each target which
+// passes all filters, and returns the one with the lowest score.
+func explainBalanceTargets(cluster schedule.Cluster, e *schedule.Explanation, source *core.StoreInfo, region *core.RegionInfo, kind core.ResourceKind, opInfluence schedule.OpInfluence) *schedule.StoreExplanation {
+	var target *schedule.StoreExplanation
+	for _, t := range e.Targets {
+		if t.Filter != "" || t.StoreID == source.GetId() {
+			continue
+		}
+		if store := cluster.GetStore(t.StoreID); store != nil {
+			t.Balance = explainBalance(cluster, source, store, region, kind, opInfluence)
+		}
+		if target == nil || t.Score < target.Score {
+			target = t
+		}
+	}
+	return target
+}
+
+// explainRegionHealth returns the reason why the region is not scheduled by
+// the balance schedulers, or an empty string if it can be scheduled.
+func explainRegionHealth(cluster schedule.Cluster, region *core.RegionInfo) string {
+	if len(region.DownPeers) != 0 || len(region.PendingPeers) != 0 {
+		return fmt.Sprintf("region %d has down or pending peers", region.GetId())
+	}
+	if cluster.IsRegionHot(region.GetId()) {
+		return fmt.Sprintf("region %d is hot", region.GetId())
+	}
+	return ""
+}
+
+func explainSkipBalance(b *schedule.BalanceExplanation) string {
+	return fmt.Sprintf("source score %.2f is not greater than target score %.2f after moving size %d",
+		b.SourceScore, b.TargetScore, b.TolerantSize)
+}
@@ -14,11 +14,14 @@
 package schedulers
 
 import (
+	"encoding/json"
+	"fmt"
 	"math"
 	"math/rand"
 	"sync"
 	"time"
 
+	"github.com/juju/errors"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/server/schedule"
@@ -39,11 +42,111 @@ func init() {
 }
 
 const (
-	hotRegionLimitFactor      = 0.75
-	storeHotRegionsDefaultLen = 100
-	hotRegionScheduleFactor   = 0.9
+	defaultHotRegionLimitFactor    = 0.75
+	defaultHotRegionScheduleFactor = 0.9
+	// defaultBalanceHotRetryLimit is the default limit to retry schedule for
+	// selected balance strategy.
+	defaultBalanceHotRetryLimit = 10
+	// defaultMinSrcHotRegionCount is the default minimal number of hot regions
+	// on a store to be selected as the source store.
+	defaultMinSrcHotRegionCount = 2
+
+	// hotBytesDimension balances the hot regions by the flow bytes.
+	hotBytesDimension = "bytes"
+	// hotKeysDimension balances the hot regions by the flow keys.
+	hotKeysDimension = "keys"
 )
 
+type hotRegionSchedulerConfig struct {
+	// LimitFactor is multiplied by the difference between the hot region count
+	// of the source store and the average to get the schedule limit, it is
+	// smaller than 1 to avoid transferring back and forth.
+	LimitFactor float64 `json:"limit-factor"`
+	// ScheduleFactor is the ratio of the source store flow that the target
+	// store flow, with the moved region counted twice, should stay below.
+	ScheduleFactor float64 `json:"schedule-factor"`
+	// RetryLimit is the limit to retry schedule for selected balance strategy.
+	RetryLimit int `json:"retry-limit"`
+	// MinSrcHotRegionCount is the minimal number of hot regions on a store to
+	// be selected as the source store.
+	MinSrcHotRegionCount int `json:"min-src-hot-region-count"`
+	// Priorities are the dimensions of the flow to balance in the order of
+	// priority, the first one is used to check whether the target store can
+	// hold the region, and the others break the ties.
+	Priorities []string `json:"priorities"`
+}
+
+func newHotRegionSchedulerConfig() hotRegionSchedulerConfig {
+	return hotRegionSchedulerConfig{
+		LimitFactor:          defaultHotRegionLimitFactor,
+		ScheduleFactor:       defaultHotRegionScheduleFactor,
+		RetryLimit:           defaultBalanceHotRetryLimit,
+		MinSrcHotRegionCount: defaultMinSrcHotRegionCount,
+		Priorities:           []string{hotBytesDimension},
+	}
+}
+
+func (c *hotRegionSchedulerConfig) validate() error {
+	if c.LimitFactor <= 0 || c.LimitFactor > 1 {
+		return errors.New("limit-factor should be in (0, 1]")
+	}
+	if c.ScheduleFactor <= 0 || c.ScheduleFactor > 1 {
+		return errors.New("schedule-factor should be in (0, 1]")
+	}
+	if c.RetryLimit <= 0 {
+		return errors.New("retry-limit should be positive")
+	}
+	if c.MinSrcHotRegionCount <= 0 {
+		return errors.New("min-src-hot-region-count should be positive")
+	}
+	if len(c.Priorities) == 0 {
+		return errors.New("priorities should not be empty")
+	}
+	for i, dim := range c.Priorities {
+		if dim != hotBytesDimension && dim != hotKeysDimension {
+			return errors.Errorf("invalid priority %s, it should be %s or %s", dim, hotBytesDimension, hotKeysDimension)
+		}
+		for _, d := range c.Priorities[:i] {
+			if d == dim {
+				return errors.Errorf("duplicated priority %s", dim)
+			}
+		}
+	}
+	return nil
+}
+
+// storeHotFlow returns the total flow of the hot regions on the store in the
+// dimension.
+func storeHotFlow(stat *core.HotRegionsStat, dim string) uint64 {
+	if dim == hotKeysDimension {
+		return stat.TotalFlowKeys
+	}
+	return stat.TotalFlowBytes
+}
+
+// regionHotFlow returns the flow of the hot region in the dimension.
+func regionHotFlow(stat *core.RegionStat, dim string) uint64 {
+	if dim == hotKeysDimension {
+		return stat.FlowKeys
+	}
+	return stat.FlowBytes
+}
+
+// compareHotFlow compares the total flow of the hot regions on two stores by
+// the dimensions in the order of priority.
+func compareHotFlow(a, b *core.HotRegionsStat, priorities []string) int {
+	for _, dim := range priorities {
+		fa, fb := storeHotFlow(a, dim), storeHotFlow(b, dim)
+		if fa > fb {
+			return 1
+		}
+		if fa < fb {
+			return -1
+		}
+	}
+	return 0
+}
+
 // BalanceType : the perspective of balance
 type BalanceType int
 
@@ -75,6 +178,9 @@ type balanceHotRegionsScheduler struct {
 	// store id -> hot regions statistics as the role of leader
 	stats *storeStatistics
 	r     *rand.Rand
+
+	confLock sync.RWMutex
+	conf     hotRegionSchedulerConfig
 }
 
 func newBalanceHotRegionsScheduler(limiter *schedule.Limiter) *balanceHotRegionsScheduler {
@@ -85,6 +191,7 @@ func newBalanceHotRegionsScheduler(limiter *schedule.Limiter) *balanceHotRegions
 		stats:         newStoreStaticstics(),
 		types:         []BalanceType{hotWriteRegionBalance, hotReadRegionBalance},
 		r:             rand.New(rand.NewSource(time.Now().UnixNano())),
+		conf:          newHotRegionSchedulerConfig(),
 	}
 }
 
@@ -96,6 +203,7 @@ func newBalanceHotReadRegionsScheduler(limiter *schedule.Limiter) *balanceHotReg
 		stats:         newStoreStaticstics(),
 		types:         []BalanceType{hotReadRegionBalance},
 		r:             rand.New(rand.NewSource(time.Now().UnixNano())),
+		conf:          newHotRegionSchedulerConfig(),
 	}
 }
 
@@ -107,6 +215,7 @@ func newBalanceHotWriteRegionsScheduler(limiter *schedule.Limiter) *balanceHotRe
 		stats:         newStoreStaticstics(),
 		types:         []BalanceType{hotWriteRegionBalance},
 		r:             rand.New(rand.NewSource(time.Now().UnixNano())),
+		conf:          newHotRegionSchedulerConfig(),
 	}
 }
 
@@ -118,6 +227,32 @@ func (h *balanceHotRegionsScheduler) GetType() string {
 	return "hot-region"
 }
 
+func (h *balanceHotRegionsScheduler) GetConfig() interface{} {
+	return h.getConfig()
+}
+
+func (h *balanceHotRegionsScheduler) SetConfig(cluster schedule.Cluster, data []byte) error {
+	h.confLock.Lock()
+	defer h.confLock.Unlock()
+	conf := h.conf
+	// Do not reuse the backing array of the current priorities.
+	conf.Priorities = append([]string(nil), h.conf.Priorities...)
+	if err := json.Unmarshal(data, &conf); err != nil {
+		return errors.Trace(err)
+	}
+	if err := conf.validate(); err != nil {
+		return errors.Trace(err)
+	}
+	h.conf = conf
+	return nil
+}
+
+func (h *balanceHotRegionsScheduler) getConfig() hotRegionSchedulerConfig {
+	h.confLock.RLock()
+	defer h.confLock.RUnlock()
+	return h.conf
+}
+
 func (h *balanceHotRegionsScheduler) IsScheduleAllowed(cluster schedule.Cluster) bool {
 	return h.allowBalanceLeader(cluster) || h.allowBalanceRegion(cluster)
 }
@@ -165,24 +300,22 @@ func (h *balanceHotRegionsScheduler) balanceHotReadRegions(cluster schedule.Clus
 	srcRegion, srcPeer, destPeer := h.balanceByPeer(cluster, h.stats.readStatAsLeader)
 	if srcRegion != nil {
 		schedulerCounter.WithLabelValues(h.GetName(), "move_peer").Inc()
-		return []*schedule.Operator{schedule.CreateMovePeerOperator("moveHotReadRegion", cluster, srcRegion, schedule.OpHotRegion, srcPeer.GetStoreId(), destPeer.GetStoreId(), destPeer.GetId())}
+		return []*schedule.Operator{createMoveHotPeerOperator("moveHotReadRegion", cluster, srcRegion, srcPeer, destPeer)}
 	}
 	schedulerCounter.WithLabelValues(h.GetName(), "skip").Inc()
 	return nil
 }
 
-// balanceHotRetryLimit is the limit to retry schedule for selected balance strategy.
-const balanceHotRetryLimit = 10
-
 func (h *balanceHotRegionsScheduler) balanceHotWriteRegions(cluster schedule.Cluster) []*schedule.Operator {
-	for i := 0; i < balanceHotRetryLimit; i++ {
+	retryLimit := h.getConfig().RetryLimit
+	for i := 0; i < retryLimit; i++ {
 		switch h.r.Int() % 2 {
 		case 0:
 			// balance by peer
 			srcRegion, srcPeer, destPeer := h.balanceByPeer(cluster, h.stats.writeStatAsPeer)
 			if srcRegion != nil {
 				schedulerCounter.WithLabelValues(h.GetName(), "move_peer").Inc()
-				return []*schedule.Operator{schedule.CreateMovePeerOperator("moveHotWriteRegion", cluster, srcRegion, schedule.OpHotRegion, srcPeer.GetStoreId(), destPeer.GetStoreId(), destPeer.GetId())}
+				return []*schedule.Operator{createMoveHotPeerOperator("moveHotWriteRegion", cluster, srcRegion, srcPeer, destPeer)}
 			}
 		case 1:
 			// balance by leader
@@ -200,50 +333,7 @@ func (h *balanceHotRegionsScheduler) balanceHotWriteRegions(cluster schedule.Clu
 }
 
 func (h *balanceHotRegionsScheduler) calcScore(items []*core.RegionStat, cluster schedule.Cluster, isCountReplica bool) core.StoreHotRegionsStat {
-	stats := make(core.StoreHotRegionsStat)
-	for _, r := range items {
-		if r.HotDegree < cluster.GetHotRegionLowThreshold() {
-			continue
-		}
-
-		regionInfo := cluster.GetRegion(r.RegionID)
-		if regionInfo == nil {
-			continue
-		}
-
-		var storeIDs []uint64
-		if isCountReplica {
-			for id := range regionInfo.GetStoreIds() {
-				storeIDs = append(storeIDs, id)
-			}
-		} else {
-			storeIDs = append(storeIDs, regionInfo.Leader.GetStoreId())
-		}
-
-		for _, storeID := range storeIDs {
-			storeStat, ok := stats[storeID]
-			if !ok {
-				storeStat = &core.HotRegionsStat{
-					RegionsStat: make(core.RegionsStat, 0, storeHotRegionsDefaultLen),
-				}
-				stats[storeID] = storeStat
-			}
-
-			s := core.RegionStat{
-				RegionID:       r.RegionID,
-				FlowBytes:      uint64(r.Stats.Median()),
-				HotDegree:      r.HotDegree,
-				LastUpdateTime: r.LastUpdateTime,
-				StoreID:        storeID,
-				AntiCount:      r.AntiCount,
-				Version:        r.Version,
-			}
-			storeStat.TotalFlowBytes += r.FlowBytes
-			storeStat.RegionsCount++
-			storeStat.RegionsStat = append(storeStat.RegionsStat, s)
-		}
-	}
-	return stats
+	return schedule.SummarizeHotPeers(items, isCountReplica, cluster.GetHotRegionLowThreshold())
 }
 
 func (h *balanceHotRegionsScheduler) balanceByPeer(cluster schedule.Cluster, storesStat core.StoreHotRegionsStat) (*core.RegionInfo, *metapb.Peer, *metapb.Peer) {
@@ -268,14 +358,7 @@ func (h *balanceHotRegionsScheduler) balanceByPeer(cluster schedule.Cluster, sto
 			continue
 		}
 
-		srcStore := cluster.GetStore(srcStoreID)
-		filters := []schedule.Filter{
-			schedule.NewHealthFilter(),
-			schedule.NewStateFilter(),
-			schedule.NewSnapshotCountFilter(),
-			schedule.NewExcludedFilter(srcRegion.GetStoreIds(), srcRegion.GetStoreIds()),
-			schedule.NewDistinctScoreFilter(cluster.GetLocationLabels(), cluster.GetRegionStores(srcRegion), srcStore),
-		}
+		filters := hotPeerFilters(h.GetName(), cluster, srcRegion, cluster.GetStore(srcStoreID))
 		destStoreIDs := make([]uint64, 0, len(stores))
 		for _, store := range stores {
 			if schedule.FilterTarget(cluster, store, filters) {
@@ -284,7 +367,7 @@ func (h *balanceHotRegionsScheduler) balanceByPeer(cluster schedule.Cluster, sto
 			destStoreIDs = append(destStoreIDs, store.GetId())
 		}
 
-		destStoreID = h.selectDestStore(destStoreIDs, rs.FlowBytes, srcStoreID, storesStat)
+		destStoreID = h.selectDestStore(destStoreIDs, &rs, srcStoreID, storesStat)
 		if destStoreID != 0 {
 			h.adjustBalanceLimit(srcStoreID, storesStat)
 
@@ -326,13 +409,7 @@ func (h *balanceHotRegionsScheduler) balanceByLeader(cluster schedule.Cluster, s
 			continue
 		}
 
-		filters := []schedule.Filter{
-			schedule.NewHealthFilter(),
-			schedule.NewStateFilter(),
-			schedule.NewDisconnectFilter(),
-			schedule.NewBlockFilter(),
-			schedule.NewRejectLeaderFilter(),
-		}
+		filters := hotLeaderFilters(h.GetName())
 		candidateStoreIDs := make([]uint64, 0, len(srcRegion.Peers)-1)
 		for _, store := range cluster.GetFollowerStores(srcRegion) {
 			if !schedule.FilterTarget(cluster, store, filters) {
@@ -342,7 +419,7 @@ func (h *balanceHotRegionsScheduler) balanceByLeader(cluster schedule.Cluster, s
 		if len(candidateStoreIDs) == 0 {
 			continue
 		}
-		destStoreID := h.selectDestStore(candidateStoreIDs, rs.FlowBytes, srcStoreID, storesStat)
+		destStoreID := h.selectDestStore(candidateStoreIDs, &rs, srcStoreID, storesStat)
 		if destStoreID == 0 {
 			continue
 		}
@@ -356,20 +433,69 @@ func (h *balanceHotRegionsScheduler) balanceByLeader(cluster schedule.Cluster, s
 	return nil, nil
 }
 
+// hotPeerFilters returns the filters of the target store to move the peer of
+// a hot region.
+func hotPeerFilters(scope string, cluster schedule.Cluster, region *core.RegionInfo, source *core.StoreInfo) []schedule.Filter {
+	filters := []schedule.Filter{
+		schedule.NewHealthFilter(scope),
+		schedule.NewStateFilter(scope),
+		schedule.NewSnapshotCountFilter(scope),
+		schedule.NewExcludedFilter(scope, region.GetStoreIds(), region.GetStoreIds()),
+		schedule.NewRejectRegionFilter(scope),
+		schedule.NewNoBalanceFilter(scope),
+		schedule.NewRuleFitFilter(scope, cluster, region, source.GetId()),
+	}
+	_, learnerConstraints := schedule.GetLearnerReplicas(cluster, nil, region)
+	if region.GetStorePeer(source.GetId()).GetIsLearner() {
+		// The read-only learner can only be moved to the stores reserved for
+		// the learners.
+		return append(filters, schedule.NewLabelConstraintFilter(scope, learnerConstraints))
+	}
+	return append(filters,
+		schedule.NewDistinctScoreFilter(scope, cluster.GetLocationLabels(), cluster.GetRegionStores(region), source),
+		schedule.NewRangeConstraintFilter(scope, cluster, region),
+		schedule.NewLearnerStoreFilter(scope, learnerConstraints),
+	)
+}
+
+// createMoveHotPeerOperator creates an operator to move the hot peer, the
+// read-only learner is moved without promotion.
+func createMoveHotPeerOperator(desc string, cluster schedule.Cluster, region *core.RegionInfo, srcPeer, destPeer *metapb.Peer) *schedule.Operator {
+	if srcPeer.GetIsLearner() {
+		return schedule.CreateMoveLearnerOperator(desc, region, schedule.OpHotRegion, srcPeer.GetStoreId(), destPeer.GetStoreId(), destPeer.GetId())
+	}
+	return schedule.CreateMovePeerOperator(desc, cluster, region, schedule.OpHotRegion, srcPeer.GetStoreId(), destPeer.GetStoreId(), destPeer.GetId())
+}
+
+// hotLeaderFilters returns the filters of the target store to transfer the
+// leader of a hot region.
+func hotLeaderFilters(scope string) []schedule.Filter {
+	return []schedule.Filter{
+		schedule.NewHealthFilter(scope),
+		schedule.NewStateFilter(scope),
+		schedule.NewDisconnectFilter(scope),
+		schedule.NewBlockFilter(scope),
+		schedule.NewRejectLeaderFilter(scope),
+		schedule.NewNoBalanceFilter(scope),
+	}
+}
+
 // Select the store to move hot regions from.
 // We choose the store with the maximum number of hot region first.
-// Inside these stores, we choose the one with maximum flow bytes.
+// Inside these stores, we choose the one with maximum flow in the order of
+// the priorities.
 func (h *balanceHotRegionsScheduler) selectSrcStore(stats core.StoreHotRegionsStat) (srcStoreID uint64) {
 	var (
-		maxFlowBytes           uint64
+		maxFlowStat            *core.HotRegionsStat
 		maxHotStoreRegionCount int
 	)
 
+	conf := h.getConfig()
 	for storeID, statistics := range stats {
-		count, flowBytes := statistics.RegionsStat.Len(), statistics.TotalFlowBytes
-		if count >= 2 && (count > maxHotStoreRegionCount || (count == maxHotStoreRegionCount && flowBytes > maxFlowBytes)) {
+		count := statistics.RegionsStat.Len()
+		if count >= conf.MinSrcHotRegionCount && (count > maxHotStoreRegionCount || (count == maxHotStoreRegionCount && compareHotFlow(statistics, maxFlowStat, conf.Priorities) > 0)) {
 			maxHotStoreRegionCount = count
-			maxFlowBytes = flowBytes
+			maxFlowStat = statistics
 			srcStoreID = storeID
 		}
 	}
@@ -377,27 +503,29 @@ func (h *balanceHotRegionsScheduler) selectSrcStore(stats core.StoreHotRegionsSt
 }
 
 // selectDestStore selects a target store to hold the region of the source region.
-// We choose a target store based on the hot region number and flow bytes of this store.
-func (h *balanceHotRegionsScheduler) selectDestStore(candidateStoreIDs []uint64, regionFlowBytes uint64, srcStoreID uint64, storesStat core.StoreHotRegionsStat) (destStoreID uint64) {
+// We choose a target store based on the hot region number and flow of this
+// store, the flow is compared in the order of the priorities.
+func (h *balanceHotRegionsScheduler) selectDestStore(candidateStoreIDs []uint64, rs *core.RegionStat, srcStoreID uint64, storesStat core.StoreHotRegionsStat) (destStoreID uint64) {
 	sr := storesStat[srcStoreID]
-	srcFlowBytes := sr.TotalFlowBytes
 	srcHotRegionsCount := sr.RegionsStat.Len()
 
 	var (
-		minFlowBytes    uint64 = math.MaxUint64
-		minRegionsCount        = int(math.MaxInt32)
+		minFlowStat     *core.HotRegionsStat
+		minRegionsCount = int(math.MaxInt32)
 	)
+	conf := h.getConfig()
+	dim := conf.Priorities[0]
 	for _, storeID := range candidateStoreIDs {
 		if s, ok := storesStat[storeID]; ok {
 			if srcHotRegionsCount-s.RegionsStat.Len() > 1 && minRegionsCount > s.RegionsStat.Len() {
 				destStoreID = storeID
-				minFlowBytes = s.TotalFlowBytes
+				minFlowStat = s
 				minRegionsCount = s.RegionsStat.Len()
 				continue
 			}
-			if minRegionsCount == s.RegionsStat.Len() && minFlowBytes > s.TotalFlowBytes &&
-				uint64(float64(srcFlowBytes)*hotRegionScheduleFactor) > s.TotalFlowBytes+2*regionFlowBytes {
-				minFlowBytes = s.TotalFlowBytes
+			if minRegionsCount == s.RegionsStat.Len() && compareHotFlow(s, minFlowStat, conf.Priorities) < 0 &&
+				uint64(float64(storeHotFlow(sr, dim))*conf.ScheduleFactor) > storeHotFlow(s, dim)+2*regionHotFlow(rs, dim) {
+				minFlowStat = s
 				destStoreID = storeID
 			}
 		} else {
@@ -417,8 +545,8 @@ func (h *balanceHotRegionsScheduler) adjustBalanceLimit(storeID uint64, storesSt
 	}
 
 	avgRegionCount := hotRegionTotalCount / float64(len(storesStat))
-	// Multiplied by hotRegionLimitFactor to avoid transfer back and forth
-	limit := uint64((float64(srcStoreStatistics.RegionsStat.Len()) - avgRegionCount) * hotRegionLimitFactor)
+	// Multiplied by the limit factor to avoid transfer back and forth
+	limit := uint64((float64(srcStoreStatistics.RegionsStat.Len()) - avgRegionCount) * h.getConfig().LimitFactor)
 	h.limit = maxUint64(1, limit)
 }
 
@@ -453,3 +581,122 @@ func (h *balanceHotRegionsScheduler) GetHotWriteStatus() *core.StoreHotRegionInf
 		AsPeer:   asPeer,
 	}
 }
+
+func (h *balanceHotRegionsScheduler) Explain(cluster schedule.Cluster, opInfluence schedule.OpInfluence, regionID, storeID uint64) *schedule.Explanation {
+	e := &schedule.Explanation{Scheduler: h.GetName(), RegionID: regionID, StoreID: storeID}
+	for _, typ := range h.types {
+		switch typ {
+		case hotReadRegionBalance:
+			readStatAsLeader := h.calcScore(cluster.RegionReadStats(), cluster, false)
+			e.Details = append(e.Details,
+				h.explainHot(cluster, "read-leader", readStatAsLeader, true, regionID, storeID),
+				h.explainHot(cluster, "read-peer", readStatAsLeader, false, regionID, storeID))
+		case hotWriteRegionBalance:
+			e.Details = append(e.Details,
+				h.explainHot(cluster, "write-leader", h.calcScore(cluster.RegionWriteStats(), cluster, false), true, regionID, storeID),
+				h.explainHot(cluster, "write-peer", h.calcScore(cluster.RegionWriteStats(), cluster, true), false, regionID, storeID))
+		}
+	}
+	e.Result = "no operator can be created"
+	for _, d := range e.Details {
+		if d.TargetStoreID != 0 {
+			e.SourceStoreID, e.TargetStoreID = d.SourceStoreID, d.TargetStoreID
+			e.Result = fmt.Sprintf("operator can be created by %s", d.Type)
+			break
+		}
+	}
+	return e
+}
+
+// explainHot explains how the hot region is balanced by transferring leader
+// if byLeader is true, or by moving peer otherwise.
+func (h *balanceHotRegionsScheduler) explainHot(cluster schedule.Cluster, typ string, storesStat core.StoreHotRegionsStat, byLeader bool, regionID, storeID uint64) *schedule.Explanation {
+	e := &schedule.Explanation{Scheduler: h.GetName(), Type: typ, RegionID: regionID, StoreID: storeID}
+	e.Sources = explainHotStores(cluster, cluster.GetStores(), storesStat, nil)
+
+	if (byLeader && !h.allowBalanceLeader(cluster)) || (!byLeader && !h.allowBalanceRegion(cluster)) {
+		e.Result = "schedule limit is reached"
+		return e
+	}
+	srcStoreID := h.selectSrcStore(storesStat)
+	if srcStoreID == 0 {
+		e.Result = fmt.Sprintf("no store has at least %d hot regions", h.getConfig().MinSrcHotRegionCount)
+		return e
+	}
+	e.SourceStoreID = srcStoreID
+	if regionID == 0 {
+		if storeID == 0 || storeID == srcStoreID {
+			e.Result = fmt.Sprintf("store %d is selected as source", srcStoreID)
+		} else {
+			e.Result = fmt.Sprintf("store %d is not selected as source, the source is store %d", storeID, srcStoreID)
+		}
+		return e
+	}
+
+	var rs *core.RegionStat
+	for i := range storesStat[srcStoreID].RegionsStat {
+		if storesStat[srcStoreID].RegionsStat[i].RegionID == regionID {
+			rs = &storesStat[srcStoreID].RegionsStat[i]
+			break
+		}
+	}
+	if rs == nil {
+		e.Result = fmt.Sprintf("region %d is not a hot region of the source store %d", regionID, srcStoreID)
+		return e
+	}
+	region := cluster.GetRegion(regionID)
+	if region == nil {
+		e.Result = fmt.Sprintf("region %d is not found", regionID)
+		return e
+	}
+	if len(region.DownPeers) != 0 || len(region.PendingPeers) != 0 {
+		e.Result = fmt.Sprintf("region %d has down or pending peers", regionID)
+		return e
+	}
+
+	var (
+		stores  []*core.StoreInfo
+		filters []schedule.Filter
+	)
+	if byLeader {
+		stores, filters = cluster.GetFollowerStores(region), hotLeaderFilters(h.GetName())
+	} else {
+		stores, filters = cluster.GetStores(), hotPeerFilters(h.GetName(), cluster, region, cluster.GetStore(srcStoreID))
+	}
+	e.Targets = explainHotStores(cluster, stores, storesStat, filters)
+	candidateStoreIDs := make([]uint64, 0, len(e.Targets))
+	for _, t := range e.Targets {
+		if t.Filter == "" {
+			candidateStoreIDs = append(candidateStoreIDs, t.StoreID)
+		}
+	}
+	e.TargetStoreID = h.selectDestStore(candidateStoreIDs, rs, srcStoreID, storesStat)
+	switch {
+	case e.TargetStoreID == 0:
+		e.Result = "no target store has fewer hot regions or low enough flow"
+	case byLeader:
+		e.Result = fmt.Sprintf("transfer leader of hot region %d from store %d to store %d", regionID, srcStoreID, e.TargetStoreID)
+	default:
+		e.Result = fmt.Sprintf("move peer of hot region %d from store %d to store %d", regionID, srcStoreID, e.TargetStoreID)
+	}
+	return e
+}
+
+// explainHotStores uses the hot region count as the score. The stores are
+// evaluated as targets if there are filters.
+func explainHotStores(opt schedule.Options, stores []*core.StoreInfo, storesStat core.StoreHotRegionsStat, filters []schedule.Filter) []*schedule.StoreExplanation {
+	res := make([]*schedule.StoreExplanation, 0, len(stores))
+	for _, store := range stores {
+		se := &schedule.StoreExplanation{
+			StoreID: store.GetId(),
+			Filter:  schedule.FilterTargetType(opt, store, filters),
+		}
+		if stat, ok := storesStat[store.GetId()]; ok {
+			se.Score = float64(stat.RegionsStat.Len())
+			se.Extra = map[string]interface{}{"total_flow_bytes": stat.TotalFlowBytes, "total_flow_keys": stat.TotalFlowKeys}
+		}
+		res = append(res, se)
+	}
+	schedule.SortStoreExplanations(res)
+	return res
+}
@@ -25,23 +25,25 @@ func init() {
 	})
 }
 
+// labelScheduler schedules the leaders and peers by the label properties of
+// the stores. It transfers the leaders out of the rejectLeader stores and into
+// the preferLeader stores, and moves the peers out of the rejectRegion stores.
 type labelScheduler struct {
 	*baseScheduler
 	selector schedule.Selector
 }
 
 func newLabelScheduler(limiter *schedule.Limiter) schedule.Scheduler {
+	s := &labelScheduler{baseScheduler: newBaseScheduler(limiter)}
 	filters := []schedule.Filter{
-		schedule.NewBlockFilter(),
-		schedule.NewStateFilter(),
-		schedule.NewHealthFilter(),
-		schedule.NewDisconnectFilter(),
-		schedule.NewRejectLeaderFilter(),
-	}
-	return &labelScheduler{
-		baseScheduler: newBaseScheduler(limiter),
-		selector:      schedule.NewBalanceSelector(core.LeaderKind, filters),
+		schedule.NewBlockFilter(s.GetName()),
+		schedule.NewStateFilter(s.GetName()),
+		schedule.NewHealthFilter(s.GetName()),
+		schedule.NewDisconnectFilter(s.GetName()),
+		schedule.NewRejectLeaderFilter(s.GetName()),
 	}
+	s.selector = schedule.NewBalanceSelector(core.LeaderKind, filters)
+	return s
 }
 
 func (s *labelScheduler) GetName() string {
@@ -53,24 +55,61 @@ func (s *labelScheduler) GetType() string {
 }
 
 func (s *labelScheduler) IsScheduleAllowed(cluster schedule.Cluster) bool {
+	return s.allowLeader(cluster) || s.allowRegion(cluster)
+}
+
+func (s *labelScheduler) allowLeader(cluster schedule.Cluster) bool {
 	return s.limiter.OperatorCount(schedule.OpLeader) < cluster.GetLeaderScheduleLimit()
 }
 
+func (s *labelScheduler) allowRegion(cluster schedule.Cluster) bool {
+	return s.limiter.OperatorCount(schedule.OpRegion) < cluster.GetRegionScheduleLimit()
+}
+
 func (s *labelScheduler) Schedule(cluster schedule.Cluster, opInfluence schedule.OpInfluence) []*schedule.Operator {
 	schedulerCounter.WithLabelValues(s.GetName(), "schedule").Inc()
-	stores := cluster.GetStores()
 	rejectLeaderStores := make(map[uint64]struct{})
-	for _, s := range stores {
+	rejectRegionStores := make(map[uint64]struct{})
+	preferLeaderStores := make(map[uint64]struct{})
+	for _, s := range cluster.GetStores() {
 		if cluster.CheckLabelProperty(schedule.RejectLeader, s.Labels) {
 			rejectLeaderStores[s.GetId()] = struct{}{}
 		}
+		if cluster.CheckLabelProperty(schedule.RejectRegion, s.Labels) {
+			rejectRegionStores[s.GetId()] = struct{}{}
+		}
+		if cluster.CheckLabelProperty(schedule.PreferLeader, s.Labels) {
+			preferLeaderStores[s.GetId()] = struct{}{}
+		}
 	}
-	if len(rejectLeaderStores) == 0 {
+	if len(rejectLeaderStores) == 0 && len(rejectRegionStores) == 0 && len(preferLeaderStores) == 0 {
 		schedulerCounter.WithLabelValues(s.GetName(), "skip").Inc()
 		return nil
 	}
-	log.Debugf("label scheduler reject leader store list: %v", rejectLeaderStores)
-	for id := range rejectLeaderStores {
+	if s.allowLeader(cluster) {
+		if op := s.rejectLeader(cluster, rejectLeaderStores); op != nil {
+			return []*schedule.Operator{op}
+		}
+		if op := s.preferLeader(cluster, preferLeaderStores); op != nil {
+			return []*schedule.Operator{op}
+		}
+	}
+	if s.allowRegion(cluster) {
+		if op := s.rejectRegion(cluster, rejectRegionStores); op != nil {
+			return []*schedule.Operator{op}
+		}
+	}
+	schedulerCounter.WithLabelValues(s.GetName(), "no_region").Inc()
+	return nil
+}
+
+// rejectLeader transfers a leader out of the rejectLeader stores.
+func (s *labelScheduler) rejectLeader(cluster schedule.Cluster, stores map[uint64]struct{}) *schedule.Operator {
+	if len(stores) == 0 {
+		return nil
+	}
+	log.Debugf("label scheduler reject leader store list: %v", stores)
+	for id := range stores {
 		if region := cluster.RandLeaderRegion(id); region != nil {
 			log.Debugf("label scheduler selects region %d to transfer leader", region.GetId())
 			excludeStores := make(map[uint64]struct{})
@@ -80,7 +119,7 @@ func (s *labelScheduler) Schedule(cluster schedule.Cluster, opInfluence schedule
 			for _, p := range region.PendingPeers {
 				excludeStores[p.GetStoreId()] = struct{}{}
 			}
-			filter := schedule.NewExcludedFilter(nil, excludeStores)
+			filter := schedule.NewExcludedFilter(s.GetName(), nil, excludeStores)
 			target := s.selector.SelectTarget(cluster, cluster.GetFollowerStores(region), filter)
 			if target == nil {
 				log.Debugf("label scheduler no target found for region %d", region.GetId())
@@ -90,10 +129,78 @@ func (s *labelScheduler) Schedule(cluster schedule.Cluster, opInfluence schedule
 
 			schedulerCounter.WithLabelValues(s.GetName(), "new_operator").Inc()
 			step := schedule.TransferLeader{FromStore: id, ToStore: target.GetId()}
-			op := schedule.NewOperator("label-reject-leader", region.GetId(), region.GetRegionEpoch(), schedule.OpLeader, step)
-			return []*schedule.Operator{op}
+			return schedule.NewOperator("label-reject-leader", region.GetId(), region.GetRegionEpoch(), schedule.OpLeader, step)
 		}
 	}
-	schedulerCounter.WithLabelValues(s.GetName(), "no_region").Inc()
+	return nil
+}
+
+// preferLeader transfers a leader into the preferLeader stores from the
+// stores which are not preferred.
+func (s *labelScheduler) preferLeader(cluster schedule.Cluster, stores map[uint64]struct{}) *schedule.Operator {
+	if len(stores) == 0 {
+		return nil
+	}
+	log.Debugf("label scheduler prefer leader store list: %v", stores)
+	for id := range stores {
+		target := cluster.GetStore(id)
+		if target == nil || schedule.FilterTarget(cluster, target, s.selector.GetFilters()) {
+			continue
+		}
+		region := cluster.RandFollowerRegion(id, core.HealthRegion())
+		if region == nil {
+			continue
+		}
+		source := region.Leader.GetStoreId()
+		if _, ok := stores[source]; ok {
+			continue
+		}
+		log.Debugf("label scheduler selects region %d to transfer leader in", region.GetId())
+		schedulerCounter.WithLabelValues(s.GetName(), "new_operator").Inc()
+		step := schedule.TransferLeader{FromStore: source, ToStore: id}
+		return schedule.NewOperator("label-prefer-leader", region.GetId(), region.GetRegionEpoch(), schedule.OpLeader, step)
+	}
+	return nil
+}
+
+// rejectRegion moves a peer out of the rejectRegion stores.
+func (s *labelScheduler) rejectRegion(cluster schedule.Cluster, stores map[uint64]struct{}) *schedule.Operator {
+	if len(stores) == 0 {
+		return nil
+	}
+	log.Debugf("label scheduler reject region store list: %v", stores)
+	checker := schedule.NewReplicaChecker(cluster, nil, s.GetName())
+	for id := range stores {
+		region := cluster.RandFollowerRegion(id, core.HealthRegion())
+		if region == nil {
+			region = cluster.RandLeaderRegion(id, core.HealthRegion())
+		}
+		if region == nil {
+			continue
+		}
+		oldPeer := region.GetStorePeer(id)
+		var storeID uint64
+		if oldPeer.GetIsLearner() {
+			storeID, _ = checker.SelectBestLearnerReplacementStore(region, oldPeer)
+		} else {
+			storeID, _ = checker.SelectBestReplacementStore(region, oldPeer)
+		}
+		if storeID == 0 {
+			log.Debugf("label scheduler no target found for region %d", region.GetId())
+			schedulerCounter.WithLabelValues(s.GetName(), "no_target").Inc()
+			continue
+		}
+		newPeer, err := cluster.AllocPeer(storeID)
+		if err != nil {
+			schedulerCounter.WithLabelValues(s.GetName(), "no_peer").Inc()
+			return nil
+		}
+		log.Debugf("label scheduler selects region %d to move peer out", region.GetId())
+		schedulerCounter.WithLabelValues(s.GetName(), "new_operator").Inc()
+		if oldPeer.GetIsLearner() {
+			return schedule.CreateMoveLearnerOperator("label-reject-region", region, schedule.OpAdmin, id, storeID, newPeer.GetId())
+		}
+		return schedule.CreateMovePeerOperator("label-reject-region", cluster, region, schedule.OpAdmin, id, storeID, newPeer.GetId())
+	}
 	return nil
 }
@@ -0,0 +1,172 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"encoding/json"
+	"math/rand"
+	"sync"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/schedule"
+	log "github.com/sirupsen/logrus"
+)
+
+func init() {
+	schedule.RegisterScheduler("preferred-leader", func(limiter *schedule.Limiter, args []string) (schedule.Scheduler, error) {
+		preference, err := schedule.ParseLeaderPreference(args)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return newPreferredLeaderScheduler(limiter, preference), nil
+	})
+}
+
+type preferredLeaderSchedulerConfig struct {
+	// Preferences are the store labels in the order of preference.
+	Preferences schedule.LeaderPreference `json:"preferences"`
+}
+
+// preferredLeaderScheduler transfers the leaders to the most preferred healthy
+// stores holding the peers of the regions. The preference is shared with the
+// cluster so that balance-leader does not move the leaders back.
+type preferredLeaderScheduler struct {
+	*baseScheduler
+	filters []schedule.Filter
+
+	confLock sync.RWMutex
+	conf     preferredLeaderSchedulerConfig
+}
+
+// newPreferredLeaderScheduler creates a scheduler that transfers leaders to the
+// stores matching the labels in the order of preference.
+func newPreferredLeaderScheduler(limiter *schedule.Limiter, preference schedule.LeaderPreference) schedule.Scheduler {
+	s := &preferredLeaderScheduler{
+		baseScheduler: newBaseScheduler(limiter),
+		conf:          preferredLeaderSchedulerConfig{Preferences: preference},
+	}
+	s.filters = []schedule.Filter{
+		schedule.NewBlockFilter(s.GetName()),
+		schedule.NewStateFilter(s.GetName()),
+		schedule.NewHealthFilter(s.GetName()),
+		schedule.NewDisconnectFilter(s.GetName()),
+		schedule.NewRejectLeaderFilter(s.GetName()),
+	}
+	return s
+}
+
+func (s *preferredLeaderScheduler) GetName() string {
+	return "preferred-leader-scheduler"
+}
+
+func (s *preferredLeaderScheduler) GetType() string {
+	return "preferred-leader"
+}
+
+func (s *preferredLeaderScheduler) GetConfig() interface{} {
+	return preferredLeaderSchedulerConfig{Preferences: s.getPreference()}
+}
+
+// SetConfig replaces the preference, which takes effect on the cluster
+// immediately.
+func (s *preferredLeaderScheduler) SetConfig(cluster schedule.Cluster, data []byte) error {
+	s.confLock.Lock()
+	defer s.confLock.Unlock()
+	var conf preferredLeaderSchedulerConfig
+	if err := json.Unmarshal(data, &conf); err != nil {
+		return errors.Trace(err)
+	}
+	if conf.Preferences == nil {
+		return nil
+	}
+	if err := conf.Preferences.Validate(); err != nil {
+		return errors.Trace(err)
+	}
+	s.conf = conf
+	cluster.SetLeaderPreference(conf.Preferences)
+	return nil
+}
+
+func (s *preferredLeaderScheduler) getPreference() schedule.LeaderPreference {
+	s.confLock.RLock()
+	defer s.confLock.RUnlock()
+	return s.conf.Preferences
+}
+
+func (s *preferredLeaderScheduler) Prepare(cluster schedule.Cluster) error {
+	cluster.SetLeaderPreference(s.getPreference())
+	return nil
+}
+
+func (s *preferredLeaderScheduler) Cleanup(cluster schedule.Cluster) {
+	cluster.SetLeaderPreference(nil)
+}
+
+func (s *preferredLeaderScheduler) IsScheduleAllowed(cluster schedule.Cluster) bool {
+	return s.limiter.OperatorCount(schedule.OpLeader) < cluster.GetLeaderScheduleLimit()
+}
+
+func (s *preferredLeaderScheduler) Schedule(cluster schedule.Cluster, opInfluence schedule.OpInfluence) []*schedule.Operator {
+	schedulerCounter.WithLabelValues(s.GetName(), "schedule").Inc()
+	preference := s.getPreference()
+	// The leaders on the most preferred stores never need to be moved.
+	var sources []*core.StoreInfo
+	for _, store := range cluster.GetStores() {
+		if store.LeaderCount > 0 && preference.Rank(store) > 0 {
+			sources = append(sources, store)
+		}
+	}
+	if len(sources) == 0 {
+		schedulerCounter.WithLabelValues(s.GetName(), "no_store").Inc()
+		return nil
+	}
+
+	for _, i := range rand.Perm(len(sources)) {
+		source := sources[i]
+		region := cluster.RandLeaderRegion(source.GetId(), core.HealthRegionAllowLearner())
+		if region == nil {
+			continue
+		}
+		target := s.selectTarget(cluster, region, preference, preference.Rank(source))
+		if target == nil {
+			log.Debugf("[%s] region %d has no more preferred store", s.GetName(), region.GetId())
+			continue
+		}
+		schedulerCounter.WithLabelValues(s.GetName(), "new_operator").Inc()
+		step := schedule.TransferLeader{FromStore: source.GetId(), ToStore: target.GetId()}
+		op := schedule.NewOperator("preferred-leader", region.GetId(), region.GetRegionEpoch(), schedule.OpLeader, step)
+		return []*schedule.Operator{op}
+	}
+	schedulerCounter.WithLabelValues(s.GetName(), "no_target_store").Inc()
+	return nil
+}
+
+// selectTarget selects the most preferred follower store which can accept the
+// leader, and the one with the lowest leader score among the equally preferred
+// stores. It returns nil if no store is more preferred than the given rank.
+func (s *preferredLeaderScheduler) selectTarget(cluster schedule.Cluster, region *core.RegionInfo, preference schedule.LeaderPreference, rank int) *core.StoreInfo {
+	var target *core.StoreInfo
+	for _, store := range cluster.GetFollowerStores(region) {
+		if schedule.FilterTarget(cluster, store, s.filters) {
+			continue
+		}
+		r := preference.Rank(store)
+		if r > rank || (r == rank && (target == nil || schedule.StoreScore(cluster, store, core.LeaderKind, 0) >= schedule.StoreScore(cluster, target, core.LeaderKind, 0))) {
+			continue
+		}
+		target, rank = store, r
+	}
+	return target
+}
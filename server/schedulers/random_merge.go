@@ -34,15 +34,14 @@ type randomMergeScheduler struct {
 // newRandomMergeScheduler creates an admin scheduler that shuffles regions
 // between stores.
 func newRandomMergeScheduler(limiter *schedule.Limiter) schedule.Scheduler {
-	filters := []schedule.Filter{
-		schedule.NewStateFilter(),
-		schedule.NewHealthFilter(),
-	}
 	base := newBaseScheduler(limiter)
-	return &randomMergeScheduler{
-		baseScheduler: base,
-		selector:      schedule.NewRandomSelector(filters),
+	s := &randomMergeScheduler{baseScheduler: base}
+	filters := []schedule.Filter{
+		schedule.NewStateFilter(s.GetName()),
+		schedule.NewHealthFilter(s.GetName()),
 	}
+	s.selector = schedule.NewRandomSelector(filters)
+	return s
 }
 
 func (s *randomMergeScheduler) GetName() string {
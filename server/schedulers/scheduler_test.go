@@ -14,11 +14,14 @@
 package schedulers
 
 import (
+	"time"
+
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/pd/pkg/testutil"
 	"github.com/pingcap/pd/server/namespace"
 	"github.com/pingcap/pd/server/schedule"
+	"github.com/pingcap/pd/server/schedule/placement"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -213,6 +216,83 @@ func (s *testScatterRegionSuite) scatter(c *C, numStores, numRegions uint64) {
 	}
 }
 
+func (s *testScatterRegionSuite) TestScatterGroup(c *C) {
+	opt := schedule.NewMockSchedulerOptions()
+	tc := schedule.NewMockCluster(opt)
+
+	// Add stores 1~6.
+	for i := uint64(1); i <= 6; i++ {
+		tc.AddRegionStore(i, 0)
+	}
+
+	// Regions 1~6 are in group a and regions 7~12 are in group b, all of
+	// them are placed on stores 1~3 with leaders on store 1.
+	for i := uint64(1); i <= 12; i++ {
+		tc.AddLeaderRegion(i, 1, 2, 3)
+	}
+
+	scatterer := schedule.NewRegionScatterer(tc, namespace.DefaultClassifier)
+	for _, group := range []string{"a", "b"} {
+		for i := uint64(1); i <= 6; i++ {
+			regionID := i
+			if group == "b" {
+				regionID += 6
+			}
+			if op := scatterer.ScatterWithGroup(tc.GetRegion(regionID), group); op != nil {
+				tc.ApplyOperator(op)
+			}
+		}
+	}
+
+	for _, ids := range [][]uint64{{1, 2, 3, 4, 5, 6}, {7, 8, 9, 10, 11, 12}} {
+		countPeers := make(map[uint64]int)
+		countLeaders := make(map[uint64]int)
+		for _, id := range ids {
+			region := tc.GetRegion(id)
+			for _, peer := range region.GetPeers() {
+				countPeers[peer.GetStoreId()]++
+			}
+			countLeaders[region.Leader.GetStoreId()]++
+		}
+		// Each store should have the same number of peers and the leaders
+		// should not be gathered in the group.
+		c.Assert(countPeers, HasLen, 6)
+		for _, count := range countPeers {
+			c.Assert(count, Equals, 3)
+		}
+		for _, count := range countLeaders {
+			c.Assert(count, LessEqual, 2)
+		}
+	}
+}
+
+func (s *testScatterRegionSuite) TestRangeConstraint(c *C) {
+	opt := schedule.NewMockSchedulerOptions()
+	tc := schedule.NewMockCluster(opt)
+
+	// Stores 1~3 are ssd and stores 4~6 are hdd.
+	for i := uint64(1); i <= 6; i++ {
+		disk := "ssd"
+		if i > 3 {
+			disk = "hdd"
+		}
+		tc.AddLabelsStore(i, 0, map[string]string{"disk": disk})
+	}
+	tc.AddLeaderRegionWithRange(1, "", "", 4, 5, 6)
+	c.Assert(tc.RangeConstraintManager.SetConstraint(&placement.RangeConstraint{
+		ID:               "ssd",
+		LabelConstraints: []placement.LabelConstraint{{Key: "disk", Op: placement.In, Values: []string{"ssd"}}},
+	}), IsNil)
+
+	scatterer := schedule.NewRegionScatterer(tc, namespace.DefaultClassifier)
+	op := scatterer.Scatter(tc.GetRegion(1))
+	c.Assert(op, NotNil)
+	tc.ApplyOperator(op)
+	for _, peer := range tc.GetRegion(1).GetPeers() {
+		c.Assert(peer.GetStoreId(), LessEqual, uint64(3))
+	}
+}
+
 var _ = Suite(&testRejectLeaderSuite{})
 
 type testRejectLeaderSuite struct{}
@@ -270,3 +350,272 @@ func (s *testRejectLeaderSuite) TestRejectLeader(c *C) {
 	op = sl.Schedule(tc, schedule.NewOpInfluence(nil, tc))
 	testutil.CheckTransferLeader(c, op[0], schedule.OpLeader, 1, 2)
 }
+
+func (s *testRejectLeaderSuite) TestLabelProperties(c *C) {
+	opt := schedule.NewMockSchedulerOptions()
+	opt.LabelProperties = map[string][]*metapb.StoreLabel{
+		schedule.PreferLeader: {{Key: "leader", Value: "true"}},
+		schedule.RejectRegion: {{Key: "drain", Value: "true"}},
+	}
+	tc := schedule.NewMockCluster(opt)
+	tc.AddLabelsStore(1, 1, map[string]string{"leader": "true"})
+	tc.AddLabelsStore(2, 1, nil)
+	tc.AddLabelsStore(3, 1, nil)
+	tc.AddLabelsStore(4, 0, nil)
+	tc.AddLabelsStore(5, 1, map[string]string{"drain": "true"})
+	tc.AddLeaderRegion(1, 2, 1, 3)
+	sl, err := schedule.CreateScheduler("label", schedule.NewLimiter())
+	c.Assert(err, IsNil)
+
+	// The leader is transferred to the preferLeader store, and balance-leader
+	// does not move it back.
+	op := sl.Schedule(tc, schedule.NewOpInfluence(nil, tc))
+	testutil.CheckTransferLeader(c, op[0], schedule.OpLeader, 2, 1)
+	tc.AddLeaderRegion(1, 1, 2, 3)
+	tc.UpdateLeaderCount(1, 10)
+	c.Assert(sl.Schedule(tc, schedule.NewOpInfluence(nil, tc)), IsNil)
+	bl, err := schedule.CreateScheduler("balance-leader", schedule.NewLimiter())
+	c.Assert(err, IsNil)
+	c.Assert(bl.Schedule(tc, schedule.NewOpInfluence(nil, tc)), IsNil)
+
+	// The peer is moved out of the rejectRegion store.
+	tc.AddLeaderRegion(1, 1, 2, 5)
+	op = sl.Schedule(tc, schedule.NewOpInfluence(nil, tc))
+	testutil.CheckTransferPeer(c, op[0], schedule.OpAdmin, 5, 4)
+}
+
+func (s *testRejectLeaderSuite) TestNoBalance(c *C) {
+	opt := schedule.NewMockSchedulerOptions()
+	opt.LabelProperties = map[string][]*metapb.StoreLabel{
+		schedule.NoBalance: {{Key: "balance", Value: "false"}},
+	}
+	opt.SetMaxReplicas(1)
+	tc := schedule.NewMockCluster(opt)
+	tc.AddLabelsStore(1, 16, map[string]string{"balance": "false"})
+	tc.AddLabelsStore(2, 1, nil)
+	tc.AddLeaderRegion(1, 1)
+	sb, err := schedule.CreateScheduler("balance-region", schedule.NewLimiter())
+	c.Assert(err, IsNil)
+	c.Assert(sb.Schedule(tc, schedule.NewOpInfluence(nil, tc)), IsNil)
+
+	// The store can be balanced after the property is removed.
+	opt.LabelProperties = nil
+	sb, err = schedule.CreateScheduler("balance-region", schedule.NewLimiter())
+	c.Assert(err, IsNil)
+	testutil.CheckTransferPeer(c, sb.Schedule(tc, schedule.NewOpInfluence(nil, tc))[0], schedule.OpBalance, 1, 2)
+}
+
+var _ = Suite(&testEvictSlowStoreSuite{})
+
+type testEvictSlowStoreSuite struct{}
+
+func (s *testEvictSlowStoreSuite) TestEvictSlowStore(c *C) {
+	opt := schedule.NewMockSchedulerOptions()
+	tc := schedule.NewMockCluster(opt)
+	tc.AddLeaderStore(1, 1)
+	tc.AddLeaderStore(2, 0)
+	tc.AddLeaderStore(3, 0)
+	tc.AddLeaderRegion(1, 1, 2, 3)
+
+	sche, err := schedule.CreateScheduler("evict-slow-store", schedule.NewLimiter())
+	c.Assert(err, IsNil)
+	es := sche.(*evictSlowStoreScheduler)
+	c.Assert(es.SetConfig(tc, []byte(`{"evict-duration": "10m"}`)), NotNil)
+	c.Assert(es.SetConfig(tc, []byte(`{"evict-duration": "0s", "recover-duration": "1m"}`)), IsNil)
+	// The stores are checked in IsScheduleAllowed like the coordinator does.
+	run := func() []*schedule.Operator {
+		if !es.IsScheduleAllowed(tc) {
+			return nil
+		}
+		return es.Schedule(tc, schedule.NewOpInfluence(nil, tc))
+	}
+	c.Assert(run(), IsNil)
+
+	// The leader is evicted from the busy store, and the store is blocked
+	// from receiving leaders only.
+	tc.SetStoreBusy(1, true)
+	testutil.CheckTransferLeaderFrom(c, run()[0], schedule.OpLeader, 1)
+	c.Assert(tc.GetStore(1).IsLeaderBlocked(), IsTrue)
+	c.Assert(tc.GetStore(1).IsBlocked(), IsFalse)
+	c.Assert(schedule.NewRejectLeaderFilter("").FilterTarget(tc, tc.GetStore(1)), IsTrue)
+
+	// Only one store is evicted at the same time.
+	tc.SetStoreBusy(2, true)
+	run()
+	e := es.Explain(tc, schedule.OpInfluence{}, 0, 2)
+	c.Assert(e.Details, HasLen, 1)
+	c.Assert(e.Details[0].Type, Equals, "unhealthy")
+	c.Assert(e.Result, Equals, "stores [1] are evicted")
+
+	// The store is kept evicted until it is healthy for the recover duration.
+	tc.SetStoreBusy(1, false)
+	testutil.CheckTransferLeaderFrom(c, run()[0], schedule.OpLeader, 1)
+	es.stores[1].healthySince = es.stores[1].healthySince.Add(-time.Minute)
+
+	// The store is recovered even if the leader limit is reached.
+	opt.LeaderScheduleLimit = 0
+	c.Assert(run(), IsNil)
+	c.Assert(tc.GetStore(1).IsLeaderBlocked(), IsFalse)
+	c.Assert(es.Explain(tc, schedule.OpInfluence{}, 0, 1).Details[0].Type, Equals, "healthy")
+	c.Assert(es.Explain(tc, schedule.OpInfluence{}, 0, 0).Result, Equals, "stores [2] are evicted")
+	opt.LeaderScheduleLimit = 4
+
+	// The store whose heartbeats keep arriving late is evicted.
+	tc.SetStoreBusy(2, false)
+	es.stores[2].healthySince = es.stores[2].healthySince.Add(-time.Minute)
+	run()
+	c.Assert(es.Explain(tc, schedule.OpInfluence{}, 0, 0).Result, Equals, "no store is evicted")
+	es.stores[1].lastHeartbeat = tc.GetStore(1).LastHeartbeatTS.Add(-defaultSlowHeartbeatInterval - time.Second)
+	testutil.CheckTransferLeaderFrom(c, run()[0], schedule.OpLeader, 1)
+
+	es.Cleanup(tc)
+	c.Assert(tc.GetStore(1).IsLeaderBlocked(), IsFalse)
+}
+
+var _ = Suite(&testPreferredLeaderSuite{})
+
+type testPreferredLeaderSuite struct{}
+
+func (s *testPreferredLeaderSuite) TestPreferredLeader(c *C) {
+	opt := schedule.NewMockSchedulerOptions()
+	tc := schedule.NewMockCluster(opt)
+
+	// Add stores 1,2,3 in zone z1,z2,z3.
+	tc.AddLabelsStore(1, 1, map[string]string{"zone": "z1"})
+	tc.AddLabelsStore(2, 1, map[string]string{"zone": "z2"})
+	tc.AddLabelsStore(3, 1, map[string]string{"zone": "z3"})
+	tc.UpdateLeaderCount(3, 1)
+	tc.AddLeaderRegion(1, 3, 1, 2)
+
+	_, err := schedule.CreateScheduler("preferred-leader", schedule.NewLimiter())
+	c.Assert(err, NotNil)
+	_, err = schedule.CreateScheduler("preferred-leader", schedule.NewLimiter(), "zone")
+	c.Assert(err, NotNil)
+	sl, err := schedule.CreateScheduler("preferred-leader", schedule.NewLimiter(), "zone=z1", "zone=z2")
+	c.Assert(err, IsNil)
+	c.Assert(sl.Prepare(tc), IsNil)
+	c.Assert(tc.GetLeaderPreference(), HasLen, 2)
+
+	// Transfer the leader to the most preferred store.
+	op := sl.Schedule(tc, schedule.NewOpInfluence(nil, tc))
+	testutil.CheckTransferLeader(c, op[0], schedule.OpLeader, 3, 1)
+
+	// Fall back to store2 if store1 is down.
+	tc.SetStoreDown(1)
+	op = sl.Schedule(tc, schedule.NewOpInfluence(nil, tc))
+	testutil.CheckTransferLeader(c, op[0], schedule.OpLeader, 3, 2)
+
+	// The leader on store2 is not moved until store1 is healthy.
+	tc.UpdateLeaderCount(3, 0)
+	tc.UpdateLeaderCount(2, 1)
+	tc.AddLeaderRegion(1, 2, 1, 3)
+	c.Assert(sl.Schedule(tc, schedule.NewOpInfluence(nil, tc)), IsNil)
+	tc.SetStoreUp(1)
+	tc.SetStoreBusy(1, true)
+	c.Assert(sl.Schedule(tc, schedule.NewOpInfluence(nil, tc)), IsNil)
+	tc.SetStoreBusy(1, false)
+	op = sl.Schedule(tc, schedule.NewOpInfluence(nil, tc))
+	testutil.CheckTransferLeader(c, op[0], schedule.OpLeader, 2, 1)
+
+	// Balance-leader does not move leaders to less preferred stores.
+	tc.UpdateLeaderCount(1, 10)
+	tc.UpdateLeaderCount(2, 0)
+	tc.AddLeaderRegion(1, 1, 2, 3)
+	tc.AddLeaderRegion(2, 1, 2, 3)
+	bl, err := schedule.CreateScheduler("balance-leader", schedule.NewLimiter())
+	c.Assert(err, IsNil)
+	c.Assert(bl.Schedule(tc, schedule.NewOpInfluence(nil, tc)), IsNil)
+	c.Assert(sl.Schedule(tc, schedule.NewOpInfluence(nil, tc)), IsNil)
+
+	// Update the preference.
+	cs := sl.(schedule.ConfigurableScheduler)
+	c.Assert(cs.SetConfig(tc, []byte(`{"preferences":[]}`)), NotNil)
+	c.Assert(cs.SetConfig(tc, []byte(`{"preferences":[{"key":"zone"}]}`)), NotNil)
+	c.Assert(cs.SetConfig(tc, []byte(`{"preferences":[{"key":"zone","value":"z2"}]}`)), IsNil)
+	c.Assert(tc.GetLeaderPreference(), HasLen, 1)
+	op = sl.Schedule(tc, schedule.NewOpInfluence(nil, tc))
+	testutil.CheckTransferLeader(c, op[0], schedule.OpLeader, 1, 2)
+
+	// Balance-leader works as usual after the scheduler is removed.
+	sl.Cleanup(tc)
+	c.Assert(tc.GetLeaderPreference(), IsNil)
+	c.Assert(bl.Schedule(tc, schedule.NewOpInfluence(nil, tc)), NotNil)
+}
+
+var _ = Suite(&testSchedulerConfigSuite{})
+
+type testSchedulerConfigSuite struct{}
+
+func (s *testSchedulerConfigSuite) TestEvictLeader(c *C) {
+	opt := schedule.NewMockSchedulerOptions()
+	tc := schedule.NewMockCluster(opt)
+
+	// Add stores 1,2,3
+	tc.AddLeaderStore(1, 1)
+	tc.AddLeaderStore(2, 1)
+	tc.AddLeaderStore(3, 0)
+	// Add regions 1,2 with leaders in stores 1,2
+	tc.AddLeaderRegion(1, 1, 2, 3)
+	tc.AddLeaderRegion(2, 2, 1, 3)
+
+	sl, err := schedule.CreateScheduler("evict-leader", schedule.NewLimiter(), "1")
+	c.Assert(err, IsNil)
+	c.Assert(sl.Prepare(tc), IsNil)
+	cs, ok := sl.(schedule.ConfigurableScheduler)
+	c.Assert(ok, IsTrue)
+	c.Assert(cs.GetConfig(), DeepEquals, evictLeaderSchedulerConfig{StoreIDs: []uint64{1}})
+	c.Assert(tc.GetStore(1).IsBlocked(), IsTrue)
+
+	// Evict leaders from store 2 as well.
+	c.Assert(cs.SetConfig(tc, []byte(`{"store-id-list":[1,2]}`)), IsNil)
+	c.Assert(tc.GetStore(2).IsBlocked(), IsTrue)
+	tc.UpdateLeaderCount(1, 0)
+	tc.AddLeaderRegion(1, 3, 1, 2)
+	op := sl.Schedule(tc, schedule.NewOpInfluence(nil, tc))
+	testutil.CheckTransferLeader(c, op[0], schedule.OpLeader, 2, 3)
+
+	// Unknown stores are rejected and nothing changes.
+	c.Assert(cs.SetConfig(tc, []byte(`{"store-id-list":[2,4]}`)), NotNil)
+	c.Assert(cs.GetConfig(), DeepEquals, evictLeaderSchedulerConfig{StoreIDs: []uint64{1, 2}})
+	c.Assert(cs.SetConfig(tc, []byte(`{"store-id-list":[]}`)), NotNil)
+
+	// Removed stores are unblocked.
+	c.Assert(cs.SetConfig(tc, []byte(`{"store-id-list":[2]}`)), IsNil)
+	c.Assert(tc.GetStore(1).IsBlocked(), IsFalse)
+	sl.Cleanup(tc)
+	c.Assert(tc.GetStore(2).IsBlocked(), IsFalse)
+}
+
+func (s *testSchedulerConfigSuite) TestBalanceConfig(c *C) {
+	bl, err := schedule.CreateScheduler("balance-leader", schedule.NewLimiter())
+	c.Assert(err, IsNil)
+	cs := bl.(schedule.ConfigurableScheduler)
+	c.Assert(cs.GetConfig(), DeepEquals, balanceLeaderSchedulerConfig{RetryLimit: defaultBalanceLeaderRetryLimit})
+	c.Assert(cs.SetConfig(nil, []byte(`{"retry-limit":20}`)), IsNil)
+	c.Assert(cs.GetConfig(), DeepEquals, balanceLeaderSchedulerConfig{RetryLimit: 20})
+	c.Assert(cs.SetConfig(nil, []byte(`{"retry-limit":0}`)), NotNil)
+	c.Assert(cs.GetConfig(), DeepEquals, balanceLeaderSchedulerConfig{RetryLimit: 20})
+
+	br, err := schedule.CreateScheduler("balance-region", schedule.NewLimiter())
+	c.Assert(err, IsNil)
+	cs = br.(schedule.ConfigurableScheduler)
+	c.Assert(cs.SetConfig(nil, []byte(`{"retry-limit":5}`)), IsNil)
+	c.Assert(cs.GetConfig(), DeepEquals, balanceRegionSchedulerConfig{RetryLimit: 5})
+
+	hs, err := schedule.CreateScheduler("hot-region", schedule.NewLimiter())
+	c.Assert(err, IsNil)
+	cs = hs.(schedule.ConfigurableScheduler)
+	c.Assert(cs.SetConfig(nil, []byte(`{"schedule-factor":0.8}`)), IsNil)
+	conf := cs.GetConfig().(hotRegionSchedulerConfig)
+	c.Assert(conf.ScheduleFactor, Equals, 0.8)
+	c.Assert(conf.LimitFactor, Equals, defaultHotRegionLimitFactor)
+	c.Assert(cs.SetConfig(nil, []byte(`{"limit-factor":2}`)), NotNil)
+	c.Assert(cs.SetConfig(nil, []byte(`{"limit-factor":"x"}`)), NotNil)
+	c.Assert(conf.Priorities, DeepEquals, []string{"bytes"})
+	c.Assert(cs.SetConfig(nil, []byte(`{"priorities":["keys","bytes"]}`)), IsNil)
+	c.Assert(cs.GetConfig().(hotRegionSchedulerConfig).Priorities, DeepEquals, []string{"keys", "bytes"})
+	c.Assert(conf.Priorities, DeepEquals, []string{"bytes"})
+	c.Assert(cs.SetConfig(nil, []byte(`{"priorities":[]}`)), NotNil)
+	c.Assert(cs.SetConfig(nil, []byte(`{"priorities":["qps"]}`)), NotNil)
+	c.Assert(cs.SetConfig(nil, []byte(`{"priorities":["keys","keys"]}`)), NotNil)
+}
@@ -32,18 +32,17 @@ type shuffleLeaderScheduler struct {
 // newShuffleLeaderScheduler creates an admin scheduler that shuffles leaders
 // between stores.
 func newShuffleLeaderScheduler(limiter *schedule.Limiter) schedule.Scheduler {
-	filters := []schedule.Filter{
-		schedule.NewBlockFilter(),
-		schedule.NewStateFilter(),
-		schedule.NewHealthFilter(),
-		schedule.NewDisconnectFilter(),
-		schedule.NewRejectLeaderFilter(),
-	}
 	base := newBaseScheduler(limiter)
-	return &shuffleLeaderScheduler{
-		baseScheduler: base,
-		selector:      schedule.NewRandomSelector(filters),
+	s := &shuffleLeaderScheduler{baseScheduler: base}
+	filters := []schedule.Filter{
+		schedule.NewBlockFilter(s.GetName()),
+		schedule.NewStateFilter(s.GetName()),
+		schedule.NewHealthFilter(s.GetName()),
+		schedule.NewDisconnectFilter(s.GetName()),
+		schedule.NewRejectLeaderFilter(s.GetName()),
 	}
+	s.selector = schedule.NewRandomSelector(filters)
+	return s
 }
 
 func (s *shuffleLeaderScheduler) GetName() string {
@@ -69,7 +68,7 @@ func (s *shuffleLeaderScheduler) Schedule(cluster schedule.Cluster, opInfluence
 		schedulerCounter.WithLabelValues(s.GetName(), "no_target_store").Inc()
 		return nil
 	}
-	region := cluster.RandFollowerRegion(targetStore.GetId(), core.HealthRegion())
+	region := cluster.RandFollowerRegion(targetStore.GetId(), core.HealthRegionAllowLearner())
 	if region == nil {
 		schedulerCounter.WithLabelValues(s.GetName(), "no_follower").Inc()
 		return nil
@@ -32,15 +32,15 @@ type shuffleRegionScheduler struct {
 // newShuffleRegionScheduler creates an admin scheduler that shuffles regions
 // between stores.
 func newShuffleRegionScheduler(limiter *schedule.Limiter) schedule.Scheduler {
-	filters := []schedule.Filter{
-		schedule.NewStateFilter(),
-		schedule.NewHealthFilter(),
-	}
 	base := newBaseScheduler(limiter)
-	return &shuffleRegionScheduler{
-		baseScheduler: base,
-		selector:      schedule.NewRandomSelector(filters),
+	s := &shuffleRegionScheduler{baseScheduler: base}
+	filters := []schedule.Filter{
+		schedule.NewStateFilter(s.GetName()),
+		schedule.NewHealthFilter(s.GetName()),
+		schedule.NewRejectRegionFilter(s.GetName()),
 	}
+	s.selector = schedule.NewRandomSelector(filters)
+	return s
 }
 
 func (s *shuffleRegionScheduler) GetName() string {
@@ -63,7 +63,7 @@ func (s *shuffleRegionScheduler) Schedule(cluster schedule.Cluster, opInfluence
 		return nil
 	}
 
-	excludedFilter := schedule.NewExcludedFilter(nil, region.GetStoreIds())
+	excludedFilter := schedule.NewExcludedFilter(s.GetName(), nil, region.GetStoreIds())
 	newPeer := scheduleAddPeer(cluster, s.selector, excludedFilter)
 	if newPeer == nil {
 		schedulerCounter.WithLabelValues(s.GetName(), "no_new_peer").Inc()
@@ -14,6 +14,7 @@
 package schedulers
 
 import (
+	"math"
 	"time"
 
 	"github.com/montanaflynn/stats"
@@ -86,21 +87,43 @@ func minDuration(a, b time.Duration) time.Duration {
 }
 
 func shouldBalance(cluster schedule.Cluster, source, target *core.StoreInfo, region *core.RegionInfo, kind core.ResourceKind, opInfluence schedule.OpInfluence) bool {
+	return explainBalance(cluster, source, target, region, kind, opInfluence).ShouldBalance
+}
+
+// explainBalance checks whether the region should be moved from the source
+// store to the target store and returns the scores used for the decision.
+// The average region size is used if the region is nil.
+func explainBalance(cluster schedule.Cluster, source, target *core.StoreInfo, region *core.RegionInfo, kind core.ResourceKind, opInfluence schedule.OpInfluence) *schedule.BalanceExplanation {
+	e := &schedule.BalanceExplanation{
+		AverageRegionSize: cluster.GetAverageRegionSize(),
+		TolerantSizeRatio: cluster.GetTolerantSizeRatio(),
+	}
+	if region != nil {
+		e.RegionSize = region.ApproximateSize
+	}
+
 	// The reason we use max(regionSize, averageRegionSize) to check is:
 	// 1. prevent moving small regions between stores with close scores, leading to unnecessary balance.
 	// 2. prevent moving huge regions, leading to over balance.
-	regionSize := region.ApproximateSize
-	if regionSize < cluster.GetAverageRegionSize() {
-		regionSize = cluster.GetAverageRegionSize()
+	regionSize := e.RegionSize
+	if regionSize < e.AverageRegionSize {
+		regionSize = e.AverageRegionSize
 	}
 
-	regionSize = int64(float64(regionSize) * cluster.GetTolerantSizeRatio())
-	sourceDelta := opInfluence.GetStoreInfluence(source.GetId()).ResourceSize(kind) - regionSize
-	targetDelta := opInfluence.GetStoreInfluence(target.GetId()).ResourceSize(kind) + regionSize
+	strategy := schedule.ScoreStrategy(cluster, kind)
+	e.TolerantSize = int64(float64(regionSize) * e.TolerantSizeRatio)
+	if strategy == core.ScoreByCount {
+		// Each resource counts 1 when scoring by count.
+		e.TolerantSize = int64(math.Round(e.TolerantSizeRatio))
+	}
+	sourceDelta := opInfluence.GetStoreInfluence(source.GetId()).ResourceDelta(kind, strategy) - e.TolerantSize
+	targetDelta := opInfluence.GetStoreInfluence(target.GetId()).ResourceDelta(kind, strategy) + e.TolerantSize
 
 	// Make sure after move, source score is still greater than target score.
-	return source.ResourceScore(kind, cluster.GetHighSpaceRatio(), cluster.GetLowSpaceRatio(), sourceDelta) >
-		target.ResourceScore(kind, cluster.GetHighSpaceRatio(), cluster.GetLowSpaceRatio(), targetDelta)
+	e.SourceScore = schedule.StoreScore(cluster, source, kind, sourceDelta)
+	e.TargetScore = schedule.StoreScore(cluster, target, kind, targetDelta)
+	e.ShouldBalance = e.SourceScore > e.TargetScore
+	return e
 }
 
 func adjustBalanceLimit(cluster schedule.Cluster, kind core.ResourceKind) uint64 {
@@ -36,6 +36,7 @@ import (
 	"github.com/pingcap/pd/pkg/logutil"
 	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/server/namespace"
+	"github.com/pingcap/pd/server/schedule"
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
 )
@@ -214,6 +215,9 @@ func (s *Server) startServer() error {
 	if s.classifier, err = namespace.CreateClassifier(s.cfg.NamespaceClassifier, s.kv, s.idAlloc); err != nil {
 		return errors.Trace(err)
 	}
+	if h, ok := s.classifier.(namespace.Hookable); ok {
+		h.SetHook(&classifierHook{s: s})
+	}
 
 	// Server has started.
 	atomic.StoreInt64(&s.isServing, 1)
@@ -458,8 +462,8 @@ func (s *Server) leaderTxn(cs ...clientv3.Cmp) clientv3.Txn {
 // GetConfig gets the config information.
 func (s *Server) GetConfig() *Config {
 	cfg := s.cfg.clone()
-	cfg.Schedule = *s.scheduleOpt.load()
-	cfg.Replication = *s.scheduleOpt.rep.load()
+	cfg.Schedule = *s.scheduleOpt.load().clone()
+	cfg.Replication = *s.scheduleOpt.rep.load().clone()
 	namespaces := make(map[string]NamespaceConfig)
 	for name, opt := range s.scheduleOpt.ns {
 		namespaces[name] = *opt.load()
@@ -472,8 +476,22 @@ func (s *Server) GetConfig() *Config {
 
 // GetScheduleConfig gets the balance config information.
 func (s *Server) GetScheduleConfig() *ScheduleConfig {
-	cfg := &ScheduleConfig{}
-	*cfg = *s.scheduleOpt.load()
+	return s.scheduleOpt.load().clone()
+}
+
+// GetEffectiveScheduleConfig gets the balance config information with the
+// options overridden by the active time window and the adaptive limits.
+func (s *Server) GetEffectiveScheduleConfig() *EffectiveScheduleConfig {
+	return s.scheduleOpt.GetEffectiveScheduleConfig()
+}
+
+// GetStoreScheduleConfig gets the balance config information which applies
+// to the store, with the options overridden by the store's namespace.
+func (s *Server) GetStoreScheduleConfig(store *core.StoreInfo) *ScheduleConfig {
+	cfg := s.GetScheduleConfig()
+	name := s.classifier.GetStoreNamespace(store)
+	cfg.LeaderScoreStrategy = s.scheduleOpt.GetLeaderScoreStrategy(name)
+	cfg.RegionScoreStrategy = s.scheduleOpt.GetRegionScoreStrategy(name)
 	return cfg
 }
 
@@ -493,9 +511,7 @@ func (s *Server) SetScheduleConfig(cfg ScheduleConfig) error {
 
 // GetReplicationConfig get the replication config.
 func (s *Server) GetReplicationConfig() *ReplicationConfig {
-	cfg := &ReplicationConfig{}
-	*cfg = *s.scheduleOpt.rep.load()
-	return cfg
+	return s.scheduleOpt.rep.load().clone()
 }
 
 // SetReplicationConfig sets the replication config.
@@ -510,34 +526,61 @@ func (s *Server) SetReplicationConfig(cfg ReplicationConfig) error {
 		return errors.Trace(err)
 	}
 	log.Infof("replication config is updated: %+v, old: %+v", cfg, old)
+	// The default placement rule follows the replicas and location labels.
+	if cluster := s.GetRaftCluster(); cluster != nil &&
+		(cfg.MaxReplicas != old.MaxReplicas || strings.Join(cfg.LocationLabels, ",") != strings.Join(old.LocationLabels, ",")) {
+		if err := cluster.cachedCluster.GetRuleManager().UpdateDefaultRule(int(cfg.MaxReplicas), cfg.LocationLabels); err != nil {
+			return errors.Trace(err)
+		}
+	}
 	return nil
 }
 
-// GetNamespaceConfig get the namespace config.
+// GetNamespaceConfig returns the options explicitly set by the namespace.
 func (s *Server) GetNamespaceConfig(name string) *NamespaceConfig {
-	if _, ok := s.scheduleOpt.ns[name]; !ok {
-		return &NamespaceConfig{}
-	}
-
-	cfg := &NamespaceConfig{
-		LeaderScheduleLimit:  s.scheduleOpt.GetLeaderScheduleLimit(name),
-		RegionScheduleLimit:  s.scheduleOpt.GetRegionScheduleLimit(name),
-		ReplicaScheduleLimit: s.scheduleOpt.GetReplicaScheduleLimit(name),
-		MaxReplicas:          uint64(s.scheduleOpt.GetMaxReplicas(name)),
+	if n, ok := s.scheduleOpt.ns[name]; ok {
+		return n.load().clone()
 	}
+	return &NamespaceConfig{}
+}
 
-	return cfg
+// NamespaceConfigDetail is the namespace config with the effective options
+// of the namespace.
+type NamespaceConfigDetail struct {
+	// Config is the options explicitly set by the namespace.
+	Config *NamespaceConfig `json:"config"`
+	// Schedule and Replication are the global configs overridden by the
+	// namespace config.
+	Schedule    *ScheduleConfig    `json:"schedule"`
+	Replication *ReplicationConfig `json:"replication"`
 }
 
-// GetNamespaceConfigWithAdjust get the namespace config that replace zero value with global config value.
-func (s *Server) GetNamespaceConfigWithAdjust(name string) *NamespaceConfig {
-	cfg := s.GetNamespaceConfig(name)
-	cfg.adjust(s.scheduleOpt)
-	return cfg
+// GetNamespaceConfigDetail returns the explicitly set and the effective
+// options of the namespace.
+func (s *Server) GetNamespaceConfigDetail(name string) *NamespaceConfigDetail {
+	sc, rc := s.scheduleOpt.getNamespaceConfigs(name)
+	return &NamespaceConfigDetail{
+		Config:      s.GetNamespaceConfig(name),
+		Schedule:    sc.clone(),
+		Replication: rc.clone(),
+	}
 }
 
 // SetNamespaceConfig sets the namespace config.
-func (s *Server) SetNamespaceConfig(name string, cfg NamespaceConfig) {
+func (s *Server) SetNamespaceConfig(name string, cfg NamespaceConfig) error {
+	if err := cfg.validate(); err != nil {
+		return errors.Trace(err)
+	}
+	sc, rc, err := cfg.resolve(s.scheduleOpt.load(), s.scheduleOpt.rep.load())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = sc.validate(); err != nil {
+		return errors.Trace(err)
+	}
+	if err = rc.validate(); err != nil {
+		return errors.Trace(err)
+	}
 	if n, ok := s.scheduleOpt.ns[name]; ok {
 		old := s.scheduleOpt.ns[name].load()
 		n.store(&cfg)
@@ -548,6 +591,7 @@ func (s *Server) SetNamespaceConfig(name string, cfg NamespaceConfig) {
 		s.scheduleOpt.persist(s.kv)
 		log.Infof("namespace:%v config is added: %+v", name, cfg)
 	}
+	return nil
 }
 
 // DeleteNamespaceConfig deletes the namespace config.
@@ -560,8 +604,46 @@ func (s *Server) DeleteNamespaceConfig(name string) {
 	}
 }
 
+// RenameNamespaceConfig moves the namespace config to the new name.
+func (s *Server) RenameNamespaceConfig(oldName, newName string) {
+	if n, ok := s.scheduleOpt.ns[oldName]; ok {
+		delete(s.scheduleOpt.ns, oldName)
+		s.scheduleOpt.ns[newName] = n
+		s.scheduleOpt.persist(s.kv)
+		log.Infof("namespace:%v config is renamed to %v", oldName, newName)
+	}
+}
+
+// classifierHook keeps the namespace configs consistent with the namespaces
+// of the classifier.
+type classifierHook struct {
+	s *Server
+}
+
+func (h *classifierHook) GetRegionCount(startKey, endKey []byte) int {
+	cluster := h.s.GetRaftCluster()
+	if cluster == nil {
+		return 0
+	}
+	return cluster.GetRegionStats(startKey, endKey).Count
+}
+
+func (h *classifierHook) OnNamespaceRenamed(oldName, newName string) {
+	h.s.RenameNamespaceConfig(oldName, newName)
+}
+
+func (h *classifierHook) OnNamespaceDeleted(name string) {
+	h.s.DeleteNamespaceConfig(name)
+}
+
 // SetLabelProperty inserts a label property config.
 func (s *Server) SetLabelProperty(typ, labelKey, labelValue string) error {
+	if err := schedule.ValidateLabelPropertyType(typ); err != nil {
+		return errors.Trace(err)
+	}
+	if labelKey == "" || labelValue == "" {
+		return errors.Errorf("invalid label %s=%s", labelKey, labelValue)
+	}
 	s.scheduleOpt.SetLabelProperty(typ, labelKey, labelValue)
 	err := s.scheduleOpt.persist(s.kv)
 	if err != nil {
@@ -587,6 +669,17 @@ func (s *Server) GetLabelProperty() LabelPropertyConfig {
 	return s.scheduleOpt.loadLabelPropertyConfig().clone()
 }
 
+// GetStoreLabelProperties returns the label property types the store has.
+func (s *Server) GetStoreLabelProperties(store *core.StoreInfo) []string {
+	var properties []string
+	for _, typ := range schedule.LabelPropertyTypes() {
+		if s.scheduleOpt.CheckLabelProperty(typ, store.Labels) {
+			properties = append(properties, typ)
+		}
+	}
+	return properties
+}
+
 // SetClusterVersion sets the version of cluster.
 func (s *Server) SetClusterVersion(v string) error {
 	version, err := ParseVersion(v)
@@ -27,6 +27,7 @@ import (
 	"github.com/pingcap/pd/pkg/typeutil"
 
 	// Register namespace classifiers.
+	_ "github.com/pingcap/pd/keyrange"
 	_ "github.com/pingcap/pd/table"
 )
 
@@ -61,6 +61,29 @@ func (k Key) IsMeta() bool {
 	return bytes.HasPrefix(key, metaPrefix)
 }
 
+// EncodeRange returns the encoded key range of the table, which contains all
+// the rows and indexes of the table.
+func EncodeRange(tableID int64) (Key, Key) {
+	return encodeTableKey(tableID), encodeTableKey(tableID + 1)
+}
+
+func encodeTableKey(tableID int64) Key {
+	key := append([]byte(nil), tablePrefix...)
+	return encodeBytes(EncodeInt(key, tableID))
+}
+
+// EncodeInt appends the encoded value to slice b and returns the appended slice.
+// EncodeInt guarantees that the encoded value is in ascending order for comparison.
+func EncodeInt(b []byte, v int64) []byte {
+	var data [8]byte
+	binary.BigEndian.PutUint64(data[:], encodeIntToCmpUint(v))
+	return append(b, data[:]...)
+}
+
+func encodeIntToCmpUint(v int64) uint64 {
+	return uint64(v) ^ signMask
+}
+
 // DecodeInt decodes value encoded by EncodeInt before.
 // It returns the leftover un-decoded slice, decoded value if no error.
 func DecodeInt(b []byte) ([]byte, int64, error) {
@@ -112,3 +135,31 @@ func decodeBytes(b []byte) ([]byte, []byte, error) {
 	}
 	return b, data, nil
 }
+
+var pads = make([]byte, encGroupSize)
+
+// encodeBytes guarantees the encoded value is in ascending order for comparison.
+// The data is encoded as groups of 8 bytes padded with 0, each group is followed
+// by a marker which is `0xFF - padding 0 count`.
+func encodeBytes(data []byte) Key {
+	// Allocate more space to avoid unnecessary slice growing.
+	// Assume that the byte slice size is about `(len(data) / encGroupSize + 1) * (encGroupSize + 1)` bytes,
+	// that is `(len(data) / 8 + 1) * 9` in our implement.
+	dLen := len(data)
+	result := make([]byte, 0, (dLen/encGroupSize+1)*(encGroupSize+1))
+	for idx := 0; idx <= dLen; idx += encGroupSize {
+		remain := dLen - idx
+		padCount := 0
+		if remain >= encGroupSize {
+			result = append(result, data[idx:idx+encGroupSize]...)
+		} else {
+			padCount = encGroupSize - remain
+			result = append(result, data[idx:]...)
+			result = append(result, pads[:padCount]...)
+		}
+
+		marker := encMarker - byte(padCount)
+		result = append(result, marker)
+	}
+	return result
+}
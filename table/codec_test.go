@@ -23,35 +23,10 @@ func TestTable(t *testing.T) {
 	TestingT(t)
 }
 
-var pads = make([]byte, encGroupSize)
-
 var _ = Suite(&testCodecSuite{})
 
 type testCodecSuite struct{}
 
-func encodeBytes(data []byte) Key {
-	// Allocate more space to avoid unnecessary slice growing.
-	// Assume that the byte slice size is about `(len(data) / encGroupSize + 1) * (encGroupSize + 1)` bytes,
-	// that is `(len(data) / 8 + 1) * 9` in our implement.
-	dLen := len(data)
-	result := make([]byte, 0, (dLen/encGroupSize+1)*(encGroupSize+1))
-	for idx := 0; idx <= dLen; idx += encGroupSize {
-		remain := dLen - idx
-		padCount := 0
-		if remain >= encGroupSize {
-			result = append(result, data[idx:idx+encGroupSize]...)
-		} else {
-			padCount = encGroupSize - remain
-			result = append(result, data[idx:]...)
-			result = append(result, pads[:padCount]...)
-		}
-
-		marker := encMarker - byte(padCount)
-		result = append(result, marker)
-	}
-	return result
-}
-
 func (s *testCodecSuite) TestDecodeBytes(c *C) {
 	key := "abcdefghijklmnopqrstuvwxyz"
 	for i := 0; i < len(key); i++ {
@@ -77,3 +52,13 @@ func (s *testCodecSuite) TestTableID(c *C) {
 	key = encodeBytes([]byte("t\x80\x00\x00\x00\x00\x00\xff"))
 	c.Assert(Key(key).TableID(), Equals, int64(0))
 }
+
+func (s *testCodecSuite) TestEncodeRange(c *C) {
+	startKey, endKey := EncodeRange(0xff)
+	c.Assert(startKey.TableID(), Equals, int64(0xff))
+	c.Assert(endKey.TableID(), Equals, int64(0x100))
+	c.Assert(string(startKey) < string(endKey), IsTrue)
+
+	key := encodeBytes([]byte("t\x80\x00\x00\x00\x00\x00\x00\xff_r\x01\x02"))
+	c.Assert(string(startKey) < string(key) && string(key) < string(endKey), IsTrue)
+}
@@ -20,6 +20,8 @@ import (
 	"net/http"
 	"path"
 	"regexp"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -42,7 +44,11 @@ type Namespace struct {
 	Name     string          `json:"Name"`
 	TableIDs map[int64]bool  `json:"table_ids,omitempty"`
 	StoreIDs map[uint64]bool `json:"store_ids,omitempty"`
-	Meta     bool            `json:"meta,omitempty"`
+	// StoreLabels is the label selector of the stores, a store which has
+	// all the labels belongs to the namespace unless it is bound to a
+	// namespace by ID explicitly.
+	StoreLabels map[string]string `json:"store_labels,omitempty"`
+	Meta        bool              `json:"meta,omitempty"`
 }
 
 // NewNamespace creates a new namespace
@@ -87,12 +93,63 @@ func (ns *Namespace) AddStoreID(storeID uint64) {
 	ns.StoreIDs[storeID] = true
 }
 
+// MatchStore returns true if the store has all the labels of the selector.
+func (ns *Namespace) MatchStore(store *core.StoreInfo) bool {
+	if len(ns.StoreLabels) == 0 {
+		return false
+	}
+	for key, value := range ns.StoreLabels {
+		if store.GetLabelValue(key) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseStoreLabels parses a label selector in the form of "k1=v1,k2=v2".
+func ParseStoreLabels(selector string) (map[string]string, error) {
+	labels := make(map[string]string)
+	for _, item := range strings.Split(selector, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" || strings.TrimSpace(kv[1]) == "" {
+			return nil, errors.Errorf("invalid store label %s, should be key=value", item)
+		}
+		key := strings.ToLower(strings.TrimSpace(kv[0]))
+		if _, ok := labels[key]; ok {
+			return nil, errors.Errorf("duplicate store label key %s", key)
+		}
+		labels[key] = strings.TrimSpace(kv[1])
+	}
+	return labels, nil
+}
+
+// storeLabelsConflict returns true if there may be a store matching both
+// selectors, which means that they don't require different values of a
+// common label.
+func storeLabelsConflict(a, b map[string]string) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return false
+	}
+	for key, value := range a {
+		if v, ok := b[key]; ok && v != value {
+			return false
+		}
+	}
+	return true
+}
+
 // tableNamespaceClassifier implements Classifier interface
 type tableNamespaceClassifier struct {
 	sync.RWMutex
-	nsInfo  *namespacesInfo
-	kv      *core.KV
-	idAlloc core.IDAllocator
+	nsInfo     *namespacesInfo
+	migrations *migrations
+	kv         *core.KV
+	idAlloc    core.IDAllocator
+	hook       namespace.Hook
 	http.Handler
 }
 
@@ -106,14 +163,23 @@ func NewTableNamespaceClassifier(kv *core.KV, idAlloc core.IDAllocator) (namespa
 		return nil, errors.Trace(err)
 	}
 	c := &tableNamespaceClassifier{
-		nsInfo:  nsInfo,
-		kv:      kv,
-		idAlloc: idAlloc,
+		nsInfo:     nsInfo,
+		migrations: newMigrations(),
+		kv:         kv,
+		idAlloc:    idAlloc,
 	}
 	c.Handler = newTableClassifierHandler(c)
 	return c, nil
 }
 
+// SetHook implements namespace.Hookable.
+func (c *tableNamespaceClassifier) SetHook(hook namespace.Hook) {
+	c.Lock()
+	defer c.Unlock()
+	c.hook = hook
+	c.migrations.setRegionCounter(hook.GetRegionCount)
+}
+
 func (c *tableNamespaceClassifier) GetAllNamespaces() []string {
 	c.RLock()
 	defer c.RUnlock()
@@ -129,14 +195,7 @@ func (c *tableNamespaceClassifier) GetAllNamespaces() []string {
 func (c *tableNamespaceClassifier) GetStoreNamespace(storeInfo *core.StoreInfo) string {
 	c.RLock()
 	defer c.RUnlock()
-
-	for name, ns := range c.nsInfo.namespaces {
-		_, ok := ns.StoreIDs[storeInfo.Id]
-		if ok {
-			return name
-		}
-	}
-	return namespace.DefaultNamespace
+	return c.nsInfo.getStoreNamespace(storeInfo).GetName()
 }
 
 func (c *tableNamespaceClassifier) GetRegionNamespace(regionInfo *core.RegionInfo) string {
@@ -205,6 +264,138 @@ func (c *tableNamespaceClassifier) CreateNamespace(name string) error {
 	return errors.Trace(err)
 }
 
+// DeleteNamespace deletes an empty Namespace.
+func (c *tableNamespaceClassifier) DeleteNamespace(name string) error {
+	c.Lock()
+	defer c.Unlock()
+
+	n := c.nsInfo.getNamespaceByName(name)
+	if n == nil {
+		return errors.Errorf("invalid namespace Name %s, not found", name)
+	}
+	if len(n.TableIDs) > 0 || n.Meta {
+		return errors.Errorf("namespace %s still has tables or meta", name)
+	}
+	if len(n.StoreIDs) > 0 || len(n.StoreLabels) > 0 {
+		return errors.Errorf("namespace %s still has stores", name)
+	}
+	if c.migrations.isMigratingTo(name) {
+		return errors.Errorf("tables are being migrated to namespace %s", name)
+	}
+
+	if c.kv != nil {
+		if err := c.kv.Delete(c.nsInfo.namespacePath(n.GetID())); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	c.nsInfo.deleteNamespace(name)
+	if c.hook != nil {
+		c.hook.OnNamespaceDeleted(name)
+	}
+	return nil
+}
+
+// RenameNamespace renames a Namespace, the namespace config is moved to the
+// new name as well.
+func (c *tableNamespaceClassifier) RenameNamespace(name, newName string) error {
+	c.Lock()
+	defer c.Unlock()
+
+	n := c.nsInfo.getNamespaceByName(name)
+	if n == nil {
+		return errors.Errorf("invalid namespace Name %s, not found", name)
+	}
+	if !regexp.MustCompile(`^\w+$`).MatchString(newName) {
+		return errors.New("Name should be 0-9, a-z or A-Z")
+	}
+	if newName == namespace.DefaultNamespace {
+		return errors.Errorf("%s is reserved as default namespace", newName)
+	}
+	if c.nsInfo.getNamespaceByName(newName) != nil {
+		return errors.New("Duplicate namespace Name")
+	}
+
+	ns := *n
+	ns.Name = newName
+	if err := c.putNamespaceLocked(&ns); err != nil {
+		return errors.Trace(err)
+	}
+	c.nsInfo.deleteNamespace(name)
+	c.migrations.rename(name, newName)
+	if c.hook != nil {
+		c.hook.OnNamespaceRenamed(name, newName)
+	}
+	return nil
+}
+
+// MigrateTable moves table ID to the target namespace, the regions of the
+// table are relocated to the stores of the target namespace by the
+// namespace checker under the replica schedule limit.
+func (c *tableNamespaceClassifier) MigrateTable(tableID int64, target string) error {
+	c.Lock()
+	defer c.Unlock()
+
+	var targetNs *Namespace
+	if target != namespace.DefaultNamespace {
+		if targetNs = c.nsInfo.getNamespaceByName(target); targetNs == nil {
+			return errors.Errorf("invalid namespace Name %s, not found", target)
+		}
+	}
+	var sourceNs *Namespace
+	for _, ns := range c.nsInfo.namespaces {
+		if ns.TableIDs[tableID] {
+			sourceNs = ns
+		}
+	}
+	if sourceNs.GetName() == target {
+		return errors.Errorf("table %d already belongs to %s", tableID, target)
+	}
+
+	if sourceNs != nil {
+		delete(sourceNs.TableIDs, tableID)
+		if err := c.putNamespaceLocked(sourceNs); err != nil {
+			sourceNs.AddTableID(tableID)
+			return errors.Trace(err)
+		}
+	}
+	if targetNs != nil {
+		targetNs.AddTableID(tableID)
+		if err := c.putNamespaceLocked(targetNs); err != nil {
+			delete(targetNs.TableIDs, tableID)
+			return errors.Trace(err)
+		}
+	}
+	log.Infof("start to migrate table %d from namespace %s to %s", tableID, sourceNs.GetName(), target)
+	c.migrations.start(tableID, sourceNs.GetName(), target)
+	return nil
+}
+
+// GetMigrations returns the progress of the table migrations.
+func (c *tableNamespaceClassifier) GetMigrations() []Migration {
+	return c.migrations.getProgress()
+}
+
+// RemoveMigration removes the progress of a table migration.
+func (c *tableNamespaceClassifier) RemoveMigration(tableID int64) error {
+	if !c.migrations.remove(tableID) {
+		return errors.Errorf("table %d is not migrating", tableID)
+	}
+	return nil
+}
+
+// IsMigrating implements namespace.Migrator.
+func (c *tableNamespaceClassifier) IsMigrating(regionInfo *core.RegionInfo) bool {
+	tableID := Key(regionInfo.StartKey).TableID()
+	return tableID != 0 && c.migrations.isMigrating(tableID)
+}
+
+// ReportRegion implements namespace.Migrator.
+func (c *tableNamespaceClassifier) ReportRegion(regionInfo *core.RegionInfo, placed bool) {
+	if tableID := Key(regionInfo.StartKey).TableID(); tableID != 0 {
+		c.migrations.report(tableID, regionInfo, placed)
+	}
+}
+
 // AddNamespaceTableID adds table ID to namespace.
 func (c *tableNamespaceClassifier) AddNamespaceTableID(name string, tableID int64) error {
 	c.Lock()
@@ -310,6 +501,34 @@ func (c *tableNamespaceClassifier) RemoveNamespaceStoreID(name string, storeID u
 	return c.putNamespaceLocked(n)
 }
 
+// SetNamespaceStoreLabels sets the store label selector of namespace, the
+// selector is removed if labels is empty.
+func (c *tableNamespaceClassifier) SetNamespaceStoreLabels(name string, labels map[string]string) error {
+	c.Lock()
+	defer c.Unlock()
+
+	n := c.nsInfo.getNamespaceByName(name)
+	if n == nil {
+		return errors.Errorf("invalid namespace Name %s, not found", name)
+	}
+
+	storeLabels := make(map[string]string, len(labels))
+	for key, value := range labels {
+		storeLabels[strings.ToLower(key)] = value
+	}
+	for _, ns := range c.nsInfo.namespaces {
+		if ns.Name != name && storeLabelsConflict(storeLabels, ns.StoreLabels) {
+			return errors.Errorf("store labels conflict with the store labels of %s", ns.Name)
+		}
+	}
+
+	n.StoreLabels = nil
+	if len(storeLabels) > 0 {
+		n.StoreLabels = storeLabels
+	}
+	return c.putNamespaceLocked(n)
+}
+
 func (c *tableNamespaceClassifier) putNamespaceLocked(ns *Namespace) error {
 	if c.kv != nil {
 		if err := c.nsInfo.saveNamespace(c.kv, ns); err != nil {
@@ -322,11 +541,18 @@ func (c *tableNamespaceClassifier) putNamespaceLocked(ns *Namespace) error {
 
 type namespacesInfo struct {
 	namespaces map[string]*Namespace
+	// storeNamespaces and labelNamespaces are the indexes to resolve the
+	// namespace of stores. labelNamespaces is keyed by the first label (in
+	// "key=value" form) of the store label selectors.
+	storeNamespaces map[uint64]*Namespace
+	labelNamespaces map[string][]*Namespace
 }
 
 func newNamespacesInfo() *namespacesInfo {
 	return &namespacesInfo{
-		namespaces: make(map[string]*Namespace),
+		namespaces:      make(map[string]*Namespace),
+		storeNamespaces: make(map[uint64]*Namespace),
+		labelNamespaces: make(map[string][]*Namespace),
 	}
 }
 
@@ -340,6 +566,52 @@ func (namespaceInfo *namespacesInfo) getNamespaceByName(name string) *Namespace
 
 func (namespaceInfo *namespacesInfo) setNamespace(item *Namespace) {
 	namespaceInfo.namespaces[item.Name] = item
+	namespaceInfo.buildStoreIndex()
+}
+
+func (namespaceInfo *namespacesInfo) deleteNamespace(name string) {
+	delete(namespaceInfo.namespaces, name)
+	namespaceInfo.buildStoreIndex()
+}
+
+func (namespaceInfo *namespacesInfo) buildStoreIndex() {
+	namespaceInfo.storeNamespaces = make(map[uint64]*Namespace)
+	namespaceInfo.labelNamespaces = make(map[string][]*Namespace)
+	for _, ns := range namespaceInfo.namespaces {
+		for storeID := range ns.StoreIDs {
+			namespaceInfo.storeNamespaces[storeID] = ns
+		}
+		if len(ns.StoreLabels) == 0 {
+			continue
+		}
+		keys := make([]string, 0, len(ns.StoreLabels))
+		for key := range ns.StoreLabels {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		label := labelIndexKey(keys[0], ns.StoreLabels[keys[0]])
+		namespaceInfo.labelNamespaces[label] = append(namespaceInfo.labelNamespaces[label], ns)
+	}
+}
+
+func labelIndexKey(key, value string) string {
+	return strings.ToLower(key) + "=" + value
+}
+
+// getStoreNamespace returns the namespace which the store is bound to by ID,
+// or the namespace whose store label selector matches the store.
+func (namespaceInfo *namespacesInfo) getStoreNamespace(store *core.StoreInfo) *Namespace {
+	if ns, ok := namespaceInfo.storeNamespaces[store.GetId()]; ok {
+		return ns
+	}
+	for _, label := range store.GetLabels() {
+		for _, ns := range namespaceInfo.labelNamespaces[labelIndexKey(label.GetKey(), label.GetValue())] {
+			if ns.MatchStore(store) {
+				return ns
+			}
+		}
+	}
+	return nil
 }
 
 func (namespaceInfo *namespacesInfo) getNamespaceCount() int {
@@ -88,6 +88,48 @@ func (s *testTableNamespaceSuite) TestTableNameSpaceGetStoreNamespace(c *C) {
 	c.Assert(classifier.GetStoreNamespace(storeInfo), Equals, "global")
 }
 
+func (s *testTableNamespaceSuite) TestNamespaceStoreLabels(c *C) {
+	classifier := s.newClassifier(c)
+	newStore := func(id uint64, labels ...string) *core.StoreInfo {
+		store := &metapb.Store{Id: id}
+		for i := 0; i < len(labels); i += 2 {
+			store.Labels = append(store.Labels, &metapb.StoreLabel{Key: labels[i], Value: labels[i+1]})
+		}
+		return core.NewStoreInfo(store)
+	}
+
+	_, err := ParseStoreLabels("tenant")
+	c.Assert(err, NotNil)
+	_, err = ParseStoreLabels("tenant=a,Tenant=b")
+	c.Assert(err, NotNil)
+	labels, err := ParseStoreLabels(" Tenant=a, disk=ssd ")
+	c.Assert(err, IsNil)
+	c.Assert(labels, DeepEquals, map[string]string{"tenant": "a", "disk": "ssd"})
+
+	c.Assert(classifier.SetNamespaceStoreLabels("ns1", labels), IsNil)
+	c.Assert(classifier.SetNamespaceStoreLabels("ns3", map[string]string{"tenant": "b"}), NotNil)
+	// Conflict with the selector of ns1 since a store may match both.
+	c.Assert(classifier.SetNamespaceStoreLabels("ns2", map[string]string{"disk": "ssd"}), NotNil)
+	c.Assert(classifier.SetNamespaceStoreLabels("ns2", map[string]string{"zone": "z1"}), NotNil)
+	c.Assert(classifier.SetNamespaceStoreLabels("ns2", map[string]string{"tenant": "b"}), IsNil)
+
+	c.Assert(classifier.GetStoreNamespace(newStore(10, "tenant", "a", "disk", "ssd", "zone", "z1")), Equals, "ns1")
+	c.Assert(classifier.GetStoreNamespace(newStore(11, "TENANT", "a", "disk", "ssd")), Equals, "ns1")
+	c.Assert(classifier.GetStoreNamespace(newStore(12, "tenant", "a", "disk", "hdd")), Equals, "global")
+	c.Assert(classifier.GetStoreNamespace(newStore(13, "tenant", "b")), Equals, "ns2")
+	c.Assert(classifier.GetStoreNamespace(newStore(14)), Equals, "global")
+	// The store bound by ID is not affected by the selectors.
+	c.Assert(classifier.GetStoreNamespace(newStore(testStore1, "tenant", "b")), Equals, "ns1")
+	c.Assert(classifier.GetStoreNamespace(newStore(testStore2, "tenant", "a", "disk", "ssd")), Equals, "ns2")
+
+	// Remove the selector.
+	c.Assert(classifier.SetNamespaceStoreLabels("ns1", nil), IsNil)
+	c.Assert(classifier.GetStoreNamespace(newStore(10, "tenant", "a", "disk", "ssd")), Equals, "global")
+	c.Assert(classifier.SetNamespaceStoreLabels("ns1", map[string]string{"disk": "ssd"}), NotNil)
+	c.Assert(classifier.SetNamespaceStoreLabels("ns1", map[string]string{"disk": "ssd", "tenant": "c"}), IsNil)
+	c.Assert(classifier.GetStoreNamespace(newStore(10, "tenant", "c", "disk", "ssd")), Equals, "ns1")
+}
+
 func (s *testTableNamespaceSuite) TestTableNameSpaceGetRegionNamespace(c *C) {
 	type Case struct {
 		endcoded         bool
@@ -199,3 +241,162 @@ func (s *testTableNamespaceSuite) TestNamespaceOperation(c *C) {
 	c.Assert(tableClassifier.RemoveMeta("test1"), IsNil)
 	c.Assert(tableClassifier.AddMetaToNamespace("test2"), IsNil)
 }
+
+type mockHook struct {
+	regionCount int
+	configs     map[string]bool
+}
+
+func (h *mockHook) GetRegionCount(startKey, endKey []byte) int {
+	return h.regionCount
+}
+
+func (h *mockHook) OnNamespaceRenamed(oldName, newName string) {
+	if h.configs[oldName] {
+		delete(h.configs, oldName)
+		h.configs[newName] = true
+	}
+}
+
+func (h *mockHook) OnNamespaceDeleted(name string) {
+	delete(h.configs, name)
+}
+
+func (s *testTableNamespaceSuite) TestNamespaceLifecycle(c *C) {
+	kv := core.NewKV(core.NewMemoryKV())
+	classifier, err := NewTableNamespaceClassifier(kv, core.NewMockIDAllocator())
+	c.Assert(err, IsNil)
+	tableClassifier := classifier.(*tableNamespaceClassifier)
+	hook := &mockHook{configs: map[string]bool{"test1": true, "test2": true}}
+	tableClassifier.SetHook(hook)
+	c.Assert(tableClassifier.CreateNamespace("test1"), IsNil)
+	c.Assert(tableClassifier.CreateNamespace("test2"), IsNil)
+	c.Assert(tableClassifier.AddNamespaceTableID("test1", 1), IsNil)
+	c.Assert(tableClassifier.AddNamespaceStoreID("test2", 1), IsNil)
+
+	// Rename.
+	c.Assert(tableClassifier.RenameNamespace("test_not_exist", "test3"), NotNil)
+	c.Assert(tableClassifier.RenameNamespace("test1", "test2"), NotNil)
+	c.Assert(tableClassifier.RenameNamespace("test1", "global"), NotNil)
+	c.Assert(tableClassifier.RenameNamespace("test1", "(invalid_name"), NotNil)
+	c.Assert(tableClassifier.RenameNamespace("test1", "test3"), IsNil)
+	c.Assert(tableClassifier.IsNamespaceExist("test1"), IsFalse)
+	c.Assert(tableClassifier.IsNamespaceExist("test3"), IsTrue)
+	c.Assert(tableClassifier.nsInfo.IsTableIDExist(1), IsTrue)
+	c.Assert(hook.configs, DeepEquals, map[string]bool{"test2": true, "test3": true})
+
+	// Migrate.
+	region := func(tableID int64, id uint64, suffix string) *core.RegionInfo {
+		startKey := encodeBytes(append(EncodeInt([]byte("t"), tableID), suffix...))
+		endKey := encodeBytes(append(EncodeInt([]byte("t"), tableID), (suffix + "\xff")...))
+		return core.NewRegionInfo(&metapb.Region{Id: id, StartKey: startKey, EndKey: endKey}, nil)
+	}
+	c.Assert(tableClassifier.MigrateTable(1, "test3"), NotNil)
+	c.Assert(tableClassifier.MigrateTable(1, "test_not_exist"), NotNil)
+	c.Assert(tableClassifier.IsMigrating(region(1, 1, "a")), IsFalse)
+	c.Assert(tableClassifier.MigrateTable(1, "test2"), IsNil)
+	c.Assert(tableClassifier.GetRegionNamespace(region(1, 1, "a")), Equals, "test2")
+	c.Assert(tableClassifier.IsMigrating(region(1, 1, "a")), IsTrue)
+	c.Assert(tableClassifier.IsMigrating(region(2, 2, "a")), IsFalse)
+
+	tableClassifier.ReportRegion(region(1, 1, "a"), true)
+	tableClassifier.ReportRegion(region(1, 2, "b"), false)
+	tableClassifier.ReportRegion(region(2, 3, "a"), false)
+	migrations := tableClassifier.GetMigrations()
+	c.Assert(migrations, HasLen, 1)
+	c.Assert(migrations[0].Source, Equals, "test3")
+	c.Assert(migrations[0].Target, Equals, "test2")
+	c.Assert(migrations[0].Regions, Equals, 2)
+	c.Assert(migrations[0].Placed, Equals, 1)
+	c.Assert(migrations[0].Finished, IsFalse)
+
+	// Only the empty namespaces which are not the migration target can be
+	// deleted.
+	c.Assert(tableClassifier.DeleteNamespace("test_not_exist"), NotNil)
+	c.Assert(tableClassifier.RemoveNamespaceStoreID("test2", 1), IsNil)
+	c.Assert(tableClassifier.MigrateTable(1, "global"), IsNil)
+	c.Assert(tableClassifier.MigrateTable(1, "test2"), IsNil)
+	c.Assert(tableClassifier.DeleteNamespace("test2"), NotNil)
+	c.Assert(tableClassifier.MigrateTable(1, "global"), IsNil)
+
+	// The regions merged into a new region are removed.
+	tableStart, tableEnd := EncodeRange(1)
+	key := func(suffix string) []byte {
+		return encodeBytes(append(EncodeInt([]byte("t"), 1), suffix...))
+	}
+	tableClassifier.ReportRegion(region(1, 1, "a"), true)
+	merged := core.NewRegionInfo(&metapb.Region{Id: 3, StartKey: key("a"), EndKey: key("c")}, nil)
+	tableClassifier.ReportRegion(merged, true)
+	migrations = tableClassifier.GetMigrations()
+	c.Assert(migrations[0].Source, Equals, "test2")
+	c.Assert(migrations[0].Regions, Equals, 1)
+	c.Assert(migrations[0].Finished, IsFalse)
+
+	// The migration is finished after all the regions of the table range are
+	// placed.
+	tableClassifier.ReportRegion(core.NewRegionInfo(&metapb.Region{Id: 4, StartKey: tableStart, EndKey: key("a")}, nil), true)
+	tableClassifier.ReportRegion(core.NewRegionInfo(&metapb.Region{Id: 5, StartKey: key("c"), EndKey: tableEnd}, nil), false)
+	c.Assert(tableClassifier.GetMigrations()[0].Finished, IsFalse)
+	tableClassifier.ReportRegion(core.NewRegionInfo(&metapb.Region{Id: 5, StartKey: key("c"), EndKey: tableEnd}, nil), true)
+	migrations = tableClassifier.GetMigrations()
+	c.Assert(migrations[0].Regions, Equals, 3)
+	c.Assert(migrations[0].Finished, IsTrue)
+	// The regions of the cluster which are not reported yet.
+	hook.regionCount = 4
+	migrations = tableClassifier.GetMigrations()
+	c.Assert(migrations[0].Regions, Equals, 4)
+	c.Assert(migrations[0].Finished, IsFalse)
+	hook.regionCount = 3
+	c.Assert(tableClassifier.DeleteNamespace("test2"), IsNil)
+	c.Assert(tableClassifier.IsNamespaceExist("test2"), IsFalse)
+	c.Assert(hook.configs, DeepEquals, map[string]bool{"test3": true})
+	c.Assert(tableClassifier.RemoveMigration(1), IsNil)
+	c.Assert(tableClassifier.RemoveMigration(1), NotNil)
+
+	// The namespaces are reloaded from the kv.
+	classifier, err = NewTableNamespaceClassifier(kv, core.NewMockIDAllocator())
+	c.Assert(err, IsNil)
+	c.Assert(classifier.GetAllNamespaces(), HasLen, 2)
+	c.Assert(classifier.IsNamespaceExist("test3"), IsTrue)
+}
+
+func (s *testTableNamespaceSuite) TestMigrationReport(c *C) {
+	key := func(suffix string) []byte {
+		return encodeBytes(append(EncodeInt([]byte("t"), 1), suffix...))
+	}
+	report := func(m *migration, id uint64, startKey, endKey []byte, placed bool) {
+		m.report(core.NewRegionInfo(&metapb.Region{Id: id, StartKey: startKey, EndKey: endKey}, nil), placed)
+	}
+	tableStart, tableEnd := EncodeRange(1)
+	m := newMigration(1, "global", "test")
+	report(m, 1, tableStart, key("b"), true)
+	report(m, 2, key("b"), key("d"), true)
+	report(m, 3, key("d"), tableEnd, false)
+	p := m.progress(nil)
+	c.Assert(p.Regions, Equals, 3)
+	c.Assert(p.Placed, Equals, 2)
+	c.Assert(p.Finished, IsFalse)
+
+	// The region is split, the new regions replace the old one.
+	report(m, 2, key("b"), key("c"), false)
+	report(m, 4, key("c"), key("d"), true)
+	p = m.progress(nil)
+	c.Assert(p.Regions, Equals, 4)
+	c.Assert(p.Placed, Equals, 2)
+
+	// The regions are merged, and the region is reported again.
+	report(m, 1, tableStart, key("c"), true)
+	report(m, 3, key("d"), tableEnd, true)
+	report(m, 3, key("d"), tableEnd, true)
+	p = m.progress(nil)
+	c.Assert(p.Regions, Equals, 3)
+	c.Assert(p.Placed, Equals, 3)
+	c.Assert(p.Finished, IsTrue)
+
+	// The region covering the whole table replaces all the regions.
+	report(m, 5, nil, nil, false)
+	p = m.progress(nil)
+	c.Assert(p.Regions, Equals, 1)
+	c.Assert(p.Placed, Equals, 0)
+	c.Assert(p.Finished, IsFalse)
+}
@@ -32,8 +32,14 @@ func newTableClassifierHandler(classifier *tableNamespaceClassifier) http.Handle
 	router := mux.NewRouter()
 	router.HandleFunc("/table/namespaces", h.Get).Methods("GET")
 	router.HandleFunc("/table/namespaces", h.Post).Methods("POST")
+	router.HandleFunc("/table/namespaces/rename", h.Rename).Methods("POST")
+	router.HandleFunc("/table/namespaces/migrate", h.Migrate).Methods("POST")
+	router.HandleFunc("/table/namespaces/migrations", h.GetMigrations).Methods("GET")
+	router.HandleFunc("/table/namespaces/migrations/{table_id}", h.RemoveMigration).Methods("DELETE")
+	router.HandleFunc("/table/namespaces/{name}", h.Delete).Methods("DELETE")
 	router.HandleFunc("/table/namespaces/table", h.Update).Methods("POST")
 	router.HandleFunc("/table/namespaces/meta", h.SetMetaNamespace).Methods("POST")
+	router.HandleFunc("/table/namespaces/store_labels", h.SetStoreLabels).Methods("POST")
 	router.HandleFunc("/table/store_ns/{id}", h.SetNamespace).Methods("POST")
 	return router
 }
@@ -87,6 +93,65 @@ func (h *tableNamespaceHandler) Post(w http.ResponseWriter, r *http.Request) {
 	h.rd.JSON(w, http.StatusOK, nil)
 }
 
+// Delete deletes an empty namespace.
+func (h *tableNamespaceHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.classifier.DeleteNamespace(mux.Vars(r)["name"]); err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, nil)
+}
+
+// Rename renames a namespace.
+func (h *tableNamespaceHandler) Rename(w http.ResponseWriter, r *http.Request) {
+	var input map[string]string
+	if err := readJSONRespondError(h.rd, w, r.Body, &input); err != nil {
+		return
+	}
+	if err := h.classifier.RenameNamespace(input["namespace"], input["new_name"]); err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, nil)
+}
+
+// Migrate moves a table to another namespace.
+func (h *tableNamespaceHandler) Migrate(w http.ResponseWriter, r *http.Request) {
+	var input map[string]string
+	if err := readJSONRespondError(h.rd, w, r.Body, &input); err != nil {
+		return
+	}
+	tableID, err := strconv.ParseInt(input["table_id"], 10, 64)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.classifier.MigrateTable(tableID, input["namespace"]); err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, nil)
+}
+
+// GetMigrations returns the progress of the table migrations.
+func (h *tableNamespaceHandler) GetMigrations(w http.ResponseWriter, r *http.Request) {
+	h.rd.JSON(w, http.StatusOK, h.classifier.GetMigrations())
+}
+
+// RemoveMigration removes the progress of a table migration.
+func (h *tableNamespaceHandler) RemoveMigration(w http.ResponseWriter, r *http.Request) {
+	tableID, err := strconv.ParseInt(mux.Vars(r)["table_id"], 10, 64)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.classifier.RemoveMigration(tableID); err != nil {
+		h.rd.JSON(w, http.StatusNotFound, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, nil)
+}
+
 func (h *tableNamespaceHandler) Update(w http.ResponseWriter, r *http.Request) {
 	var input map[string]string
 	if err := readJSONRespondError(h.rd, w, r.Body, &input); err != nil {
@@ -150,6 +215,25 @@ func (h *tableNamespaceHandler) SetMetaNamespace(w http.ResponseWriter, r *http.
 	h.rd.JSON(w, http.StatusOK, nil)
 }
 
+// SetStoreLabels sets the store label selector of a namespace, the selector
+// is removed if it is empty.
+func (h *tableNamespaceHandler) SetStoreLabels(w http.ResponseWriter, r *http.Request) {
+	var input map[string]string
+	if err := readJSONRespondError(h.rd, w, r.Body, &input); err != nil {
+		return
+	}
+	labels, err := ParseStoreLabels(input["store_labels"])
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.classifier.SetNamespaceStoreLabels(input["namespace"], labels); err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, nil)
+}
+
 func (h *tableNamespaceHandler) SetNamespace(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	storeIDStr := vars["id"]
@@ -0,0 +1,245 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package table
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/btree"
+	"github.com/pingcap/pd/server/core"
+)
+
+const migrationBTreeDegree = 32
+
+// Migration is the progress of moving a table from a namespace to another.
+// The regions of the table are relocated by the namespace checker, which
+// reports whether the regions are placed on the stores of the target
+// namespace. The progress is kept in memory only.
+type Migration struct {
+	TableID   int64     `json:"table_id"`
+	Source    string    `json:"source"`
+	Target    string    `json:"target"`
+	StartTime time.Time `json:"start_time"`
+	// Regions is the number of the regions of the table, which is the larger
+	// one of the reported regions and the regions in the table range of the
+	// cluster, and Placed is the number of the reported regions which are
+	// placed on the target stores.
+	Regions  int  `json:"regions"`
+	Placed   int  `json:"placed"`
+	Finished bool `json:"finished"`
+}
+
+type regionPlacement struct {
+	id               uint64
+	startKey, endKey []byte
+	placed           bool
+}
+
+// Less returns true if the region start key is less than the other.
+func (r *regionPlacement) Less(other btree.Item) bool {
+	return bytes.Compare(r.startKey, other.(*regionPlacement).startKey) < 0
+}
+
+type migration struct {
+	Migration
+	// regions are the reported regions ordered by the start keys, they do not
+	// overlap with each other. ids indexes them by the region ids, and placed
+	// counts the placed ones.
+	regions *btree.BTree
+	ids     map[uint64]*regionPlacement
+	placed  int
+}
+
+func newMigration(tableID int64, source, target string) *migration {
+	return &migration{
+		Migration: Migration{
+			TableID:   tableID,
+			Source:    source,
+			Target:    target,
+			StartTime: time.Now(),
+		},
+		regions: btree.New(migrationBTreeDegree),
+		ids:     make(map[uint64]*regionPlacement),
+	}
+}
+
+// report records the placement of the region, the regions overlapped by it
+// are removed since they are split or merged.
+func (m *migration) report(region *core.RegionInfo, placed bool) {
+	item := &regionPlacement{
+		id:       region.GetId(),
+		startKey: region.GetStartKey(),
+		endKey:   region.GetEndKey(),
+		placed:   placed,
+	}
+	if old, ok := m.ids[item.id]; ok {
+		m.remove(old)
+	}
+
+	// The last region starting before the region may overlap with it.
+	first := item
+	m.regions.DescendLessOrEqual(item, func(i btree.Item) bool {
+		if r := i.(*regionPlacement); len(r.endKey) == 0 || bytes.Compare(item.startKey, r.endKey) < 0 {
+			first = r
+		}
+		return false
+	})
+	var overlaps []*regionPlacement
+	m.regions.AscendGreaterOrEqual(first, func(i btree.Item) bool {
+		r := i.(*regionPlacement)
+		if len(item.endKey) > 0 && bytes.Compare(item.endKey, r.startKey) <= 0 {
+			return false
+		}
+		overlaps = append(overlaps, r)
+		return true
+	})
+	for _, r := range overlaps {
+		m.remove(r)
+	}
+
+	m.regions.ReplaceOrInsert(item)
+	m.ids[item.id] = item
+	if item.placed {
+		m.placed++
+	}
+}
+
+func (m *migration) remove(r *regionPlacement) {
+	m.regions.Delete(r)
+	delete(m.ids, r.id)
+	if r.placed {
+		m.placed--
+	}
+}
+
+// covered returns true if the reported regions cover the whole table range.
+func (m *migration) covered() bool {
+	startKey, endKey := EncodeRange(m.TableID)
+	next := []byte(startKey)
+	var covered bool
+	m.regions.Ascend(func(i btree.Item) bool {
+		r := i.(*regionPlacement)
+		if bytes.Compare(r.startKey, next) > 0 {
+			return false
+		}
+		if len(r.endKey) == 0 || bytes.Compare(r.endKey, endKey) >= 0 {
+			covered = true
+			return false
+		}
+		if bytes.Compare(r.endKey, next) > 0 {
+			next = r.endKey
+		}
+		return true
+	})
+	return covered
+}
+
+func (m *migration) progress(regionCount func(startKey, endKey []byte) int) Migration {
+	p := m.Migration
+	p.Regions = m.regions.Len()
+	p.Placed = m.placed
+	if regionCount != nil {
+		startKey, endKey := EncodeRange(m.TableID)
+		if n := regionCount(startKey, endKey); n > p.Regions {
+			p.Regions = n
+		}
+	}
+	p.Finished = p.Regions > 0 && p.Placed == p.Regions && m.covered()
+	return p
+}
+
+type migrations struct {
+	sync.Mutex
+	tables map[int64]*migration
+	// regionCount returns the number of the regions in the key range of the
+	// cluster, it is nil if the classifier is not attached to a cluster.
+	regionCount func(startKey, endKey []byte) int
+}
+
+func newMigrations() *migrations {
+	return &migrations{tables: make(map[int64]*migration)}
+}
+
+func (ms *migrations) setRegionCounter(regionCount func(startKey, endKey []byte) int) {
+	ms.Lock()
+	defer ms.Unlock()
+	ms.regionCount = regionCount
+}
+
+func (ms *migrations) start(tableID int64, source, target string) {
+	ms.Lock()
+	defer ms.Unlock()
+	ms.tables[tableID] = newMigration(tableID, source, target)
+}
+
+func (ms *migrations) isMigrating(tableID int64) bool {
+	ms.Lock()
+	defer ms.Unlock()
+	_, ok := ms.tables[tableID]
+	return ok
+}
+
+func (ms *migrations) report(tableID int64, region *core.RegionInfo, placed bool) {
+	ms.Lock()
+	defer ms.Unlock()
+	if m, ok := ms.tables[tableID]; ok {
+		m.report(region, placed)
+	}
+}
+
+func (ms *migrations) remove(tableID int64) bool {
+	ms.Lock()
+	defer ms.Unlock()
+	_, ok := ms.tables[tableID]
+	delete(ms.tables, tableID)
+	return ok
+}
+
+func (ms *migrations) rename(oldName, newName string) {
+	ms.Lock()
+	defer ms.Unlock()
+	for _, m := range ms.tables {
+		if m.Source == oldName {
+			m.Source = newName
+		}
+		if m.Target == oldName {
+			m.Target = newName
+		}
+	}
+}
+
+func (ms *migrations) isMigratingTo(name string) bool {
+	ms.Lock()
+	defer ms.Unlock()
+	for _, m := range ms.tables {
+		if m.Target == name && !m.progress(ms.regionCount).Finished {
+			return true
+		}
+	}
+	return false
+}
+
+func (ms *migrations) getProgress() []Migration {
+	ms.Lock()
+	defer ms.Unlock()
+	res := make([]Migration, 0, len(ms.tables))
+	for _, m := range ms.tables {
+		res = append(res, m.progress(ms.regionCount))
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].TableID < res[j].TableID })
+	return res
+}